@@ -139,6 +139,9 @@ const validateSource = (src, context) => {
       `${context}.pulls must be numeric`,
     );
   }
+  if (src.netIncome !== undefined) {
+    assert(typeof src.netIncome === "boolean", `${context}.netIncome must be a boolean when provided`);
+  }
   validateRewardsShape(src.rewards, `${context}.rewards`);
   validateRewardsShape(src.costs, `${context}.costs`);
   assert(Array.isArray(src.scalers), `${context}.scalers must be an array`);
@@ -208,6 +211,34 @@ const validatePatch = (patch, context) => {
       );
     }
   }
+  if (patch.completeness !== undefined) {
+    assert(
+      Number.isFinite(patch.completeness) && patch.completeness >= 0 && patch.completeness <= 100,
+      `${context}.completeness must be a percentage between 0 and 100`,
+    );
+  }
+  if (patch.startTimes !== undefined) {
+    assert(
+      patch.startTimes && typeof patch.startTimes === "object",
+      `${context}.startTimes must be an object when provided`,
+    );
+    for (const [region, value] of Object.entries(patch.startTimes)) {
+      assert(
+        typeof value === "string" && !Number.isNaN(Date.parse(value)),
+        `${context}.startTimes.${region} must be an ISO timestamp`,
+      );
+    }
+  }
+  if (patch.dailyIncome !== undefined) {
+    assert(
+      patch.dailyIncome && typeof patch.dailyIncome === "object",
+      `${context}.dailyIncome must be an object when provided`,
+    );
+    validateRewardsShape(patch.dailyIncome.base, `${context}.dailyIncome.base`);
+    for (const [sourceId, rewards] of Object.entries(patch.dailyIncome.sources ?? {})) {
+      validateRewardsShape(rewards, `${context}.dailyIncome.sources.${sourceId}`);
+    }
+  }
   assert(Array.isArray(patch.sources), `${context}.sources must be an array`);
   assert(patch.sources.length > 0, `${context}.sources must not be empty`);
 
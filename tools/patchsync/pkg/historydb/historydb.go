@@ -0,0 +1,248 @@
+// Package historydb mirrors the patchsync change log into SQLite, so the history of one patch or source can be
+// looked up with an index instead of a scan of every rotated JSONL segment. The mirror is optional: the JSONL
+// log stays the record, and Replace rebuilds the database from it at any time.
+//
+// The driver is modernc.org/sqlite, which is pure Go, so the tool still builds without cgo.
+package historydb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS records (
+	id             INTEGER PRIMARY KEY,
+	timestamp      TEXT    NOT NULL,
+	unix           INTEGER NOT NULL,
+	game_id        TEXT    NOT NULL,
+	spreadsheet_id TEXT    NOT NULL
+);
+CREATE TABLE IF NOT EXISTS changes (
+	record_id   INTEGER NOT NULL REFERENCES records(id) ON DELETE CASCADE,
+	position    INTEGER NOT NULL,
+	patch       TEXT    NOT NULL,
+	change_type TEXT    NOT NULL,
+	entry       TEXT    NOT NULL,
+	PRIMARY KEY (record_id, position)
+);
+CREATE TABLE IF NOT EXISTS change_sources (
+	record_id INTEGER NOT NULL,
+	position  INTEGER NOT NULL,
+	source_id TEXT    NOT NULL,
+	PRIMARY KEY (record_id, position, source_id),
+	FOREIGN KEY (record_id, position) REFERENCES changes(record_id, position) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS records_game_unix ON records(game_id, unix);
+CREATE INDEX IF NOT EXISTS changes_patch ON changes(patch);
+CREATE INDEX IF NOT EXISTS change_sources_source ON change_sources(source_id);
+`
+
+// Record is one sync or manual edit: the changes it made to one game's generated file.
+type Record struct {
+	Timestamp     string // RFC 3339
+	GameID        string
+	SpreadsheetID string
+	Changes       []Change
+}
+
+// Change is one patch of a Record. Sources are the ids of the sources it changed, Entry the change log entry
+// as JSON, returned unchanged by Query.
+type Change struct {
+	Patch      string
+	ChangeType string
+	Sources    []string
+	Entry      json.RawMessage
+}
+
+// Query selects changes. Empty fields and a zero Since match everything; Limit 0 returns all matches.
+type Query struct {
+	GameID string
+	Patch  string
+	Source string
+	Since  time.Time
+	Limit  int
+}
+
+// Result is one change returned by Query, with the record it belongs to.
+type Result struct {
+	Timestamp     string
+	GameID        string
+	SpreadsheetID string
+	Change        Change
+}
+
+// DB is an open history database.
+type DB struct {
+	db *sql.DB
+}
+
+// Open opens the database at path, creating it and its tables when missing.
+func Open(path string) (*DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create history tables: %w", err)
+	}
+	return &DB{db: db}, nil
+}
+
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Add appends records in one transaction.
+func (d *DB) Add(records ...Record) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := insertRecords(tx, records); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Replace drops every stored record and stores records instead, in one transaction.
+func (d *DB) Replace(records []Record) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, table := range []string{"change_sources", "changes", "records"} {
+		if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := insertRecords(tx, records); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertRecords(tx *sql.Tx, records []Record) error {
+	for _, record := range records {
+		ts, err := time.Parse(time.RFC3339, record.Timestamp)
+		if err != nil {
+			return fmt.Errorf("record timestamp: %w", err)
+		}
+		result, err := tx.Exec(`INSERT INTO records (timestamp, unix, game_id, spreadsheet_id) VALUES (?, ?, ?, ?)`,
+			record.Timestamp, ts.Unix(), record.GameID, record.SpreadsheetID)
+		if err != nil {
+			return err
+		}
+		recordID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		for position, change := range record.Changes {
+			if !json.Valid(change.Entry) {
+				return errors.New("change entry is not valid JSON")
+			}
+			if _, err := tx.Exec(`INSERT INTO changes (record_id, position, patch, change_type, entry) VALUES (?, ?, ?, ?, ?)`,
+				recordID, position, change.Patch, change.ChangeType, string(change.Entry)); err != nil {
+				return err
+			}
+			for _, source := range change.Sources {
+				if _, err := tx.Exec(`INSERT OR IGNORE INTO change_sources (record_id, position, source_id) VALUES (?, ?, ?)`,
+					recordID, position, source); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Query returns the matching changes, newest record first and in their original order within a record.
+func (d *DB) Query(query Query) ([]Result, error) {
+	where := []string{}
+	args := []any{}
+	if query.GameID != "" {
+		where = append(where, "r.game_id = ?")
+		args = append(args, query.GameID)
+	}
+	if query.Patch != "" {
+		where = append(where, "c.patch = ?")
+		args = append(args, query.Patch)
+	}
+	if query.Source != "" {
+		where = append(where, "EXISTS (SELECT 1 FROM change_sources s WHERE s.record_id = c.record_id AND s.position = c.position AND s.source_id = ?)")
+		args = append(args, query.Source)
+	}
+	if !query.Since.IsZero() {
+		where = append(where, "r.unix >= ?")
+		args = append(args, query.Since.Unix())
+	}
+	statement := `SELECT r.id, r.timestamp, r.game_id, r.spreadsheet_id, c.position, c.patch, c.change_type, c.entry
+		FROM changes c JOIN records r ON r.id = c.record_id`
+	if len(where) > 0 {
+		statement += " WHERE " + strings.Join(where, " AND ")
+	}
+	statement += " ORDER BY r.unix DESC, r.id DESC, c.position ASC"
+	if query.Limit > 0 {
+		statement += " LIMIT ?"
+		args = append(args, query.Limit)
+	}
+	rows, err := d.db.Query(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type changeKey struct{ recordID, position int64 }
+	results := []Result{}
+	keys := []changeKey{}
+	for rows.Next() {
+		var (
+			key    changeKey
+			result Result
+			entry  string
+		)
+		if err := rows.Scan(&key.recordID, &result.Timestamp, &result.GameID, &result.SpreadsheetID, &key.position, &result.Change.Patch, &result.Change.ChangeType, &entry); err != nil {
+			return nil, err
+		}
+		result.Change.Entry = json.RawMessage(entry)
+		results = append(results, result)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	for idx, key := range keys {
+		if results[idx].Change.Sources, err = d.sources(key.recordID, key.position); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (d *DB) sources(recordID, position int64) ([]string, error) {
+	rows, err := d.db.Query(`SELECT source_id FROM change_sources WHERE record_id = ? AND position = ? ORDER BY source_id`, recordID, position)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	sources := []string{}
+	for rows.Next() {
+		var source string
+		if err := rows.Scan(&source); err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, rows.Err()
+}
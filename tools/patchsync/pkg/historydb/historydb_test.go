@@ -0,0 +1,68 @@
+package historydb
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func change(patch, changeType string, sources ...string) Change {
+	entry, _ := json.Marshal(map[string]any{"patch": patch, "changeType": changeType, "changedSources": sources})
+	return Change{Patch: patch, ChangeType: changeType, Sources: sources, Entry: entry}
+}
+
+func TestAddAndQuery(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+	err = db.Add(
+		Record{Timestamp: "2026-01-05T10:00:00Z", GameID: "wuthering-waves", SpreadsheetID: "sheet", Changes: []Change{
+			change("2.1", "updated", "events"),
+			change("2.2", "added"),
+		}},
+		Record{Timestamp: "2026-02-01T10:00:00Z", GameID: "wuthering-waves", SpreadsheetID: "manual", Changes: []Change{
+			change("2.1", "updated", "events", "mailbox"),
+		}},
+		Record{Timestamp: "2026-02-02T10:00:00Z", GameID: "genshin-impact", SpreadsheetID: "sheet", Changes: []Change{
+			change("2.1", "updated", "events"),
+		}},
+	)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	results, err := db.Query(Query{GameID: "wuthering-waves", Patch: "2.1", Source: "events"})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Timestamp != "2026-02-01T10:00:00Z" || results[0].SpreadsheetID != "manual" {
+		t.Fatalf("results = %+v, want the manual change first", results)
+	}
+	if len(results[0].Change.Sources) != 2 || results[0].Change.Sources[1] != "mailbox" {
+		t.Fatalf("sources = %v", results[0].Change.Sources)
+	}
+
+	since, _ := time.Parse(time.DateOnly, "2026-01-10")
+	if results, err := db.Query(Query{GameID: "wuthering-waves", Since: since}); err != nil || len(results) != 1 {
+		t.Fatalf("Query(since) = %+v, %v; want one change", results, err)
+	}
+	if results, err := db.Query(Query{Limit: 2}); err != nil || len(results) != 2 || results[0].GameID != "genshin-impact" {
+		t.Fatalf("Query(limit) = %+v, %v", results, err)
+	}
+	if results, err := db.Query(Query{GameID: "wuthering-waves", Since: time.Time{}}); err != nil || len(results) != 3 || results[1].Change.Patch != "2.1" || results[2].Change.Patch != "2.2" {
+		t.Fatalf("Query(game) = %+v, %v; want changes of one record in their order", results, err)
+	}
+
+	if err := db.Replace([]Record{{Timestamp: "2026-03-01T00:00:00Z", GameID: "wuthering-waves", Changes: []Change{change("3.0", "added")}}}); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	if results, err := db.Query(Query{}); err != nil || len(results) != 1 || results[0].Change.Patch != "3.0" {
+		t.Fatalf("after Replace = %+v, %v", results, err)
+	}
+	if err := db.Add(Record{Timestamp: "yesterday"}); err == nil {
+		t.Fatal("Add() accepted a record without an RFC 3339 timestamp")
+	}
+}
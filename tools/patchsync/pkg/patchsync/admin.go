@@ -0,0 +1,82 @@
+package patchsync
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const recentSyncLogLimit = 500
+
+//go:embed admin/index.html
+var adminIndexHTML string
+
+// syncLogRing keeps the latest sync log lines across all requests for the admin UI's live log view.
+type syncLogRing struct {
+	mu    sync.Mutex
+	lines []string
+	limit int
+}
+
+var recentSyncLogs = &syncLogRing{limit: recentSyncLogLimit}
+
+func (r *syncLogRing) add(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, line)
+	if overflow := len(r.lines) - r.limit; overflow > 0 {
+		r.lines = append(r.lines[:0], r.lines[overflow:]...)
+	}
+}
+
+func (r *syncLogRing) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.lines...)
+}
+
+func renderAdminPage() string {
+	games, _ := json.Marshal(AvailableGameIDs())
+	return strings.NewReplacer(
+		"__PATCHSYNC_GAMES__", string(games),
+		"__PATCHSYNC_BASE__", apiVersionPrefix,
+	).Replace(adminIndexHTML)
+}
+
+func registerAdminUI(mux *http.ServeMux, allowedOrigins map[string]struct{}) {
+	page := renderAdminPage()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'")
+		_, _ = w.Write([]byte(page))
+	})
+	mux.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
+		if !withCORS(w, r, allowedOrigins) {
+			writeJSON(w, http.StatusForbidden, syncResponse{
+				OK:      false,
+				Message: "origin is not allowed",
+			})
+			return
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, syncResponse{
+				OK:      false,
+				Message: "method not allowed",
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, syncResponse{
+			OK:       true,
+			Message:  "recent sync log",
+			Logs:     recentSyncLogs.snapshot(),
+			Progress: liveSyncProgress.snapshot(),
+		})
+	})
+}
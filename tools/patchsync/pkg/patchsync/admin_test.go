@@ -0,0 +1,49 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSyncLogRingKeepsLatestLines(t *testing.T) {
+	ring := &syncLogRing{limit: 3}
+	for idx := range 5 {
+		ring.add(fmt.Sprintf("line %d", idx))
+	}
+	if got := strings.Join(ring.snapshot(), ","); got != "line 2,line 3,line 4" {
+		t.Fatalf("snapshot = %s", got)
+	}
+}
+
+func TestAdminUIServesPageAndLogs(t *testing.T) {
+	mux := http.NewServeMux()
+	registerAdminUI(mux, nil)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, `"`+gameIDEndfield+`"`) || strings.Contains(body, "__PATCHSYNC_") {
+		t.Fatalf("unexpected admin page (status %d)", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown path status = %d, want 404", rec.Code)
+	}
+
+	logs := syncLog{requestID: "admin-test"}
+	appendSyncLog(&logs, "hello")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/logs", nil))
+	var response syncResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode logs: %v", err)
+	}
+	if len(response.Logs) == 0 || !strings.HasSuffix(response.Logs[len(response.Logs)-1], "[admin-test] hello") {
+		t.Fatalf("logs = %v", response.Logs)
+	}
+}
@@ -0,0 +1,90 @@
+package patchsync
+
+import (
+	"fmt"
+	"sort"
+)
+
+// patchAliases is the "aliases" block of an overrides file, mapping a patch ID a game no longer uses to the ID
+// that replaced it. Chains are followed, so {"1.3": "1.4", "1.4": "2.0"} resolves 1.3 to 2.0.
+type patchAliases map[string]string
+
+func newPatchAliases(raw map[string]string) (patchAliases, error) {
+	aliases := make(patchAliases, len(raw))
+	for rawFrom, rawTo := range raw {
+		from, to := canonicalPatchID(rawFrom), canonicalPatchID(rawTo)
+		if from == "" || to == "" {
+			return nil, fmt.Errorf("alias %q -> %q needs two patch IDs", rawFrom, rawTo)
+		}
+		if from == to {
+			return nil, fmt.Errorf("alias %q points at itself", rawFrom)
+		}
+		aliases[from] = to
+	}
+	froms := make([]string, 0, len(aliases))
+	for from := range aliases {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+	for _, from := range froms {
+		seen := map[string]struct{}{from: {}}
+		for next, ok := aliases[from]; ok; next, ok = aliases[next] {
+			if _, loop := seen[next]; loop {
+				return nil, fmt.Errorf("aliases loop back to %s", from)
+			}
+			seen[next] = struct{}{}
+		}
+	}
+	return aliases, nil
+}
+
+// resolve returns the ID patchID is known by now. newPatchAliases rejects loops, so the walk ends.
+func (aliases patchAliases) resolve(patchID string) string {
+	for {
+		next, ok := aliases[patchID]
+		if !ok {
+			return patchID
+		}
+		patchID = next
+	}
+}
+
+// rename moves patch to its current ID and returns the ID it had, or "" when no alias applies.
+func (aliases patchAliases) rename(patch *Patch) string {
+	patchID := patchIDOrFallback(*patch)
+	target := aliases.resolve(patchID)
+	if target == patchID {
+		return ""
+	}
+	patch.ID, patch.Patch = target, target
+	return patchID
+}
+
+// renameExisting renames aliased patches of the previous output so a resync updates them in place. When both the
+// old and the new ID are already there, the old copy is dropped.
+func (aliases patchAliases) renameExisting(patches []Patch, logs *syncLog) []Patch {
+	if len(aliases) == 0 {
+		return patches
+	}
+	present := map[string]struct{}{}
+	for _, patch := range patches {
+		present[patchIDOrFallback(patch)] = struct{}{}
+	}
+	renamed := make([]Patch, 0, len(patches))
+	for _, patch := range patches {
+		from := aliases.rename(&patch)
+		if from == "" {
+			renamed = append(renamed, patch)
+			continue
+		}
+		target := patchIDOrFallback(patch)
+		if _, ok := present[target]; ok {
+			appendSyncLog(logs, "drop existing patch %s (aliased to %s, which is already present)", from, target)
+			continue
+		}
+		present[target] = struct{}{}
+		appendSyncLog(logs, "rename existing patch %s to %s (alias)", from, target)
+		renamed = append(renamed, patch)
+	}
+	return renamed
+}
@@ -0,0 +1,73 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewPatchAliases(t *testing.T) {
+	aliases, err := newPatchAliases(map[string]string{"1.3": "1.4", " 1.4 ": "2.0"})
+	if err != nil {
+		t.Fatalf("newPatchAliases() error = %v", err)
+	}
+	if got := aliases.resolve("1.3"); got != "2.0" {
+		t.Fatalf("resolve(1.3) = %q, want the end of the chain", got)
+	}
+	if got := aliases.resolve("3.4"); got != "3.4" {
+		t.Fatalf("resolve(3.4) = %q, want it unchanged", got)
+	}
+	for _, raw := range []map[string]string{{"1.3": "1.3"}, {"1.3": "1.4", "1.4": "1.3"}, {"1.3": ""}} {
+		if _, err := newPatchAliases(raw); err == nil {
+			t.Errorf("newPatchAliases(%v) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestRunSyncFollowsPatchAliases(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	overridesDir := filepath.Join(dir, "overrides")
+	if err := os.MkdirAll(overridesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    overridesDir,
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.3": wuwaSheetCSV("3.3")}},
+	}
+	if _, err := RunSync(t.Context(), cfg); err != nil {
+		t.Fatalf("initial RunSync() error = %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(overridesDir, gameIDWuwa+".json"), []byte(`{"aliases":{"3.3":"3.4"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Fetcher = fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}}
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	patches, err := readGeneratedPatches(cfg.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 1 || patches[0].ID != "3.4" {
+		t.Fatalf("patches after rename = %+v, want 3.3 carried over as 3.4", patches)
+	}
+	if !strings.Contains(strings.Join(result.Logs, "\n"), "rename existing patch 3.3 to 3.4") {
+		t.Fatalf("logs do not mention the rename: %v", result.Logs)
+	}
+
+	if err := os.WriteFile(filepath.Join(overridesDir, gameIDWuwa+".json"), []byte(`{"aliases":{"3.3":"3.4","3.4":"3.3"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RunSync(t.Context(), cfg); errorCode(err) != errCodeConfig {
+		t.Fatalf("RunSync() with looping aliases = %v, want %s", err, errCodeConfig)
+	}
+}
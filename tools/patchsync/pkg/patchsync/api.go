@@ -0,0 +1,186 @@
+package patchsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var generatedMetaDeclPattern = regexp.MustCompile(`export const GENERATED_PATCHES_META\s*=\s*`)
+
+type generatedPayload struct {
+	Patches []json.RawMessage
+	Meta    json.RawMessage
+}
+
+type patchIdentity struct {
+	ID    string `json:"id"`
+	Patch string `json:"patch"`
+}
+
+// generatedMetaJSON returns the object assigned to GENERATED_PATCHES_META, or nil when the file has none. The
+// object is read as JSON rather than matched, so strings such as notes may contain "};".
+func generatedMetaJSON(body []byte) (json.RawMessage, error) {
+	loc := generatedMetaDeclPattern.FindIndex(body)
+	if loc == nil {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body[loc[1]:]))
+	var meta json.RawMessage
+	if err := decoder.Decode(&meta); err != nil {
+		return nil, fmt.Errorf("parse GENERATED_PATCHES_META: %w", err)
+	}
+	if !bytes.HasPrefix(meta, []byte("{")) {
+		return nil, errors.New("parse GENERATED_PATCHES_META: not an object")
+	}
+	if rest := bytes.TrimSpace(body[loc[1]+int(decoder.InputOffset()):]); !bytes.HasPrefix(rest, []byte(";")) {
+		return nil, errors.New("parse GENERATED_PATCHES_META: missing ; after the object")
+	}
+	return meta, nil
+}
+
+func readGeneratedPayload(path string) (generatedPayload, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return generatedPayload{}, err
+	}
+	payload := generatedPayload{Patches: []json.RawMessage{}, Meta: json.RawMessage("{}")}
+	if match := generatedPatchesBlockPattern.FindSubmatch(body); len(match) >= 2 {
+		if err := json.Unmarshal(match[1], &payload.Patches); err != nil {
+			return generatedPayload{}, fmt.Errorf("parse GENERATED_PATCHES: %w", err)
+		}
+	}
+	meta, err := generatedMetaJSON(body)
+	if err != nil {
+		return generatedPayload{}, err
+	}
+	if meta != nil {
+		payload.Meta = meta
+	}
+	return payload, nil
+}
+
+func findGeneratedPatch(patches []json.RawMessage, rawID string) (json.RawMessage, bool) {
+	target := canonicalPatchID(rawID)
+	if target == "" {
+		return nil, false
+	}
+	for _, raw := range patches {
+		var identity patchIdentity
+		if err := json.Unmarshal(raw, &identity); err != nil {
+			continue
+		}
+		if patchIDOrFallback(Patch{ID: identity.ID, Patch: identity.Patch}) == target {
+			return raw, true
+		}
+	}
+	return nil, false
+}
+
+func writeCachedJSON(w http.ResponseWriter, r *http.Request, body []byte) {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, body); err == nil {
+		body = compacted.Bytes()
+	}
+	etag := `"` + contentHash(string(body)) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == etag || candidate == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// configuredOutputPath is the generated file of profile under cfg: cfg.OutputPath for the game cfg is for, the
+// profile default for every other game.
+func configuredOutputPath(cfg SyncConfig, profile GameProfile) string {
+	if strings.TrimSpace(cfg.OutputPath) != "" && cfg.GameID == profile.ID {
+		return cfg.OutputPath
+	}
+	return profile.DefaultOutputPath
+}
+
+func loadGeneratedPayloadForRequest(w http.ResponseWriter, r *http.Request, allowedOrigins map[string]struct{}, cfg SyncConfig) (generatedPayload, bool) {
+	if !withCORS(w, r, allowedOrigins) {
+		writeJSON(w, http.StatusForbidden, syncResponse{
+			OK:      false,
+			Message: "origin is not allowed",
+		})
+		return generatedPayload{}, false
+	}
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	profile, err := ResolveGameProfile(r.PathValue("game"))
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, syncResponse{
+			OK:      false,
+			Message: err.Error(),
+		})
+		return generatedPayload{}, false
+	}
+	payload, err := readGeneratedPayload(resolveFilePath(configuredOutputPath(cfg, profile)))
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := fmt.Sprintf("read generated patches: %v", err)
+		if errors.Is(err, os.ErrNotExist) {
+			status = http.StatusNotFound
+			message = fmt.Sprintf("no generated patches for game %s", profile.ID)
+		}
+		writeJSON(w, status, syncResponse{
+			OK:      false,
+			Message: message,
+		})
+		return generatedPayload{}, false
+	}
+	return payload, true
+}
+
+func registerReadAPI(mux *http.ServeMux, allowedOrigins map[string]struct{}, cfg SyncConfig) {
+	mux.HandleFunc("GET /api/{game}/patches", func(w http.ResponseWriter, r *http.Request) {
+		payload, ok := loadGeneratedPayloadForRequest(w, r, allowedOrigins, cfg)
+		if !ok {
+			return
+		}
+		body, err := json.Marshal(payload.Patches)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, syncResponse{
+				OK:      false,
+				Message: err.Error(),
+			})
+			return
+		}
+		writeCachedJSON(w, r, body)
+	})
+	mux.HandleFunc("GET /api/{game}/patches/{id}", func(w http.ResponseWriter, r *http.Request) {
+		payload, ok := loadGeneratedPayloadForRequest(w, r, allowedOrigins, cfg)
+		if !ok {
+			return
+		}
+		patch, found := findGeneratedPatch(payload.Patches, r.PathValue("id"))
+		if !found {
+			writeJSON(w, http.StatusNotFound, syncResponse{
+				OK:      false,
+				Message: fmt.Sprintf("patch %s not found", r.PathValue("id")),
+			})
+			return
+		}
+		writeCachedJSON(w, r, patch)
+	})
+	mux.HandleFunc("GET /api/{game}/meta", func(w http.ResponseWriter, r *http.Request) {
+		payload, ok := loadGeneratedPayloadForRequest(w, r, allowedOrigins, cfg)
+		if !ok {
+			return
+		}
+		writeCachedJSON(w, r, payload.Meta)
+	})
+}
@@ -0,0 +1,88 @@
+package patchsync
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadGeneratedPayloadAndETag(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuwa.generated.js")
+	meta := GeneratedMeta{GameID: gameIDWuwa, SpreadsheetID: "sheet", Sheets: []string{"2.0", "2.1"}}
+	patches := []Patch{
+		{ID: "2.0", Patch: "2.0", Sources: []Source{}},
+		{ID: "2.1", Patch: "2.1", Sources: []Source{}},
+	}
+	if err := writeGeneratedFile(path, patches, meta); err != nil {
+		t.Fatalf("writeGeneratedFile() error = %v", err)
+	}
+
+	payload, err := readGeneratedPayload(path)
+	if err != nil {
+		t.Fatalf("readGeneratedPayload() error = %v", err)
+	}
+	if len(payload.Patches) != 2 {
+		t.Fatalf("expected 2 patches, got %d", len(payload.Patches))
+	}
+	if _, ok := findGeneratedPatch(payload.Patches, "2.1 (STC)"); !ok {
+		t.Fatalf("expected 2.1 to be found by canonical id")
+	}
+	if _, ok := findGeneratedPatch(payload.Patches, "3.0"); ok {
+		t.Fatalf("did not expect 3.0 to be found")
+	}
+
+	first := httptest.NewRecorder()
+	writeCachedJSON(first, httptest.NewRequest(http.MethodGet, "/api/wuthering-waves/meta", nil), payload.Meta)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first response code=%d etag=%q", first.Code, etag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/wuthering-waves/meta", nil)
+	req.Header.Set("If-None-Match", etag)
+	second := httptest.NewRecorder()
+	writeCachedJSON(second, req, payload.Meta)
+	if second.Code != http.StatusNotModified || second.Body.Len() != 0 {
+		t.Fatalf("expected 304 with empty body, got %d (%d bytes)", second.Code, second.Body.Len())
+	}
+
+	if _, err := readGeneratedPayload(filepath.Join(t.TempDir(), "missing.js")); !os.IsNotExist(err) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+}
+
+func TestGeneratedMetaWithBraceSemicolonInStrings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuwa.generated.js")
+	meta := GeneratedMeta{GameID: gameIDWuwa, SpreadsheetID: "sheet", Sheets: []string{"2.0 };", "2.1"}}
+	if err := writeGeneratedFile(path, []Patch{}, meta); err != nil {
+		t.Fatalf("writeGeneratedFile() error = %v", err)
+	}
+	parsed, err := readGeneratedMeta(path)
+	if err != nil {
+		t.Fatalf("readGeneratedMeta() error = %v", err)
+	}
+	if len(parsed.Sheets) != 2 || parsed.Sheets[1] != "2.1" {
+		t.Fatalf("Sheets = %v, want both sheets", parsed.Sheets)
+	}
+	if _, err := generatedMetaJSON([]byte(`export const GENERATED_PATCHES_META = {"gameId": "x"`)); err == nil {
+		t.Fatal("generatedMetaJSON() accepted a truncated object")
+	}
+}
+
+func TestReadAPIServesConfiguredOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "custom.generated.js")
+	patches := []Patch{{ID: "2.0", Patch: "2.0", Sources: []Source{}}}
+	if err := writeGeneratedFile(path, patches, GeneratedMeta{GameID: gameIDWuwa}); err != nil {
+		t.Fatalf("writeGeneratedFile() error = %v", err)
+	}
+	mux := http.NewServeMux()
+	registerReadAPI(mux, nil, SyncConfig{GameID: gameIDWuwa, OutputPath: path})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/wuthering-waves/patches/2.0", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET patch from --output path: code %d, body %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,162 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultAPIDir = "public/api"
+
+type staticAPIIndex struct {
+	Meta    GeneratedMeta    `json:"meta"`
+	Patches []generatedPatch `json:"patches"`
+}
+
+func writeJSONFile(path string, value any) error {
+	body, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(body, '\n'), 0o644)
+}
+
+func resolveAPIDir(dir string) string {
+	cleanPath := filepath.Clean(strings.TrimSpace(dir))
+	if filepath.IsAbs(cleanPath) {
+		return cleanPath
+	}
+	if _, err := os.Stat(cleanPath); err == nil {
+		return cleanPath
+	}
+	// The tree usually does not exist yet, so anchor it at the repo root when running from tools/patchsync.
+	if stat, err := os.Stat(filepath.Join("..", "..", "src")); err == nil && stat.IsDir() {
+		return filepath.Join("..", "..", cleanPath)
+	}
+	return cleanPath
+}
+
+func writeStaticAPI(dir string, patches []Patch, meta GeneratedMeta) error {
+	gameDir := filepath.Join(resolveAPIDir(dir), meta.GameID)
+	if err := os.MkdirAll(gameDir, 0o755); err != nil {
+		return fmt.Errorf("create api dir: %w", err)
+	}
+
+	index := staticAPIIndex{Meta: meta, Patches: make([]generatedPatch, 0, len(patches))}
+	keep := map[string]struct{}{"index.json": {}}
+	for _, patch := range patches {
+		patchID := patchIDOrFallback(patch)
+		if patchID == "" {
+			continue
+		}
+		generated := toGeneratedPatch(patch, meta.GameID)
+		index.Patches = append(index.Patches, generated)
+		fileName := patchID + ".json"
+		keep[fileName] = struct{}{}
+		if err := writeJSONFile(filepath.Join(gameDir, fileName), generated); err != nil {
+			return fmt.Errorf("write api patch %s: %w", patchID, err)
+		}
+	}
+	if err := writeJSONFile(filepath.Join(gameDir, "index.json"), index); err != nil {
+		return fmt.Errorf("write api index: %w", err)
+	}
+	keep["daily.json"] = struct{}{}
+	if err := writeJSONFile(filepath.Join(gameDir, "daily.json"), buildDailySeries(meta.GameID, patches)); err != nil {
+		return fmt.Errorf("write api daily series: %w", err)
+	}
+
+	entries, err := os.ReadDir(gameDir)
+	if err != nil {
+		return fmt.Errorf("read api dir: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		if _, ok := keep[entry.Name()]; ok {
+			continue
+		}
+		if err := os.Remove(filepath.Join(gameDir, entry.Name())); err != nil {
+			return fmt.Errorf("remove stale api file %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+func readGeneratedMeta(path string) (GeneratedMeta, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return GeneratedMeta{}, err
+	}
+	return parseGeneratedMeta(body)
+}
+
+func parseGeneratedMeta(body []byte) (GeneratedMeta, error) {
+	var meta GeneratedMeta
+	raw, err := generatedMetaJSON(body)
+	if err != nil || raw == nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return GeneratedMeta{}, fmt.Errorf("parse GENERATED_PATCHES_META: %w", err)
+	}
+	return meta, nil
+}
+
+func runExportAPICommand(args []string) int {
+	fs := flag.NewFlagSet("export-api", flag.ContinueOnError)
+	var (
+		gameID string
+		apiDir string
+	)
+	fs.StringVar(&gameID, "game", "", fmt.Sprintf("Game id (%s); empty exports every game", strings.Join(AvailableGameIDs(), ", ")))
+	fs.StringVar(&apiDir, "api-dir", defaultAPIDir, "Directory for the static JSON API tree")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	gameIDs := AvailableGameIDs()
+	if strings.TrimSpace(gameID) != "" {
+		gameIDs = []string{gameID}
+	}
+	failed := false
+	for _, id := range gameIDs {
+		profile, err := ResolveGameProfile(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "export failed: %v\n", err)
+			return 2
+		}
+		outputPath := resolveFilePath(profile.DefaultOutputPath)
+		meta, err := readGeneratedMeta(outputPath)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				fmt.Printf("Skip %s: no generated file at %s\n", profile.ID, outputPath)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "export %s failed: %v\n", profile.ID, err)
+			failed = true
+			continue
+		}
+		meta.GameID = profile.ID
+		patches, err := readGeneratedPatches(outputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "export %s failed: %v\n", profile.ID, err)
+			failed = true
+			continue
+		}
+		if err := writeStaticAPI(apiDir, patches, meta); err != nil {
+			fmt.Fprintf(os.Stderr, "export %s failed: %v\n", profile.ID, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Exported %d patches for %s\n", len(patches), profile.ID)
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
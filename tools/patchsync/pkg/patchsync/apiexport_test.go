@@ -0,0 +1,59 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteStaticAPI(t *testing.T) {
+	dir := t.TempDir()
+	gameDir := filepath.Join(dir, gameIDWuwa)
+	if err := os.MkdirAll(gameDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	stalePath := filepath.Join(gameDir, "1.9.json")
+	if err := os.WriteFile(stalePath, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	patches := []Patch{
+		{ID: "2.0", Patch: "2.0", Sources: []Source{source("events", "Version Events", "always", nil, true, Rewards{Oroberyl: 5000})}},
+		{ID: "2.1", Patch: "2.1", Sources: []Source{}},
+	}
+	meta := GeneratedMeta{GameID: gameIDWuwa, SpreadsheetID: "sheet", Sheets: []string{"2.0", "2.1"}}
+	if err := writeStaticAPI(dir, patches, meta); err != nil {
+		t.Fatalf("writeStaticAPI() error = %v", err)
+	}
+
+	if _, err := os.Stat(stalePath); !os.IsNotExist(err) {
+		t.Fatalf("expected stale patch file to be removed, stat err = %v", err)
+	}
+	body, err := os.ReadFile(filepath.Join(gameDir, "2.0.json"))
+	if err != nil {
+		t.Fatalf("read patch file: %v", err)
+	}
+	var patch generatedPatch
+	if err := json.Unmarshal(body, &patch); err != nil {
+		t.Fatalf("decode patch file: %v", err)
+	}
+	if patch.Sources[0].Rewards["astrite"] != 5000 {
+		t.Fatalf("expected game-specific reward keys, got %+v", patch.Sources[0].Rewards)
+	}
+
+	body, err = os.ReadFile(filepath.Join(gameDir, "index.json"))
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+	var index staticAPIIndex
+	if err := json.Unmarshal(body, &index); err != nil {
+		t.Fatalf("decode index: %v", err)
+	}
+	if index.Meta.GameID != gameIDWuwa || len(index.Patches) != 2 {
+		t.Fatalf("index = %+v, want 2 wuwa patches", index)
+	}
+	if _, err := os.Stat(filepath.Join(gameDir, "daily.json")); err != nil {
+		t.Fatalf("expected daily.json next to the index: %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package patchsync
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultAuditLogPath = "tools/patchsync/logs/audit.jsonl"
+
+type auditRecord struct {
+	Timestamp        string `json:"timestamp"`
+	RequestID        string `json:"requestId,omitempty"`
+	RemoteAddr       string `json:"remoteAddr"`
+	Method           string `json:"method"`
+	Path             string `json:"path"`
+	GameID           string `json:"gameId,omitempty"`
+	DryRun           bool   `json:"dryRun,omitempty"`
+	TokenFingerprint string `json:"tokenFingerprint,omitempty"`
+	Status           int    `json:"status"`
+	Outcome          string `json:"outcome"`
+	DurationMs       int64  `json:"durationMs"`
+}
+
+type auditLogger struct {
+	mu   sync.Mutex
+	path string
+}
+
+// auditedPathPrefixes lists the endpoints that change data or trigger outbound syncs.
+var auditedPathPrefixes = []string{"/sync", "/ledger/", "/patches/"}
+
+func isAuditedRequest(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	for _, prefix := range auditedPathPrefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenFingerprint(token string) string {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:6])
+}
+
+func (l *auditLogger) append(record auditRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(record)
+}
+
+// auditResponseWriter keeps the status and the start of the body so the JSON message can be logged as the outcome.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *auditResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if remaining := 4096 - w.body.Len(); remaining > 0 {
+		w.body.Write(data[:min(len(data), remaining)])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) outcome() string {
+	var response struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(w.body.Bytes(), &response); err == nil && response.Message != "" {
+		return response.Message
+	}
+	return http.StatusText(w.status)
+}
+
+// auditedGameID prefers the gameId from the body and falls back to the /ledger/{game} or /patches/{game} path segment.
+func auditedGameID(r *http.Request, body []byte) (string, bool) {
+	var fields struct {
+		GameID string `json:"gameId"`
+		DryRun bool   `json:"dryRun"`
+	}
+	_ = json.Unmarshal(body, &fields)
+	if fields.GameID == "" {
+		for _, prefix := range []string{"/ledger/", "/patches/"} {
+			if rest, ok := strings.CutPrefix(r.URL.Path, prefix); ok {
+				fields.GameID, _, _ = strings.Cut(rest, "/")
+			}
+		}
+	}
+	return fields.GameID, fields.DryRun
+}
+
+func withAuditLog(next http.Handler, logger *auditLogger, maxBodyBytes int64) http.Handler {
+	if logger == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAuditedRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		started := time.Now()
+		body, _ := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		recorder := &auditResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		gameID, dryRun := auditedGameID(r, body)
+		record := auditRecord{
+			Timestamp:        started.UTC().Format(time.RFC3339),
+			RequestID:        requestIDFromContext(r.Context()),
+			RemoteAddr:       clientIP(r),
+			Method:           r.Method,
+			Path:             r.URL.Path,
+			GameID:           gameID,
+			DryRun:           dryRun,
+			TokenFingerprint: tokenFingerprint(r.Header.Get("X-Patchsync-Token")),
+			Status:           recorder.status,
+			Outcome:          recorder.outcome(),
+			DurationMs:       time.Since(started).Milliseconds(),
+		}
+		if err := logger.append(record); err != nil {
+			fmt.Fprintf(os.Stderr, "audit log write failed: %v\n", err)
+		}
+	})
+}
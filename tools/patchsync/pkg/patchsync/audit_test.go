@@ -0,0 +1,55 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithAuditLogRecordsMutatingRequests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	handler := withAuditLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req syncRequest
+		if err := parseSyncRequestBody(r, &req, defaultMaxBodyBytes); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusUnauthorized, syncResponse{OK: false, Message: "unauthorized"})
+	}), &auditLogger{path: path}, defaultMaxBodyBytes)
+
+	sync := httptest.NewRequest(http.MethodPost, "/sync", strings.NewReader(`{"gameId":"wuthering-waves","dryRun":true}`))
+	sync.RemoteAddr = "10.1.2.3:5555"
+	sync.Header.Set("X-Patchsync-Token", "secret")
+	handler.ServeHTTP(httptest.NewRecorder(), sync)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/ledger/genshin-impact/adjustments/3", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/wuthering-waves/patches", nil))
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 audit records, got %d: %s", len(lines), body)
+	}
+	var first, second auditRecord
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("parse first record: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("parse second record: %v", err)
+	}
+	if first.GameID != "wuthering-waves" || !first.DryRun || first.RemoteAddr != "10.1.2.3" || first.Status != http.StatusUnauthorized || first.Outcome != "unauthorized" {
+		t.Fatalf("unexpected sync record %+v", first)
+	}
+	if first.TokenFingerprint != tokenFingerprint("secret") || strings.Contains(lines[0], "secret") {
+		t.Fatalf("token fingerprint missing or token leaked: %s", lines[0])
+	}
+	if second.GameID != "genshin-impact" || second.Method != http.MethodDelete {
+		t.Fatalf("unexpected ledger record %+v", second)
+	}
+}
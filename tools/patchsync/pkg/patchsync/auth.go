@@ -0,0 +1,229 @@
+package patchsync
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	scopeRead = "read"
+	scopeSync = "sync"
+)
+
+type scopedToken struct {
+	Scope string
+	Token string
+}
+
+// authTokens holds every accepted token. A sync token can do anything a read token can.
+type authTokens struct {
+	mu           sync.RWMutex
+	tokens       []scopedToken
+	static       []scopedToken
+	tokenFile    string
+	fileModTime  time.Time
+	privateReads bool
+}
+
+// parseScopedTokens reads "scope:token" entries separated by commas, for example "read:abc,sync:def".
+func parseScopedTokens(raw string) ([]scopedToken, error) {
+	tokens := []scopedToken{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		scope, token, ok := strings.Cut(entry, ":")
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		token = strings.TrimSpace(token)
+		if !ok || token == "" {
+			return nil, fmt.Errorf("token entry %q must look like scope:token", entry)
+		}
+		if scope != scopeRead && scope != scopeSync {
+			return nil, fmt.Errorf("unknown token scope %q (allowed: %s, %s)", scope, scopeRead, scopeSync)
+		}
+		tokens = append(tokens, scopedToken{Scope: scope, Token: token})
+	}
+	return tokens, nil
+}
+
+// parseTokenFile accepts one entry per line. A line without a read: or sync: prefix is a sync token,
+// so a secret file holding just the token works as a drop-in for PATCHSYNC_TOKEN.
+func parseTokenFile(body string) ([]scopedToken, error) {
+	entries := []string{}
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		scope, _, ok := strings.Cut(line, ":")
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !ok || (scope != scopeRead && scope != scopeSync) {
+			line = scopeSync + ":" + line
+		}
+		entries = append(entries, line)
+	}
+	return parseScopedTokens(strings.Join(entries, ","))
+}
+
+// newAuthTokens combines the legacy single --auth-token, which keeps full sync access, with scoped tokens
+// and the optional token file.
+func newAuthTokens(legacyToken, scopedRaw, tokenFile string, privateReads bool) (*authTokens, error) {
+	tokens, err := parseScopedTokens(scopedRaw)
+	if err != nil {
+		return nil, err
+	}
+	if legacy := strings.TrimSpace(legacyToken); legacy != "" {
+		tokens = append(tokens, scopedToken{Scope: scopeSync, Token: legacy})
+	}
+	auth := &authTokens{static: tokens, tokens: tokens, tokenFile: strings.TrimSpace(tokenFile), privateReads: privateReads}
+	if err := auth.reload(); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// reload re-reads the token file. On error the previous tokens stay active.
+func (a *authTokens) reload() error {
+	if a.tokenFile == "" {
+		return nil
+	}
+	info, err := os.Stat(a.tokenFile)
+	if err != nil {
+		return fmt.Errorf("read token file: %w", err)
+	}
+	body, err := os.ReadFile(a.tokenFile)
+	if err != nil {
+		return fmt.Errorf("read token file: %w", err)
+	}
+	fileTokens, err := parseTokenFile(string(body))
+	if err != nil {
+		return fmt.Errorf("parse token file: %w", err)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tokens = append(append([]scopedToken{}, a.static...), fileTokens...)
+	a.fileModTime = info.ModTime()
+	return nil
+}
+
+func (a *authTokens) fileChanged() bool {
+	if a.tokenFile == "" {
+		return false
+	}
+	info, err := os.Stat(a.tokenFile)
+	if err != nil {
+		return false
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return !info.ModTime().Equal(a.fileModTime)
+}
+
+// watchTokenFile reloads the token file on SIGHUP and whenever its modification time changes.
+func (a *authTokens) watchTokenFile(interval time.Duration) {
+	if a.tokenFile == "" {
+		return
+	}
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-hangup:
+			case <-ticker.C:
+				if !a.fileChanged() {
+					continue
+				}
+			}
+			if err := a.reload(); err != nil {
+				fmt.Fprintf(os.Stderr, "token reload failed, keeping previous tokens: %v\n", err)
+				continue
+			}
+			fmt.Printf("reloaded tokens from %s\n", a.tokenFile)
+		}
+	}()
+}
+
+func (a *authTokens) empty() bool {
+	if a == nil {
+		return true
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.tokens) == 0
+}
+
+// authorize reports whether the request token grants scope. With no tokens configured everything is allowed.
+func (a *authTokens) authorize(r *http.Request, scope string) bool {
+	return a.authorizeToken(r.Header.Get("X-Patchsync-Token"), scope)
+}
+
+// authorizeToken is authorize for a token that did not arrive in the header, such as the auth message on /ws.
+func (a *authTokens) authorizeToken(token, scope string) bool {
+	if a == nil {
+		return true
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if len(a.tokens) == 0 {
+		return true
+	}
+	requestToken := strings.TrimSpace(token)
+	if requestToken == "" {
+		return false
+	}
+	allowed := false
+	for _, candidate := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(requestToken), []byte(candidate.Token)) != 1 {
+			continue
+		}
+		if candidate.Scope == scopeSync || candidate.Scope == scope {
+			allowed = true
+		}
+	}
+	return allowed
+}
+
+// publicPaths skip withReadAuth. /ws cannot carry the token header from a browser, so it checks the token sent in
+// its first message instead.
+var publicPaths = map[string]struct{}{
+	"/":        {},
+	"/health":  {},
+	"/healthz": {},
+	"/readyz":  {},
+	"/ws":      {},
+}
+
+// withReadAuth requires a read token for every endpoint except health checks and the admin page when --private-reads is set.
+// Sync and ledger endpoints still check their own, stricter scopes.
+func withReadAuth(next http.Handler, tokens *authTokens, allowedOrigins map[string]struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tokens == nil || !tokens.privateReads || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, ok := publicPaths[r.URL.Path]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !tokens.authorize(r, scopeRead) {
+			withCORS(w, r, allowedOrigins)
+			writeJSON(w, http.StatusUnauthorized, syncResponse{
+				OK:      false,
+				Message: "unauthorized",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
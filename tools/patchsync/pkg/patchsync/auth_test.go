@@ -0,0 +1,114 @@
+package patchsync
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuthTokensScopes(t *testing.T) {
+	tokens, err := newAuthTokens("legacy", "read:reader, sync:syncer", "", true)
+	if err != nil {
+		t.Fatalf("newAuthTokens() error = %v", err)
+	}
+	request := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/wuthering-waves/patches", nil)
+		if token != "" {
+			r.Header.Set("X-Patchsync-Token", token)
+		}
+		return r
+	}
+	cases := []struct {
+		token string
+		scope string
+		want  bool
+	}{
+		{"reader", scopeRead, true},
+		{"reader", scopeSync, false},
+		{"syncer", scopeRead, true},
+		{"syncer", scopeSync, true},
+		{"legacy", scopeSync, true},
+		{"", scopeRead, false},
+		{"wrong", scopeRead, false},
+	}
+	for _, tc := range cases {
+		if got := tokens.authorize(request(tc.token), tc.scope); got != tc.want {
+			t.Errorf("authorize(%q, %s) = %v, want %v", tc.token, tc.scope, got, tc.want)
+		}
+	}
+
+	handler := withReadAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), tokens, map[string]struct{}{})
+	for _, tc := range []struct {
+		path  string
+		token string
+		want  int
+	}{
+		{"/api/wuthering-waves/patches", "", http.StatusUnauthorized},
+		{"/api/wuthering-waves/patches", "reader", http.StatusNoContent},
+		{"/healthz", "", http.StatusNoContent},
+	} {
+		recorder := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.token != "" {
+			r.Header.Set("X-Patchsync-Token", tc.token)
+		}
+		handler.ServeHTTP(recorder, r)
+		if recorder.Code != tc.want {
+			t.Errorf("%s with token %q: code = %d, want %d", tc.path, tc.token, recorder.Code, tc.want)
+		}
+	}
+
+	open, _ := newAuthTokens("", "", "", false)
+	if !open.empty() || !open.authorize(request(""), scopeSync) {
+		t.Fatalf("no configured tokens should allow everything")
+	}
+	if _, err := newAuthTokens("", "admin:x", "", false); err == nil {
+		t.Fatalf("expected unknown scope error")
+	}
+	if _, err := newAuthTokens("", "read:", "", false); err == nil {
+		t.Fatalf("expected empty token error")
+	}
+}
+
+func TestAuthTokensReloadFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(path, []byte("# mounted secret\nfirst\nread:viewer\n"), 0o600); err != nil {
+		t.Fatalf("write token file: %v", err)
+	}
+	tokens, err := newAuthTokens("", "", path, false)
+	if err != nil {
+		t.Fatalf("newAuthTokens() error = %v", err)
+	}
+	request := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/sync", nil)
+		r.Header.Set("X-Patchsync-Token", token)
+		return r
+	}
+	if !tokens.authorize(request("first"), scopeSync) || tokens.authorize(request("viewer"), scopeSync) {
+		t.Fatalf("unexpected scopes after initial load")
+	}
+
+	if err := os.WriteFile(path, []byte("second\n"), 0o600); err != nil {
+		t.Fatalf("rewrite token file: %v", err)
+	}
+	if err := tokens.reload(); err != nil {
+		t.Fatalf("reload() error = %v", err)
+	}
+	if tokens.authorize(request("first"), scopeSync) || !tokens.authorize(request("second"), scopeSync) {
+		t.Fatalf("rotation did not replace the old token")
+	}
+
+	if err := os.WriteFile(path, []byte("read:\n"), 0o600); err != nil {
+		t.Fatalf("rewrite token file: %v", err)
+	}
+	if err := tokens.reload(); err == nil {
+		t.Fatalf("expected parse error")
+	}
+	if !tokens.authorize(request("second"), scopeSync) {
+		t.Fatalf("failed reload should keep the previous tokens")
+	}
+}
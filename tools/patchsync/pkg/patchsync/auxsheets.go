@@ -0,0 +1,72 @@
+package patchsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// normalizeAuxSheetName lowercases name and reduces it to its letter and digit runs, so "Pull-Data " and
+// "pull data" compare equal.
+func normalizeAuxSheetName(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// pickAuxSheet returns the tab that best matches candidates, in candidate order: an exact match after
+// normalization first, then a non-version tab containing the candidate as whole words ("Data (new)").
+func pickAuxSheet(tabs, candidates []string) string {
+	for _, candidate := range candidates {
+		want := normalizeAuxSheetName(candidate)
+		for _, tab := range tabs {
+			if want != "" && normalizeAuxSheetName(tab) == want {
+				return tab
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		want := normalizeAuxSheetName(candidate)
+		if want == "" {
+			continue
+		}
+		for _, tab := range tabs {
+			if isVersionLikeSheetName(tab) {
+				continue
+			}
+			if strings.Contains(" "+normalizeAuxSheetName(tab)+" ", " "+want+" ") {
+				return tab
+			}
+		}
+	}
+	return ""
+}
+
+// resolveAuxSheet finds and fetches an auxiliary tab such as Data or Summary. Fetchers that can list tabs
+// are matched with pickAuxSheet; others get each candidate name tried in turn.
+func resolveAuxSheet(ctx context.Context, fetcher SheetFetcher, spreadsheetID string, candidates []string) (string, string, error) {
+	if lister, ok := fetcher.(sheetTabLister); ok {
+		if tabs, err := lister.ListTabs(ctx, spreadsheetID); err == nil && len(tabs) > 0 {
+			name := pickAuxSheet(tabs, candidates)
+			if name == "" {
+				return "", "", fmt.Errorf("no tab matches %s (%s)", strings.Join(candidates, ", "), describeFoundSheetNames(tabs))
+			}
+			csvText, err := fetcher.FetchCSV(ctx, spreadsheetID, name)
+			return name, csvText, err
+		}
+	}
+	var lastErr error
+	for _, candidate := range uniqueStrings(candidates) {
+		csvText, err := fetcher.FetchCSV(ctx, spreadsheetID, candidate)
+		if err == nil {
+			return candidate, csvText, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no candidate tab names")
+	}
+	return "", "", lastErr
+}
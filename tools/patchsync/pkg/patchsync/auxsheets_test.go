@@ -0,0 +1,90 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPickAuxSheet(t *testing.T) {
+	for _, tc := range []struct {
+		tabs []string
+		want string
+	}{
+		{[]string{"2.0", "Pull Data", "Data"}, "Data"},
+		{[]string{"2.0", "pull-data "}, "pull-data "},
+		{[]string{"Notes", "Data (new)"}, "Data (new)"},
+		{[]string{"2.1 Data", "Totals"}, "Totals"},
+		{[]string{"Database", "Notes"}, ""},
+		{[]string{"データ"}, "データ"},
+	} {
+		if got := pickAuxSheet(tc.tabs, dataSheetCandidates); got != tc.want {
+			t.Errorf("pickAuxSheet(%v) = %q, want %q", tc.tabs, got, tc.want)
+		}
+	}
+}
+
+func TestResolveAuxSheet(t *testing.T) {
+	dir := t.TempDir()
+	sheetDir := filepath.Join(dir, "abc")
+	if err := os.MkdirAll(sheetDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{"2.0.csv": "a", "Pull Data.csv": "pulls"} {
+		if err := os.WriteFile(filepath.Join(sheetDir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	name, csvText, err := resolveAuxSheet(t.Context(), localSheetFetcher{dir: dir}, "abc", dataSheetCandidates)
+	if err != nil || name != "Pull Data" || csvText != "pulls" {
+		t.Fatalf("resolveAuxSheet(local) = %q, %q, %v", name, csvText, err)
+	}
+	if _, _, err := resolveAuxSheet(t.Context(), localSheetFetcher{dir: dir}, "abc", summarySheetCandidates); err == nil {
+		t.Fatalf("expected no Summary tab to be found")
+	}
+
+	// Fetchers that cannot list tabs get each candidate tried in order.
+	fetcher := fakeSheetFetcher{sheets: map[string]string{"Totals": "t", "Pulls": "p"}}
+	name, csvText, err = resolveAuxSheet(t.Context(), fetcher, "abc", dataSheetCandidates)
+	if err != nil || name != "Pulls" || csvText != "p" {
+		t.Fatalf("resolveAuxSheet(fake) = %q, %q, %v", name, csvText, err)
+	}
+}
+
+func TestRunSyncRecordsAuxSheetInMeta(t *testing.T) {
+	dir := t.TempDir()
+	sheetDir := filepath.Join(dir, "sheets", "aux-sheet")
+	if err := os.MkdirAll(sheetDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{"3.4.csv": wuwaSheetCSV("3.4"), "Pull Totals.csv": "Patch,3.4\n"} {
+		if err := os.WriteFile(filepath.Join(sheetDir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	overridesDir := filepath.Join(dir, "overrides")
+	if err := os.MkdirAll(overridesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(overridesDir, gameIDWuwa+".json"), []byte(`{"sheets":{"aux":["Pull Totals"]}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := RunSync(t.Context(), SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "aux-sheet",
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    overridesDir,
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		SheetSource:     sheetSourceLocal,
+		LocalSheetsDir:  filepath.Join(dir, "sheets"),
+		DryRun:          true,
+	})
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	if want := map[string]string{"aux-sheet": "Pull Totals"}; !reflect.DeepEqual(result.Meta.AuxSheets, want) {
+		t.Fatalf("Meta.AuxSheets = %v, want %v", result.Meta.AuxSheets, want)
+	}
+}
@@ -0,0 +1,84 @@
+package patchsync
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+func runBackfillCommand(args []string) int {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	var (
+		gameID        string
+		spreadsheetID string
+		outputPath    string
+		overridesDir  string
+		createBranch  bool
+		branchPrefix  string
+		repoRoot      string
+		dryRun        bool
+		allowHistory  bool
+		clientTimeout time.Duration
+		syncTimeout   time.Duration
+		encoding      OutputEncoding
+		outbound      OutboundOptions
+	)
+	fs.StringVar(&gameID, "game", defaultGameID, fmt.Sprintf("Game id (%s)", strings.Join(AvailableGameIDs(), ", ")))
+	fs.StringVar(&spreadsheetID, "spreadsheet-id", "", "Google Spreadsheet ID or full spreadsheet URL (comma-separated list merges spreadsheets in priority order)")
+	fs.StringVar(&outputPath, "output", "", "Output JS file path (optional; defaults by game)")
+	fs.StringVar(&overridesDir, "overrides-dir", defaultOverridesDir, "Directory with <game>.json manual patch/source overrides")
+	fs.BoolVar(&createBranch, "create-branch", false, "Write and commit the generated file on a new git branch, in a temporary worktree")
+	fs.StringVar(&branchPrefix, "branch-prefix", "data/backfill", "Git branch prefix for create-branch")
+	fs.StringVar(&repoRoot, "repo-root", "", "Git repository create-branch works in (default: the one containing the working directory)")
+	fs.BoolVar(&dryRun, "dry-run", false, "Parse and validate only, do not write file")
+	fs.BoolVar(&allowHistory, "allow-historical-edits", false, "Also rewrite patches older than the live one")
+	fs.DurationVar(&clientTimeout, "timeout", 20*time.Second, "HTTP client timeout")
+	fs.DurationVar(&syncTimeout, "sync-timeout", defaultSyncTimeout, "Overall deadline for one game's sync, checked between sheets (0 disables)")
+	fs.StringVar(&encoding.Newline, "newline", newlineLF, "Line endings of the generated file: lf or crlf")
+	fs.BoolVar(&encoding.BOM, "bom", false, "Start the generated file with a UTF-8 byte order mark")
+	registerOutboundFlags(fs, &outbound)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	result, err := RunSync(context.Background(), SyncConfig{
+		GameID:          gameID,
+		SpreadsheetID:   spreadsheetID,
+		OutputPath:      outputPath,
+		BasePatchesPath: "src/data/patches.js",
+		OverridesDir:    overridesDir,
+		CreateBranch:    createBranch,
+		BranchPrefix:    branchPrefix,
+		RepoRoot:        repoRoot,
+		Backfill:        true,
+		AllowHistorical: allowHistory,
+		DryRun:          dryRun,
+		ClientTimeout:   clientTimeout,
+		SyncTimeout:     syncTimeout,
+		Outbound:        outbound,
+		ChangeLogLimits: defaultChangeLogRetention(),
+		OutputHistory:   defaultOutputHistory(),
+		OutputEncoding:  encoding,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backfill failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Game: %s\n", result.GameID)
+	fmt.Printf("Regenerated patches: %s\n", strings.Join(patchNamesFromPatches(result.AllPatches), ", "))
+	fmt.Printf("Changed patches: %d\n", result.ChangeCount)
+	for _, frozen := range result.FrozenPatches {
+		fmt.Printf("Kept historical patch %s (%s); rerun with --allow-historical-edits to rewrite it\n", frozen.Patch, frozen.Status)
+	}
+	fmt.Printf("Output: %s\n", result.OutputPath)
+	if result.BranchName != "" {
+		fmt.Printf("Branch: %s\n", result.BranchName)
+	}
+	if result.Commit != "" {
+		fmt.Printf("Commit: %s\n", result.Commit)
+	}
+	return 0
+}
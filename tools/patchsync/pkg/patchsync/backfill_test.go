@@ -0,0 +1,47 @@
+package patchsync
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBackfillKeepsPatchesWhenASheetFails(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		AllowHistorical: true,
+		Fetcher: fakeSheetFetcher{sheets: map[string]string{
+			"3.3": wuwaSheetCSV("3.3"),
+			"3.4": wuwaSheetCSV("3.4"),
+		}},
+	}
+	if _, err := RunSync(t.Context(), cfg); err != nil {
+		t.Fatalf("initial RunSync() error = %v", err)
+	}
+
+	cfg.Backfill = true
+	cfg.Fetcher = fakeSheetFetcher{sheets: map[string]string{
+		"3.3": "not a patch sheet",
+		"3.4": wuwaSheetCSV("3.4"),
+	}}
+	result, err := RunSync(t.Context(), cfg)
+	if err == nil {
+		t.Fatal("backfill RunSync() with a failing sheet succeeded")
+	}
+	if len(result.SheetFailures) != 1 || result.SheetFailures[0].Sheet != "3.3" || errorCode(err) != result.SheetFailures[0].Code {
+		t.Fatalf("SheetFailures = %+v, error %v; want sheet 3.3 with the same code", result.SheetFailures, err)
+	}
+	patches, err := readGeneratedPatches(cfg.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 2 {
+		t.Fatalf("patches after failed backfill = %d, want both kept", len(patches))
+	}
+}
@@ -0,0 +1,65 @@
+package patchsync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Base overlap policies decide what a sync does with patch IDs that the base patches file already defines for the
+// game. The app lets a generated patch replace the base one with the same ID, which is what prefer-generated keeps.
+const (
+	baseOverlapPreferGenerated = "prefer-generated"
+	baseOverlapPreferBase      = "prefer-base"
+	baseOverlapError           = "error"
+)
+
+func parseBaseOverlapPolicy(raw string) (string, error) {
+	switch policy := strings.ToLower(strings.TrimSpace(raw)); policy {
+	case "":
+		return baseOverlapPreferGenerated, nil
+	case baseOverlapPreferGenerated, baseOverlapPreferBase, baseOverlapError:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid base overlap policy %q (use %s, %s, or %s)", raw, baseOverlapPreferGenerated, baseOverlapPreferBase, baseOverlapError)
+	}
+}
+
+// readBasePatchIDs returns the patch IDs the base patches file defines for one game: those in its arrayName array
+// when the file has one, else every patch ID in the file.
+func readBasePatchIDs(path, arrayName string) (map[string]struct{}, error) {
+	result := map[string]struct{}{}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return result, nil
+		}
+		return nil, err
+	}
+	content := string(body)
+	if arrayName != "" {
+		pattern := regexp.MustCompile(`(?s)const\s+` + regexp.QuoteMeta(arrayName) + `\s*=\s*\[(.*?)\n\];`)
+		if match := pattern.FindStringSubmatch(content); len(match) >= 2 {
+			content = match[1]
+		}
+	}
+	for _, patchID := range readPatchIDsFromContent(content) {
+		result[canonicalPatchID(patchID)] = struct{}{}
+	}
+	return result, nil
+}
+
+// baseOverlaps returns the sorted IDs of patches that baseIDs also defines.
+func baseOverlaps(baseIDs map[string]struct{}, patches []Patch) []string {
+	overlaps := []string{}
+	for _, patch := range patches {
+		if _, ok := baseIDs[patchIDOrFallback(patch)]; ok {
+			overlaps = append(overlaps, patchIDOrFallback(patch))
+		}
+	}
+	overlaps = uniqueStrings(overlaps)
+	sortVersionStrings(overlaps)
+	return overlaps
+}
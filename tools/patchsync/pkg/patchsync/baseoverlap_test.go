@@ -0,0 +1,88 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const basePatchesForTest = `const END_FIELD_BASE_PATCHES = [
+  {
+    id: "1.0",
+    patch: "1.0",
+  },
+];
+
+const WUWA_BASE_PATCHES = [
+  {
+    id: "3.4",
+    patch: "3.4",
+  },
+];
+`
+
+func TestReadBasePatchIDsPerGame(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patches.js")
+	if err := os.WriteFile(path, []byte(basePatchesForTest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := readBasePatchIDs(path, "WUWA_BASE_PATCHES")
+	if err != nil || !reflect.DeepEqual(ids, map[string]struct{}{"3.4": {}}) {
+		t.Fatalf("readBasePatchIDs(WUWA) = %v, %v; want only 3.4", ids, err)
+	}
+	if ids, _ := readBasePatchIDs(path, "MISSING_BASE_PATCHES"); len(ids) != 2 {
+		t.Fatalf("readBasePatchIDs without the array = %v, want every id in the file", ids)
+	}
+}
+
+func TestRunSyncBaseOverlapPolicies(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4", "3.5"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		DryRun:          true,
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4"), "3.5": wuwaSheetCSV("3.5")}},
+	}
+	if err := os.WriteFile(cfg.BasePatchesPath, []byte(basePatchesForTest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	if !reflect.DeepEqual(result.BaseOverlaps, []string{"3.4"}) || len(result.AllPatches) != 2 {
+		t.Fatalf("prefer-generated overlaps = %v with %d patches, want 3.4 reported and kept", result.BaseOverlaps, len(result.AllPatches))
+	}
+
+	cfg.BaseOverlap = baseOverlapError
+	result, err = RunSync(t.Context(), cfg)
+	if errorCode(err) != errCodeDuplicatePatch || !reflect.DeepEqual(result.BaseOverlaps, []string{"3.4"}) {
+		t.Fatalf("error policy = %v, %v; want %s for 3.4", err, result.BaseOverlaps, errCodeDuplicatePatch)
+	}
+
+	cfg.BaseOverlap = baseOverlapPreferBase
+	cfg.DryRun = false
+	if _, err := RunSync(t.Context(), cfg); err != nil {
+		t.Fatalf("prefer-base RunSync() error = %v", err)
+	}
+	patches, err := readGeneratedPatches(cfg.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 1 || patches[0].ID != "3.5" {
+		t.Fatalf("prefer-base output = %+v, want only 3.5", patches)
+	}
+
+	cfg.BaseOverlap = "merge"
+	if _, err := RunSync(t.Context(), cfg); errorCode(err) != errCodeConfig {
+		t.Fatalf("unknown policy error = %v, want %s", err, errCodeConfig)
+	}
+}
@@ -0,0 +1,60 @@
+package patchsync
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// currencyBound is the plausible amount of one currency in a patch. A nil Min or Max leaves that side open.
+type currencyBound struct {
+	Min *float64 `json:"min"`
+	Max *float64 `json:"max"`
+}
+
+// currencyBounds is the "bounds" block of an overrides file, keyed by the game's currency keys (see
+// currenciesByGame). A sheet value outside its bound is most often a misaligned column, so the sync warns
+// about it instead of publishing it silently.
+type currencyBounds map[string]currencyBound
+
+func (bounds currencyBounds) validate(gameID string) error {
+	known := map[string]bool{}
+	keys := []string{}
+	for _, currency := range currenciesForGame(gameID) {
+		known[currency.Key] = true
+		keys = append(keys, currency.Key)
+	}
+	for key, bound := range bounds {
+		if !known[key] {
+			return fmt.Errorf("unknown currency %q in bounds (use %s)", key, strings.Join(keys, ", "))
+		}
+		if bound.Min != nil && bound.Max != nil && *bound.Min > *bound.Max {
+			return fmt.Errorf("bounds for %s: min %v is above max %v", key, *bound.Min, *bound.Max)
+		}
+	}
+	return nil
+}
+
+// check returns a warning for every bounded currency whose patch total, summed over all sources, is out of
+// bounds.
+func (bounds currencyBounds) check(gameID string, patch Patch) []string {
+	warnings := []string{}
+	for _, currency := range currenciesForGame(gameID) {
+		bound, ok := bounds[currency.Key]
+		if !ok {
+			continue
+		}
+		total := 0.0
+		for _, src := range patch.Sources {
+			total += currency.value(src.Rewards)
+		}
+		switch {
+		case bound.Min != nil && total < *bound.Min:
+			warnings = append(warnings, fmt.Sprintf("patch %s: %s %v is below the plausible minimum %v", patchIDOrFallback(patch), currency.Key, total, *bound.Min))
+		case bound.Max != nil && total > *bound.Max:
+			warnings = append(warnings, fmt.Sprintf("patch %s: %s %v is above the plausible maximum %v", patchIDOrFallback(patch), currency.Key, total, *bound.Max))
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
@@ -0,0 +1,71 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCurrencyBoundsCheck(t *testing.T) {
+	minimum, maximum := 5000.0, 30000.0
+	bounds := currencyBounds{"primogem": {Min: &minimum, Max: &maximum}}
+	patch := Patch{ID: "5.4", Sources: []Source{
+		{ID: "events", Rewards: Rewards{Oroberyl: 30}},
+		{ID: "permanent", Rewards: Rewards{Oroberyl: 12}},
+	}}
+	got := bounds.check(gameIDGenshin, patch)
+	if len(got) != 1 || got[0] != "patch 5.4: primogem 42 is below the plausible minimum 5000" {
+		t.Fatalf("check() = %v", got)
+	}
+	patch.Sources[0].Rewards.Oroberyl = 12000
+	if got := bounds.check(gameIDGenshin, patch); len(got) != 0 {
+		t.Fatalf("check() within bounds = %v, want none", got)
+	}
+
+	if err := (currencyBounds{"astrite": {Min: &minimum}}).validate(gameIDGenshin); err == nil {
+		t.Fatal("expected an error for a currency of another game")
+	}
+	if err := (currencyBounds{"primogem": {Min: &maximum, Max: &minimum}}).validate(gameIDGenshin); err == nil {
+		t.Fatal("expected an error for min above max")
+	}
+}
+
+func TestRunSyncWarnsAboutOutOfBoundsCurrency(t *testing.T) {
+	dir := t.TempDir()
+	overridesDir := filepath.Join(dir, "overrides")
+	if err := os.MkdirAll(overridesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(overridesDir, gameIDWuwa+".json"), []byte(`{"bounds":{"astrite":{"min":20000}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    overridesDir,
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		DryRun:          true,
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+	}
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	if len(result.OutOfBounds) != 1 || !strings.Contains(result.OutOfBounds[0], "astrite") {
+		t.Fatalf("OutOfBounds = %v, want one astrite warning", result.OutOfBounds)
+	}
+	if !strings.Contains(strings.Join(result.Logs, "\n"), "warning: patch 3.4: astrite") {
+		t.Fatalf("logs do not warn about the bounds: %v", result.Logs)
+	}
+
+	if err := os.WriteFile(filepath.Join(overridesDir, gameIDWuwa+".json"), []byte(`{"bounds":{"primogem":{"min":1}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RunSync(t.Context(), cfg); errorCode(err) != errCodeConfig {
+		t.Fatalf("RunSync() with a foreign currency = %v, want %s", err, errCodeConfig)
+	}
+}
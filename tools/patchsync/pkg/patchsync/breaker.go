@@ -0,0 +1,106 @@
+package patchsync
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+)
+
+const defaultBreakerThreshold = 3
+
+// breakerSpreadsheetPattern finds the spreadsheet ID in gviz, export, pubhtml, and Sheets API URLs.
+var breakerSpreadsheetPattern = regexp.MustCompile(`/spreadsheets/(?:d/e/|d/)?([a-zA-Z0-9-_]+)`)
+
+// spreadsheetBreaker stops requests to a spreadsheet once Google answered 429 or 403 threshold times in a
+// row. /sync-all shares one breaker between its games, so a throttled spreadsheet fails fast for the rest
+// of the run instead of repeating the same failure for every probe and tab.
+type spreadsheetBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	failures  map[string]int
+	open      map[string]int
+}
+
+func newSpreadsheetBreaker(threshold int) *spreadsheetBreaker {
+	return &spreadsheetBreaker{threshold: threshold, failures: map[string]int{}, open: map[string]int{}}
+}
+
+type spreadsheetThrottledError struct {
+	spreadsheetID string
+	status        int
+}
+
+func (e *spreadsheetThrottledError) Error() string {
+	return fmt.Sprintf("spreadsheet %s is rate-limited (HTTP %d), retry later", e.spreadsheetID, e.status)
+}
+
+func (b *spreadsheetBreaker) enabled() bool {
+	return b != nil && b.threshold > 0
+}
+
+// openStatus returns the status that opened the breaker for spreadsheetID, or 0 while it is closed.
+func (b *spreadsheetBreaker) openStatus(spreadsheetID string) int {
+	if !b.enabled() {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open[spreadsheetID]
+}
+
+func (b *spreadsheetBreaker) record(spreadsheetID string, status int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case status == http.StatusTooManyRequests || status == http.StatusForbidden:
+		b.failures[spreadsheetID]++
+		if b.failures[spreadsheetID] >= b.threshold {
+			b.open[spreadsheetID] = status
+		}
+	case status < 300:
+		b.failures[spreadsheetID] = 0
+	}
+}
+
+// throttled returns the error for the first of spreadsheetIDs whose breaker is open, or nil.
+func (b *spreadsheetBreaker) throttled(spreadsheetIDs []string) error {
+	for _, id := range spreadsheetIDs {
+		if status := b.openStatus(id); status != 0 {
+			return &spreadsheetThrottledError{spreadsheetID: id, status: status}
+		}
+	}
+	return nil
+}
+
+func (b *spreadsheetBreaker) wrap(next http.RoundTripper) http.RoundTripper {
+	if !b.enabled() {
+		return next
+	}
+	return breakerTransport{breaker: b, next: next}
+}
+
+type breakerTransport struct {
+	breaker *spreadsheetBreaker
+	next    http.RoundTripper
+}
+
+func (t breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	matches := breakerSpreadsheetPattern.FindStringSubmatch(req.URL.Path)
+	if len(matches) < 2 {
+		return next.RoundTrip(req)
+	}
+	spreadsheetID := matches[1]
+	if status := t.breaker.openStatus(spreadsheetID); status != 0 {
+		return nil, &spreadsheetThrottledError{spreadsheetID: spreadsheetID, status: status}
+	}
+	resp, err := next.RoundTrip(req)
+	if err == nil {
+		t.breaker.record(spreadsheetID, resp.StatusCode)
+	}
+	return resp, err
+}
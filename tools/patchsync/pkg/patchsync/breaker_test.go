@@ -0,0 +1,86 @@
+package patchsync
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBreakerTransportOpensPerSpreadsheet(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if strings.Contains(r.URL.Path, "/throttled/") {
+			http.Error(w, "quota", http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	breaker := newSpreadsheetBreaker(2)
+	client := &http.Client{Transport: breaker.wrap(nil)}
+	for range 4 {
+		fetchText(t.Context(), client, server.URL+"/spreadsheets/d/throttled/gviz/tq")
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("requests reaching the server = %d, want 2", got)
+	}
+	_, err := fetchText(t.Context(), client, server.URL+"/spreadsheets/d/throttled/edit")
+	var throttled *spreadsheetThrottledError
+	if !errors.As(err, &throttled) || throttled.spreadsheetID != "throttled" || throttled.status != http.StatusTooManyRequests {
+		t.Fatalf("open breaker error = %v", err)
+	}
+	if body, err := fetchText(t.Context(), client, server.URL+"/v4/spreadsheets/other/values/3.4"); err != nil || body != "ok" {
+		t.Fatalf("other spreadsheet = %q, %v", body, err)
+	}
+	if err := breaker.throttled([]string{"other", "throttled"}); err == nil {
+		t.Fatalf("expected throttled() to report the open spreadsheet")
+	}
+	if disabled := newSpreadsheetBreaker(0); disabled.wrap(http.DefaultTransport) != http.DefaultTransport {
+		t.Fatalf("a zero threshold should leave the transport unwrapped")
+	}
+}
+
+func TestRunSyncReportsThrottledSpreadsheet(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "busy",
+		SheetNames:      []string{"3.4"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		GoogleBaseURL:   server.URL,
+		BreakerLimit:    2,
+		DryRun:          true,
+		breaker:         newSpreadsheetBreaker(2),
+	}
+	_, err := RunSync(t.Context(), cfg)
+	if errorCode(err) != errCodeSpreadsheetThrottled || !strings.Contains(err.Error(), "retry later") {
+		t.Fatalf("RunSync() error = %v (%s)", err, errorCode(err))
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("requests reaching the server = %d, want 2", got)
+	}
+
+	// A second game of the same /sync-all run shares the breaker and never reaches the server.
+	if _, err := RunSync(t.Context(), cfg); errorCode(err) != errCodeSpreadsheetThrottled {
+		t.Fatalf("second RunSync() error = %v", err)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("requests after the breaker opened = %d, want 2", got)
+	}
+}
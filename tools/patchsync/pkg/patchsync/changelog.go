@@ -0,0 +1,345 @@
+package patchsync
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultChangeLogMaxBytes = 1 << 20
+	defaultChangeLogMaxAge   = 90 * 24 * time.Hour
+	defaultChangeLogKeep     = 12
+)
+
+// ChangeLogRetention controls when the live change log is rotated into a gzipped segment next to it.
+type ChangeLogRetention struct {
+	MaxBytes int64         // rotate once the live log reaches this size (0 disables)
+	MaxAge   time.Duration // rotate once the oldest live record is this old (0 disables)
+	Keep     int           // rotated segments to keep, oldest are deleted first (0 keeps all)
+}
+
+func defaultChangeLogRetention() ChangeLogRetention {
+	return ChangeLogRetention{MaxBytes: defaultChangeLogMaxBytes, MaxAge: defaultChangeLogMaxAge, Keep: defaultChangeLogKeep}
+}
+
+// changeLogMu serializes rotation and appends, which /sync-all runs from several goroutines.
+var changeLogMu sync.Mutex
+
+// changeLogSegmentPattern matches rotated segments of logPath: table-changes.<timestamp>-<n>.jsonl.gz.
+func changeLogSegmentPattern(logPath string) string {
+	ext := filepath.Ext(logPath)
+	return strings.TrimSuffix(logPath, ext) + ".*" + ext + ".gz"
+}
+
+func changeLogSegments(logPath string) ([]string, error) {
+	segments, err := filepath.Glob(changeLogSegmentPattern(logPath))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(segments)
+	return segments, nil
+}
+
+func firstChangeLogTimestamp(logPath string) (time.Time, error) {
+	file, err := os.Open(logPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var record syncChangeLogRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return time.Time{}, err
+		}
+		return time.Parse(time.RFC3339, record.Timestamp)
+	}
+	return time.Time{}, scanner.Err()
+}
+
+// rotateChangeLog moves the live log into a gzipped segment when it crossed a retention limit and prunes
+// old segments. It returns the new segment path, or "" when nothing was rotated.
+func rotateChangeLog(logPath string, retention ChangeLogRetention, now time.Time) (string, error) {
+	info, err := os.Stat(logPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	if info.Size() == 0 {
+		return "", nil
+	}
+	due := retention.MaxBytes > 0 && info.Size() >= retention.MaxBytes
+	if !due && retention.MaxAge > 0 {
+		if oldest, tsErr := firstChangeLogTimestamp(logPath); tsErr == nil && now.Sub(oldest) >= retention.MaxAge {
+			due = true
+		}
+	}
+	if !due {
+		return "", nil
+	}
+	body, err := os.ReadFile(logPath)
+	if err != nil {
+		return "", err
+	}
+	ext := filepath.Ext(logPath)
+	base := strings.TrimSuffix(logPath, ext) + "." + now.UTC().Format("20060102T150405Z")
+	segment := ""
+	for n := 1; ; n++ {
+		segment = fmt.Sprintf("%s-%03d%s.gz", base, n, ext)
+		if _, statErr := os.Stat(segment); errors.Is(statErr, os.ErrNotExist) {
+			break
+		}
+	}
+	if err := writeGzipFile(segment, body); err != nil {
+		return "", fmt.Errorf("write change log segment: %w", err)
+	}
+	if err := os.Remove(logPath); err != nil {
+		return "", err
+	}
+	if retention.Keep > 0 {
+		segments, err := changeLogSegments(logPath)
+		if err != nil {
+			return segment, err
+		}
+		for len(segments) > retention.Keep {
+			if err := os.Remove(segments[0]); err != nil {
+				return segment, err
+			}
+			segments = segments[1:]
+		}
+	}
+	return segment, nil
+}
+
+// appendChangeLogRecordWithRetention rotates the live log when it is due and then appends record.
+func appendChangeLogRecordWithRetention(path string, retention ChangeLogRetention, record syncChangeLogRecord, now time.Time) (string, error) {
+	changeLogMu.Lock()
+	defer changeLogMu.Unlock()
+	segment, err := rotateChangeLog(resolveOutputPath(path), retention, now)
+	if err != nil {
+		return "", fmt.Errorf("rotate change log: %w", err)
+	}
+	return segment, appendChangeLogRecord(path, record)
+}
+
+func writeGzipFile(path string, body []byte) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+func readGzipFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+func parseChangeLogRecords(body []byte, source string) ([]syncChangeLogRecord, error) {
+	records := []syncChangeLogRecord{}
+	for lineNo, line := range strings.Split(string(body), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var record syncChangeLogRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, fmt.Errorf("parse %s line %d: %w", source, lineNo+1, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// readFullChangeLog reads every rotated segment, oldest first, followed by the live log.
+func readFullChangeLog(path string) ([]syncChangeLogRecord, error) {
+	logPath := resolveFilePath(path)
+	segments, err := changeLogSegments(logPath)
+	if err != nil {
+		return nil, err
+	}
+	records := []syncChangeLogRecord{}
+	for _, segment := range segments {
+		body, err := readGzipFile(segment)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", segment, err)
+		}
+		parsed, err := parseChangeLogRecords(body, filepath.Base(segment))
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, parsed...)
+	}
+	live, err := readChangeLogRecords(logPath)
+	if err != nil {
+		return nil, err
+	}
+	return append(records, live...), nil
+}
+
+// compactChangeLogRecords collapses the history into one record per game and patch, holding the latest
+// change, the union of every source that changed, and how many records were merged into it.
+func compactChangeLogRecords(records []syncChangeLogRecord) []syncChangeLogRecord {
+	type patchKey struct{ game, patch string }
+	latest := map[patchKey]syncChangeLogRecord{}
+	counts := map[patchKey]int{}
+	sources := map[patchKey]map[string]struct{}{}
+	for _, record := range records {
+		for _, entry := range record.UpdatedPatches {
+			key := patchKey{record.GameID, entry.Patch}
+			if sources[key] == nil {
+				sources[key] = map[string]struct{}{}
+			}
+			for _, source := range entry.ChangedSources {
+				sources[key][source] = struct{}{}
+			}
+			counts[key] += max(record.Compacted, 1)
+			summary := record
+			summary.UpdatedPatches = []patchChangeLogEntry{entry}
+			latest[key] = summary
+		}
+	}
+	compacted := make([]syncChangeLogRecord, 0, len(latest))
+	for key, summary := range latest {
+		changed := make([]string, 0, len(sources[key]))
+		for source := range sources[key] {
+			changed = append(changed, source)
+		}
+		sort.Strings(changed)
+		entry := summary.UpdatedPatches[0]
+		entry.ChangedSources = changed
+		summary.UpdatedPatches = []patchChangeLogEntry{entry}
+		summary.Compacted = counts[key]
+		compacted = append(compacted, summary)
+	}
+	sort.Slice(compacted, func(i, j int) bool {
+		a, b := compacted[i], compacted[j]
+		if a.Timestamp != b.Timestamp {
+			return a.Timestamp < b.Timestamp
+		}
+		if a.GameID != b.GameID {
+			return a.GameID < b.GameID
+		}
+		patchA, patchB := a.UpdatedPatches[0].Patch, b.UpdatedPatches[0].Patch
+		majorA, minorA, okA := versionSortKey(patchA)
+		majorB, minorB, okB := versionSortKey(patchB)
+		if okA && okB && majorA != majorB {
+			return majorA < majorB
+		}
+		if okA && okB {
+			return minorA < minorB
+		}
+		return patchA < patchB
+	})
+	return compacted
+}
+
+// compactChangeLog archives the full history (segments and live log) into one gzipped file, then replaces
+// the live log with the compacted summaries and removes the segments.
+func compactChangeLog(path string, now time.Time) (string, int, int, error) {
+	changeLogMu.Lock()
+	defer changeLogMu.Unlock()
+	logPath := resolveOutputPath(path)
+	records, err := readFullChangeLog(logPath)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	if len(records) == 0 {
+		return "", 0, 0, nil
+	}
+	var full bytes.Buffer
+	encoder := json.NewEncoder(&full)
+	encoder.SetEscapeHTML(false)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return "", 0, 0, err
+		}
+	}
+	ext := filepath.Ext(logPath)
+	archiveDir := filepath.Join(filepath.Dir(logPath), "archive")
+	if err := os.MkdirAll(archiveDir, 0o755); err != nil {
+		return "", 0, 0, err
+	}
+	archivePath := filepath.Join(archiveDir, strings.TrimSuffix(filepath.Base(logPath), ext)+"."+now.UTC().Format("20060102T150405Z")+ext+".gz")
+	if err := writeGzipFile(archivePath, full.Bytes()); err != nil {
+		return "", 0, 0, fmt.Errorf("write change log archive: %w", err)
+	}
+
+	compacted := compactChangeLogRecords(records)
+	var live bytes.Buffer
+	encoder = json.NewEncoder(&live)
+	encoder.SetEscapeHTML(false)
+	for _, record := range compacted {
+		if err := encoder.Encode(record); err != nil {
+			return "", 0, 0, err
+		}
+	}
+	tmpPath := logPath + ".tmp"
+	if err := os.WriteFile(tmpPath, live.Bytes(), 0o644); err != nil {
+		return "", 0, 0, err
+	}
+	if err := os.Rename(tmpPath, logPath); err != nil {
+		return "", 0, 0, err
+	}
+	segments, err := changeLogSegments(logPath)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	for _, segment := range segments {
+		if err := os.Remove(segment); err != nil {
+			return "", 0, 0, err
+		}
+	}
+	return archivePath, len(records), len(compacted), nil
+}
+
+func runCompactChangesCommand(args []string) int {
+	fs := flag.NewFlagSet("compact-changes", flag.ContinueOnError)
+	var logPath string
+	fs.StringVar(&logPath, "change-log", defaultChangeLogPath, "Change log to compact; rotated segments next to it are included")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	archivePath, before, after, err := compactChangeLog(logPath, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "compact-changes failed: %v\n", err)
+		return 1
+	}
+	if archivePath == "" {
+		fmt.Println("Change log is empty; nothing to compact")
+		return 0
+	}
+	fmt.Printf("Archived %d records to %s\n", before, archivePath)
+	fmt.Printf("Compacted change log to %d records\n", after)
+	return 0
+}
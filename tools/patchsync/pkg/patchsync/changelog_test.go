@@ -0,0 +1,111 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func changeLogTestRecord(ts, game, patch, changeType string, sources ...string) syncChangeLogRecord {
+	return syncChangeLogRecord{
+		Timestamp:      ts,
+		GameID:         game,
+		UpdatedPatches: []patchChangeLogEntry{{Patch: patch, ChangeType: changeType, ChangedSources: sources}},
+	}
+}
+
+func TestChangeLogRotatesBySizeAndAgeAndPrunesSegments(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "table-changes.jsonl")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	retention := ChangeLogRetention{MaxBytes: 1, Keep: 2}
+	for i := range 4 {
+		segment, err := appendChangeLogRecordWithRetention(logPath, retention, changeLogTestRecord(now.Format(time.RFC3339), gameIDWuwa, "2.1", "updated", "events"), now)
+		if err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+		if (segment != "") != (i > 0) {
+			t.Fatalf("append %d rotated into %q", i, segment)
+		}
+	}
+	segments, err := changeLogSegments(logPath)
+	if err != nil || len(segments) != 2 {
+		t.Fatalf("segments = %v, %v; want the two newest", segments, err)
+	}
+	records, err := readFullChangeLog(logPath)
+	if err != nil || len(records) != 3 {
+		t.Fatalf("readFullChangeLog() = %d records, %v; want 3", len(records), err)
+	}
+
+	ageLog := filepath.Join(t.TempDir(), "table-changes.jsonl")
+	old := now.Add(-48 * time.Hour).Format(time.RFC3339)
+	if err := appendChangeLogRecord(ageLog, changeLogTestRecord(old, gameIDWuwa, "2.0", "added")); err != nil {
+		t.Fatal(err)
+	}
+	if segment, err := rotateChangeLog(ageLog, ChangeLogRetention{MaxAge: 72 * time.Hour}, now); err != nil || segment != "" {
+		t.Fatalf("rotated a young log: %q, %v", segment, err)
+	}
+	if segment, err := rotateChangeLog(ageLog, ChangeLogRetention{MaxAge: 24 * time.Hour}, now); err != nil || segment == "" {
+		t.Fatalf("expected an old log to rotate, got %q, %v", segment, err)
+	}
+}
+
+func TestCompactChangeLogKeepsLatestStatePerPatch(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "table-changes.jsonl")
+	for _, record := range []syncChangeLogRecord{
+		changeLogTestRecord("2026-01-01T00:00:00Z", gameIDWuwa, "2.1", "added"),
+		changeLogTestRecord("2026-01-02T00:00:00Z", gameIDWuwa, "2.1", "updated", "events"),
+		changeLogTestRecord("2026-01-03T00:00:00Z", gameIDWuwa, "2.2", "added"),
+		changeLogTestRecord("2026-01-04T00:00:00Z", gameIDWuwa, "2.1", "updated", "mailbox"),
+	} {
+		if err := appendChangeLogRecord(logPath, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := rotateChangeLog(logPath, ChangeLogRetention{MaxBytes: 1}, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendChangeLogRecord(logPath, changeLogTestRecord("2026-01-06T00:00:00Z", gameIDWuwa, "2.2", "removed")); err != nil {
+		t.Fatal(err)
+	}
+
+	archivePath, before, after, err := compactChangeLog(logPath, time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("compactChangeLog() error = %v", err)
+	}
+	if before != 5 || after != 2 {
+		t.Fatalf("compacted %d records into %d, want 5 into 2", before, after)
+	}
+	archived, err := readGzipFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records, err := parseChangeLogRecords(archived, "archive"); err != nil || len(records) != 5 {
+		t.Fatalf("archive holds %d records, %v; want 5", len(records), err)
+	}
+	if segments, _ := changeLogSegments(logPath); len(segments) != 0 {
+		t.Fatalf("segments left after compaction: %v", segments)
+	}
+	records, err := readFullChangeLog(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []syncChangeLogRecord{
+		{Timestamp: "2026-01-04T00:00:00Z", GameID: gameIDWuwa, Compacted: 3, UpdatedPatches: []patchChangeLogEntry{{Patch: "2.1", ChangeType: "updated", ChangedSources: []string{"events", "mailbox"}}}},
+		{Timestamp: "2026-01-06T00:00:00Z", GameID: gameIDWuwa, Compacted: 2, UpdatedPatches: []patchChangeLogEntry{{Patch: "2.2", ChangeType: "removed"}}},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("compacted log = %+v, want %+v", records, want)
+	}
+
+	if _, _, _, err := compactChangeLog(logPath, time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := readFullChangeLog(logPath); !reflect.DeepEqual(again, want) {
+		t.Fatalf("compacting twice changed the log: %+v", again)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(logPath), "archive")); err != nil {
+		t.Fatalf("archive directory missing: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package patchsync
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Clock is the time source of a sync: log timestamps, branch names, generatedAt, and change log records.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// FixedClock always reports the same instant.
+type FixedClock time.Time
+
+func (c FixedClock) Now() time.Time { return time.Time(c) }
+
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return systemClock{}
+	}
+	return clock
+}
+
+// reproducibleClock pins time to SOURCE_DATE_EPOCH, or to the commit time of HEAD when it is unset,
+// so two syncs of the same sheets produce byte-identical output.
+func reproducibleClock() (Clock, error) {
+	raw := strings.TrimSpace(os.Getenv("SOURCE_DATE_EPOCH"))
+	source := "SOURCE_DATE_EPOCH"
+	if raw == "" {
+		output, err := exec.Command("git", "log", "-1", "--format=%ct").Output()
+		if err != nil {
+			return nil, fmt.Errorf("--reproducible needs SOURCE_DATE_EPOCH or a git checkout: %w", err)
+		}
+		raw = strings.TrimSpace(string(output))
+		source = "HEAD commit time"
+	}
+	seconds, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", source, raw, err)
+	}
+	return FixedClock(time.Unix(seconds, 0).UTC()), nil
+}
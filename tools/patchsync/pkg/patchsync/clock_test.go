@@ -0,0 +1,59 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunSyncUsesInjectedClock(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	pinned := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		Clock:           FixedClock(pinned),
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+	}
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	if result.GeneratedAt != "2025-01-02T03:04:05Z" {
+		t.Fatalf("GeneratedAt = %q", result.GeneratedAt)
+	}
+	for _, line := range result.Logs {
+		if !strings.HasPrefix(line, "[03:04:05] ") {
+			t.Fatalf("log line not stamped with the pinned clock: %q", line)
+		}
+	}
+	body, err := os.ReadFile(cfg.OutputPath)
+	if err != nil {
+		t.Fatalf("read generated file: %v", err)
+	}
+	if !strings.Contains(string(body), `"generatedAt": "2025-01-02T03:04:05Z"`) {
+		t.Fatalf("generated file does not carry the pinned generatedAt")
+	}
+}
+
+func TestReproducibleClockFromSourceDateEpoch(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	clock, err := reproducibleClock()
+	if err != nil {
+		t.Fatalf("reproducibleClock() error = %v", err)
+	}
+	if got := clock.Now(); !got.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("Now() = %v", got)
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "soon")
+	if _, err := reproducibleClock(); err == nil {
+		t.Fatalf("expected an error for a non-numeric SOURCE_DATE_EPOCH")
+	}
+}
@@ -0,0 +1,276 @@
+package patchsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type fieldDiff struct {
+	Field string `json:"field"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+}
+
+type sourceDiff struct {
+	SourceID string      `json:"sourceId"`
+	Status   string      `json:"status"`
+	Fields   []fieldDiff `json:"fields,omitempty"`
+}
+
+type patchDiff struct {
+	Patch   string       `json:"patch"`
+	Status  string       `json:"status"`
+	Fields  []fieldDiff  `json:"fields,omitempty"`
+	Sources []sourceDiff `json:"sources,omitempty"`
+}
+
+func parseSpreadsheetPatches(ctx context.Context, fetcher SheetFetcher, profile GameProfile, spreadsheetID string, logs *syncLog) (map[string]Patch, error) {
+	src, err := loadSpreadsheetSource(ctx, fetcher, profile.ID, spreadsheetID, true, nil, profile.AuxSheetNames, nil, decimalAuto, logs)
+	if err != nil {
+		return nil, err
+	}
+	patches := map[string]Patch{}
+	for _, sheetName := range src.SheetNames {
+		csvText, fetchErr := fetcher.FetchCSV(ctx, src.ID, sheetName)
+		if fetchErr != nil {
+			appendSyncLog(logs, "skip fetch failed sheet %s: %v", sheetName, fetchErr)
+			continue
+		}
+		patch, parseErr := profile.ParseSheet(sheetName, csvText, ParseOptions{DecimalSeparator: src.DecimalSeparator, Warn: func(message string) {
+			appendSyncLog(logs, "warning: sheet %s: %s", sheetName, message)
+		}})
+		if parseErr != nil {
+			appendSyncLog(logs, "skip parse failed sheet %s: %v", sheetName, parseErr)
+			continue
+		}
+		applySheetCompleteness(&patch, csvText)
+		if auxSheet, applyErr := applySpreadsheetOverrides(profile.ID, &patch, src, ""); applyErr != nil {
+			appendSyncLog(logs, "skip %s overrides for %s: %v", auxSheet, sheetName, applyErr)
+		}
+		patchID := patchIDOrFallback(patch)
+		if dataTags, ok := src.DataTags[patchID]; ok {
+			patch.Tags = mergeTagLists(patch.Tags, dataTags)
+		}
+		applyComputedPulls(profile.ID, &patch)
+		if patchID != "" {
+			patches[patchID] = patch
+		}
+	}
+	if len(patches) == 0 {
+		return nil, errors.New("no valid patch sheets found with N.N names")
+	}
+	return patches, nil
+}
+
+func formatCompareNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatComparePulls(value *float64) string {
+	if value == nil {
+		return "-"
+	}
+	return formatCompareNumber(*value)
+}
+
+func appendFieldDiff(diffs []fieldDiff, field, a, b string) []fieldDiff {
+	if a == b {
+		return diffs
+	}
+	return append(diffs, fieldDiff{Field: field, A: a, B: b})
+}
+
+func appendRewardDiffs(diffs []fieldDiff, prefix string, a, b Rewards, gameID string) []fieldDiff {
+	valuesA := rewardsForGame(a, gameID)
+	valuesB := rewardsForGame(b, gameID)
+	keys := make([]string, 0, len(valuesA))
+	for key := range valuesA {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		diffs = appendFieldDiff(diffs, prefix+"."+key, formatCompareNumber(valuesA[key]), formatCompareNumber(valuesB[key]))
+	}
+	return diffs
+}
+
+func compareJSONValue(value any) string {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(body)
+}
+
+func compareSources(a, b Source, gameID string) []fieldDiff {
+	diffs := make([]fieldDiff, 0, 4)
+	diffs = appendFieldDiff(diffs, "label", a.Label, b.Label)
+	diffs = appendFieldDiff(diffs, "gate", a.Gate, b.Gate)
+	diffs = appendFieldDiff(diffs, "countInPulls", strconv.FormatBool(a.CountInPulls), strconv.FormatBool(b.CountInPulls))
+	diffs = appendFieldDiff(diffs, "pulls", formatComparePulls(a.Pulls), formatComparePulls(b.Pulls))
+	diffs = appendRewardDiffs(diffs, "rewards", a.Rewards, b.Rewards, gameID)
+	diffs = appendRewardDiffs(diffs, "costs", a.Costs, b.Costs, gameID)
+	diffs = appendFieldDiff(diffs, "scalers", compareJSONValue(a.Scalers), compareJSONValue(b.Scalers))
+	diffs = appendFieldDiff(diffs, "bpCrateModel", compareJSONValue(a.BPCrateModel), compareJSONValue(b.BPCrateModel))
+	return diffs
+}
+
+func comparePatchSets(a, b map[string]Patch, gameID string) []patchDiff {
+	ids := make([]string, 0, len(a)+len(b))
+	for id := range a {
+		ids = append(ids, id)
+	}
+	for id := range b {
+		if _, ok := a[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sortVersionStrings(ids)
+
+	diffs := make([]patchDiff, 0, len(ids))
+	for _, id := range ids {
+		patchA, hasA := a[id]
+		patchB, hasB := b[id]
+		if !hasA {
+			diffs = append(diffs, patchDiff{Patch: id, Status: "onlyB"})
+			continue
+		}
+		if !hasB {
+			diffs = append(diffs, patchDiff{Patch: id, Status: "onlyA"})
+			continue
+		}
+
+		diff := patchDiff{Patch: id, Status: "changed"}
+		diff.Fields = appendFieldDiff(diff.Fields, "versionName", patchA.VersionName, patchB.VersionName)
+		diff.Fields = appendFieldDiff(diff.Fields, "startDate", patchA.StartDate, patchB.StartDate)
+		diff.Fields = appendFieldDiff(diff.Fields, "durationDays", strconv.Itoa(patchA.DurationDays), strconv.Itoa(patchB.DurationDays))
+		diff.Fields = appendFieldDiff(diff.Fields, "tags", strings.Join(patchA.Tags, ","), strings.Join(patchB.Tags, ","))
+
+		sourcesA := sourceByID(patchA)
+		sourcesB := sourceByID(patchB)
+		for _, sourceID := range changedSourceIDs(patchA, patchB) {
+			srcA, hasSrcA := sourcesA[sourceID]
+			srcB, hasSrcB := sourcesB[sourceID]
+			switch {
+			case !hasSrcA:
+				diff.Sources = append(diff.Sources, sourceDiff{SourceID: sourceID, Status: "onlyB"})
+			case !hasSrcB:
+				diff.Sources = append(diff.Sources, sourceDiff{SourceID: sourceID, Status: "onlyA"})
+			default:
+				diff.Sources = append(diff.Sources, sourceDiff{
+					SourceID: sourceID,
+					Status:   "changed",
+					Fields:   compareSources(srcA, srcB, gameID),
+				})
+			}
+		}
+		if len(diff.Fields) > 0 || len(diff.Sources) > 0 {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs
+}
+
+func writeCompareReport(w io.Writer, gameID, spreadsheetA, spreadsheetB string, diffs []patchDiff) {
+	fmt.Fprintf(w, "Game: %s\n", gameID)
+	fmt.Fprintf(w, "A: %s\n", spreadsheetA)
+	fmt.Fprintf(w, "B: %s\n", spreadsheetB)
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "No differences.")
+		return
+	}
+	for _, diff := range diffs {
+		switch diff.Status {
+		case "onlyA":
+			fmt.Fprintf(w, "patch %s: only in A\n", diff.Patch)
+			continue
+		case "onlyB":
+			fmt.Fprintf(w, "patch %s: only in B\n", diff.Patch)
+			continue
+		}
+		fmt.Fprintf(w, "patch %s:\n", diff.Patch)
+		for _, field := range diff.Fields {
+			fmt.Fprintf(w, "  %s: %q -> %q\n", field.Field, field.A, field.B)
+		}
+		for _, src := range diff.Sources {
+			switch src.Status {
+			case "onlyA":
+				fmt.Fprintf(w, "  source %s: only in A\n", src.SourceID)
+			case "onlyB":
+				fmt.Fprintf(w, "  source %s: only in B\n", src.SourceID)
+			default:
+				fmt.Fprintf(w, "  source %s:\n", src.SourceID)
+				for _, field := range src.Fields {
+					fmt.Fprintf(w, "    %s: %s -> %s\n", field.Field, field.A, field.B)
+				}
+			}
+		}
+	}
+	fmt.Fprintf(w, "%d patches differ.\n", len(diffs))
+}
+
+func runCompareCommand(args []string) int {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	var (
+		gameID        string
+		spreadsheetA  string
+		spreadsheetB  string
+		clientTimeout time.Duration
+		outbound      OutboundOptions
+	)
+	fs.StringVar(&gameID, "game", defaultGameID, fmt.Sprintf("Game id (%s)", strings.Join(AvailableGameIDs(), ", ")))
+	fs.StringVar(&spreadsheetA, "spreadsheet-a", "", "Baseline spreadsheet ID or URL")
+	fs.StringVar(&spreadsheetB, "spreadsheet-b", "", "Spreadsheet ID or URL to compare against the baseline")
+	fs.DurationVar(&clientTimeout, "timeout", 20*time.Second, "HTTP client timeout")
+	registerOutboundFlags(fs, &outbound)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	spreadsheetA = extractSpreadsheetID(spreadsheetA)
+	spreadsheetB = extractSpreadsheetID(spreadsheetB)
+	if spreadsheetA == "" || spreadsheetB == "" {
+		fmt.Fprintln(os.Stderr, "compare failed: --spreadsheet-a and --spreadsheet-b are required")
+		return 2
+	}
+	profile, err := ResolveGameProfile(gameID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "compare failed: %v\n", err)
+		return 2
+	}
+
+	client, err := newOutboundClient(outbound, clientTimeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "compare failed: %v\n", err)
+		return 2
+	}
+	ctx := context.Background()
+	fetcher := newGoogleSheetFetcher(client, profile.ParseSheet)
+	logs := syncLog{}
+	patchesA, err := parseSpreadsheetPatches(ctx, fetcher, profile, spreadsheetA, &logs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "compare failed: spreadsheet A: %v\n", err)
+		return 2
+	}
+	patchesB, err := parseSpreadsheetPatches(ctx, fetcher, profile, spreadsheetB, &logs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "compare failed: spreadsheet B: %v\n", err)
+		return 2
+	}
+
+	diffs := comparePatchSets(patchesA, patchesB, profile.ID)
+	writeCompareReport(os.Stdout, profile.ID, spreadsheetA, spreadsheetB, diffs)
+	if len(diffs) > 0 {
+		return 1
+	}
+	return 0
+}
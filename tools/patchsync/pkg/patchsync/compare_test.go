@@ -0,0 +1,63 @@
+package patchsync
+
+import "testing"
+
+func TestComparePatchSets(t *testing.T) {
+	pulls := func(v float64) *float64 { return &v }
+	base := Patch{
+		ID:           "2.1",
+		Patch:        "2.1",
+		StartDate:    "2025-02-13",
+		DurationDays: 42,
+		Sources: []Source{
+			source("events", "Version Events", "always", nil, true, Rewards{Oroberyl: 5000}),
+			source("permanent", "Permanent Content", "always", nil, true, Rewards{Oroberyl: 2000}),
+		},
+	}
+	changed := base
+	changed.Sources = []Source{
+		source("events", "Version Events", "always", nil, true, Rewards{Oroberyl: 5200}),
+		source("mailbox", "Mailbox", "always", nil, true, Rewards{Oroberyl: 300}),
+	}
+	changed.Sources[0].Pulls = pulls(33)
+
+	a := map[string]Patch{"2.0": {ID: "2.0", Patch: "2.0"}, "2.1": base, "2.2": base}
+	b := map[string]Patch{"2.1": changed, "2.2": base, "2.3": {ID: "2.3", Patch: "2.3"}}
+	diffs := comparePatchSets(a, b, gameIDWuwa)
+	if len(diffs) != 3 {
+		t.Fatalf("expected 3 patch diffs, got %d: %+v", len(diffs), diffs)
+	}
+	if diffs[0].Patch != "2.0" || diffs[0].Status != "onlyA" {
+		t.Errorf("diffs[0] = %+v, want 2.0 onlyA", diffs[0])
+	}
+	if diffs[2].Patch != "2.3" || diffs[2].Status != "onlyB" {
+		t.Errorf("diffs[2] = %+v, want 2.3 onlyB", diffs[2])
+	}
+
+	shared := diffs[1]
+	if shared.Patch != "2.1" || len(shared.Fields) != 0 || len(shared.Sources) != 3 {
+		t.Fatalf("diffs[1] = %+v, want 2.1 with 3 source diffs", shared)
+	}
+	events := shared.Sources[0]
+	if events.SourceID != "events" || events.Status != "changed" {
+		t.Fatalf("events diff = %+v", events)
+	}
+	want := []fieldDiff{
+		{Field: "pulls", A: "-", B: "33"},
+		{Field: "rewards.astrite", A: "5000", B: "5200"},
+	}
+	if len(events.Fields) != len(want) {
+		t.Fatalf("events fields = %+v, want %+v", events.Fields, want)
+	}
+	for idx := range want {
+		if events.Fields[idx] != want[idx] {
+			t.Errorf("events field %d = %+v, want %+v", idx, events.Fields[idx], want[idx])
+		}
+	}
+	if shared.Sources[1].SourceID != "mailbox" || shared.Sources[1].Status != "onlyB" {
+		t.Errorf("mailbox diff = %+v, want onlyB", shared.Sources[1])
+	}
+	if shared.Sources[2].SourceID != "permanent" || shared.Sources[2].Status != "onlyA" {
+		t.Errorf("permanent diff = %+v, want onlyA", shared.Sources[2])
+	}
+}
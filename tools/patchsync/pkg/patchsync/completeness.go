@@ -0,0 +1,34 @@
+package patchsync
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// completenessPattern matches the "data 60% complete" note that STC and WIP tabs carry while a patch is still
+// being filled in.
+var completenessPattern = regexp.MustCompile(`(?i)\bdata\s+(?:is\s+)?(?:~\s*)?(\d{1,3}(?:\.\d+)?)\s*%\s*complete\b`)
+
+// parseSheetCompleteness returns the percentage from the first completeness note in a version sheet.
+func parseSheetCompleteness(csvText string) (float64, bool) {
+	for _, record := range readNoteRecords(csvText) {
+		for idx := range record {
+			match := completenessPattern.FindStringSubmatch(getCell(record, idx))
+			if len(match) < 2 {
+				continue
+			}
+			value, err := strconv.ParseFloat(match[1], 64)
+			if err != nil || value > 100 {
+				continue
+			}
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+func applySheetCompleteness(patch *Patch, csvText string) {
+	if value, ok := parseSheetCompleteness(csvText); ok {
+		patch.Completeness = &value
+	}
+}
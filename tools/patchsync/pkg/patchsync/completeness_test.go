@@ -0,0 +1,45 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseSheetCompleteness(t *testing.T) {
+	tests := []struct {
+		name   string
+		csv    string
+		want   float64
+		wantOK bool
+	}{
+		{name: "header note", csv: csvLines("3.4 (STC),Data 60% complete", "Events,1,2"), want: 60, wantOK: true},
+		{name: "footnote", csv: csvLines("3.4,", "Events,1,2", "* data is ~87.5 % complete"), want: 87.5, wantOK: true},
+		{name: "first note wins", csv: csvLines("data 40% complete", "data 90% complete"), want: 40, wantOK: true},
+		{name: "over 100", csv: csvLines("data 120% complete"), wantOK: false},
+		{name: "no note", csv: csvLines("3.4,", "Events,1,2", "Completion,50%"), wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSheetCompleteness(tt.csv)
+		if ok != tt.wantOK || got != tt.want {
+			t.Fatalf("%s: parseSheetCompleteness() = %v, %v; want %v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestGeneratedPatchCarriesCompleteness(t *testing.T) {
+	patch := Patch{ID: "3.4", Patch: "3.4"}
+	applySheetCompleteness(&patch, csvLines("3.4 (WIP),data 75% complete"))
+	body, err := json.Marshal(toGeneratedPatch(patch, gameIDWuwa))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), `"completeness":75`) {
+		t.Fatalf("generated patch = %s, want completeness 75", body)
+	}
+
+	body, _ = json.Marshal(toGeneratedPatch(Patch{ID: "3.3", Patch: "3.3"}, gameIDWuwa))
+	if strings.Contains(string(body), "completeness") {
+		t.Fatalf("generated patch without a note = %s, want no completeness", body)
+	}
+}
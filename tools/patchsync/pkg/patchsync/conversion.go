@@ -0,0 +1,109 @@
+package patchsync
+
+import "math"
+
+type pullConversion struct {
+	BasePerPull   float64
+	PremiumToBase float64
+	PermitKeys    []string
+
+	gameID string
+}
+
+// Mirrors economy.rates and pullPermitKeys in src/data/patches.js; TestPullConversionsMatchFrontend keeps
+// the two in step. PermitKeys are frontend currency keys, resolved through currenciesByGame.
+var pullConversionsByGame = map[string]pullConversion{
+	gameIDEndfield: {BasePerPull: 500, PremiumToBase: 75, PermitKeys: []string{"chartered", "firewalker", "messenger", "hues"}},
+	gameIDWuwa:     {BasePerPull: 160, PremiumToBase: 1, PermitKeys: []string{"radiantTide", "forgingTide"}},
+	gameIDZzz:      {BasePerPull: 160, PremiumToBase: 1, PermitKeys: []string{"encryptedMasterTape"}},
+	gameIDGenshin:  {BasePerPull: 160, PremiumToBase: 1, PermitKeys: []string{"intertwinedFate"}},
+	gameIDHsr:      {BasePerPull: 160, PremiumToBase: 1, PermitKeys: []string{"specialPass"}},
+}
+
+func pullConversionForGame(gameID string) pullConversion {
+	conversion, ok := pullConversionsByGame[gameID]
+	if !ok {
+		gameID = defaultGameID
+		conversion = pullConversionsByGame[gameID]
+	}
+	conversion.gameID = gameID
+	return conversion
+}
+
+// permitValue sums the Rewards fields behind one permit key, the same way the frontend reads the key from
+// the generated rewards. Keys outside the game's currency table fall back to mappedField.
+func (c pullConversion) permitValue(r Rewards, key string) float64 {
+	for _, currency := range currenciesForGame(c.gameID) {
+		if currency.Key == key {
+			return currency.value(r)
+		}
+	}
+	if field := r.mappedField(key); field != nil {
+		return *field
+	}
+	return 0
+}
+
+func (c pullConversion) pullsFromRewards(r Rewards) float64 {
+	pulls := 0.0
+	if c.BasePerPull > 0 {
+		pulls = r.Oroberyl / c.BasePerPull
+	}
+	for _, key := range c.PermitKeys {
+		pulls += c.permitValue(r, key)
+	}
+	return pulls
+}
+
+func applyScalerRounding(value float64, rounding string) float64 {
+	switch rounding {
+	case "ceil":
+		return math.Ceil(value)
+	case "round":
+		return math.Round(value)
+	}
+	return math.Floor(value)
+}
+
+func (r Rewards) scaled(factor float64) Rewards {
+	return Rewards{
+		Oroberyl:    r.Oroberyl * factor,
+		Origeometry: r.Origeometry * factor,
+		Chartered:   r.Chartered * factor,
+		Basic:       r.Basic * factor,
+		Firewalker:  r.Firewalker * factor,
+		Messenger:   r.Messenger * factor,
+		Hues:        r.Hues * factor,
+		Arsenal:     r.Arsenal * factor,
+	}
+}
+
+func sourceRewardsForDuration(src Source, durationDays int) Rewards {
+	rewards := src.Rewards
+	days := math.Max(0, float64(durationDays))
+	for _, scaler := range src.Scalers {
+		if scaler.Type != "per_duration" {
+			continue
+		}
+		cycles := days
+		if scaler.Unit != "" && scaler.Unit != "day" {
+			everyDays := math.Max(1, float64(scaler.EveryDays))
+			cycles = applyScalerRounding(days/everyDays, scaler.Rounding)
+		}
+		rewards.add(scaler.Rewards.scaled(cycles))
+	}
+	return rewards
+}
+
+func applyComputedPulls(gameID string, patch *Patch) {
+	conversion := pullConversionForGame(gameID)
+	for idx := range patch.Sources {
+		src := &patch.Sources[idx]
+		// BP crate estimates depend on the selected pass tier, so the frontend has to compute those.
+		if src.Pulls != nil || !src.CountInPulls || src.BPCrateModel != nil {
+			continue
+		}
+		pulls := roundToTenth(conversion.pullsFromRewards(sourceRewardsForDuration(*src, patch.DurationDays)))
+		src.Pulls = &pulls
+	}
+}
@@ -0,0 +1,112 @@
+package patchsync
+
+import (
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestApplyComputedPulls(t *testing.T) {
+	preset := 12.0
+	monthly := source("monthly", "Lunite Subscription", "monthly", nil, true, Rewards{Origeometry: 300})
+	monthly.Scalers = []Scaler{{Type: "per_duration", Unit: "day", EveryDays: 1, Rounding: "floor", Rewards: Rewards{Oroberyl: 90}}}
+	weekly := source("weekly", "Weekly", "always", nil, true, Rewards{})
+	weekly.Scalers = []Scaler{{Type: "per_duration", Unit: "week", EveryDays: 7, Rounding: "floor", Rewards: Rewards{Chartered: 1}}}
+	crates := source("bpCrateM", "BP Crates", "bp2", nil, true, Rewards{Oroberyl: 1600})
+	crates.BPCrateModel = &BPCrateModel{Type: "post_bp60_estimate"}
+	fixed := source("events", "Version Events", "always", nil, true, Rewards{Oroberyl: 9999})
+	fixed.Pulls = &preset
+
+	patch := Patch{
+		DurationDays: 42,
+		Sources: []Source{
+			source("permanent", "Permanent Content", "always", nil, true, Rewards{Oroberyl: 1600, Chartered: 3, Firewalker: 2}),
+			monthly,
+			weekly,
+			crates,
+			fixed,
+			source("shop", "Shop", "always", nil, false, Rewards{Oroberyl: 1600}),
+		},
+	}
+	applyComputedPulls(gameIDWuwa, &patch)
+
+	want := map[string]float64{"permanent": 15, "monthly": 23.6, "weekly": 6, "events": 12}
+	for _, src := range patch.Sources {
+		expected, ok := want[src.ID]
+		if !ok {
+			if src.Pulls != nil {
+				t.Errorf("source %s pulls = %v, want unset", src.ID, *src.Pulls)
+			}
+			continue
+		}
+		if src.Pulls == nil || *src.Pulls != expected {
+			t.Errorf("source %s pulls = %v, want %v", src.ID, src.Pulls, expected)
+		}
+	}
+
+	endfield := Patch{Sources: []Source{source("events", "Events", "always", nil, true, Rewards{Oroberyl: 1000, Chartered: 1})}}
+	applyComputedPulls(gameIDEndfield, &endfield)
+	if got := *endfield.Sources[0].Pulls; got != 3 {
+		t.Errorf("endfield pulls = %v, want 3 (500 oroberyl per pull)", got)
+	}
+}
+
+func TestPullConversionsMatchFrontend(t *testing.T) {
+	raw, err := os.ReadFile("../../../../src/data/patches.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := string(raw)
+	gameIDs := map[string]string{
+		"ENDFIELD_GAME_ID": gameIDEndfield,
+		"WUWA_GAME_ID":     gameIDWuwa,
+		"ZZZ_GAME_ID":      gameIDZzz,
+		"GENSHIN_GAME_ID":  gameIDGenshin,
+		"HSR_GAME_ID":      gameIDHsr,
+	}
+	blockPattern := regexp.MustCompile(`id: ([A-Z]+_GAME_ID),`)
+	permitPattern := regexp.MustCompile(`pullPermitKeys: \[([^\]]*)\]`)
+	basePattern := regexp.MustCompile(`basePerPull: ([0-9.]+)`)
+	premiumPattern := regexp.MustCompile(`premiumToBase: ([0-9.]+)`)
+
+	blocks := blockPattern.FindAllStringSubmatchIndex(source, -1)
+	if len(blocks) != len(pullConversionsByGame) {
+		t.Fatalf("found %d game blocks in patches.js, want %d", len(blocks), len(pullConversionsByGame))
+	}
+	for idx, block := range blocks {
+		end := len(source)
+		if idx+1 < len(blocks) {
+			end = blocks[idx+1][0]
+		}
+		body := source[block[1]:end]
+		gameID := gameIDs[source[block[2]:block[3]]]
+		conversion, ok := pullConversionsByGame[gameID]
+		if !ok {
+			t.Errorf("no pull conversion for %s", source[block[2]:block[3]])
+			continue
+		}
+
+		permits := permitPattern.FindStringSubmatch(body)
+		base := basePattern.FindStringSubmatch(body)
+		premium := premiumPattern.FindStringSubmatch(body)
+		if permits == nil || base == nil || premium == nil {
+			t.Fatalf("%s: economy block not found in patches.js", gameID)
+		}
+		keys := []string{}
+		for _, key := range strings.Split(permits[1], ",") {
+			keys = append(keys, strings.Trim(strings.TrimSpace(key), `"`))
+		}
+		if !slices.Equal(conversion.PermitKeys, keys) {
+			t.Errorf("%s PermitKeys = %v, frontend has %v", gameID, conversion.PermitKeys, keys)
+		}
+		if value, _ := strconv.ParseFloat(base[1], 64); conversion.BasePerPull != value {
+			t.Errorf("%s BasePerPull = %v, frontend has %v", gameID, conversion.BasePerPull, value)
+		}
+		if value, _ := strconv.ParseFloat(premium[1], 64); conversion.PremiumToBase != value {
+			t.Errorf("%s PremiumToBase = %v, frontend has %v", gameID, conversion.PremiumToBase, value)
+		}
+	}
+}
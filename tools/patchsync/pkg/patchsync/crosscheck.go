@@ -0,0 +1,53 @@
+package patchsync
+
+import (
+	"fmt"
+	"math"
+)
+
+// defaultPullTolerance is how far, in pulls, a Data or Summary sheet value may be from the pulls computed from
+// the version sheet rewards before the sync reports it. Both sides are rounded to a tenth, and the sheets round
+// their conversions differently.
+const defaultPullTolerance = 0.5
+
+// pullDiscrepancy is a source whose pulls from the Data or Summary sheet replaced a value computed from its
+// rewards that was more than the tolerance away.
+type pullDiscrepancy struct {
+	Patch    string  `json:"patch"`
+	Source   string  `json:"source"`
+	Computed float64 `json:"computed"`
+	Sheet    float64 `json:"sheet"`
+}
+
+func (d pullDiscrepancy) String() string {
+	return fmt.Sprintf("patch %s %s: sheet says %v pulls, rewards give %v", d.Patch, d.Source, d.Sheet, d.Computed)
+}
+
+// computedSourcePulls returns the pulls of every source as applyComputedPulls would set them, without touching
+// the patch. Call it before the spreadsheet overrides to keep what the version sheet alone says.
+func computedSourcePulls(gameID string, patch Patch) map[string]float64 {
+	patch.Sources = append([]Source(nil), patch.Sources...)
+	applyComputedPulls(gameID, &patch)
+	pulls := map[string]float64{}
+	for _, src := range patch.Sources {
+		if src.Pulls != nil {
+			pulls[src.ID] = *src.Pulls
+		}
+	}
+	return pulls
+}
+
+// crossCheckSourcePulls compares the pulls the overrides left on patch with those computed beforehand.
+func crossCheckSourcePulls(patch Patch, computed map[string]float64, tolerance float64) []pullDiscrepancy {
+	found := []pullDiscrepancy{}
+	for _, src := range patch.Sources {
+		want, ok := computed[src.ID]
+		if !ok || src.Pulls == nil {
+			continue
+		}
+		if math.Abs(*src.Pulls-want) > tolerance+1e-9 {
+			found = append(found, pullDiscrepancy{Patch: patchIDOrFallback(patch), Source: src.ID, Computed: want, Sheet: *src.Pulls})
+		}
+	}
+	return found
+}
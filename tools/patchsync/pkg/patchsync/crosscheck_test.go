@@ -0,0 +1,54 @@
+package patchsync
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCrossCheckSourcePulls(t *testing.T) {
+	computed, fromSheet := 61.3, 61.7
+	patch := Patch{ID: "3.4", Sources: []Source{
+		{ID: "events", Pulls: &fromSheet},
+		{ID: "permanent"},
+	}}
+	got := crossCheckSourcePulls(patch, map[string]float64{"events": computed, "permanent": 20}, defaultPullTolerance)
+	if len(got) != 0 {
+		t.Fatalf("crossCheckSourcePulls() within tolerance = %v, want none", got)
+	}
+	got = crossCheckSourcePulls(patch, map[string]float64{"events": computed}, 0.2)
+	if len(got) != 1 || got[0].Source != "events" || got[0].Computed != computed || got[0].Sheet != fromSheet {
+		t.Fatalf("crossCheckSourcePulls() = %v, want the events source", got)
+	}
+}
+
+func TestRunSyncReportsDataSheetDiscrepancies(t *testing.T) {
+	dir := t.TempDir()
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		DryRun:          true,
+		Fetcher: fakeSheetFetcher{sheets: map[string]string{
+			"3.4":  wuwaSheetCSV("3.4"),
+			"Data": csvLines("Version,3.4", "Version Events,99"),
+		}},
+	}
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	if len(result.Discrepancies) != 1 || result.Discrepancies[0].Source != "events" || result.Discrepancies[0].Sheet != 99 {
+		t.Fatalf("Discrepancies = %v, want events at 99", result.Discrepancies)
+	}
+	if !strings.Contains(strings.Join(result.Logs, "\n"), "warning: Data sheet disagrees with 3.4: patch 3.4 events") {
+		t.Fatalf("logs do not report the discrepancy: %v", result.Logs)
+	}
+	if got := result.Patches[0].Sources[0]; got.ID != "events" || got.Pulls == nil || *got.Pulls != 99 {
+		t.Fatalf("events source = %+v, want the Data sheet pulls kept", got)
+	}
+}
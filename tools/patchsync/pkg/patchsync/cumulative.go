@@ -0,0 +1,54 @@
+package patchsync
+
+type cumulativeRow struct {
+	Patch           string  `json:"patch"`
+	StartDate       string  `json:"startDate"`
+	F2PPulls        float64 `json:"f2pPulls"`
+	PaidPulls       float64 `json:"paidPulls"`
+	CumulativeF2P   float64 `json:"cumulativeF2p"`
+	CumulativePaid  float64 `json:"cumulativePaid"`
+	CumulativeTotal float64 `json:"cumulativeTotal"`
+}
+
+func patchBefore(patchID, fromID string) bool {
+	major, minor, ok := versionSortKey(patchID)
+	fromMajor, fromMinor, fromOK := versionSortKey(fromID)
+	if !ok || !fromOK {
+		return false
+	}
+	if major != fromMajor {
+		return major < fromMajor
+	}
+	return minor < fromMinor
+}
+
+// Paid pulls are the extra pulls from the monthly pass and the top battle pass tier.
+// Option-gated sources are left out of both columns because they are user toggles.
+func buildCumulativePulls(gameID string, patches []Patch, from string) []cumulativeRow {
+	sorted := append([]Patch(nil), patches...)
+	sortPatches(sorted)
+	fromID := canonicalPatchID(from)
+	rows := make([]cumulativeRow, 0, len(sorted))
+	f2pTotal := 0.0
+	paidTotal := 0.0
+	for _, patch := range sorted {
+		patchID := patchIDOrFallback(patch)
+		if fromID != "" && patchBefore(patchID, fromID) {
+			continue
+		}
+		f2p := computePatchTotals(gameID, patch, projectionOptions{}).Pulls
+		paid := computePatchTotals(gameID, patch, projectionOptions{MonthlySub: true, BattlePassTier: 3}).Pulls - f2p
+		f2pTotal += f2p
+		paidTotal += paid
+		rows = append(rows, cumulativeRow{
+			Patch:           patchID,
+			StartDate:       patch.StartDate,
+			F2PPulls:        roundToTenth(f2p),
+			PaidPulls:       roundToTenth(paid),
+			CumulativeF2P:   roundToTenth(f2pTotal),
+			CumulativePaid:  roundToTenth(paidTotal),
+			CumulativeTotal: roundToTenth(f2pTotal + paidTotal),
+		})
+	}
+	return rows
+}
@@ -0,0 +1,34 @@
+package patchsync
+
+import "testing"
+
+func TestBuildCumulativePulls(t *testing.T) {
+	patches := []Patch{
+		{ID: "2.1", Patch: "2.1", StartDate: "2025-02-11", DurationDays: 40, Sources: []Source{
+			source("events", "Events", "always", nil, true, Rewards{Chartered: 20}),
+			source("monthly", "Monthly", "monthly", nil, true, Rewards{Chartered: 5}),
+		}},
+		{ID: "2.0", Patch: "2.0", StartDate: "2025-01-02", DurationDays: 40, Sources: []Source{
+			source("events", "Events", "always", nil, true, Rewards{Chartered: 10}),
+			source("bp", "Pass", "bp3", nil, true, Rewards{Chartered: 3}),
+		}},
+		{ID: "2.2", Patch: "2.2", StartDate: "2025-03-23", DurationDays: 40, Sources: []Source{
+			source("events", "Events", "always", nil, true, Rewards{Chartered: 15}),
+		}},
+	}
+	rows := buildCumulativePulls(gameIDWuwa, patches, "")
+	if len(rows) != 3 || rows[0].Patch != "2.0" || rows[2].Patch != "2.2" {
+		t.Fatalf("unexpected rows %+v", rows)
+	}
+	if rows[1].F2PPulls != 20 || rows[1].PaidPulls != 5 || rows[1].CumulativeF2P != 30 || rows[1].CumulativePaid != 8 {
+		t.Fatalf("unexpected 2.1 row %+v", rows[1])
+	}
+	if rows[2].CumulativeTotal != 53 {
+		t.Fatalf("cumulative total = %v, want 53", rows[2].CumulativeTotal)
+	}
+
+	rows = buildCumulativePulls(gameIDWuwa, patches, "2.1")
+	if len(rows) != 2 || rows[0].Patch != "2.1" || rows[1].CumulativeTotal != 40 {
+		t.Fatalf("unexpected rows from 2.1 %+v", rows)
+	}
+}
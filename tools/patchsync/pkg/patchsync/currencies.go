@@ -0,0 +1,101 @@
+package patchsync
+
+// currencyDef is one entry of the per-game currency manifest in GENERATED_PATCHES_META. Key is the name the
+// frontend uses, Fields the internal Rewards fields summed into it, and Icon a path below assets/.
+// PullsPerUnit is how many pulls one unit is worth; currencies that never convert to pulls leave it out.
+type currencyDef struct {
+	Key          string   `json:"key"`
+	Name         string   `json:"name"`
+	Icon         string   `json:"icon,omitempty"`
+	Fields       []string `json:"fields"`
+	PullsPerUnit float64  `json:"pullsPerUnit,omitempty"`
+}
+
+// Mirrors economy.resourceKeys and ui.resourceLabels in src/data/patches.js. Renaming a frontend key only
+// needs a change here: the generated rewards and the manifest both follow this table.
+var currenciesByGame = map[string][]currencyDef{
+	gameIDEndfield: {
+		{Key: "oroberyl", Name: "Oroberyl", Icon: "Endfield/Oroberyl.png", Fields: []string{"oroberyl"}},
+		{Key: "origeometry", Name: "Origeometry", Icon: "Endfield/Origeometry.png", Fields: []string{"origeometry"}},
+		{Key: "chartered", Name: "Chartered HH Permit", Icon: "Endfield/Chartered_HH_Permit.png", Fields: []string{"chartered"}},
+		{Key: "basic", Name: "Basic HH Permit", Icon: "Endfield/Basic_HH_Permit.png", Fields: []string{"basic"}},
+		{Key: "firewalker", Name: "Timed HH Permit (Firewalker)", Icon: "Endfield/Timed_HH_Permit.png", Fields: []string{"firewalker"}},
+		{Key: "messenger", Name: "Timed HH Permit (Messenger)", Icon: "Endfield/Timed_HH_Permit.png", Fields: []string{"messenger"}},
+		{Key: "hues", Name: "Timed HH Permit (Hues)", Icon: "Endfield/Timed_HH_Permit.png", Fields: []string{"hues"}},
+		{Key: "arsenal", Name: "Arsenal Tickets", Icon: "Endfield/Arsenal_Ticket.png", Fields: []string{"arsenal"}},
+	},
+	gameIDWuwa: {
+		{Key: "astrite", Name: "Astrites", Icon: "WuWa/Astrite.webp", Fields: []string{"oroberyl"}},
+		{Key: "lunite", Name: "Lunite", Icon: "WuWa/Astrite.webp", Fields: []string{"origeometry"}},
+		{Key: "forgingToken", Name: "Forging Tokens", Icon: "WuWa/Forging_Tide.webp", Fields: []string{"arsenal"}},
+		{Key: "radiantTide", Name: "Radiant Tide", Icon: "WuWa/Radiant_Tide.webp", Fields: []string{"chartered"}},
+		{Key: "lustrousTide", Name: "Lustrous Tide", Icon: "WuWa/Lustrous_Tide.webp", Fields: []string{"basic"}},
+		{Key: "forgingTide", Name: "Forging Tide", Icon: "WuWa/Forging_Tide.webp", Fields: []string{"firewalker", "messenger", "hues"}},
+	},
+	gameIDZzz: {
+		{Key: "polychrome", Name: "Polychromes", Icon: "ZZZ/Polychrome.webp", Fields: []string{"oroberyl"}},
+		{Key: "monochrome", Name: "Monochrome", Icon: "ZZZ/Polychrome.webp", Fields: []string{"origeometry"}},
+		{Key: "boopon", Name: "Boopons", Icon: "ZZZ/Boopon.webp", Fields: []string{"arsenal"}},
+		{Key: "encryptedMasterTape", Name: "Encrypted Master Tape", Icon: "ZZZ/Encrypted_Master_Tape.webp", Fields: []string{"chartered", "firewalker", "messenger", "hues"}},
+		{Key: "masterTape", Name: "Master Tape", Icon: "ZZZ/Master_Tape.webp", Fields: []string{"basic"}},
+	},
+	gameIDGenshin: {
+		{Key: "primogem", Name: "Primogems", Icon: "Genshin/Primogem.webp", Fields: []string{"oroberyl"}},
+		{Key: "genesisCrystal", Name: "Genesis Crystals", Fields: []string{"origeometry"}},
+		{Key: "starglitter", Name: "Masterless Starglitter", Fields: []string{"arsenal"}},
+		{Key: "intertwinedFate", Name: "Intertwined Fate", Icon: "Genshin/Intertwined_Fate.webp", Fields: []string{"chartered", "firewalker", "messenger", "hues"}},
+		{Key: "acquaintFate", Name: "Acquaint Fate", Icon: "Genshin/Acquaint_Fate.webp", Fields: []string{"basic"}},
+	},
+	gameIDHsr: {
+		{Key: "stellarJade", Name: "Stellar Jade", Icon: "HSR/Stellar_Jade.webp", Fields: []string{"oroberyl"}},
+		{Key: "oneiricShard", Name: "Oneiric Shard", Fields: []string{"origeometry"}},
+		{Key: "tracksOfDestiny", Name: "Tracks of Destiny", Fields: []string{"arsenal"}},
+		{Key: "specialPass", Name: "Star Rail Special Pass", Icon: "HSR/Star_Rail_Special_Pass.webp", Fields: []string{"chartered", "firewalker", "messenger", "hues"}},
+		{Key: "railPass", Name: "Star Rail Pass", Icon: "HSR/Star_Rail_Pass.webp", Fields: []string{"basic"}},
+	},
+}
+
+func currenciesForGame(gameID string) []currencyDef {
+	if currencies, ok := currenciesByGame[gameID]; ok {
+		return currencies
+	}
+	return currenciesByGame[defaultGameID]
+}
+
+// currencyManifest returns the currencies of a game with their pull conversion filled in from
+// pullConversionsByGame: the base currency at 1/BasePerPull, the premium currency through PremiumToBase,
+// and pull permits at one pull each.
+func currencyManifest(gameID string) []currencyDef {
+	conversion := pullConversionForGame(gameID)
+	manifest := []currencyDef{}
+	for _, currency := range currenciesForGame(gameID) {
+		entry := currency
+		entry.Fields = append([]string{}, currency.Fields...)
+		unit := currency.unit()
+		entry.PullsPerUnit = conversion.pullsFromRewards(unit)
+		if unit.Origeometry > 0 && conversion.BasePerPull > 0 {
+			entry.PullsPerUnit = conversion.PremiumToBase / conversion.BasePerPull
+		}
+		manifest = append(manifest, entry)
+	}
+	return manifest
+}
+
+func (c currencyDef) value(r Rewards) float64 {
+	total := 0.0
+	for _, field := range c.Fields {
+		if value := r.mappedField(field); value != nil {
+			total += *value
+		}
+	}
+	return total
+}
+
+// unit holds one of the currency, in its first field.
+func (c currencyDef) unit() Rewards {
+	unit := Rewards{}
+	if len(c.Fields) > 0 {
+		unit.setMappedValue(c.Fields[0], 1)
+	}
+	return unit
+}
@@ -0,0 +1,63 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCurrencyManifest(t *testing.T) {
+	want := map[string]float64{"astrite": 1.0 / 160, "lunite": 1.0 / 160, "radiantTide": 1, "forgingTide": 1, "forgingToken": 0, "lustrousTide": 0}
+	manifest := currencyManifest(gameIDWuwa)
+	if len(manifest) != len(want) {
+		t.Fatalf("got %d currencies, want %d", len(manifest), len(want))
+	}
+	for _, currency := range manifest {
+		if rate, ok := want[currency.Key]; !ok || currency.PullsPerUnit != rate {
+			t.Fatalf("%s pullsPerUnit = %v, want %v", currency.Key, currency.PullsPerUnit, rate)
+		}
+	}
+	for _, currency := range currencyManifest(gameIDEndfield) {
+		if currency.Key == "origeometry" && currency.PullsPerUnit != 0.15 {
+			t.Fatalf("origeometry pullsPerUnit = %v, want 0.15", currency.PullsPerUnit)
+		}
+	}
+}
+
+func TestRewardsForGameFollowsManifest(t *testing.T) {
+	rewards := Rewards{Oroberyl: 300, Chartered: 2, Firewalker: 1, Hues: 1, Basic: 5}
+	values := rewardsForGame(rewards, gameIDZzz)
+	if values["polychrome"] != 300 || values["encryptedMasterTape"] != 4 || values["masterTape"] != 5 || len(values) != 5 {
+		t.Fatalf("unexpected ZZZ rewards %v", values)
+	}
+	for gameID := range currenciesByGame {
+		for _, currency := range currenciesForGame(gameID) {
+			if _, ok := rewardsForGame(rewards, gameID)[currency.Key]; !ok {
+				t.Fatalf("%s: %s missing from rewards", gameID, currency.Key)
+			}
+			for _, field := range currency.Fields {
+				if (&Rewards{}).mappedField(field) == nil {
+					t.Fatalf("%s: %s maps unknown field %q", gameID, currency.Key, field)
+				}
+			}
+		}
+	}
+}
+
+func TestGeneratedMetaHasCurrencies(t *testing.T) {
+	content, err := renderGeneratedFile(nil, GeneratedMeta{GameID: gameIDHsr})
+	if err != nil {
+		t.Fatalf("renderGeneratedFile() error = %v", err)
+	}
+	raw, err := generatedMetaJSON([]byte(content))
+	if err != nil || raw == nil {
+		t.Fatalf("meta block missing (%v):\n%s", err, content)
+	}
+	var meta GeneratedMeta
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		t.Fatal(err)
+	}
+	if len(meta.Currencies) != 5 || meta.Currencies[0].Key != "stellarJade" || !strings.HasPrefix(meta.Currencies[0].Icon, "HSR/") {
+		t.Fatalf("unexpected currencies %+v", meta.Currencies)
+	}
+}
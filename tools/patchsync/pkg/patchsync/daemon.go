@@ -0,0 +1,163 @@
+package patchsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const defaultPIDFile = "tools/patchsync/logs/patchsync.pid"
+
+// instanceLock marks the output directories of a running serve process. The lock files live in the system
+// temp directory, keyed by the absolute directory, so checkouts in different places still see each other.
+type instanceLock struct {
+	pid     int
+	pidFile string
+	paths   []string
+}
+
+// serveOutputDirs lists the directories a serve process writes generated files to, for every game.
+func serveOutputDirs(cfg SyncConfig) []string {
+	dirs := []string{}
+	add := func(dir string) {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dirs = append(dirs, abs)
+		}
+	}
+	for _, gameID := range AvailableGameIDs() {
+		profile, err := ResolveGameProfile(gameID)
+		if err != nil {
+			continue
+		}
+		outputPath := configuredOutputPath(cfg, profile)
+		add(filepath.Dir(resolveOutputPath(outputPath)))
+	}
+	if strings.TrimSpace(cfg.APIDir) != "" {
+		add(resolveAPIDir(cfg.APIDir))
+	}
+	slices.Sort(dirs)
+	return slices.Compact(dirs)
+}
+
+func instanceLockPath(dir string) string {
+	sum := sha256.Sum256([]byte(dir))
+	return filepath.Join(os.TempDir(), "patchsync-"+hex.EncodeToString(sum[:8])+".lock")
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// FindProcess only succeeds for running processes on Windows; elsewhere signal 0 probes without effect.
+	if runtime.GOOS == "windows" {
+		_ = process.Release()
+		return true
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+func readPIDFile(path string) int {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(raw)))
+	return pid
+}
+
+// claimLockFile creates path holding pid. A lock left by a process that is gone is taken over.
+func claimLockFile(path string, pid int) (int, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, err = fmt.Fprintf(file, "%d\n", pid)
+			return 0, errors.Join(err, file.Close())
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return 0, err
+		}
+		if owner := readPIDFile(path); owner != pid && processAlive(owner) {
+			return owner, nil
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, err
+		}
+	}
+	return 0, fmt.Errorf("could not claim %s", path)
+}
+
+// acquireInstanceLock refuses to start when another live patchsync serves one of the same output
+// directories, then writes pidFile (when set) for service managers and "kill -HUP".
+func acquireInstanceLock(cfg SyncConfig, pidFile string) (*instanceLock, error) {
+	lock := &instanceLock{pid: os.Getpid()}
+	for _, dir := range serveOutputDirs(cfg) {
+		path := instanceLockPath(dir)
+		owner, err := claimLockFile(path, lock.pid)
+		if err == nil && owner != 0 {
+			err = fmt.Errorf("another patchsync (pid %d) already serves %s", owner, dir)
+		}
+		if err != nil {
+			lock.release()
+			return nil, err
+		}
+		lock.paths = append(lock.paths, path)
+	}
+	if strings.TrimSpace(pidFile) != "" {
+		lock.pidFile = resolveOutputPath(pidFile)
+		if err := os.MkdirAll(filepath.Dir(lock.pidFile), 0o755); err != nil {
+			lock.release()
+			return nil, err
+		}
+		if err := os.WriteFile(lock.pidFile, []byte(strconv.Itoa(lock.pid)+"\n"), 0o644); err != nil {
+			lock.release()
+			return nil, err
+		}
+	}
+	return lock, nil
+}
+
+// release removes the lock and PID files that still name this process.
+func (l *instanceLock) release() {
+	if l == nil {
+		return
+	}
+	for _, path := range append(slices.Clone(l.paths), l.pidFile) {
+		if path != "" && readPIDFile(path) == l.pid {
+			_ = os.Remove(path)
+		}
+	}
+	l.paths, l.pidFile = nil, ""
+}
+
+// watchConfigReload re-reads the env files on SIGHUP. Spreadsheet IDs are looked up per sync, so the next sync uses
+// the new values; flags only change on restart.
+func watchConfigReload(envFiles []string) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			paths, changed, err := reloadDotEnv(envFiles)
+			switch {
+			case err != nil:
+				fmt.Fprintf(os.Stderr, "SIGHUP: %v; configuration unchanged\n", err)
+			case len(paths) == 0:
+				fmt.Println("SIGHUP: no .env found, configuration unchanged")
+			default:
+				fmt.Printf("SIGHUP: reloaded %s (%d settings changed)\n", strings.Join(paths, ", "), changed)
+			}
+		}
+	}()
+}
@@ -0,0 +1,54 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAcquireInstanceLock(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	dir := t.TempDir()
+	t.Chdir(dir)
+	cfg := SyncConfig{GameID: gameIDWuwa, OutputPath: filepath.Join(dir, "out", "wuwa.generated.js")}
+	busy := instanceLockPath(filepath.Join(dir, "out"))
+	if err := os.WriteFile(busy, []byte(strconv.Itoa(os.Getppid())+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := acquireInstanceLock(cfg, "patchsync.pid"); err == nil || !strings.Contains(err.Error(), "already serves") {
+		t.Fatalf("expected a running instance to block the start, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "patchsync.pid")); !os.IsNotExist(err) {
+		t.Fatalf("refused start must not write the PID file: %v", err)
+	}
+	for _, outputDir := range serveOutputDirs(cfg) {
+		if path := instanceLockPath(outputDir); path != busy {
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Fatalf("lock of %s kept after a refused start", outputDir)
+			}
+		}
+	}
+
+	// The owner is gone now, so its lock is stale and gets taken over.
+	if err := os.WriteFile(busy, []byte("999999999\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := acquireInstanceLock(cfg, "patchsync.pid")
+	if err != nil {
+		t.Fatalf("acquireInstanceLock() error = %v", err)
+	}
+	if pid := readPIDFile(filepath.Join(dir, "patchsync.pid")); pid != os.Getpid() {
+		t.Fatalf("PID file holds %d, want %d", pid, os.Getpid())
+	}
+	if pid := readPIDFile(busy); pid != os.Getpid() {
+		t.Fatalf("stale lock not taken over, holds %d", pid)
+	}
+	lock.release()
+	for _, path := range []string{busy, filepath.Join(dir, "patchsync.pid")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("%s not removed on release", path)
+		}
+	}
+}
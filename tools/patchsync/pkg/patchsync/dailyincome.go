@@ -0,0 +1,49 @@
+package patchsync
+
+import "math"
+
+// dailyIncome is the average income per day of a patch, scalers included, written next to each generated
+// patch so the frontend can prorate a partial patch without redoing the scaler arithmetic. Base sums the
+// sources every player gets (gate "always", no option); Sources holds every source so other gate and option
+// combinations can be added up. Sources with a BP crate model are left out: their value depends on the
+// pass tier.
+type dailyIncome struct {
+	Base    map[string]float64            `json:"base"`
+	Sources map[string]map[string]float64 `json:"sources"`
+}
+
+func roundToHundredth(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func nonZeroRewards(values map[string]float64) map[string]float64 {
+	result := map[string]float64{}
+	for key, value := range values {
+		if rounded := roundToHundredth(value); rounded != 0 {
+			result[key] = rounded
+		}
+	}
+	return result
+}
+
+// computeDailyIncome returns nil for patches without a positive duration.
+func computeDailyIncome(patch Patch, gameID string) *dailyIncome {
+	if patch.DurationDays <= 0 {
+		return nil
+	}
+	days := float64(patch.DurationDays)
+	base := Rewards{}
+	income := &dailyIncome{Sources: map[string]map[string]float64{}}
+	for _, src := range patch.Sources {
+		if src.BPCrateModel != nil || src.ID == "" {
+			continue
+		}
+		daily := sourceRewardsForDuration(src, patch.DurationDays).scaled(1 / days)
+		income.Sources[src.ID] = nonZeroRewards(rewardsForGame(daily, gameID))
+		if (src.Gate == "" || src.Gate == "always") && (src.OptionKey == nil || *src.OptionKey == "") {
+			base.add(daily)
+		}
+	}
+	income.Base = nonZeroRewards(rewardsForGame(base, gameID))
+	return income
+}
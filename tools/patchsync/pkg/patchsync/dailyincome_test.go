@@ -0,0 +1,39 @@
+package patchsync
+
+import "testing"
+
+func TestComputeDailyIncomeIncludesScalers(t *testing.T) {
+	option := "includeTopups"
+	events := source("events", "Events", "always", nil, true, Rewards{Oroberyl: 4200})
+	daily := source("dailies", "Daily Missions", "always", nil, true, Rewards{})
+	daily.Scalers = []Scaler{{Type: "per_duration", Unit: "cycle", EveryDays: 7, Rounding: "floor", Rewards: Rewards{Oroberyl: 70}}}
+	monthly := source("monthly", "Lunite Subscription", "monthly", nil, true, Rewards{Oroberyl: 3780})
+	topups := source("topups", "Top-ups", "always", &option, false, Rewards{Origeometry: 420})
+	crates := source("bpCrates", "BP Crates", "bp2", nil, true, Rewards{Oroberyl: 1000})
+	crates.BPCrateModel = &BPCrateModel{Type: "post_bp60_estimate", DaysToLevel60T3: 30}
+	patch := Patch{ID: "2.3", Patch: "2.3", DurationDays: 42, Sources: []Source{events, daily, monthly, topups, crates}}
+
+	income := computeDailyIncome(patch, gameIDWuwa)
+	if income == nil {
+		t.Fatalf("expected daily income")
+	}
+	// 4200/42 from events plus 6 weekly cycles of 70 spread over 42 days.
+	if got := income.Base["astrite"]; got != 110 {
+		t.Fatalf("base astrite = %v, want 110", got)
+	}
+	if _, ok := income.Base["lunite"]; ok {
+		t.Fatalf("option-gated top-ups leaked into base: %v", income.Base)
+	}
+	if got := income.Sources["monthly"]["astrite"]; got != 90 {
+		t.Fatalf("monthly astrite = %v, want 90", got)
+	}
+	if got := income.Sources["topups"]["lunite"]; got != 10 {
+		t.Fatalf("topups lunite = %v, want 10", got)
+	}
+	if _, ok := income.Sources["bpCrates"]; ok {
+		t.Fatalf("tier-dependent BP crates should be left out")
+	}
+	if computeDailyIncome(Patch{ID: "2.4"}, gameIDWuwa) != nil {
+		t.Fatalf("expected nil for a patch without duration")
+	}
+}
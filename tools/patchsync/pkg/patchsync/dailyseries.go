@@ -0,0 +1,112 @@
+package patchsync
+
+import "time"
+
+// dailySeriesDay is one day of the income series, with rewards split by source gate ("always", "monthly",
+// "bp2", "bp3") so a chart can add up the gates a player has.
+type dailySeriesDay struct {
+	Date    string                        `json:"date"`
+	Patch   string                        `json:"patch,omitempty"`
+	Rewards map[string]map[string]float64 `json:"rewards"`
+}
+
+// dailySeries is the daily.json export: every day from the first patch start to the last patch end, in order.
+type dailySeries struct {
+	GameID string           `json:"gameId"`
+	From   string           `json:"from"`
+	To     string           `json:"to"`
+	Days   []dailySeriesDay `json:"days"`
+}
+
+type seriesDay struct {
+	patch   string
+	rewards map[string]*Rewards
+}
+
+func (day *seriesDay) add(gate string, rewards Rewards) {
+	if day.rewards[gate] == nil {
+		day.rewards[gate] = &Rewards{}
+	}
+	day.rewards[gate].add(rewards)
+}
+
+// buildDailySeries spreads the fixed rewards of each source evenly over its patch, pays day scalers every
+// day and cycle scalers on the first day of each cycle. Option-gated sources and BP crate estimates are left
+// out, as in GENERATED_CUMULATIVE; forecast patches are kept so the curve can run into the future.
+func buildDailySeries(gameID string, patches []Patch) dailySeries {
+	series := dailySeries{GameID: gameID, Days: []dailySeriesDay{}}
+	byDate := map[string]*seriesDay{}
+	var first, last time.Time
+	sorted := append([]Patch(nil), patches...)
+	sortPatches(sorted)
+	for _, patch := range sorted {
+		start, err := time.Parse(isoDateLayout, patch.StartDate)
+		if err != nil || patch.DurationDays <= 0 {
+			continue
+		}
+		days := patch.DurationDays
+		end := start.AddDate(0, 0, days-1)
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if end.After(last) {
+			last = end
+		}
+		dayAt := func(offset int) *seriesDay {
+			date := start.AddDate(0, 0, offset).Format(isoDateLayout)
+			if byDate[date] == nil {
+				byDate[date] = &seriesDay{rewards: map[string]*Rewards{}}
+			}
+			byDate[date].patch = patchIDOrFallback(patch)
+			return byDate[date]
+		}
+		for _, src := range patch.Sources {
+			if (src.OptionKey != nil && *src.OptionKey != "") || src.BPCrateModel != nil {
+				continue
+			}
+			gate := src.Gate
+			if gate == "" {
+				gate = "always"
+			}
+			perDay := src.Rewards.scaled(1 / float64(days))
+			for offset := range days {
+				dayAt(offset).add(gate, perDay)
+			}
+			for _, scaler := range src.Scalers {
+				if scaler.Type != "per_duration" {
+					continue
+				}
+				if scaler.Unit == "" || scaler.Unit == "day" {
+					for offset := range days {
+						dayAt(offset).add(gate, scaler.Rewards)
+					}
+					continue
+				}
+				everyDays := max(1, scaler.EveryDays)
+				cycles := int(applyScalerRounding(float64(days)/float64(everyDays), scaler.Rounding))
+				for cycle := range cycles {
+					dayAt(min(cycle*everyDays, days-1)).add(gate, scaler.Rewards)
+				}
+			}
+		}
+	}
+	if first.IsZero() {
+		return series
+	}
+
+	series.From, series.To = first.Format(isoDateLayout), last.Format(isoDateLayout)
+	for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+		key := date.Format(isoDateLayout)
+		entry := dailySeriesDay{Date: key, Rewards: map[string]map[string]float64{}}
+		if day := byDate[key]; day != nil {
+			entry.Patch = day.patch
+			for gate, rewards := range day.rewards {
+				if values := nonZeroRewards(rewardsForGame(*rewards, gameID)); len(values) > 0 {
+					entry.Rewards[gate] = values
+				}
+			}
+		}
+		series.Days = append(series.Days, entry)
+	}
+	return series
+}
@@ -0,0 +1,49 @@
+package patchsync
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuildDailySeries(t *testing.T) {
+	option := "includeTopups"
+	events := source("events", "Events", "always", nil, true, Rewards{Oroberyl: 1400})
+	weekly := source("weekly", "Weekly Bosses", "always", nil, true, Rewards{})
+	weekly.Scalers = []Scaler{{Type: "per_duration", Unit: "cycle", EveryDays: 7, Rounding: "floor", Rewards: Rewards{Oroberyl: 60}}}
+	monthly := source("monthly", "Subscription", "monthly", nil, true, Rewards{})
+	monthly.Scalers = []Scaler{{Type: "per_duration", Unit: "day", EveryDays: 1, Rounding: "floor", Rewards: Rewards{Oroberyl: 90}}}
+	topups := source("topups", "Top-ups", "always", &option, false, Rewards{Origeometry: 100})
+	patches := []Patch{
+		{ID: "2.1", Patch: "2.1", StartDate: "2025-01-15", DurationDays: 14, Sources: []Source{events, weekly, monthly, topups}},
+		{ID: "2.0", Patch: "2.0", StartDate: "2025-01-01", DurationDays: 10, Sources: []Source{events}},
+	}
+
+	series := buildDailySeries(gameIDWuwa, patches)
+	if series.From != "2025-01-01" || series.To != "2025-01-28" || len(series.Days) != 28 {
+		t.Fatalf("unexpected range %s..%s with %d days", series.From, series.To, len(series.Days))
+	}
+	if day := series.Days[0]; day.Patch != "2.0" || day.Rewards["always"]["astrite"] != 140 {
+		t.Fatalf("unexpected first day %+v", day)
+	}
+	// 2.0 ends on the 10th, so the days until 2.1 starts are empty but still listed.
+	if gap := series.Days[11]; gap.Patch != "" || len(gap.Rewards) != 0 {
+		t.Fatalf("expected an empty gap day, got %+v", gap)
+	}
+	if day := series.Days[14]; day.Patch != "2.1" || day.Rewards["always"]["astrite"] != 160 || day.Rewards["monthly"]["astrite"] != 90 {
+		t.Fatalf("unexpected first day of 2.1 %+v", day)
+	}
+	if day := series.Days[21]; day.Rewards["always"]["astrite"] != 160 {
+		t.Fatalf("second weekly cycle not paid on day 8 of 2.1: %+v", day)
+	}
+
+	total := 0.0
+	for _, day := range series.Days {
+		total += day.Rewards["always"]["astrite"]
+		if _, ok := day.Rewards["always"]["lunite"]; ok {
+			t.Fatalf("option-gated top-ups should be left out: %+v", day)
+		}
+	}
+	if math.Abs(total-(1400+1400+120)) > 0.01 {
+		t.Fatalf("always total = %v, want 2920", total)
+	}
+}
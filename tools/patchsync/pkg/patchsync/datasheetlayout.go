@@ -0,0 +1,45 @@
+package patchsync
+
+// isTransposedDataSheet reports whether a Data sheet lists patches as rows instead of columns. Row labels in the
+// usual layout are source names, so a first column made mostly of versions means the sheet is transposed.
+// Footnote rows are left out of the count.
+func isTransposedDataSheet(records [][]string) bool {
+	labels := 0
+	versions := 0
+	for _, record := range records {
+		cell := getCell(record, 0)
+		if _, footnote := footnoteText(record); cell == "" || footnote {
+			continue
+		}
+		labels++
+		if patchID := canonicalPatchID(cell); isVersionLikeSheetName(patchID) {
+			versions++
+		}
+	}
+	return versions >= 2 && versions*2 > labels
+}
+
+// orientDataSheetRecords returns records with patches as columns, transposing a sheet that has them as rows.
+func orientDataSheetRecords(records [][]string) [][]string {
+	if !isTransposedDataSheet(records) {
+		return records
+	}
+	return transposeRecords(records)
+}
+
+func transposeRecords(records [][]string) [][]string {
+	width := 0
+	for _, record := range records {
+		width = max(width, len(record))
+	}
+	transposed := make([][]string, width)
+	for col := range transposed {
+		transposed[col] = make([]string, len(records))
+		for row, record := range records {
+			if col < len(record) {
+				transposed[col][row] = record[col]
+			}
+		}
+	}
+	return transposed
+}
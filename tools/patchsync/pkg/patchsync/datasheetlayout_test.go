@@ -0,0 +1,49 @@
+package patchsync
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWuwaDataSheetTransposed(t *testing.T) {
+	csvText := csvLines(
+		"Patch,Version Events,Permanent Content,Limited Total F2P",
+		"3.2,30.3,28.6,120.2",
+		"3.3 (STC),13.9,3.7,72.7",
+		"",
+		"* 3.3: event extended by 3 days",
+	)
+
+	pullsByPatch, err := parseWuwaDataSheet(csvText, nil, decimalAuto)
+	if err != nil {
+		t.Fatalf("parseWuwaDataSheet() error = %v", err)
+	}
+	want := map[string]map[string]float64{
+		"3.2": {"events": 30.3, "permanent": 28.6, "__totalF2P": 120.2},
+		"3.3": {"events": 13.9, "permanent": 3.7, "__totalF2P": 72.7},
+	}
+	if !reflect.DeepEqual(pullsByPatch, want) {
+		t.Fatalf("parseWuwaDataSheet() = %v, want %v", pullsByPatch, want)
+	}
+
+	tags, err := parseDataSheetPatchTags(csvText)
+	if err != nil {
+		t.Fatalf("parseDataSheetPatchTags() error = %v", err)
+	}
+	if !reflect.DeepEqual(tags, map[string][]string{"3.3": {"WIP"}}) {
+		t.Fatalf("parseDataSheetPatchTags() = %v, want 3.3 tagged WIP", tags)
+	}
+}
+
+func TestIsTransposedDataSheet(t *testing.T) {
+	usual := [][]string{{"Version", "3.2", "3.3"}, {"Version Events", "30.3", "13.9"}, {"Permanent Content", "28.6", "3.7"}}
+	if isTransposedDataSheet(usual) {
+		t.Fatal("usual layout detected as transposed")
+	}
+	if !isTransposedDataSheet(transposeRecords(usual)) {
+		t.Fatal("transposed layout not detected")
+	}
+	if got := transposeRecords([][]string{{"a", "b"}, {"c"}}); !reflect.DeepEqual(got, [][]string{{"a", "c"}, {"b", ""}}) {
+		t.Fatalf("transposeRecords() of ragged rows = %v", got)
+	}
+}
@@ -0,0 +1,123 @@
+package patchsync
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateOrder decides how an ambiguous numeric date such as 03/04/2025 is read. Dates where one part is above 12
+// are read the only way they can be.
+type dateOrder int
+
+const (
+	dateOrderMonthFirst dateOrder = iota
+	dateOrderDayFirst
+)
+
+// sheetDateOrders lists games whose sheets write dates day first; the rest are month first.
+var sheetDateOrders = map[string]dateOrder{
+	gameIDWuwa: dateOrderDayFirst,
+}
+
+func sheetDateOrder(gameID string) dateOrder {
+	return sheetDateOrders[gameID]
+}
+
+var (
+	isoDatePattern     = regexp.MustCompile(`^(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})$`)
+	numericDatePattern = regexp.MustCompile(`^(\d{1,2})[-/.](\d{1,2})[-/.](\d{4})$`)
+	ordinalDayPattern  = regexp.MustCompile(`(?i)(\d)(?:st|nd|rd|th)\b`)
+	parenthesizedText  = regexp.MustCompile(`\(([^)]*)\)`)
+)
+
+var monthNames = []string{
+	"january", "february", "march", "april", "may", "june",
+	"july", "august", "september", "october", "november", "december",
+}
+
+// monthFromName accepts full and abbreviated English month names ("Jan", "Sept.", "September").
+func monthFromName(raw string) int {
+	word := strings.TrimSuffix(strings.ToLower(raw), ".")
+	if len(word) < 3 {
+		return 0
+	}
+	for idx, name := range monthNames {
+		if strings.HasPrefix(name, word) {
+			return idx + 1
+		}
+	}
+	return 0
+}
+
+func formatISODate(year, month, day int) string {
+	if month < 1 || month > 12 || day < 1 {
+		return ""
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		return ""
+	}
+	return date.Format(isoDateLayout)
+}
+
+// parseDateToISO reads one date in ISO (2025-01-15), numeric (15.01.2025, 1/15/2025), or month-name
+// (Jan 15, 2025 or 15 January 2025) form and returns it as YYYY-MM-DD, or "" when raw is not a date.
+func parseDateToISO(raw string, order dateOrder) string {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return ""
+	}
+	if match := isoDatePattern.FindStringSubmatch(value); match != nil {
+		year, _ := strconv.Atoi(match[1])
+		month, _ := strconv.Atoi(match[2])
+		day, _ := strconv.Atoi(match[3])
+		return formatISODate(year, month, day)
+	}
+	if match := numericDatePattern.FindStringSubmatch(value); match != nil {
+		first, _ := strconv.Atoi(match[1])
+		second, _ := strconv.Atoi(match[2])
+		year, _ := strconv.Atoi(match[3])
+		switch {
+		case first > 12:
+			return formatISODate(year, second, first)
+		case second > 12:
+			return formatISODate(year, first, second)
+		case order == dateOrderDayFirst:
+			return formatISODate(year, second, first)
+		default:
+			return formatISODate(year, first, second)
+		}
+	}
+
+	fields := strings.Fields(strings.ReplaceAll(ordinalDayPattern.ReplaceAllString(value, "$1"), ",", " "))
+	if len(fields) != 3 {
+		return ""
+	}
+	year, err := strconv.Atoi(fields[2])
+	if err != nil || len(fields[2]) != 4 {
+		return ""
+	}
+	if month := monthFromName(fields[0]); month > 0 {
+		if day, err := strconv.Atoi(fields[1]); err == nil {
+			return formatISODate(year, month, day)
+		}
+	}
+	if month := monthFromName(fields[1]); month > 0 {
+		if day, err := strconv.Atoi(fields[0]); err == nil {
+			return formatISODate(year, month, day)
+		}
+	}
+	return ""
+}
+
+// findParenthesizedDate returns the first "(...)" group of text that holds a date.
+func findParenthesizedDate(text string, order dateOrder) string {
+	for _, match := range parenthesizedText.FindAllStringSubmatch(text, -1) {
+		if date := parseDateToISO(match[1], order); date != "" {
+			return date
+		}
+	}
+	return ""
+}
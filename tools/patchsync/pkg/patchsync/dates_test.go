@@ -0,0 +1,50 @@
+package patchsync
+
+import "testing"
+
+func TestParseDateToISO(t *testing.T) {
+	cases := []struct {
+		raw   string
+		order dateOrder
+		want  string
+	}{
+		{"2025-01-15", dateOrderMonthFirst, "2025-01-15"},
+		{"2025/1/5", dateOrderDayFirst, "2025-01-05"},
+		{"01/15/2025", dateOrderMonthFirst, "2025-01-15"},
+		{"15/01/2025", dateOrderMonthFirst, "2025-01-15"},
+		{"15.01.2025", dateOrderDayFirst, "2025-01-15"},
+		{"03/04/2025", dateOrderMonthFirst, "2025-03-04"},
+		{"03/04/2025", dateOrderDayFirst, "2025-04-03"},
+		{"Jan 15, 2025", dateOrderDayFirst, "2025-01-15"},
+		{"January 15th 2025", dateOrderMonthFirst, "2025-01-15"},
+		{"15 Sept. 2025", dateOrderMonthFirst, "2025-09-15"},
+		{"1st March, 2025", dateOrderMonthFirst, "2025-03-01"},
+		{"Feb 30, 2025", dateOrderMonthFirst, ""},
+		{"13/13/2025", dateOrderMonthFirst, ""},
+		{"Ma 15 2025", dateOrderMonthFirst, ""},
+		{"Version 2.0", dateOrderMonthFirst, ""},
+		{"", dateOrderMonthFirst, ""},
+	}
+	for _, tc := range cases {
+		if got := parseDateToISO(tc.raw, tc.order); got != tc.want {
+			t.Errorf("parseDateToISO(%q, %d) = %q, want %q", tc.raw, tc.order, got, tc.want)
+		}
+	}
+}
+
+func TestParsePatchHeaderMetaDates(t *testing.T) {
+	cases := []struct {
+		title, name, date string
+	}{
+		{"Version 2.0: Into the Wilds (01/15/2025)", "Into the Wilds", "2025-01-15"},
+		{"Version 2.0: Into the Wilds (Jan 15, 2025)", "Into the Wilds", "2025-01-15"},
+		{"Version 2.0 (leaks) (2025-01-15)", "Version 2.0 (leaks)", "2025-01-15"},
+		{"Version 2.0: Into the Wilds", "Into the Wilds", ""},
+	}
+	for _, tc := range cases {
+		name, date := parsePatchHeaderMeta(tc.title, dateOrderMonthFirst)
+		if name != tc.name || date != tc.date {
+			t.Errorf("parsePatchHeaderMeta(%q) = %q, %q, want %q, %q", tc.title, name, date, tc.name, tc.date)
+		}
+	}
+}
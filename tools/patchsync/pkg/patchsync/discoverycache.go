@@ -0,0 +1,129 @@
+package patchsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDiscoveryCachePath = "tools/patchsync/logs/discovery-cache.json"
+	defaultDiscoveryTTL       = 6 * time.Hour
+)
+
+type discoveryCacheEntry struct {
+	Names        []string `json:"names"`
+	DiscoveredAt string   `json:"discoveredAt"`
+}
+
+// discoveryCacheMu guards the cache file, which concurrent /sync-all games read and rewrite.
+var discoveryCacheMu sync.Mutex
+
+func readDiscoveryCache(path string) (map[string]discoveryCacheEntry, error) {
+	result := map[string]discoveryCacheEntry{}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return result, nil
+		}
+		return nil, err
+	}
+	if strings.TrimSpace(string(body)) == "" {
+		return result, nil
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parse discovery cache: %w", err)
+	}
+	return result, nil
+}
+
+func writeDiscoveryCacheEntry(path, key string, entry discoveryCacheEntry) error {
+	all, err := readDiscoveryCache(path)
+	if err != nil {
+		all = map[string]discoveryCacheEntry{}
+	}
+	all[key] = entry
+	body, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal discovery cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create discovery cache directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(body, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write discovery cache: %w", err)
+	}
+	return nil
+}
+
+// cachedDiscoveryFetcher keeps the tab names found by ListSheets for ttl, keyed by sheet source and
+// spreadsheet id, plus variant when non-default sheet patterns apply. refresh skips the cached names once and stores a fresh discovery.
+type cachedDiscoveryFetcher struct {
+	SheetFetcher
+	path    string
+	source  string
+	ttl     time.Duration
+	variant string
+	refresh bool
+	clock   Clock
+	logs    *syncLog
+}
+
+func (f cachedDiscoveryFetcher) key(spreadsheetID string) string {
+	source := f.source
+	if source == "" {
+		source = sheetSourceGoogle
+	}
+	key := source + ":" + strings.TrimSpace(spreadsheetID)
+	if f.variant != "" {
+		key += "#" + f.variant
+	}
+	return key
+}
+
+func (f cachedDiscoveryFetcher) ListSheets(ctx context.Context, spreadsheetID string) ([]string, error) {
+	key := f.key(spreadsheetID)
+	now := clockOrSystem(f.clock).Now()
+	if !f.refresh {
+		discoveryCacheMu.Lock()
+		all, err := readDiscoveryCache(f.path)
+		discoveryCacheMu.Unlock()
+		if err != nil {
+			appendSyncLog(f.logs, "discovery cache unavailable: %v", err)
+		} else if entry, ok := all[key]; ok && len(entry.Names) > 0 {
+			discoveredAt, parseErr := time.Parse(time.RFC3339, entry.DiscoveredAt)
+			if age := now.Sub(discoveredAt); parseErr == nil && age >= 0 && age < f.ttl {
+				appendSyncLog(f.logs, "using cached sheet names for %s (discovered %s ago)", spreadsheetID, age.Round(time.Second))
+				return append([]string(nil), entry.Names...), nil
+			}
+		}
+	} else {
+		appendSyncLog(f.logs, "refreshing sheet discovery for %s", spreadsheetID)
+	}
+	names, err := f.SheetFetcher.ListSheets(ctx, spreadsheetID)
+	if err != nil || len(names) == 0 {
+		return names, err
+	}
+	discoveryCacheMu.Lock()
+	writeErr := writeDiscoveryCacheEntry(f.path, key, discoveryCacheEntry{Names: names, DiscoveredAt: now.UTC().Format(time.RFC3339)})
+	discoveryCacheMu.Unlock()
+	if writeErr != nil {
+		appendSyncLog(f.logs, "discovery cache write failed: %v", writeErr)
+	}
+	return names, nil
+}
+
+// ListTabs is not cached; it passes through so auxiliary tab lookups still see every tab.
+func (f cachedDiscoveryFetcher) ListTabs(ctx context.Context, spreadsheetID string) ([]string, error) {
+	lister, ok := f.SheetFetcher.(sheetTabLister)
+	if !ok {
+		return nil, errors.New("sheet source cannot list tabs")
+	}
+	return lister.ListTabs(ctx, spreadsheetID)
+}
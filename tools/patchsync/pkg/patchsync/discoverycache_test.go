@@ -0,0 +1,66 @@
+package patchsync
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type countingSheetFetcher struct {
+	SheetFetcher
+	lists *int
+}
+
+func (f countingSheetFetcher) ListSheets(ctx context.Context, spreadsheetID string) ([]string, error) {
+	*f.lists++
+	return f.SheetFetcher.ListSheets(ctx, spreadsheetID)
+}
+
+func TestCachedDiscoveryFetcherHonorsTTLAndRefresh(t *testing.T) {
+	lists := 0
+	base := countingSheetFetcher{
+		SheetFetcher: fakeSheetFetcher{sheets: map[string]string{"2.0": "a", "2.1": "b", "Data": "d"}},
+		lists:        &lists,
+	}
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	fetcher := cachedDiscoveryFetcher{
+		SheetFetcher: base,
+		path:         filepath.Join(t.TempDir(), "discovery-cache.json"),
+		ttl:          time.Hour,
+		clock:        FixedClock(start),
+		logs:         &syncLog{},
+	}
+	list := func(f cachedDiscoveryFetcher) {
+		t.Helper()
+		names, err := f.ListSheets(t.Context(), "sheet")
+		if err != nil || !reflect.DeepEqual(names, []string{"2.0", "2.1"}) {
+			t.Fatalf("ListSheets() = %v, %v", names, err)
+		}
+	}
+
+	list(fetcher)
+	list(fetcher)
+	if lists != 1 {
+		t.Fatalf("discovered %d times within the TTL, want 1", lists)
+	}
+	refreshed := fetcher
+	refreshed.refresh = true
+	list(refreshed)
+	if lists != 2 {
+		t.Fatalf("refresh did not rediscover (lists = %d)", lists)
+	}
+	expired := fetcher
+	expired.clock = FixedClock(start.Add(2 * time.Hour))
+	list(expired)
+	if lists != 3 {
+		t.Fatalf("expired entry was reused (lists = %d)", lists)
+	}
+	otherSource := fetcher
+	otherSource.source = sheetSourceSheetsAPI
+	list(otherSource)
+	if lists != 4 {
+		t.Fatalf("cache entries are shared across sheet sources (lists = %d)", lists)
+	}
+}
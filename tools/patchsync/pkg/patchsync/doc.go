@@ -0,0 +1,23 @@
+// Package patchsync turns the community Google Sheets of each supported gacha game into the
+// generated patch files used by the bookkeeper frontend.
+//
+// The command in tools/patchsync is a thin wrapper around Main. Other tools can embed the
+// sync engine directly:
+//
+//	result, err := patchsync.RunSync(ctx, patchsync.SyncConfig{
+//		GameID: "wuthering-waves",
+//		DryRun: true,
+//	})
+//
+// SyncConfig.Fetcher replaces the Google backends with any SheetFetcher, and ParseSheet parses
+// a single exported sheet without running a full sync.
+package patchsync
+
+// ParseSheet parses one version sheet of gameID, given as CSV, with that game's parser.
+func ParseSheet(gameID, sheetName, csvText string) (Patch, error) {
+	profile, err := ResolveGameProfile(gameID)
+	if err != nil {
+		return Patch{}, err
+	}
+	return profile.ParseSheet(sheetName, csvText, ParseOptions{})
+}
@@ -0,0 +1,179 @@
+package patchsync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// dotEnvValues holds the variables taken from env files, so a reload may change or drop them without
+// touching variables that came from the real environment.
+var (
+	dotEnvMu     sync.Mutex
+	dotEnvValues = map[string]string{}
+)
+
+// extractEnvFileArgs removes --env-file flags from args, since env files are loaded before any flag set is
+// parsed, and returns the remaining args and the absolute env file paths in order.
+func extractEnvFileArgs(args []string) ([]string, []string, error) {
+	rest := []string{}
+	files := []string{}
+	for idx := 0; idx < len(args); idx++ {
+		arg := args[idx]
+		if arg == "--" {
+			rest = append(rest, args[idx:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "env-file" {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if idx+1 >= len(args) {
+				return nil, nil, errors.New("--env-file needs a path")
+			}
+			idx++
+			value = args[idx]
+		}
+		for _, path := range uniqueStrings(strings.Split(value, ",")) {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, abs)
+		}
+	}
+	return rest, files, nil
+}
+
+// dotEnvLayers lists the env files to apply, lowest priority first: .env then .env.local of every directory
+// from the repository root down to the working directory, then envFiles (the --env-file paths). Outside a
+// repository only the nearest directory holding an env file is used.
+func dotEnvLayers(envFiles []string) []string {
+	layers := []string{}
+	cwd, err := os.Getwd()
+	if err == nil {
+		dirs := []string{}
+		nearest := ""
+		for dir := cwd; ; {
+			dirs = append(dirs, dir)
+			if nearest == "" && (fileExists(filepath.Join(dir, ".env")) || fileExists(filepath.Join(dir, ".env.local"))) {
+				nearest = dir
+			}
+			if _, statErr := os.Stat(filepath.Join(dir, ".git")); statErr == nil {
+				break
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				dirs = []string{nearest}
+				break
+			}
+			dir = parent
+		}
+		slices.Reverse(dirs)
+		for _, dir := range dirs {
+			if dir == "" {
+				continue
+			}
+			for _, name := range []string{".env", ".env.local"} {
+				if path := filepath.Join(dir, name); fileExists(path) {
+					layers = append(layers, path)
+				}
+			}
+		}
+	}
+	return append(layers, envFiles...)
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func parseDotEnv(raw string) map[string]string {
+	values := map[string]string{}
+	for _, rawLine := range strings.Split(raw, "\n") {
+		line := strings.TrimSpace(strings.TrimPrefix(rawLine, "\uFEFF"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "export ") {
+			line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		if key == "" {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		if len(value) >= 2 {
+			if (strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"")) ||
+				(strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'")) {
+				value = value[1 : len(value)-1]
+			}
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// readDotEnv merges the env file layers; later files win. A missing --env-file is an error.
+func readDotEnv(envFiles []string) ([]string, map[string]string, error) {
+	paths := dotEnvLayers(envFiles)
+	values := map[string]string{}
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read env file: %w", err)
+		}
+		for key, value := range parseDotEnv(string(raw)) {
+			values[key] = value
+		}
+	}
+	return paths, values, nil
+}
+
+func loadDotEnv(envFiles []string) error {
+	_, _, err := reloadDotEnv(envFiles)
+	return err
+}
+
+// reloadDotEnv applies the env files and returns them and how many variables changed. Variables set in the
+// real environment win over env files; ones an earlier load took from env files are updated or unset.
+func reloadDotEnv(envFiles []string) ([]string, int, error) {
+	paths, values, err := readDotEnv(envFiles)
+	if err != nil || len(paths) == 0 {
+		return paths, 0, err
+	}
+	dotEnvMu.Lock()
+	defer dotEnvMu.Unlock()
+	changed := 0
+	for key := range dotEnvValues {
+		if _, ok := values[key]; !ok {
+			_ = os.Unsetenv(key)
+			delete(dotEnvValues, key)
+			changed++
+		}
+	}
+	for key, value := range values {
+		if previous, ours := dotEnvValues[key]; ours {
+			if previous == value {
+				continue
+			}
+		} else if _, exists := os.LookupEnv(key); exists {
+			continue
+		}
+		_ = os.Setenv(key, value)
+		dotEnvValues[key] = value
+		changed++
+	}
+	return paths, changed, nil
+}
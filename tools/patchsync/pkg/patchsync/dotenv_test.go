@@ -0,0 +1,90 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeEnvFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func forgetDotEnv(t *testing.T, keys ...string) {
+	t.Cleanup(func() {
+		for _, key := range keys {
+			_ = os.Unsetenv(key)
+			delete(dotEnvValues, key)
+		}
+	})
+}
+
+func TestReloadDotEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("PATCHSYNC_TEST_FROM_ENV", "real")
+	forgetDotEnv(t, "PATCHSYNC_TEST_KEPT", "PATCHSYNC_TEST_DROPPED")
+
+	writeEnvFile(t, filepath.Join(dir, ".env"), "PATCHSYNC_TEST_KEPT=1\nPATCHSYNC_TEST_DROPPED=x\nPATCHSYNC_TEST_FROM_ENV=dotenv\n")
+	if _, changed, err := reloadDotEnv(nil); err != nil || changed != 2 || os.Getenv("PATCHSYNC_TEST_KEPT") != "1" || os.Getenv("PATCHSYNC_TEST_FROM_ENV") != "real" {
+		t.Fatalf("first load changed %d (%v), KEPT=%q FROM_ENV=%q", changed, err, os.Getenv("PATCHSYNC_TEST_KEPT"), os.Getenv("PATCHSYNC_TEST_FROM_ENV"))
+	}
+
+	writeEnvFile(t, filepath.Join(dir, ".env"), "PATCHSYNC_TEST_KEPT=2\nPATCHSYNC_TEST_FROM_ENV=dotenv\n")
+	paths, changed, err := reloadDotEnv(nil)
+	if err != nil || !slices.Equal(paths, []string{filepath.Join(dir, ".env")}) || changed != 2 {
+		t.Fatalf("reload of %v changed %d, want 2 (%v)", paths, changed, err)
+	}
+	if os.Getenv("PATCHSYNC_TEST_KEPT") != "2" || os.Getenv("PATCHSYNC_TEST_FROM_ENV") != "real" {
+		t.Fatalf("KEPT=%q FROM_ENV=%q after reload", os.Getenv("PATCHSYNC_TEST_KEPT"), os.Getenv("PATCHSYNC_TEST_FROM_ENV"))
+	}
+	if _, ok := os.LookupEnv("PATCHSYNC_TEST_DROPPED"); ok {
+		t.Fatalf("variable removed from .env is still set")
+	}
+}
+
+func TestDotEnvLayers(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "tools", "patchsync")
+	writeEnvFile(t, filepath.Join(root, ".env"), "PATCHSYNC_TEST_A=root\nPATCHSYNC_TEST_B=root\nPATCHSYNC_TEST_C=root\nPATCHSYNC_TEST_D=root\n")
+	writeEnvFile(t, filepath.Join(root, ".env.local"), "PATCHSYNC_TEST_B=root-local\nPATCHSYNC_TEST_C=root-local\nPATCHSYNC_TEST_D=root-local\n")
+	writeEnvFile(t, filepath.Join(sub, ".env"), "PATCHSYNC_TEST_C=sub\nPATCHSYNC_TEST_D=sub\n")
+	extra := filepath.Join(t.TempDir(), "ci.env")
+	writeEnvFile(t, extra, "PATCHSYNC_TEST_D=extra\n")
+	// A .env above the repository root is not part of the checkout.
+	writeEnvFile(t, filepath.Join(filepath.Dir(root), ".env"), "PATCHSYNC_TEST_E=outside\n")
+	t.Cleanup(func() { _ = os.Remove(filepath.Join(filepath.Dir(root), ".env")) })
+	t.Chdir(sub)
+	forgetDotEnv(t, "PATCHSYNC_TEST_A", "PATCHSYNC_TEST_B", "PATCHSYNC_TEST_C", "PATCHSYNC_TEST_D", "PATCHSYNC_TEST_E")
+
+	rest, files, err := extractEnvFileArgs([]string{"--serve", "--env-file", extra, "--", "--env-file=kept"})
+	if err != nil || !slices.Equal(rest, []string{"--serve", "--", "--env-file=kept"}) || !slices.Equal(files, []string{extra}) {
+		t.Fatalf("extractEnvFileArgs() = %v, %v, %v", rest, files, err)
+	}
+	if err := loadDotEnv(files); err != nil {
+		t.Fatalf("loadDotEnv() error = %v", err)
+	}
+	want := map[string]string{"PATCHSYNC_TEST_A": "root", "PATCHSYNC_TEST_B": "root-local", "PATCHSYNC_TEST_C": "sub", "PATCHSYNC_TEST_D": "extra", "PATCHSYNC_TEST_E": ""}
+	for key, value := range want {
+		if got := os.Getenv(key); got != value {
+			t.Fatalf("%s = %q, want %q", key, got, value)
+		}
+	}
+
+	if _, _, err := reloadDotEnv([]string{filepath.Join(root, "missing.env")}); err == nil {
+		t.Fatalf("expected an error for a missing --env-file")
+	}
+	if _, _, err := extractEnvFileArgs([]string{"--env-file"}); err == nil {
+		t.Fatalf("expected an error for --env-file without a path")
+	}
+}
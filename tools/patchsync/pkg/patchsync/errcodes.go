@@ -0,0 +1,150 @@
+package patchsync
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const apiVersionPrefix = "/v1"
+
+// Stable error codes returned in syncResponse.code. Clients should branch on these, not on message text.
+const (
+	errCodeBadRequest             = "ERR_BAD_REQUEST"
+	errCodeUnauthorized           = "ERR_UNAUTHORIZED"
+	errCodeForbidden              = "ERR_FORBIDDEN"
+	errCodeNotFound               = "ERR_NOT_FOUND"
+	errCodeMethodNotAllowed       = "ERR_METHOD_NOT_ALLOWED"
+	errCodeBodyTooLarge           = "ERR_BODY_TOO_LARGE"
+	errCodeRateLimited            = "ERR_RATE_LIMITED"
+	errCodeNotReady               = "ERR_NOT_READY"
+	errCodeInternal               = "ERR_INTERNAL"
+	errCodeUnknownGame            = "ERR_UNKNOWN_GAME"
+	errCodeConfig                 = "ERR_CONFIG"
+	errCodeLocalFile              = "ERR_LOCAL_FILE"
+	errCodeSpreadsheetUnreachable = "ERR_SPREADSHEET_UNREACHABLE"
+	errCodeSpreadsheetThrottled   = "ERR_SPREADSHEET_THROTTLED"
+	errCodeNoSheets               = "ERR_NO_SHEETS"
+	errCodeParse                  = "ERR_PARSE"
+	errCodeSheetStructure         = "ERR_SHEET_STRUCTURE"
+	errCodeGit                    = "ERR_GIT"
+	errCodeGitLab                 = "ERR_GITLAB"
+	errCodeWrite                  = "ERR_WRITE"
+	errCodeDuplicatePatch         = "ERR_DUPLICATE_PATCH"
+	errCodeSyncCancelled          = "ERR_SYNC_CANCELLED"
+	errCodeSyncFailed             = "ERR_SYNC_FAILED"
+	errCodePartialFailure         = "ERR_PARTIAL_FAILURE"
+)
+
+type codedError struct {
+	code string
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+
+func (e *codedError) Unwrap() error { return e.err }
+
+// Is lets errors.Is(err, ErrParse) and friends match any error carrying a code of that class.
+func (e *codedError) Is(target error) bool {
+	class, ok := errorClassByCode[e.code]
+	return ok && class == target
+}
+
+// SyncErrorClass groups error codes by how a caller should react. Retriable failures can pass on their own
+// (network trouble, throttling, a busy file); the others need the sheet or the configuration fixed first.
+type SyncErrorClass struct {
+	Name      string
+	Retriable bool
+}
+
+func (c *SyncErrorClass) Error() string { return c.Name }
+
+var (
+	ErrSpreadsheetUnreachable = &SyncErrorClass{Name: "spreadsheet_unreachable", Retriable: true}
+	ErrSheetNotFound          = &SyncErrorClass{Name: "sheet_not_found"}
+	ErrParse                  = &SyncErrorClass{Name: "parse"}
+	ErrValidation             = &SyncErrorClass{Name: "validation"}
+	ErrWrite                  = &SyncErrorClass{Name: "write", Retriable: true}
+)
+
+var errorClassByCode = map[string]*SyncErrorClass{
+	errCodeSpreadsheetUnreachable: ErrSpreadsheetUnreachable,
+	errCodeSpreadsheetThrottled:   ErrSpreadsheetUnreachable,
+	errCodeNoSheets:               ErrSheetNotFound,
+	errCodeParse:                  ErrParse,
+	errCodeSheetStructure:         ErrParse,
+	errCodeUnknownGame:            ErrValidation,
+	errCodeConfig:                 ErrValidation,
+	errCodeBadRequest:             ErrValidation,
+	errCodeDuplicatePatch:         ErrValidation,
+	errCodeLocalFile:              ErrWrite,
+	errCodeWrite:                  ErrWrite,
+	errCodeGit:                    ErrWrite,
+}
+
+// errorClass returns the class of err, or nil when its code has none (ERR_SYNC_FAILED, ERR_GITLAB, ...).
+func errorClass(err error) *SyncErrorClass {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return errorClassByCode[coded.code]
+	}
+	return nil
+}
+
+func withErrorCode(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// errorCode returns the innermost-wrapped code of err, so a later fmt.Errorf("...: %w") keeps it.
+func errorCode(err error) string {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return errCodeSyncFailed
+}
+
+func errorCodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return errCodeBadRequest
+	case http.StatusUnauthorized:
+		return errCodeUnauthorized
+	case http.StatusForbidden:
+		return errCodeForbidden
+	case http.StatusNotFound:
+		return errCodeNotFound
+	case http.StatusMethodNotAllowed:
+		return errCodeMethodNotAllowed
+	case http.StatusRequestEntityTooLarge:
+		return errCodeBodyTooLarge
+	case http.StatusTooManyRequests:
+		return errCodeRateLimited
+	case http.StatusServiceUnavailable:
+		return errCodeNotReady
+	}
+	if status >= 500 {
+		return errCodeInternal
+	}
+	return ""
+}
+
+// withAPIVersion serves every endpoint under /v1. The unversioned paths stay as aliases for existing scripts.
+func withAPIVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, apiVersionPrefix)
+		if ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+			if rest == "" {
+				rest = "/"
+			}
+			r = r.Clone(r.Context())
+			r.URL.Path = rest
+			r.URL.RawPath = strings.TrimPrefix(r.URL.RawPath, apiVersionPrefix)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
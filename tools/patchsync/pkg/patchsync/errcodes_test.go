@@ -0,0 +1,85 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorCodeSurvivesWrapping(t *testing.T) {
+	err := fmt.Errorf("spreadsheet abc: %w", withErrorCode(errCodeSpreadsheetUnreachable, errors.New("status 404")))
+	if got := errorCode(err); got != errCodeSpreadsheetUnreachable {
+		t.Fatalf("errorCode() = %q, want %q", got, errCodeSpreadsheetUnreachable)
+	}
+	if err.Error() != "spreadsheet abc: status 404" {
+		t.Fatalf("message changed: %q", err.Error())
+	}
+	if got := errorCode(errors.New("boom")); got != errCodeSyncFailed {
+		t.Fatalf("errorCode() of plain error = %q, want %q", got, errCodeSyncFailed)
+	}
+}
+
+func TestWriteJSONFillsCodeFromStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeJSON(rec, http.StatusTooManyRequests, syncResponse{OK: false, Message: "slow down"})
+	var response syncResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.Code != errCodeRateLimited {
+		t.Fatalf("code = %q, want %q", response.Code, errCodeRateLimited)
+	}
+}
+
+func TestWithAPIVersionStripsPrefix(t *testing.T) {
+	var paths []string
+	handler := withAPIVersion(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	for _, target := range []string{"/v1/sync", "/v1", "/sync", "/v1beta/sync"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	want := []string{"/sync", "/", "/sync", "/v1beta/sync"}
+	for idx := range want {
+		if paths[idx] != want[idx] {
+			t.Fatalf("paths = %v, want %v", paths, want)
+		}
+	}
+}
+
+func TestErrorClasses(t *testing.T) {
+	err := fmt.Errorf("sheet 2.4: %w", withErrorCode(errCodeSheetStructure, errors.New("missing rows")))
+	if !errors.Is(err, ErrParse) || errors.Is(err, ErrWrite) {
+		t.Fatalf("structure errors should be parse errors only")
+	}
+	for code, want := range map[string]*SyncErrorClass{
+		errCodeSpreadsheetThrottled: ErrSpreadsheetUnreachable,
+		errCodeNoSheets:             ErrSheetNotFound,
+		errCodeConfig:               ErrValidation,
+		errCodeWrite:                ErrWrite,
+		errCodeGitLab:               nil,
+	} {
+		if got := errorClass(withErrorCode(code, errors.New("boom"))); got != want {
+			t.Errorf("errorClass(%s) = %v, want %v", code, got, want)
+		}
+	}
+
+	var result syncGameResult
+	result.classify(withErrorCode(errCodeSpreadsheetUnreachable, errors.New("timeout")))
+	if result.ErrorClass != "spreadsheet_unreachable" || !result.Retriable || result.Permanent {
+		t.Fatalf("unexpected classification %+v", result)
+	}
+	result = syncGameResult{}
+	result.classify(withErrorCode(errCodeParse, errors.New("bad cell")))
+	if result.ErrorClass != "parse" || result.Retriable || !result.Permanent {
+		t.Fatalf("unexpected classification %+v", result)
+	}
+	result = syncGameResult{}
+	result.classify(errors.New("boom"))
+	if result.ErrorClass != "" || result.Retriable || result.Permanent {
+		t.Fatalf("unclassified error got %+v", result)
+	}
+}
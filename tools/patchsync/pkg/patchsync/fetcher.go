@@ -0,0 +1,301 @@
+package patchsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	sheetSourceGoogle    = "google"
+	sheetSourceSheetsAPI = "sheets-api"
+	sheetSourceLocal     = "local"
+)
+
+// SheetFetcher is where a sync reads spreadsheet tabs from. ListSheets returns only version-like (N.N) tab names,
+// adjusted by the game's sheet include/exclude patterns.
+type SheetFetcher interface {
+	ListSheets(ctx context.Context, spreadsheetID string) ([]string, error)
+	FetchCSV(ctx context.Context, spreadsheetID, sheetName string) (string, error)
+}
+
+// sheetTabLister is implemented by fetchers that can list every tab of a spreadsheet, not only version-like
+// ones. It is used to find auxiliary tabs such as Data and Summary.
+type sheetTabLister interface {
+	ListTabs(ctx context.Context, spreadsheetID string) ([]string, error)
+}
+
+// gvizSheetFetcher reads link-shared spreadsheets through the gviz CSV export. Tab names come from the
+// worksheet feed and the edit page, falling back to probing N.N sheets with parser. Tabs with a known gid
+// (from gids or the edit page) are read through the export CSV instead, which does not depend on the name.
+type gvizSheetFetcher struct {
+	client   *http.Client
+	parser   PatchParser
+	filter   sheetNameFilter
+	gids     map[string]string
+	editTabs *editTabCache
+	probe    ProbeOptions
+}
+
+func (f gvizSheetFetcher) ListSheets(ctx context.Context, spreadsheetID string) ([]string, error) {
+	return discoverGvizSheetNames(ctx, f.client, spreadsheetID, f.parser, f.filter, f.editTabs, f.probe)
+}
+
+func (f gvizSheetFetcher) ListTabs(ctx context.Context, spreadsheetID string) ([]string, error) {
+	tabs, err := getEditSheetTabs(ctx, f.client, f.editTabs, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tabs))
+	for _, tab := range tabs {
+		names = append(names, tab.Name)
+	}
+	return names, nil
+}
+
+func (f gvizSheetFetcher) FetchCSV(ctx context.Context, spreadsheetID, sheetName string) (string, error) {
+	gid, ok := lookupSheetGID(f.gids, sheetName)
+	if !ok {
+		if tabs, err := getEditSheetTabs(ctx, f.client, f.editTabs, spreadsheetID); err == nil {
+			gid, ok = lookupSheetGID(sheetTabGIDs(tabs), sheetName)
+		}
+	}
+	if ok {
+		if body, err := fetchCSVText(ctx, f.client, sheetExportCSVURL(spreadsheetID, gid)); err == nil {
+			return body, nil
+		}
+	}
+	return fetchGvizSheetCSV(ctx, f.client, spreadsheetID, sheetName)
+}
+
+// publishedSheetFetcher reads "Publish to the web" spreadsheets (2PACX ids) via pubhtml and per-gid CSV.
+type publishedSheetFetcher struct {
+	client *http.Client
+	filter sheetNameFilter
+	gids   map[string]string
+}
+
+func (f publishedSheetFetcher) ListSheets(ctx context.Context, spreadsheetID string) ([]string, error) {
+	names, err := discoverPublishedSheetNames(ctx, f.client, spreadsheetID, f.filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover version sheets automatically: %w", err)
+	}
+	return names, nil
+}
+
+func (f publishedSheetFetcher) ListTabs(ctx context.Context, spreadsheetID string) ([]string, error) {
+	gidByName, err := getPublishedSheetGIDs(ctx, f.client, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(gidByName))
+	for name := range gidByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (f publishedSheetFetcher) FetchCSV(ctx context.Context, spreadsheetID, sheetName string) (string, error) {
+	if gid, ok := lookupSheetGID(f.gids, sheetName); ok {
+		return fetchCSVText(ctx, f.client, publishedSheetCSVURL(spreadsheetID, gid))
+	}
+	return fetchPublishedSheetCSV(ctx, f.client, spreadsheetID, sheetName)
+}
+
+// googleSheetFetcher picks the published or gviz backend per spreadsheet id.
+type googleSheetFetcher struct {
+	gviz      gvizSheetFetcher
+	published publishedSheetFetcher
+}
+
+func newGoogleSheetFetcher(client *http.Client, parser PatchParser) googleSheetFetcher {
+	return googleSheetFetcher{
+		gviz:      gvizSheetFetcher{client: client, parser: parser, editTabs: newEditTabCache()},
+		published: publishedSheetFetcher{client: client},
+	}
+}
+
+func (f googleSheetFetcher) backend(spreadsheetID string) SheetFetcher {
+	if isPublishedSpreadsheetID(spreadsheetID) {
+		return f.published
+	}
+	return f.gviz
+}
+
+func (f googleSheetFetcher) ListSheets(ctx context.Context, spreadsheetID string) ([]string, error) {
+	return f.backend(spreadsheetID).ListSheets(ctx, spreadsheetID)
+}
+
+func (f googleSheetFetcher) ListTabs(ctx context.Context, spreadsheetID string) ([]string, error) {
+	return f.backend(spreadsheetID).(sheetTabLister).ListTabs(ctx, spreadsheetID)
+}
+
+func (f googleSheetFetcher) FetchCSV(ctx context.Context, spreadsheetID, sheetName string) (string, error) {
+	return f.backend(spreadsheetID).FetchCSV(ctx, spreadsheetID, sheetName)
+}
+
+// sheetsAPIFetcher uses the Google Sheets API v4 with an API key, which works for link-shared spreadsheets
+// without scraping any HTML.
+type sheetsAPIFetcher struct {
+	client  *http.Client
+	apiKey  string
+	baseURL string
+	filter  sheetNameFilter
+}
+
+func (f sheetsAPIFetcher) endpoint(spreadsheetID, suffix string, query url.Values) string {
+	base := f.baseURL
+	if base == "" {
+		base = "https://sheets.googleapis.com/v4/spreadsheets"
+	}
+	query.Set("key", f.apiKey)
+	return fmt.Sprintf("%s/%s%s?%s", strings.TrimRight(base, "/"), url.PathEscape(strings.TrimSpace(spreadsheetID)), suffix, query.Encode())
+}
+
+func (f sheetsAPIFetcher) ListTabs(ctx context.Context, spreadsheetID string) ([]string, error) {
+	body, err := fetchText(ctx, f.client, f.endpoint(spreadsheetID, "", url.Values{"fields": {"sheets.properties.title"}}))
+	if err != nil {
+		return nil, fmt.Errorf("sheets api: %w", err)
+	}
+	var payload struct {
+		Sheets []struct {
+			Properties struct {
+				Title string `json:"title"`
+			} `json:"properties"`
+		} `json:"sheets"`
+	}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		return nil, fmt.Errorf("sheets api: parse sheet list: %w", err)
+	}
+	names := make([]string, 0, len(payload.Sheets))
+	for _, sheet := range payload.Sheets {
+		names = append(names, sheet.Properties.Title)
+	}
+	return names, nil
+}
+
+func (f sheetsAPIFetcher) ListSheets(ctx context.Context, spreadsheetID string) ([]string, error) {
+	tabs, err := f.ListTabs(ctx, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tabs))
+	for _, tab := range tabs {
+		if f.filter.match(tab) {
+			names = append(names, tab)
+		}
+	}
+	names = uniqueSheetNames(names)
+	sortVersionStrings(names)
+	if len(names) == 0 {
+		return nil, errors.New("sheets api: no version-like sheet names found")
+	}
+	return names, nil
+}
+
+func (f sheetsAPIFetcher) FetchCSV(ctx context.Context, spreadsheetID, sheetName string) (string, error) {
+	sheetRange := "'" + strings.ReplaceAll(sheetName, "'", "''") + "'"
+	body, err := fetchText(ctx, f.client, f.endpoint(spreadsheetID, "/values/"+url.PathEscape(sheetRange), url.Values{}))
+	if err != nil {
+		return "", fmt.Errorf("sheets api: %w", err)
+	}
+	var payload struct {
+		Values [][]string `json:"values"`
+	}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		return "", fmt.Errorf("sheets api: parse values of %s: %w", sheetName, err)
+	}
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(payload.Values); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// localSheetFetcher reads <dir>/<spreadsheet id>/<sheet name>.csv, for offline runs and fixtures.
+type localSheetFetcher struct {
+	dir    string
+	filter sheetNameFilter
+}
+
+func (f localSheetFetcher) spreadsheetDir(spreadsheetID string) string {
+	return filepath.Join(f.dir, filepath.Base(strings.TrimSpace(spreadsheetID)))
+}
+
+func (f localSheetFetcher) ListTabs(_ context.Context, spreadsheetID string) ([]string, error) {
+	entries, err := os.ReadDir(f.spreadsheetDir(spreadsheetID))
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".csv"); ok && !entry.IsDir() {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (f localSheetFetcher) ListSheets(ctx context.Context, spreadsheetID string) ([]string, error) {
+	tabs, err := f.ListTabs(ctx, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, tab := range tabs {
+		if f.filter.match(tab) {
+			names = append(names, tab)
+		}
+	}
+	sortVersionStrings(names)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no version-like .csv files in %s", f.spreadsheetDir(spreadsheetID))
+	}
+	return names, nil
+}
+
+func (f localSheetFetcher) FetchCSV(_ context.Context, spreadsheetID, sheetName string) (string, error) {
+	if strings.ContainsAny(sheetName, `/\`) {
+		return "", fmt.Errorf("invalid sheet name %q", sheetName)
+	}
+	body, err := os.ReadFile(filepath.Join(f.spreadsheetDir(spreadsheetID), sheetName+".csv"))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func newSheetFetcher(cfg SyncConfig, client *http.Client, parser PatchParser, filter sheetNameFilter) (SheetFetcher, error) {
+	switch strings.TrimSpace(cfg.SheetSource) {
+	case "", sheetSourceGoogle:
+		fetcher := newGoogleSheetFetcher(client, parser)
+		fetcher.gviz.filter = filter
+		fetcher.published.filter = filter
+		fetcher.gviz.gids = cfg.SheetGIDs
+		fetcher.gviz.probe = cfg.ProbeLimits
+		fetcher.published.gids = cfg.SheetGIDs
+		return fetcher, nil
+	case sheetSourceSheetsAPI:
+		if strings.TrimSpace(cfg.SheetsAPIKey) == "" {
+			return nil, errors.New("--source sheets-api needs --sheets-api-key or GOOGLE_SHEETS_API_KEY")
+		}
+		return sheetsAPIFetcher{client: client, apiKey: strings.TrimSpace(cfg.SheetsAPIKey), filter: filter}, nil
+	case sheetSourceLocal:
+		if strings.TrimSpace(cfg.LocalSheetsDir) == "" {
+			return nil, errors.New("--source local needs --local-sheets-dir")
+		}
+		return localSheetFetcher{dir: resolveFilePath(cfg.LocalSheetsDir), filter: filter}, nil
+	}
+	return nil, fmt.Errorf("unknown sheet source %q (use %s, %s or %s)", cfg.SheetSource, sheetSourceGoogle, sheetSourceSheetsAPI, sheetSourceLocal)
+}
@@ -0,0 +1,222 @@
+package patchsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeSheetFetcher serves sheets from memory; missing sheets fail like an unreachable tab.
+type fakeSheetFetcher struct {
+	sheets map[string]string
+}
+
+func (f fakeSheetFetcher) ListSheets(_ context.Context, _ string) ([]string, error) {
+	names := []string{}
+	for name := range f.sheets {
+		if isVersionLikeSheetName(name) {
+			names = append(names, name)
+		}
+	}
+	sortVersionStrings(names)
+	return names, nil
+}
+
+func (f fakeSheetFetcher) FetchCSV(_ context.Context, _ string, sheetName string) (string, error) {
+	csvText, ok := f.sheets[sheetName]
+	if !ok {
+		return "", fmt.Errorf("sheet %q not found", sheetName)
+	}
+	return csvText, nil
+}
+
+func wuwaSheetCSV(version string) string {
+	return csvLines(
+		"Version "+version+" (03.01.2026)",
+		"Version Length,42",
+		"",
+		"Version Events,5000,30,5,10",
+		"Permanent Content,2000,10,0,0",
+		"Mailbox/Miscellaneous,1000,5,0,0",
+		"Recurring Sources,3000,0,10,0",
+		"Paid Pioneer Podcast,500,2,0,0",
+		"Lunite Subscription,3780,21,0,0",
+		"Total F2P,11000,45,15,0",
+		"Total Paid,15280,47,15,0",
+	)
+}
+
+func TestRunSyncWithFakeFetcherContinuesOnError(t *testing.T) {
+	dir := t.TempDir()
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4", "3.5", "3.6"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		DryRun:          true,
+		Fetcher: fakeSheetFetcher{sheets: map[string]string{
+			"3.4": wuwaSheetCSV("3.4"),
+			"3.5": "not a patch sheet",
+		}},
+	}
+
+	if _, err := RunSync(t.Context(), cfg); err == nil || errorCode(err) != errCodeParse {
+		t.Fatalf("expected an ERR_PARSE failure without continueOnError, got %v", err)
+	}
+
+	cfg.ContinueOnError = true
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	if got := patchNamesFromPatches(result.Patches); !reflect.DeepEqual(got, []string{"3.4"}) {
+		t.Fatalf("patches = %v, want [3.4]", got)
+	}
+	stages := map[string]string{}
+	for _, failure := range result.SheetFailures {
+		stages[failure.Sheet] = failure.Stage + "/" + failure.Code
+	}
+	want := map[string]string{"3.5": "parse/" + errCodeParse, "3.6": "fetch/" + errCodeSpreadsheetUnreachable}
+	if !reflect.DeepEqual(stages, want) {
+		t.Fatalf("failures = %v, want %v", stages, want)
+	}
+}
+
+// cancelAfterFetcher cancels the sync's context once the named sheet has been fetched.
+type cancelAfterFetcher struct {
+	fakeSheetFetcher
+	after  string
+	cancel context.CancelFunc
+}
+
+func (f cancelAfterFetcher) FetchCSV(ctx context.Context, id string, sheetName string) (string, error) {
+	csvText, err := f.fakeSheetFetcher.FetchCSV(ctx, id, sheetName)
+	if sheetName == f.after {
+		f.cancel()
+	}
+	return csvText, err
+}
+
+func TestRunSyncStopsBetweenSheetsWhenCancelled(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4", "3.5", "3.6"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		Fetcher: cancelAfterFetcher{
+			fakeSheetFetcher: fakeSheetFetcher{sheets: map[string]string{
+				"3.4": wuwaSheetCSV("3.4"),
+				"3.5": wuwaSheetCSV("3.5"),
+				"3.6": wuwaSheetCSV("3.6"),
+			}},
+			after:  "3.4",
+			cancel: cancel,
+		},
+	}
+
+	result, err := RunSync(ctx, cfg)
+	if errorCode(err) != errCodeSyncCancelled || !errors.Is(err, context.Canceled) {
+		t.Fatalf("RunSync() error = %v, want %s wrapping context.Canceled", err, errCodeSyncCancelled)
+	}
+	if !reflect.DeepEqual(result.SheetNames, []string{"3.4"}) {
+		t.Fatalf("parsed sheets = %v, want [3.4]", result.SheetNames)
+	}
+	if !reflect.DeepEqual(result.PendingSheets, []string{"3.5", "3.6"}) {
+		t.Fatalf("pending sheets = %v, want [3.5 3.6]", result.PendingSheets)
+	}
+	if _, statErr := os.Stat(cfg.OutputPath); !os.IsNotExist(statErr) {
+		t.Fatalf("cancelled sync wrote %s (stat error %v)", cfg.OutputPath, statErr)
+	}
+}
+
+func TestRunSyncHonoursSyncTimeout(t *testing.T) {
+	dir := t.TempDir()
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		DryRun:          true,
+		SyncTimeout:     time.Nanosecond,
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+	}
+
+	_, err := RunSync(t.Context(), cfg)
+	if errorCode(err) != errCodeSyncCancelled || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RunSync() error = %v, want %s wrapping context.DeadlineExceeded", err, errCodeSyncCancelled)
+	}
+}
+
+func TestLocalSheetFetcher(t *testing.T) {
+	dir := t.TempDir()
+	sheetDir := filepath.Join(dir, "abc")
+	if err := os.MkdirAll(sheetDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{"2.1.csv": "b", "2.0.csv": "a", "Data.csv": "d", "notes.txt": "x"} {
+		if err := os.WriteFile(filepath.Join(sheetDir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fetcher := localSheetFetcher{dir: dir}
+	names, err := fetcher.ListSheets(t.Context(), "abc")
+	if err != nil || !reflect.DeepEqual(names, []string{"2.0", "2.1"}) {
+		t.Fatalf("ListSheets() = %v, %v", names, err)
+	}
+	if body, err := fetcher.FetchCSV(t.Context(), "abc", "Data"); err != nil || body != "d" {
+		t.Fatalf("FetchCSV(Data) = %q, %v", body, err)
+	}
+	if _, err := fetcher.FetchCSV(t.Context(), "abc", "../abc/2.0"); err == nil {
+		t.Fatalf("expected path traversal to be rejected")
+	}
+}
+
+func TestSheetsAPIFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "k" {
+			http.Error(w, "missing key", http.StatusForbidden)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/sheet1":
+			fmt.Fprint(w, `{"sheets":[{"properties":{"title":"Data"}},{"properties":{"title":"1.1"}},{"properties":{"title":"1.0"}}]}`)
+		case "/sheet1/values/%271.0%27":
+			fmt.Fprint(w, `{"values":[["Version 1.0"],["Events","5,000","a \"b\""]]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := sheetsAPIFetcher{client: server.Client(), apiKey: "k", baseURL: server.URL}
+	names, err := fetcher.ListSheets(t.Context(), "sheet1")
+	if err != nil || !reflect.DeepEqual(names, []string{"1.0", "1.1"}) {
+		t.Fatalf("ListSheets() = %v, %v", names, err)
+	}
+	body, err := fetcher.FetchCSV(t.Context(), "sheet1", "1.0")
+	if err != nil {
+		t.Fatalf("FetchCSV() error = %v", err)
+	}
+	if want := "Version 1.0\nEvents,\"5,000\",\"a \"\"b\"\"\"\n"; body != want {
+		t.Fatalf("FetchCSV() = %q, want %q", body, want)
+	}
+}
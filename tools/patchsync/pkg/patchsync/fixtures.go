@@ -0,0 +1,132 @@
+package patchsync
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// httpFixture is one recorded response. Fixture files are named after a hash of method and URL,
+// and keep the URL so a directory of fixtures can still be read by hand.
+type httpFixture struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body"`
+}
+
+func fixtureFileName(method, rawURL string) string {
+	sum := sha256.Sum256([]byte(method + " " + rawURL))
+	return hex.EncodeToString(sum[:10]) + ".json"
+}
+
+// recordingTransport passes requests through and saves every response, including errors pages, to dir.
+type recordingTransport struct {
+	base http.RoundTripper
+	dir  string
+	mu   sync.Mutex
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	fixture := httpFixture{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(body),
+	}
+	if err := t.save(fixture); err != nil {
+		return nil, fmt.Errorf("record fixture for %s: %w", req.URL, err)
+	}
+	return resp, nil
+}
+
+func (t *recordingTransport) save(fixture httpFixture) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return err
+	}
+	encoded, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(t.dir, fixtureFileName(fixture.Method, fixture.URL)), append(encoded, '\n'), 0o644)
+}
+
+// replayTransport answers every request from fixtures recorded by recordingTransport and never touches the network.
+type replayTransport struct {
+	dir string
+}
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rawURL := req.URL.String()
+	body, err := os.ReadFile(filepath.Join(t.dir, fixtureFileName(req.Method, rawURL)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no fixture recorded for %s %s", req.Method, rawURL)
+		}
+		return nil, err
+	}
+	var fixture httpFixture
+	if err := json.Unmarshal(body, &fixture); err != nil {
+		return nil, fmt.Errorf("parse fixture for %s: %w", rawURL, err)
+	}
+	header := http.Header{}
+	if fixture.ContentType != "" {
+		header.Set("Content-Type", fixture.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(fixture.Body)),
+		ContentLength: int64(len(fixture.Body)),
+		Request:       req,
+	}, nil
+}
+
+// fixtureTransport wraps base for the configured fixture mode; without one it returns base unchanged.
+func fixtureTransport(cfg SyncConfig, base http.RoundTripper) (http.RoundTripper, error) {
+	record := strings.TrimSpace(cfg.RecordFixtures)
+	replay := strings.TrimSpace(cfg.ReplayFixtures)
+	switch {
+	case record != "" && replay != "":
+		return nil, fmt.Errorf("--record-fixtures and --replay-fixtures cannot be combined")
+	case record != "":
+		return &recordingTransport{base: base, dir: resolveOutputPath(record)}, nil
+	case replay != "":
+		dir := resolveFilePath(replay)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("fixture directory %s does not exist", dir)
+		}
+		return replayTransport{dir: dir}, nil
+	}
+	return base, nil
+}
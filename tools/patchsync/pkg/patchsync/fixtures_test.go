@@ -0,0 +1,73 @@
+package patchsync
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestRecordedFixturesReplayASync(t *testing.T) {
+	dir := t.TempDir()
+	fixturesDir := filepath.Join(dir, "fixtures")
+	const spreadsheetID = "1AbCdEfGhIjKlMnOpQrStUvWxYz0123456789abcdEf"
+	stub := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, status := "", http.StatusNotFound
+		if sheet := req.URL.Query().Get("sheet"); sheet != "" {
+			body, status = wuwaSheetCSV(sheet), http.StatusOK
+		}
+		return &http.Response{StatusCode: status, Header: http.Header{"Content-Type": {"text/csv"}}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+	})
+	recorder := newGoogleSheetFetcher(&http.Client{Transport: &recordingTransport{base: stub, dir: fixturesDir}}, nil)
+	if _, err := recorder.FetchCSV(t.Context(), spreadsheetID, "3.4"); err != nil {
+		t.Fatalf("record FetchCSV() error = %v", err)
+	}
+	// The edit page (looked up for tab gids, a 404 here) is recorded next to the gviz CSV.
+	entries, err := os.ReadDir(fixturesDir)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("expected two recorded fixtures, got %v, %v", entries, err)
+	}
+
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   spreadsheetID,
+		SheetNames:      []string{"3.4"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		DryRun:          true,
+		ReplayFixtures:  fixturesDir,
+	}
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("replayed RunSync() error = %v", err)
+	}
+	if got := patchNamesFromPatches(result.Patches); !reflect.DeepEqual(got, []string{"3.4"}) {
+		t.Fatalf("patches = %v, want [3.4]", got)
+	}
+
+	cfg.SheetNames = []string{"3.5"}
+	if _, err := RunSync(t.Context(), cfg); err == nil || !strings.Contains(err.Error(), "no fixture recorded") {
+		t.Fatalf("expected a missing fixture error, got %v", err)
+	}
+}
+
+func TestFixtureTransportRejectsConflictingModes(t *testing.T) {
+	if _, err := fixtureTransport(SyncConfig{RecordFixtures: "a", ReplayFixtures: "b"}, nil); err == nil {
+		t.Fatalf("expected record and replay together to be rejected")
+	}
+	if _, err := fixtureTransport(SyncConfig{ReplayFixtures: filepath.Join(t.TempDir(), "missing")}, nil); err == nil {
+		t.Fatalf("expected a missing replay directory to be rejected")
+	}
+	if transport, err := fixtureTransport(SyncConfig{}, nil); err != nil || transport != nil {
+		t.Fatalf("fixtureTransport() = %v, %v; want the default transport", transport, err)
+	}
+}
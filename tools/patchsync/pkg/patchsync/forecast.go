@@ -0,0 +1,145 @@
+package patchsync
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	forecastTag           = "forecast"
+	defaultForecastWindow = 3
+)
+
+func isForecastPatch(patch Patch) bool {
+	for _, tag := range patch.Tags {
+		if strings.EqualFold(strings.TrimSpace(tag), forecastTag) {
+			return true
+		}
+	}
+	return false
+}
+
+func withoutForecastPatches(patches []Patch) []Patch {
+	result := make([]Patch, 0, len(patches))
+	for _, patch := range patches {
+		if !isForecastPatch(patch) {
+			result = append(result, patch)
+		}
+	}
+	return result
+}
+
+func (r Rewards) rounded() Rewards {
+	return Rewards{
+		Oroberyl:    math.Round(r.Oroberyl),
+		Origeometry: math.Round(r.Origeometry),
+		Chartered:   math.Round(r.Chartered),
+		Basic:       math.Round(r.Basic),
+		Firewalker:  math.Round(r.Firewalker),
+		Messenger:   math.Round(r.Messenger),
+		Hues:        math.Round(r.Hues),
+		Arsenal:     math.Round(r.Arsenal),
+	}
+}
+
+// averageSources averages every source seen in the window. A source missing from a patch counts as zero,
+// and per-duration scalers are folded into flat rewards for that patch's length.
+func averageSources(window []Patch) []Source {
+	order := []string{}
+	latest := map[string]Source{}
+	rewards := map[string]Rewards{}
+	costs := map[string]Rewards{}
+	pulls := map[string]float64{}
+	missingPulls := map[string]bool{}
+	for idx := len(window) - 1; idx >= 0; idx-- {
+		for _, src := range window[idx].Sources {
+			if _, seen := latest[src.ID]; !seen {
+				order = append(order, src.ID)
+				latest[src.ID] = src
+			}
+			sum := rewards[src.ID]
+			sum.add(sourceRewardsForDuration(src, window[idx].DurationDays))
+			rewards[src.ID] = sum
+			costSum := costs[src.ID]
+			costSum.add(src.Costs)
+			costs[src.ID] = costSum
+			if src.Pulls == nil {
+				missingPulls[src.ID] = true
+			} else {
+				pulls[src.ID] += *src.Pulls
+			}
+		}
+	}
+	factor := 1 / float64(len(window))
+	sources := make([]Source, 0, len(order))
+	for _, id := range order {
+		src := latest[id]
+		src.Rewards = rewards[id].scaled(factor).rounded()
+		src.Costs = costs[id].scaled(factor).rounded()
+		src.Scalers = []Scaler{}
+		src.Pulls = nil
+		if !missingPulls[id] {
+			average := roundToTenth(pulls[id] * factor)
+			src.Pulls = &average
+		}
+		sources = append(sources, src)
+	}
+	return sources
+}
+
+// forecastPatches appends count synthetic patches after the latest one, each averaging the trailing window.
+func forecastPatches(gameID string, patches []Patch, count, window int) ([]Patch, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+	if window <= 0 {
+		window = defaultForecastWindow
+	}
+	history := withoutForecastPatches(patches)
+	sortPatches(history)
+	if len(history) == 0 {
+		return nil, errors.New("no patches to forecast from")
+	}
+	window = min(window, len(history))
+	trailing := history[len(history)-window:]
+	last := history[len(history)-1]
+	major, minor, ok := versionSortKey(last.Patch)
+	if !ok {
+		return nil, fmt.Errorf("latest patch %q has no N.N version", last.Patch)
+	}
+	lastStart, err := time.Parse(isoDateLayout, strings.TrimSpace(last.StartDate))
+	if err != nil || last.DurationDays <= 0 {
+		return nil, fmt.Errorf("latest patch %s has no start date or duration", patchIDOrFallback(last))
+	}
+	totalDays := 0
+	for _, patch := range trailing {
+		totalDays += patch.DurationDays
+	}
+	duration := int(math.Round(float64(totalDays) / float64(len(trailing))))
+	sources := averageSources(trailing)
+	notes := fmt.Sprintf("Forecast: average of patches %s to %s", patchIDOrFallback(trailing[0]), patchIDOrFallback(last))
+
+	start := lastStart.AddDate(0, 0, last.DurationDays)
+	forecasts := make([]Patch, 0, count)
+	for idx := 1; idx <= count; idx++ {
+		patchID := fmt.Sprintf("%d.%d", major, minor+idx)
+		forecast := Patch{
+			ID:           patchID,
+			Patch:        patchID,
+			VersionName:  "Forecast",
+			StartDate:    start.Format(isoDateLayout),
+			DurationDays: duration,
+			Tags:         []string{forecastTag},
+			Notes:        notes,
+			Sources:      make([]Source, len(sources)),
+		}
+		copy(forecast.Sources, sources)
+		applyComputedPulls(gameID, &forecast)
+		forecasts = append(forecasts, forecast)
+		start = start.AddDate(0, 0, duration)
+	}
+	return forecasts, nil
+}
@@ -0,0 +1,49 @@
+package patchsync
+
+import "testing"
+
+func TestForecastPatches(t *testing.T) {
+	pulls := func(value float64) *float64 { return &value }
+	events := func(astrite float64, p float64) Source {
+		src := source("events", "Events", "always", nil, true, Rewards{Oroberyl: astrite})
+		src.Pulls = pulls(p)
+		return src
+	}
+	patches := []Patch{
+		{ID: "2.0", Patch: "2.0", StartDate: "2025-01-02", DurationDays: 42, Sources: []Source{events(9000, 56)}},
+		{ID: "2.1", Patch: "2.1", StartDate: "2025-02-13", DurationDays: 42, Sources: []Source{events(6000, 37.5)}},
+		{ID: "2.2", Patch: "2.2", StartDate: "2025-03-27", DurationDays: 39, Sources: []Source{
+			events(7500, 46.9),
+			source("login", "Login", "always", nil, true, Rewards{Chartered: 6}),
+		}},
+		{ID: "2.3", Patch: "2.3", StartDate: "2025-05-05", DurationDays: 40, Tags: []string{"forecast"}},
+	}
+	forecasts, err := forecastPatches(gameIDWuwa, patches, 2, 2)
+	if err != nil {
+		t.Fatalf("forecastPatches() error = %v", err)
+	}
+	if len(forecasts) != 2 || forecasts[0].ID != "2.3" || forecasts[1].ID != "2.4" {
+		t.Fatalf("unexpected forecast ids %+v", forecasts)
+	}
+	first := forecasts[0]
+	if first.StartDate != "2025-05-05" || first.DurationDays != 41 || !isForecastPatch(first) {
+		t.Fatalf("unexpected forecast patch %+v", first)
+	}
+	if forecasts[1].StartDate != "2025-06-15" {
+		t.Fatalf("second forecast start = %s, want 2025-06-15", forecasts[1].StartDate)
+	}
+	if len(first.Sources) != 2 || first.Sources[0].ID != "events" || first.Sources[0].Rewards.Oroberyl != 6750 {
+		t.Fatalf("unexpected averaged sources %+v", first.Sources)
+	}
+	if first.Sources[0].Pulls == nil || *first.Sources[0].Pulls != 42.2 {
+		t.Fatalf("events pulls = %v, want 42.2", first.Sources[0].Pulls)
+	}
+	// Login only appears in 2.2, so it averages to 3 permits and gets computed pulls.
+	if first.Sources[1].Rewards.Chartered != 3 || first.Sources[1].Pulls == nil || *first.Sources[1].Pulls != 3 {
+		t.Fatalf("unexpected login source %+v", first.Sources[1])
+	}
+
+	if _, err := forecastPatches(gameIDWuwa, nil, 1, 3); err == nil {
+		t.Fatalf("expected error without history")
+	}
+}
@@ -0,0 +1,44 @@
+package patchsync
+
+import (
+	"fmt"
+	"strings"
+)
+
+// formulaErrorMarkers are the error values Google Sheets exports in place of a formula result.
+var formulaErrorMarkers = []string{"#REF!", "#N/A", "#VALUE!", "#DIV/0!", "#NAME?", "#NUM!", "#NULL!", "#ERROR!"}
+
+func formulaErrorMarker(raw string) (string, bool) {
+	cell := strings.ToUpper(strings.TrimSpace(raw))
+	for _, marker := range formulaErrorMarkers {
+		if cell == marker {
+			return marker, true
+		}
+	}
+	return "", false
+}
+
+// dataSheetFormulaErrors lists the Data sheet cells holding a formula error as "row, patch: marker". Those
+// cells are skipped like empty ones, so the patch keeps the pulls computed from its version sheet.
+func dataSheetFormulaErrors(csvText string) []string {
+	records := orientDataSheetRecords(readNoteRecords(csvText))
+	header, headerIdx := findDataSheetHeader(records, nil)
+	found := []string{}
+	for rowIdx, record := range records {
+		if rowIdx <= headerIdx {
+			continue
+		}
+		for idx := 1; idx < len(record); idx++ {
+			marker, ok := formulaErrorMarker(record[idx])
+			if !ok {
+				continue
+			}
+			column := getCell(header, idx)
+			if column == "" {
+				column = fmt.Sprintf("column %d", idx+1)
+			}
+			found = append(found, fmt.Sprintf("%s, %s: %s", getCell(record, 0), column, marker))
+		}
+	}
+	return found
+}
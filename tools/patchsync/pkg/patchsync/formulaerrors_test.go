@@ -0,0 +1,70 @@
+package patchsync
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNumberScientificNotation(t *testing.T) {
+	tests := []struct {
+		raw       string
+		separator string
+		want      float64
+	}{
+		{raw: "1.6E+03", separator: decimalAuto, want: 1600},
+		{raw: "1,6E+03", separator: decimalAuto, want: 1600},
+		{raw: "1,6E+03", separator: decimalComma, want: 1600},
+		{raw: "1.6E+03", separator: decimalComma, want: 1600},
+		{raw: "1.600E+03", separator: decimalAuto, want: 1600},
+		{raw: "-2.5e2", separator: decimalAuto, want: -250},
+	}
+	for _, tt := range tests {
+		if got := parseNumberWith(tt.raw, tt.separator); got != tt.want {
+			t.Fatalf("parseNumberWith(%q, %s) = %v, want %v", tt.raw, tt.separator, got, tt.want)
+		}
+	}
+	if got, ok := parseDataPullValue("2.56E+01"); !ok || got != 25.6 {
+		t.Fatalf("parseDataPullValue(2.56E+01) = %v, %v; want 25.6", got, ok)
+	}
+}
+
+func TestFormulaErrorsAreWarned(t *testing.T) {
+	var warnings []string
+	opts := ParseOptions{Warn: func(message string) { warnings = append(warnings, message) }}
+	if got := opts.number("#REF!"); got != 0 {
+		t.Fatalf("number(#REF!) = %v, want 0", got)
+	}
+	if got := opts.number(" #n/a "); got != 0 {
+		t.Fatalf("number(#N/A) = %v, want 0", got)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "#REF!") || !strings.Contains(warnings[1], "#N/A") {
+		t.Fatalf("warnings = %v", warnings)
+	}
+	if _, ok := parseDataPullValue("#DIV/0!"); ok {
+		t.Fatal("formula error parsed as a Data sheet value")
+	}
+
+	got := dataSheetFormulaErrors(csvLines("Version,3.3,3.4", "Version Events,25.6,#REF!", "Permanent Content,#N/A,3.7"))
+	want := []string{"Version Events, 3.4: #REF!", "Permanent Content, 3.3: #N/A"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("dataSheetFormulaErrors() = %v, want %v", got, want)
+	}
+}
+
+func TestVersionSheetFormulaErrorIsWarned(t *testing.T) {
+	sheet := strings.Replace(wuwaSheetCSV("3.4"), "Paid Pioneer Podcast,500,2,0,0", "Paid Pioneer Podcast,#REF!,2,0,0", 1)
+	var warnings []string
+	patch, err := parseSheetToPatchWuwa("3.4", sheet, ParseOptions{Warn: func(message string) { warnings = append(warnings, message) }})
+	if err != nil {
+		t.Fatalf("parseSheetToPatchWuwa() error = %v", err)
+	}
+	if !strings.Contains(strings.Join(warnings, "\n"), "formula error #REF!") {
+		t.Fatalf("warnings = %v, want the #REF! cell", warnings)
+	}
+	for _, src := range patch.Sources {
+		if src.ID == "paidPodcast" && src.Rewards.Oroberyl != 0 {
+			t.Fatalf("paidPodcast oroberyl = %v, want 0", src.Rewards.Oroberyl)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package patchsync
+
+import (
+	"strings"
+	"time"
+)
+
+// livePatchID returns the newest patch that has started by now, the version players are on. Patches without
+// a start date are ignored.
+func livePatchID(patches []Patch, now time.Time) string {
+	today := now.UTC().Format(isoDateLayout)
+	live, liveStart := "", ""
+	for _, patch := range patches {
+		start := strings.TrimSpace(patch.StartDate)
+		if _, err := time.Parse(isoDateLayout, start); err != nil || start > today {
+			continue
+		}
+		if start > liveStart || (start == liveStart && patchVersionLess(live, patchIDOrFallback(patch))) {
+			live, liveStart = patchIDOrFallback(patch), start
+		}
+	}
+	return live
+}
+
+func patchVersionLess(a, b string) bool {
+	majorA, minorA, okA := versionSortKey(a)
+	majorB, minorB, okB := versionSortKey(b)
+	if !okA || !okB {
+		return false
+	}
+	if majorA != majorB {
+		return majorA < majorB
+	}
+	return minorA < minorB
+}
+
+// frozenPatchIDs returns the published patches older than the live one. Sheet edits to them are refused
+// unless --allow-historical-edits is given.
+func frozenPatchIDs(published []Patch, now time.Time) (map[string]struct{}, string) {
+	live := livePatchID(published, now)
+	frozen := map[string]struct{}{}
+	if live == "" {
+		return frozen, ""
+	}
+	for _, patch := range published {
+		if patchID := patchIDOrFallback(patch); patchVersionLess(patchID, live) {
+			frozen[patchID] = struct{}{}
+		}
+	}
+	return frozen, live
+}
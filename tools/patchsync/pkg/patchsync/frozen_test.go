@@ -0,0 +1,96 @@
+package patchsync
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFrozenPatchIDs(t *testing.T) {
+	patches := []Patch{
+		{ID: "2.0", Patch: "2.0", StartDate: "2025-01-01"},
+		{ID: "2.1", Patch: "2.1", StartDate: "2025-02-12"},
+		{ID: "2.2", Patch: "2.2", StartDate: "2025-03-26"},
+		{ID: "2.3", Patch: "2.3"},
+	}
+	frozen, live := frozenPatchIDs(patches, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	if live != "2.1" {
+		t.Fatalf("live patch = %q, want 2.1", live)
+	}
+	if _, ok := frozen["2.0"]; !ok || len(frozen) != 1 {
+		t.Fatalf("frozen = %v, want only 2.0", frozen)
+	}
+	if frozen, live := frozenPatchIDs(patches, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)); live != "" || len(frozen) != 0 {
+		t.Fatalf("before the first patch: live = %q, frozen = %v", live, frozen)
+	}
+}
+
+func TestRunSyncRefusesHistoricalEdits(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	sheet := func(version, date string, events int) string {
+		csvText := strings.Replace(wuwaSheetCSV(version), "03.01.2026", date, 1)
+		csvText = strings.Replace(csvText, "Version Events,5000", "Version Events,"+strconv.Itoa(events), 1)
+		return strings.Replace(csvText, "Total F2P,11000", "Total F2P,"+strconv.Itoa(events+6000), 1)
+	}
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		Clock:           FixedClock(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)),
+		Fetcher: fakeSheetFetcher{sheets: map[string]string{
+			"3.4": sheet("3.4", "03.01.2025", 5000),
+			"3.5": sheet("3.5", "14.02.2025", 5000),
+		}},
+	}
+	if _, err := RunSync(t.Context(), cfg); err != nil {
+		t.Fatalf("initial RunSync() error = %v", err)
+	}
+
+	cfg.Fetcher = fakeSheetFetcher{sheets: map[string]string{
+		"3.4": sheet("3.4", "03.01.2025", 5200),
+		"3.5": sheet("3.5", "14.02.2025", 5300),
+	}}
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	if len(result.FrozenPatches) != 1 || result.FrozenPatches[0].Patch != "3.4" {
+		t.Fatalf("frozen patches = %+v, want 3.4", result.FrozenPatches)
+	}
+	eventsAstrite := func() map[string]float64 {
+		patches, err := readGeneratedPatches(cfg.OutputPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := map[string]float64{}
+		for _, patch := range patches {
+			for _, src := range patch.Sources {
+				if src.ID == "events" {
+					values[patch.Patch] = src.Rewards.Oroberyl
+				}
+			}
+		}
+		return values
+	}
+	if got := eventsAstrite(); got["3.4"] != 5000 || got["3.5"] != 5300 {
+		t.Fatalf("events astrite = %v, want 3.4 kept at 5000 and 3.5 updated to 5300", got)
+	}
+
+	cfg.AllowHistorical = true
+	result, err = RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() with --allow-historical-edits error = %v", err)
+	}
+	if len(result.FrozenPatches) != 0 {
+		t.Fatalf("frozen patches = %+v, want none", result.FrozenPatches)
+	}
+	if got := eventsAstrite(); got["3.4"] != 5200 {
+		t.Fatalf("events astrite = %v, want 3.4 updated to 5200", got)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package patchsync
 
 import (
 	"encoding/csv"
@@ -7,7 +7,15 @@ import (
 	"strings"
 )
 
-func parseSheetToPatch(sheetName, csvText string) (Patch, error) {
+// Option keys gating Endfield sources; GameProfile.Options describes them for the frontend.
+const (
+	optionIncludeBpCrates         = "includeBpCrates"
+	optionIncludeAicQuotaExchange = "includeAicQuotaExchange"
+	optionIncludeUrgentRecruit    = "includeUrgentRecruit"
+	optionIncludeHhDossier        = "includeHhDossier"
+)
+
+func parseSheetToPatch(sheetName, csvText string, opts ParseOptions) (Patch, error) {
 	reader := csv.NewReader(strings.NewReader(csvText))
 	reader.FieldsPerRecord = -1
 	reader.LazyQuotes = true
@@ -56,7 +64,7 @@ func parseSheetToPatch(sheetName, csvText string) (Patch, error) {
 		dataStartRow = 1
 	}
 	for _, record := range records[dataStartRow:] {
-		rows = append(rows, rowFromRecord(record, idxName, idxOro, idxOri, idxChartered, idxBasic, idxArsenal))
+		rows = append(rows, rowFromRecord(record, idxName, idxOro, idxOri, idxChartered, idxBasic, idxArsenal, opts))
 	}
 
 	var (
@@ -83,6 +91,8 @@ func parseSheetToPatch(sheetName, csvText string) (Patch, error) {
 	)
 
 	currentSection := ""
+	seenSections := map[string]bool{}
+	netSources := map[string]bool{}
 	for _, row := range rows {
 		name := normalizeName(row.Name)
 		if name == "" {
@@ -92,18 +102,22 @@ func parseSheetToPatch(sheetName, csvText string) (Patch, error) {
 		switch name {
 		case "events":
 			currentSection = "events"
+			seenSections[name] = true
 			if row.HasData {
 				eventsAggregate = row.Rewards
 			}
 			continue
 		case "permanent content":
 			currentSection = "permanent"
+			seenSections[name] = true
 			continue
 		case "mailbox & web events":
 			currentSection = "mailbox"
+			seenSections[name] = true
 			continue
 		case "recurring sources":
 			currentSection = "recurring"
+			seenSections[name] = true
 			continue
 		case "total":
 			continue
@@ -112,15 +126,15 @@ func parseSheetToPatch(sheetName, csvText string) (Patch, error) {
 		switch currentSection {
 		case "events":
 			if row.HasData {
-				eventsFallbackSum.add(row.Rewards)
+				addSectionRow(&eventsFallbackSum, row.Rewards, "events", netSources)
 			}
 		case "permanent":
 			if row.HasData {
-				permanentSum.add(row.Rewards)
+				addSectionRow(&permanentSum, row.Rewards, "permanent", netSources)
 			}
 		case "mailbox":
 			if row.HasData {
-				mailboxSum.add(row.Rewards)
+				addSectionRow(&mailboxSum, row.Rewards, "mailbox", netSources)
 			}
 		}
 
@@ -170,7 +184,16 @@ func parseSheetToPatch(sheetName, csvText string) (Patch, error) {
 		}
 	}
 
-	if !eventsAggregate.hasAny() {
+	hasSection := func(name string) bool { return seenSections[name] }
+	if err := opts.checkAggregateRows("Endfield",
+		missingRows(hasSection, "events", "permanent content", "mailbox & web events"),
+		missingRows(hasSection, "recurring sources"),
+	); err != nil {
+		return Patch{}, err
+	}
+	if eventsAggregate.hasAny() {
+		delete(netSources, "events")
+	} else {
 		eventsAggregate = eventsFallbackSum
 	}
 
@@ -215,9 +238,9 @@ func parseSheetToPatch(sheetName, csvText string) (Patch, error) {
 		source("dailyActivity", "Daily Activity", "always", nil, true, dailyRewards),
 		source("weekly", "Weekly Routine", "always", nil, true, weeklyRewards),
 		source("monumental", "Monumental Etching", "always", nil, true, monumentalRewards),
-		source("aicQuota", "AIC Quota Exchange", "always", ptr("includeAicQuotaExchange"), true, aicRewards),
-		source("urgentRecruit", "Urgent Recruit", "always", ptr("includeUrgentRecruit"), true, urgentRewards),
-		source("hhDossier", "HH Dossier", "always", ptr("includeHhDossier"), true, hhDossierRewards),
+		source("aicQuota", "AIC Quota Exchange", "always", ptr(optionIncludeAicQuotaExchange), true, aicRewards),
+		source("urgentRecruit", "Urgent Recruit", "always", ptr(optionIncludeUrgentRecruit), true, urgentRewards),
+		source("hhDossier", "HH Dossier", "always", ptr(optionIncludeHhDossier), true, hhDossierRewards),
 		source("monthly", "Monthly Pass", "monthly", nil, true, monthlyRewards),
 		source("monthlyBonus", "Monthly Pass Bonus", "monthly", nil, false, monthlyBonusRewards),
 		source("bp2Core", "Originium Supply Pass", "bp2", nil, false, bp2CoreRewards),
@@ -226,7 +249,7 @@ func parseSheetToPatch(sheetName, csvText string) (Patch, error) {
 			ID:           "bpCrateM",
 			Label:        "Exchange Crate-o-Surprise [M]",
 			Gate:         "bp2",
-			OptionKey:    ptr("includeBpCrates"),
+			OptionKey:    ptr(optionIncludeBpCrates),
 			CountInPulls: true,
 			Rewards:      bpCrateMRewards,
 			Costs:        zeroRewards(),
@@ -237,7 +260,7 @@ func parseSheetToPatch(sheetName, csvText string) (Patch, error) {
 			ID:           "bpCrateL",
 			Label:        "Exchange Crate-o-Surprise [L]",
 			Gate:         "bp3",
-			OptionKey:    ptr("includeBpCrates"),
+			OptionKey:    ptr(optionIncludeBpCrates),
 			CountInPulls: true,
 			Rewards:      bpCrateLRewards,
 			Costs:        zeroRewards(),
@@ -246,9 +269,11 @@ func parseSheetToPatch(sheetName, csvText string) (Patch, error) {
 		},
 	}
 
-	versionName, startDate := parsePatchHeaderMeta(getCell(headers, 0))
+	markNetIncomeSources(sources, netSources)
+
+	versionName, startDate := parsePatchHeaderMeta(getCell(headers, 0), sheetDateOrder(gameIDEndfield))
 	if startDate == "" && !hasExplicitHeaders {
-		startDate = inferStartDateFromTitleRow(headers)
+		startDate = inferStartDateFromTitleRow(headers, sheetDateOrder(gameIDEndfield))
 	}
 	patchID := canonicalPatchID(sheetName)
 	patch := Patch{
@@ -1,4 +1,4 @@
-package main
+package patchsync
 
 import (
 	"encoding/csv"
@@ -25,7 +25,7 @@ func isGenshinWelkinPassRow(rowName string) bool {
 	return rowName == "welkin"
 }
 
-func parseSheetToPatchGenshin(sheetName, csvText string) (Patch, error) {
+func parseSheetToPatchGenshin(sheetName, csvText string, opts ParseOptions) (Patch, error) {
 	patchID := canonicalPatchID(sheetName)
 
 	reader := csv.NewReader(strings.NewReader(csvText))
@@ -62,6 +62,8 @@ func parseSheetToPatchGenshin(sheetName, csvText string) (Patch, error) {
 	)
 
 	currentSection := ""
+	seenSections := map[string]bool{}
+	netSources := map[string]bool{}
 	for _, record := range records {
 		sectionName := normalizeName(getCell(record, 0))
 		rowName := normalizeName(getCell(record, 1))
@@ -69,15 +71,19 @@ func parseSheetToPatchGenshin(sheetName, csvText string) (Patch, error) {
 		switch sectionName {
 		case "events":
 			currentSection = "events"
+			seenSections[sectionName] = true
 			continue
 		case "other new content":
 			currentSection = "other"
+			seenSections[sectionName] = true
 			continue
 		case "web, mail, apologems":
 			currentSection = "web"
+			seenSections[sectionName] = true
 			continue
 		case "repeating content":
 			currentSection = "repeating"
+			seenSections[sectionName] = true
 			continue
 		}
 
@@ -88,48 +94,56 @@ func parseSheetToPatchGenshin(sheetName, csvText string) (Patch, error) {
 			continue
 		}
 
-		rewards := parseGenshinGachaRewards(record)
+		rewards := parseGenshinGachaRewards(record, opts)
 		if !rewards.hasAny() {
 			continue
 		}
 
 		switch currentSection {
 		case "events":
-			eventsRewards.add(rewards)
+			addSectionRow(&eventsRewards, rewards, "events", netSources)
 		case "other":
-			otherRewards.add(rewards)
+			addSectionRow(&otherRewards, rewards, "other", netSources)
 		case "web":
-			webRewards.add(rewards)
+			addSectionRow(&webRewards, rewards, "webMail", netSources)
 		case "repeating":
 			switch {
 			case strings.Contains(rowName, "daily resin/commissions"):
-				dailyRewards.add(rewards)
+				addSectionRow(&dailyRewards, rewards, "dailyActivity", netSources)
 			case strings.Contains(rowName, "expeditions"):
-				expeditionsRewards.add(rewards)
+				addSectionRow(&expeditionsRewards, rewards, "expeditions", netSources)
 			case strings.Contains(rowName, "parametric transformer"):
-				parametricRewards.add(rewards)
+				addSectionRow(&parametricRewards, rewards, "parametric", netSources)
 			case strings.Contains(rowName, "weekly requests and bounties"):
-				weeklyRewards.add(rewards)
+				addSectionRow(&weeklyRewards, rewards, "weekly", netSources)
 			case strings.Contains(rowName, "serenitea realm shop"):
-				sereniteaRewards.add(rewards)
+				addSectionRow(&sereniteaRewards, rewards, "serenitea", netSources)
 			case strings.Contains(rowName, "abyss") || strings.Contains(rowName, "imaginarium") || strings.Contains(rowName, "stygian"):
-				endgameRewards.add(rewards)
+				addSectionRow(&endgameRewards, rewards, "endgame", netSources)
 			case strings.Contains(rowName, "paimon's bargains"):
-				shopRewards.add(rewards)
+				addSectionRow(&shopRewards, rewards, "shop", netSources)
 			case strings.Contains(rowName, "battle pass - f2p"):
-				bpF2PRewards.add(rewards)
+				addSectionRow(&bpF2PRewards, rewards, "bpF2P", netSources)
 			case strings.Contains(rowName, "battle pass - paid bonus"):
-				bpPaidRewards.add(rewards)
+				addSectionRow(&bpPaidRewards, rewards, "bpPaid", netSources)
 			case isGenshinWelkinPassRow(rowName):
-				welkinRewards.add(rewards)
+				addSectionRow(&welkinRewards, rewards, "welkin", netSources)
 			case strings.Contains(rowName, "total f2p") || strings.Contains(rowName, "total p2p"):
 				continue
 			default:
-				repeatingOther.add(rewards)
+				addSectionRow(&repeatingOther, rewards, "repeatingOther", netSources)
 			}
 		}
 	}
 
+	hasSection := func(name string) bool { return seenSections[name] }
+	if err := opts.checkAggregateRows("Genshin",
+		missingRows(hasSection, "events"),
+		missingRows(hasSection, "other new content", "web, mail, apologems", "repeating content"),
+	); err != nil {
+		return Patch{}, err
+	}
+
 	sources := []Source{
 		source("events", "Events", "always", nil, true, eventsRewards),
 		source("other", "Other New Content", "always", nil, true, otherRewards),
@@ -148,6 +162,7 @@ func parseSheetToPatchGenshin(sheetName, csvText string) (Patch, error) {
 	if repeatingOther.hasAny() {
 		sources = append(sources, source("repeatingOther", "Other Repeating Content", "always", nil, true, repeatingOther))
 	}
+	markNetIncomeSources(sources, netSources)
 
 	return Patch{
 		ID:           patchID,
@@ -161,16 +176,16 @@ func parseSheetToPatchGenshin(sheetName, csvText string) (Patch, error) {
 	}, nil
 }
 
-func parseGenshinGachaRewards(record []string) Rewards {
+func parseGenshinGachaRewards(record []string, opts ParseOptions) Rewards {
 	return Rewards{
-		Oroberyl:  parseNumber(getCell(record, 9)),
-		Basic:     parseNumber(getCell(record, 10)),
-		Chartered: parseNumber(getCell(record, 11)),
+		Oroberyl:  opts.number(getCell(record, 9)),
+		Basic:     opts.number(getCell(record, 10)),
+		Chartered: opts.number(getCell(record, 11)),
 	}
 }
 
 func genshinPullsFromRewards(r Rewards) float64 {
-	return (r.Oroberyl / 160.0) + r.Chartered
+	return pullConversionForGame(gameIDGenshin).pullsFromRewards(r)
 }
 
 func parseGenshinSummaryPullTotals(csvText string, orderedSheetNames []string) (map[string]float64, error) {
@@ -248,7 +263,7 @@ func lookupPatchPullTotal(totalsByPatch map[string]float64, patchName string) (f
 	return 0, false
 }
 
-func applyGenshinSummaryPullOverrides(patch *Patch, totalsByPatch map[string]float64) error {
+func applyGenshinSummaryPullOverrides(patch *Patch, totalsByPatch map[string]float64, reconcile string) error {
 	if patch == nil {
 		return errors.New("patch is nil")
 	}
@@ -273,10 +288,9 @@ func applyGenshinSummaryPullOverrides(patch *Patch, totalsByPatch map[string]flo
 		"repeatingOther": {},
 	}
 
-	sourceIndex := map[string]int{}
 	sum := 0.0
+	counted := []int{}
 	for idx, src := range patch.Sources {
-		sourceIndex[src.ID] = idx
 		if !src.CountInPulls || src.Gate != "always" {
 			continue
 		}
@@ -288,6 +302,7 @@ func applyGenshinSummaryPullOverrides(patch *Patch, totalsByPatch map[string]flo
 			pulls = *src.Pulls
 		}
 		sum += pulls
+		counted = append(counted, idx)
 	}
 
 	delta := total - sum
@@ -295,28 +310,17 @@ func applyGenshinSummaryPullOverrides(patch *Patch, totalsByPatch map[string]flo
 		return nil
 	}
 
-	adjustSourceID := "endgame"
-	idx, okAdjust := sourceIndex[adjustSourceID]
-	if !okAdjust {
-		for _, sourceID := range []string{"events", "other", "webMail", "dailyActivity", "shop"} {
-			if candidateIdx, okSource := sourceIndex[sourceID]; okSource {
-				idx = candidateIdx
-				okAdjust = true
-				break
-			}
-		}
-		if !okAdjust {
-			return fmt.Errorf("cannot apply Summary pull override for patch %q: no F2P sources found", patchName)
-		}
+	if !reconcilePullDelta(patch, delta, counted, genshinSourcePulls, reconcile, "endgame", "events", "other", "webMail", "dailyActivity", "shop") {
+		return fmt.Errorf("cannot apply Summary pull override for patch %q: no F2P sources found", patchName)
 	}
+	return nil
+}
 
-	base := genshinPullsFromRewards(patch.Sources[idx].Rewards)
-	if patch.Sources[idx].Pulls != nil {
-		base = *patch.Sources[idx].Pulls
+func genshinSourcePulls(src Source) float64 {
+	if src.Pulls != nil {
+		return *src.Pulls
 	}
-	v := roundToTenth(base + delta)
-	patch.Sources[idx].Pulls = &v
-	return nil
+	return genshinPullsFromRewards(src.Rewards)
 }
 
 func findGenshinDurationDays(records [][]string) int {
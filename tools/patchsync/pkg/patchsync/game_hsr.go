@@ -1,4 +1,4 @@
-package main
+package patchsync
 
 import (
 	"encoding/csv"
@@ -7,7 +7,7 @@ import (
 	"strings"
 )
 
-func parseSheetToPatchHsr(sheetName, csvText string) (Patch, error) {
+func parseSheetToPatchHsr(sheetName, csvText string, opts ParseOptions) (Patch, error) {
 	normalizedSheetName := canonicalPatchID(sheetName)
 
 	reader := csv.NewReader(strings.NewReader(csvText))
@@ -26,7 +26,7 @@ func parseSheetToPatchHsr(sheetName, csvText string) (Patch, error) {
 		return Patch{}, errors.New("unable to determine durationDays from sheet")
 	}
 
-	versionName, startDate := parsePatchHeaderMeta(getCell(records[0], 0))
+	versionName, startDate := parsePatchHeaderMeta(getCell(records[0], 0), sheetDateOrder(gameIDHsr))
 	if versionName == "" {
 		versionName = fmt.Sprintf("Version %s", normalizedSheetName)
 	}
@@ -52,25 +52,33 @@ func parseSheetToPatchHsr(sheetName, csvText string) (Patch, error) {
 			"paid + f2p limited total",
 			"total f2p",
 			"total paid":
-			aggregateRows[name] = parseHsrRewards(record)
+			aggregateRows[name] = parseHsrRewards(record, opts)
 		}
 	}
 
-	travelLogEvents, okTravelLogEvents := aggregateRows["travel log events"]
-	permanent, okPermanent := aggregateRows["permanent content"]
+	hasRow := func(name string) bool {
+		_, ok := aggregateRows[name]
+		if !ok && name == "mailbox & web events" {
+			ok = aggregateRows["mailbox and web events"].hasAny()
+		}
+		return ok
+	}
+	if err := opts.checkAggregateRows("HSR",
+		missingRows(hasRow, "travel log events", "permanent content", "mailbox & web events", "daily training", "weekly modes", "treasures lightward", "embers store"),
+		missingRows(hasRow, "paid battle pass", "supply pass"),
+	); err != nil {
+		return Patch{}, err
+	}
+	travelLogEvents := aggregateRows["travel log events"]
+	permanent := aggregateRows["permanent content"]
 	mailbox, okMailbox := aggregateRows["mailbox & web events"]
 	if !okMailbox {
 		mailbox = aggregateRows["mailbox and web events"]
-		okMailbox = mailbox.hasAny()
-	}
-	dailyTraining, okDailyTraining := aggregateRows["daily training"]
-	weeklyModes, okWeeklyModes := aggregateRows["weekly modes"]
-	treasuresLightward, okTreasuresLightward := aggregateRows["treasures lightward"]
-	embersStore, okEmbersStore := aggregateRows["embers store"]
-
-	if !okTravelLogEvents || !okPermanent || !okMailbox || !okDailyTraining || !okWeeklyModes || !okTreasuresLightward || !okEmbersStore {
-		return Patch{}, errors.New("missing required aggregate rows in HSR sheet")
 	}
+	dailyTraining := aggregateRows["daily training"]
+	weeklyModes := aggregateRows["weekly modes"]
+	treasuresLightward := aggregateRows["treasures lightward"]
+	embersStore := aggregateRows["embers store"]
 
 	paidBattlePass := aggregateRows["paid battle pass"]
 	supplyPass := aggregateRows["supply pass"]
@@ -99,19 +107,19 @@ func parseSheetToPatchHsr(sheetName, csvText string) (Patch, error) {
 	}, nil
 }
 
-func parseHsrRewards(record []string) Rewards {
+func parseHsrRewards(record []string, opts ParseOptions) Rewards {
 	return Rewards{
-		Oroberyl:  parseNumber(getCell(record, 1)),
-		Chartered: parseNumber(getCell(record, 2)),
-		Basic:     parseNumber(getCell(record, 3)),
+		Oroberyl:  opts.number(getCell(record, 1)),
+		Chartered: opts.number(getCell(record, 2)),
+		Basic:     opts.number(getCell(record, 3)),
 	}
 }
 
-func parseHsrDataSheet(csvText string, fallbackSheetNames []string) (map[string]map[string]float64, error) {
-	return parseDataSheetPulls(csvText, hsrDataRowToSourceID, fallbackSheetNames)
+func parseHsrDataSheet(csvText string, fallbackSheetNames []string, separator string) (map[string]map[string]float64, error) {
+	return parseDataSheetPulls(csvText, hsrDataRowToSourceID, fallbackSheetNames, separator)
 }
 
-func applyHsrDataPullOverrides(patch *Patch, pullsByPatch map[string]map[string]float64) error {
+func applyHsrDataPullOverrides(patch *Patch, pullsByPatch map[string]map[string]float64, reconcile string) error {
 	if patch == nil {
 		return errors.New("patch is nil")
 	}
@@ -146,24 +154,19 @@ func applyHsrDataPullOverrides(patch *Patch, pullsByPatch map[string]map[string]
 			"mailbox":            {},
 		}
 		sum := 0.0
-		for _, src := range patch.Sources {
+		counted := []int{}
+		for idx, src := range patch.Sources {
 			if src.Pulls != nil && src.CountInPulls {
 				if _, okF2P := f2pSourceIDs[src.ID]; !okF2P {
 					continue
 				}
 				sum += *src.Pulls
+				counted = append(counted, idx)
 			}
 		}
 		delta := total - sum
 		if absFloat(delta) > 0.0001 {
-			if idx, okAdjust := sourceIndex["permanent"]; okAdjust {
-				base := 0.0
-				if patch.Sources[idx].Pulls != nil {
-					base = *patch.Sources[idx].Pulls
-				}
-				v := roundToTenth(base + delta)
-				patch.Sources[idx].Pulls = &v
-			}
+			reconcilePullDelta(patch, delta, counted, sourcePullsOrZero, reconcile, "permanent")
 		}
 	}
 	return nil
@@ -1,14 +1,13 @@
-package main
+package patchsync
 
 import (
 	"encoding/csv"
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 )
 
-func parseSheetToPatchWuwa(sheetName, csvText string) (Patch, error) {
+func parseSheetToPatchWuwa(sheetName, csvText string, opts ParseOptions) (Patch, error) {
 	normalizedSheetName := canonicalPatchID(sheetName)
 
 	reader := csv.NewReader(strings.NewReader(csvText))
@@ -36,7 +35,7 @@ func parseSheetToPatchWuwa(sheetName, csvText string) (Patch, error) {
 		candidate := strings.TrimSpace(getCell(record, 0))
 		match := patchVersionWithDatePattern.FindStringSubmatch(candidate)
 		if len(match) >= 2 {
-			startDate = parseDateToISO(match[1])
+			startDate = parseDateToISO(match[1], sheetDateOrder(gameIDWuwa))
 			break
 		}
 	}
@@ -62,17 +61,24 @@ func parseSheetToPatchWuwa(sheetName, csvText string) (Patch, error) {
 			if _, exists := aggregateRows[name]; exists {
 				continue
 			}
-			aggregateRows[name] = parseWuwaRewards(record)
+			aggregateRows[name] = parseWuwaRewards(record, opts)
 		}
 	}
 
-	events, okEvents := aggregateRows["version events"]
-	permanent, okPermanent := aggregateRows["permanent content"]
-	mailbox, okMailbox := aggregateRows["mailbox/miscellaneous"]
-	recurring, okRecurring := aggregateRows["recurring sources"]
-	if !okEvents || !okPermanent || !okMailbox || !okRecurring {
-		return Patch{}, errors.New("missing required aggregate rows in Wuthering Waves sheet")
+	hasRow := func(name string) bool {
+		_, ok := aggregateRows[name]
+		return ok
 	}
+	if err := opts.checkAggregateRows("Wuthering Waves",
+		missingRows(hasRow, "version events", "permanent content", "mailbox/miscellaneous", "recurring sources"),
+		missingRows(hasRow, "paid pioneer podcast", "lunite subscription"),
+	); err != nil {
+		return Patch{}, err
+	}
+	events := aggregateRows["version events"]
+	permanent := aggregateRows["permanent content"]
+	mailbox := aggregateRows["mailbox/miscellaneous"]
+	recurring := aggregateRows["recurring sources"]
 	paidPodcast := aggregateRows["paid pioneer podcast"]
 	monthly := aggregateRows["lunite subscription"]
 
@@ -136,8 +142,8 @@ func parseSheetToPatchWuwa(sheetName, csvText string) (Patch, error) {
 			)
 		}
 		if absFloat(expectedPaidPulls-actualPaidPulls) > epsilon {
-			fmt.Fprintf(os.Stderr,
-				"WARNING: patch %s paid mismatch: expected %.3f pulls from Total Paid, got %.3f (using F2P-only validation)\n",
+			opts.warnf(
+				"patch %s paid mismatch: expected %.3f pulls from Total Paid, got %.3f (using F2P-only validation)",
 				normalizedSheetName,
 				expectedPaidPulls,
 				actualPaidPulls,
@@ -157,19 +163,19 @@ func parseSheetToPatchWuwa(sheetName, csvText string) (Patch, error) {
 	}, nil
 }
 
-func parseWuwaRewards(record []string) Rewards {
+func parseWuwaRewards(record []string, opts ParseOptions) Rewards {
 	return Rewards{
-		Oroberyl:   parseNumber(getCell(record, 1)),
-		Chartered:  parseNumber(getCell(record, 2)),
-		Firewalker: parseNumber(getCell(record, 3)),
-		Basic:      parseNumber(getCell(record, 4)),
+		Oroberyl:   opts.number(getCell(record, 1)),
+		Chartered:  opts.number(getCell(record, 2)),
+		Firewalker: opts.number(getCell(record, 3)),
+		Basic:      opts.number(getCell(record, 4)),
 	}
 }
 
 func wwPullsFromRewards(r Rewards) float64 {
-	return (r.Oroberyl / 160.0) + r.Chartered + r.Firewalker
+	return pullConversionForGame(gameIDWuwa).pullsFromRewards(r)
 }
 
-func parseWuwaDataSheet(csvText string, fallbackSheetNames []string) (map[string]map[string]float64, error) {
-	return parseDataSheetPulls(csvText, wuwaDataRowToSourceID, fallbackSheetNames)
+func parseWuwaDataSheet(csvText string, fallbackSheetNames []string, separator string) (map[string]map[string]float64, error) {
+	return parseDataSheetPulls(csvText, wuwaDataRowToSourceID, fallbackSheetNames, separator)
 }
@@ -1,4 +1,4 @@
-package main
+package patchsync
 
 import (
 	"encoding/csv"
@@ -7,7 +7,7 @@ import (
 	"strings"
 )
 
-func parseSheetToPatchZzz(sheetName, csvText string) (Patch, error) {
+func parseSheetToPatchZzz(sheetName, csvText string, opts ParseOptions) (Patch, error) {
 	normalizedSheetName := canonicalPatchID(sheetName)
 
 	reader := csv.NewReader(strings.NewReader(csvText))
@@ -26,7 +26,7 @@ func parseSheetToPatchZzz(sheetName, csvText string) (Patch, error) {
 		return Patch{}, errors.New("unable to determine durationDays from sheet")
 	}
 
-	versionName, startDate := parsePatchHeaderMeta(getCell(records[0], 0))
+	versionName, startDate := parsePatchHeaderMeta(getCell(records[0], 0), sheetDateOrder(gameIDZzz))
 	if versionName == "" {
 		versionName = fmt.Sprintf("Version %s", normalizedSheetName)
 	}
@@ -52,19 +52,31 @@ func parseSheetToPatchZzz(sheetName, csvText string) (Patch, error) {
 			"inter-knot membership",
 			"total f2p",
 			"total paid":
-			aggregateRows[name] = parseZzzRewards(record)
+			aggregateRows[name] = parseZzzRewards(record, opts)
 		}
 	}
 
-	events, okEvents := aggregateRows["events"]
-	permanent, okPermanent := aggregateRows["permanent content"]
+	hasRow := func(name string) bool {
+		_, ok := aggregateRows[name]
+		switch {
+		case !ok && name == "mailbox & web events":
+			ok = aggregateRows["mailbox and web events"].hasAny()
+		case !ok && name == "errands":
+			_, ok = aggregateRows["recurring sources"]
+		}
+		return ok
+	}
+	if err := opts.checkAggregateRows("ZZZ",
+		missingRows(hasRow, "events", "permanent content", "mailbox & web events"),
+		missingRows(hasRow, "errands", "hollow zero", "f2p battle pass", "24-hour shop", "endgame modes", "paid battle pass", "inter-knot membership"),
+	); err != nil {
+		return Patch{}, err
+	}
+	events := aggregateRows["events"]
+	permanent := aggregateRows["permanent content"]
 	mailbox, okMailbox := aggregateRows["mailbox & web events"]
 	if !okMailbox {
 		mailbox = aggregateRows["mailbox and web events"]
-		okMailbox = mailbox.hasAny()
-	}
-	if !okEvents || !okPermanent || !okMailbox {
-		return Patch{}, errors.New("missing required aggregate rows in ZZZ sheet")
 	}
 
 	recurring := aggregateRows["recurring sources"]
@@ -105,12 +117,12 @@ func parseSheetToPatchZzz(sheetName, csvText string) (Patch, error) {
 	}, nil
 }
 
-func parseZzzRewards(record []string) Rewards {
+func parseZzzRewards(record []string, opts ParseOptions) Rewards {
 	return Rewards{
-		Oroberyl:  parseNumber(getCell(record, 1)),
-		Chartered: parseNumber(getCell(record, 2)),
-		Basic:     parseNumber(getCell(record, 3)),
-		Arsenal:   parseNumber(getCell(record, 4)),
+		Oroberyl:  opts.number(getCell(record, 1)),
+		Chartered: opts.number(getCell(record, 2)),
+		Basic:     opts.number(getCell(record, 3)),
+		Arsenal:   opts.number(getCell(record, 4)),
 	}
 }
 
@@ -118,7 +130,7 @@ func isZzzBooponsTotalRow(rowName string) bool {
 	return rowName == "f2p boopons total"
 }
 
-func parseZzzDataSheet(csvText string, fallbackSheetNames []string) (map[string]map[string]float64, error) {
+func parseZzzDataSheet(csvText string, fallbackSheetNames []string, separator string) (map[string]map[string]float64, error) {
 	reader := csv.NewReader(strings.NewReader(csvText))
 	reader.FieldsPerRecord = -1
 	reader.LazyQuotes = true
@@ -126,11 +138,12 @@ func parseZzzDataSheet(csvText string, fallbackSheetNames []string) (map[string]
 	if err != nil {
 		return nil, fmt.Errorf("csv parse error: %w", err)
 	}
+	records = orientDataSheetRecords(records)
 	if len(records) < 2 {
 		return nil, errors.New("Data sheet has no rows")
 	}
 
-	header, headerIdx := findDataSheetHeader(records)
+	header, headerIdx := findDataSheetHeader(records, zzzDataRowToSourceID)
 	patchCols := explicitDataSheetPatchColumns(header)
 	patchCols = inferDataSheetPatchColumns(records, zzzDataRowToSourceID, headerIdx, patchCols, fallbackSheetNames)
 	if len(patchCols) == 0 {
@@ -155,7 +168,7 @@ func parseZzzDataSheet(csvText string, fallbackSheetNames []string) (map[string]
 			}
 			for colIdx, patchName := range patchCols {
 				raw := getCell(record, colIdx)
-				value, okValue := parseDataPullValue(raw)
+				value, okValue := parseDataPullValueWith(raw, separator)
 				if !okValue {
 					continue
 				}
@@ -177,7 +190,7 @@ func parseZzzDataSheet(csvText string, fallbackSheetNames []string) (map[string]
 
 		for colIdx, patchName := range patchCols {
 			raw := getCell(record, colIdx)
-			value, okValue := parseDataPullValue(raw)
+			value, okValue := parseDataPullValueWith(raw, separator)
 			if !okValue {
 				continue
 			}
@@ -193,7 +206,7 @@ func parseZzzDataSheet(csvText string, fallbackSheetNames []string) (map[string]
 	return result, nil
 }
 
-func applyZzzDataPullOverrides(patch *Patch, pullsByPatch map[string]map[string]float64) error {
+func applyZzzDataPullOverrides(patch *Patch, pullsByPatch map[string]map[string]float64, reconcile string) error {
 	if patch == nil {
 		return errors.New("patch is nil")
 	}
@@ -229,24 +242,19 @@ func applyZzzDataPullOverrides(patch *Patch, pullsByPatch map[string]map[string]
 			"endgameModes":  {},
 		}
 		sum := 0.0
-		for _, src := range patch.Sources {
+		counted := []int{}
+		for idx, src := range patch.Sources {
 			if src.Pulls != nil && src.CountInPulls {
 				if _, okF2P := f2pSourceIDs[src.ID]; !okF2P {
 					continue
 				}
 				sum += *src.Pulls
+				counted = append(counted, idx)
 			}
 		}
 		delta := total - sum
 		if absFloat(delta) <= 1 {
-			if idx, okAdjust := sourceIndex["endgameModes"]; okAdjust {
-				base := 0.0
-				if patch.Sources[idx].Pulls != nil {
-					base = *patch.Sources[idx].Pulls
-				}
-				v := roundToTenth(base + delta)
-				patch.Sources[idx].Pulls = &v
-			}
+			reconcilePullDelta(patch, delta, counted, sourcePullsOrZero, reconcile, "endgameModes")
 		}
 	}
 	return nil
@@ -1,4 +1,4 @@
-package main
+package patchsync
 
 import (
 	"encoding/csv"
@@ -7,10 +7,10 @@ import (
 	"math"
 	"os"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
-	"time"
 )
 
 
@@ -22,44 +22,81 @@ var patchVersionWithDatePattern = regexp.MustCompile(`(?i)^version\s+\d+\.\d+\s*
 
 
 
-func availableGameIDs() []string {
+// AvailableGameIDs lists the supported game ids in display order.
+func AvailableGameIDs() []string {
 	return []string{gameIDEndfield, gameIDWuwa, gameIDZzz, gameIDGenshin, gameIDHsr}
 }
 
-func spreadsheetEnvKeyForGame(gameID string) string {
+// gameEnvPrefix returns the PATCHSYNC_<GAME> prefix of the per-game environment variables, or "".
+func gameEnvPrefix(gameID string) string {
 	switch gameID {
 	case gameIDEndfield:
-		return envSpreadsheetEndfield
+		return envGameEndfield
 	case gameIDWuwa:
-		return envSpreadsheetWuwa
+		return envGameWuwa
 	case gameIDZzz:
-		return envSpreadsheetZzz
+		return envGameZzz
 	case gameIDGenshin:
-		return envSpreadsheetGenshin
+		return envGameGenshin
 	case gameIDHsr:
-		return envSpreadsheetHsr
+		return envGameHsr
 	default:
 		return ""
 	}
 }
 
-func resolveGameProfile(gameID string) (gameProfile, error) {
+func spreadsheetEnvKeyForGame(gameID string) string {
+	if prefix := gameEnvPrefix(gameID); prefix != "" {
+		return prefix + "_SPREADSHEET_ID"
+	}
+	return ""
+}
+
+// legacySpreadsheetEnvKey is the PATCHSYNC_SPREADSHEET_<GAME> name older .env files use.
+func legacySpreadsheetEnvKey(gameID string) string {
+	if prefix := gameEnvPrefix(gameID); prefix != "" {
+		return "PATCHSYNC_SPREADSHEET_" + strings.TrimPrefix(prefix, "PATCHSYNC_")
+	}
+	return ""
+}
+
+// ResolveGameProfile returns the profile for gameID (the default game when empty), with the per-game
+// environment applied: spreadsheet ids, the output path, and the Data, Summary and shop tab names.
+func ResolveGameProfile(gameID string) (GameProfile, error) {
 	trimmed := strings.TrimSpace(gameID)
 	if trimmed == "" {
 		trimmed = defaultGameID
 	}
 	profile, ok := profilesByGameID[trimmed]
 	if !ok {
-		return gameProfile{}, fmt.Errorf(
+		return GameProfile{}, fmt.Errorf(
 			"unknown game id %q (allowed: %s)",
 			trimmed,
-			strings.Join(availableGameIDs(), ", "),
+			strings.Join(AvailableGameIDs(), ", "),
 		)
 	}
 
-	envKey := spreadsheetEnvKeyForGame(trimmed)
-	if envKey != "" {
-		profile.DefaultSpreadsheetID = extractSpreadsheetID(strings.TrimSpace(os.Getenv(envKey)))
+	prefix := gameEnvPrefix(trimmed)
+	if prefix == "" {
+		return profile, nil
+	}
+	profile.DefaultSpreadsheetIDs = spreadsheetIDList(os.Getenv(prefix + "_SPREADSHEET_ID"))
+	if len(profile.DefaultSpreadsheetIDs) == 0 {
+		profile.DefaultSpreadsheetIDs = spreadsheetIDList(os.Getenv(legacySpreadsheetEnvKey(trimmed)))
+	}
+	if output := strings.TrimSpace(os.Getenv(prefix + "_OUTPUT")); output != "" {
+		profile.DefaultOutputPath = output
+	}
+	// Genshin reads its Summary tab, every other game its Data tab; a configured name replaces the guesses.
+	auxKey := prefix + "_DATA_SHEET"
+	if slices.Equal(profile.AuxSheetNames, summarySheetCandidates) {
+		auxKey = prefix + "_SUMMARY_SHEET"
+	}
+	if name := strings.TrimSpace(os.Getenv(auxKey)); name != "" {
+		profile.AuxSheetNames = []string{name}
+	}
+	if name := strings.TrimSpace(os.Getenv(prefix + "_SHOP_SHEET")); name != "" {
+		profile.ShopSheetNames = []string{name}
 	}
 	return profile, nil
 }
@@ -79,29 +116,6 @@ func canonicalPatchID(raw string) string {
 	return normalized
 }
 
-func mergeTagLists(base []string, extra []string) []string {
-	if len(base) == 0 && len(extra) == 0 {
-		return nil
-	}
-	seen := map[string]struct{}{}
-	merged := make([]string, 0, len(base)+len(extra))
-	for _, tag := range append(append([]string{}, base...), extra...) {
-		normalized := strings.TrimSpace(tag)
-		if normalized == "" {
-			continue
-		}
-		if _, ok := seen[normalized]; ok {
-			continue
-		}
-		seen[normalized] = struct{}{}
-		merged = append(merged, normalized)
-	}
-	if len(merged) == 0 {
-		return nil
-	}
-	return merged
-}
-
 func parseDataSheetPatchTags(csvText string) (map[string][]string, error) {
 	reader := csv.NewReader(strings.NewReader(csvText))
 	reader.FieldsPerRecord = -1
@@ -110,11 +124,12 @@ func parseDataSheetPatchTags(csvText string) (map[string][]string, error) {
 	if err != nil {
 		return nil, fmt.Errorf("csv parse error: %w", err)
 	}
+	records = orientDataSheetRecords(records)
 	if len(records) == 0 {
 		return map[string][]string{}, nil
 	}
 
-	header, _ := findDataSheetHeader(records)
+	header, _ := findDataSheetHeader(records, nil)
 	tagsByPatch := map[string][]string{}
 	for _, cell := range header {
 		patchID := canonicalPatchID(cell)
@@ -130,8 +145,33 @@ func parseDataSheetPatchTags(csvText string) (map[string][]string, error) {
 	return tagsByPatch, nil
 }
 
-func findDataSheetHeader(records [][]string) ([]string, int) {
+// dataSheetHeaderScanRows is how many non-blank rows findDataSheetHeader looks at. Some sheets put a banner row
+// above the versions, but the header is always near the top.
+const dataSheetHeaderScanRows = 5
+
+// dataSheetHeaderCandidates returns the indexes of the rows that can hold the patch header: the first
+// dataSheetHeaderScanRows non-blank rows, up to the first pull row named in rowToSourceID.
+func dataSheetHeaderCandidates(records [][]string, rowToSourceID map[string]string) []int {
+	candidates := []int{}
 	for idx, record := range records {
+		if len(candidates) == dataSheetHeaderScanRows {
+			break
+		}
+		if _, ok := rowToSourceID[normalizeName(getCell(record, 0))]; ok {
+			break
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		candidates = append(candidates, idx)
+	}
+	return candidates
+}
+
+func findDataSheetHeader(records [][]string, rowToSourceID map[string]string) ([]string, int) {
+	candidates := dataSheetHeaderCandidates(records, rowToSourceID)
+	for _, idx := range candidates {
+		record := records[idx]
 		if normalizeName(getCell(record, 0)) != "version" {
 			continue
 		}
@@ -145,9 +185,9 @@ func findDataSheetHeader(records [][]string) ([]string, int) {
 
 	bestIdx := 0
 	bestCount := 0
-	for idx, record := range records {
+	for _, idx := range candidates {
 		count := 0
-		for _, cell := range record {
+		for _, cell := range records[idx] {
 			patchName := canonicalPatchID(cell)
 			if patchName != "" && isVersionLikeSheetName(patchName) {
 				count++
@@ -250,7 +290,7 @@ func inferDataSheetPatchColumns(records [][]string, rowToSourceID map[string]str
 	return explicitCols
 }
 
-func parseDataSheetPulls(csvText string, rowToSourceID map[string]string, fallbackSheetNames []string) (map[string]map[string]float64, error) {
+func parseDataSheetPulls(csvText string, rowToSourceID map[string]string, fallbackSheetNames []string, separator string) (map[string]map[string]float64, error) {
 	reader := csv.NewReader(strings.NewReader(csvText))
 	reader.FieldsPerRecord = -1
 	reader.LazyQuotes = true
@@ -258,11 +298,12 @@ func parseDataSheetPulls(csvText string, rowToSourceID map[string]string, fallba
 	if err != nil {
 		return nil, fmt.Errorf("csv parse error: %w", err)
 	}
+	records = orientDataSheetRecords(records)
 	if len(records) < 2 {
 		return nil, errors.New("Data sheet has no rows")
 	}
 
-	header, headerIdx := findDataSheetHeader(records)
+	header, headerIdx := findDataSheetHeader(records, rowToSourceID)
 	patchCols := explicitDataSheetPatchColumns(header)
 	patchCols = inferDataSheetPatchColumns(records, rowToSourceID, headerIdx, patchCols, fallbackSheetNames)
 	if len(patchCols) == 0 {
@@ -278,7 +319,7 @@ func parseDataSheetPulls(csvText string, rowToSourceID map[string]string, fallba
 		}
 		for colIdx, patchName := range patchCols {
 			raw := getCell(record, colIdx)
-			value, okValue := parseDataPullValue(raw)
+			value, okValue := parseDataPullValueWith(raw, separator)
 			if !okValue {
 				continue
 			}
@@ -294,40 +335,53 @@ func parseDataSheetPulls(csvText string, rowToSourceID map[string]string, fallba
 	return result, nil
 }
 
-func parseEndfieldDataSheet(csvText string, fallbackSheetNames []string) (map[string]map[string]float64, error) {
-	return parseDataSheetPulls(csvText, endfieldDataRowToSourceID, fallbackSheetNames)
+func parseEndfieldDataSheet(csvText string, fallbackSheetNames []string, separator string) (map[string]map[string]float64, error) {
+	return parseDataSheetPulls(csvText, endfieldDataRowToSourceID, fallbackSheetNames, separator)
 }
 
 
 
 
 func parseDataPullValue(raw string) (float64, bool) {
+	return parseDataPullValueWith(raw, decimalAuto)
+}
+
+func parseDataPullValueWith(raw, separator string) (float64, bool) {
 	value := strings.TrimSpace(raw)
 	if value == "" {
 		return 0, false
 	}
 	value = strings.ReplaceAll(value, "\u00a0", "")
 	value = strings.ReplaceAll(value, " ", "")
-
-	lastComma := strings.LastIndex(value, ",")
-	lastDot := strings.LastIndex(value, ".")
-	switch {
-	case lastComma >= 0 && lastDot >= 0:
-		if lastComma > lastDot {
-			value = strings.ReplaceAll(value, ".", "")
+	value, sign := splitNumberSign(value)
+	value, exponent := splitExponent(value)
+	if exponent != "" {
+		separator = mantissaSeparator(value)
+	}
+
+	if text, ok := explicitDecimalText(value, separator); ok {
+		value = text
+	} else {
+		lastComma := strings.LastIndex(value, ",")
+		lastDot := strings.LastIndex(value, ".")
+		switch {
+		case lastComma >= 0 && lastDot >= 0:
+			if lastComma > lastDot {
+				value = strings.ReplaceAll(value, ".", "")
+				value = strings.ReplaceAll(value, ",", ".")
+			} else {
+				value = strings.ReplaceAll(value, ",", "")
+			}
+		case lastComma >= 0:
 			value = strings.ReplaceAll(value, ",", ".")
-		} else {
-			value = strings.ReplaceAll(value, ",", "")
 		}
-	case lastComma >= 0:
-		value = strings.ReplaceAll(value, ",", ".")
 	}
 
-	parsed, err := strconv.ParseFloat(value, 64)
+	parsed, err := strconv.ParseFloat(value+exponent, 64)
 	if err != nil {
 		return 0, false
 	}
-	return roundToTenth(parsed), true
+	return roundToTenth(sign * parsed), true
 }
 
 func lookupSourcePullsByPatchName(pullsByPatch map[string]map[string]float64, patchName string) (map[string]float64, bool) {
@@ -377,7 +431,7 @@ func applyEndfieldDataPullOverrides(patch *Patch, pullsByPatch map[string]map[st
 	return nil
 }
 
-func applyWuwaDataPullOverrides(patch *Patch, pullsByPatch map[string]map[string]float64) error {
+func applyWuwaDataPullOverrides(patch *Patch, pullsByPatch map[string]map[string]float64, reconcile string) error {
 	if patch == nil {
 		return errors.New("patch is nil")
 	}
@@ -412,51 +466,25 @@ func applyWuwaDataPullOverrides(patch *Patch, pullsByPatch map[string]map[string
 			"weaponPulls":   {},
 		}
 		sum := 0.0
-		for _, src := range patch.Sources {
+		counted := []int{}
+		for idx, src := range patch.Sources {
 			if src.Pulls != nil && src.CountInPulls {
 				if _, okF2P := f2pSourceIDs[src.ID]; !okF2P {
 					continue
 				}
 				sum += *src.Pulls
+				counted = append(counted, idx)
 			}
 		}
 		delta := total - sum
 		if delta != 0 {
-			if idx, okAdjust := sourceIndex["endgameModes"]; okAdjust {
-				base := 0.0
-				if patch.Sources[idx].Pulls != nil {
-					base = *patch.Sources[idx].Pulls
-				}
-				v := roundToTenth(base + delta)
-				patch.Sources[idx].Pulls = &v
-			}
+			reconcilePullDelta(patch, delta, counted, sourcePullsOrZero, reconcile, "endgameModes")
 		}
 	}
 	return nil
 }
 
 
-func parseDateToISO(raw string) string {
-	value := strings.TrimSpace(raw)
-	if value == "" {
-		return ""
-	}
-	layouts := []string{
-		"02.01.2006",
-		"2.1.2006",
-		"02/01/2006",
-		"2/1/2006",
-		"01/02/2006",
-		"1/2/2006",
-	}
-	for _, layout := range layouts {
-		if parsed, err := time.Parse(layout, value); err == nil {
-			return parsed.Format("2006-01-02")
-		}
-	}
-	return ""
-}
-
 func findWuwaDurationDays(records [][]string) int {
 	for rowIdx, record := range records {
 		for colIdx, cell := range record {
@@ -0,0 +1,169 @@
+package patchsync
+
+const (
+	gameIDEndfield = "arknights-endfield"
+	gameIDWuwa     = "wuthering-waves"
+	gameIDZzz      = "zenless-zone-zero"
+	gameIDGenshin  = "genshin-impact"
+	gameIDHsr      = "honkai-star-rail"
+	defaultGameID  = gameIDEndfield
+
+	// Per-game environment variables are <prefix>_SPREADSHEET_ID, _OUTPUT, _DATA_SHEET, _SUMMARY_SHEET and
+	// _SHOP_SHEET.
+	envGameEndfield = "PATCHSYNC_ENDFIELD"
+	envGameWuwa     = "PATCHSYNC_WUWA"
+	envGameZzz      = "PATCHSYNC_ZZZ"
+	envGameGenshin  = "PATCHSYNC_GENSHIN"
+	envGameHsr      = "PATCHSYNC_HSR"
+)
+
+// PatchParser turns one version sheet exported as CSV into a Patch.
+type PatchParser func(sheetName, csvText string, opts ParseOptions) (Patch, error)
+
+// SourceOption is a user toggle that gates every source carrying its Key as optionKey.
+type SourceOption struct {
+	Key     string
+	Label   string
+	Default bool
+}
+
+// GameProfile describes a supported game: where its sheets live, where its output goes, and how to parse them.
+// AuxSheetNames are the names tried, in order, for the Data (or Genshin's Summary) tab. PatchStart is the time
+// of day patches go live, see parsePatchStart. ShopSheetNames are tried for the exchange shop tab; games
+// without them skip it. BasePatchesArray names the game's hand-written patches in src/data/patches.js.
+type GameProfile struct {
+	ID                    string
+	DefaultSpreadsheetIDs []string
+	DefaultOutputPath     string
+	BasePatchesArray      string
+	ParseSheet            PatchParser
+	AuxSheetNames         []string
+	ShopSheetNames        []string
+	Options               []SourceOption
+	Regions               []ServerRegion
+	PatchStart            string
+}
+
+var (
+	dataSheetCandidates    = []string{"Data", "Pull Data", "Pulls", "Totals", "Datos", "Данные", "データ", "数据"}
+	summarySheetCandidates = []string{"Summary", "summary", "Totals", "Overview", "Resumen", "Сводка", "概要", "总览"}
+)
+
+var profilesByGameID = map[string]GameProfile{
+	gameIDEndfield: {
+		ID:                gameIDEndfield,
+		DefaultOutputPath: "src/data/endfield.generated.js",
+		BasePatchesArray:  "END_FIELD_BASE_PATCHES",
+		ParseSheet:        parseSheetToPatch,
+		AuxSheetNames:     dataSheetCandidates,
+		Regions:           defaultServerRegions,
+		PatchStart:        defaultPatchStart,
+		Options: []SourceOption{
+			{Key: optionIncludeBpCrates, Label: "Include BP 60+ Crates [M]/[L]", Default: true},
+			{Key: optionIncludeAicQuotaExchange, Label: "AIC Quota Exchange", Default: true},
+			{Key: optionIncludeUrgentRecruit, Label: "Urgent Recruit"},
+			{Key: optionIncludeHhDossier, Label: "HH Dossier"},
+		},
+	},
+	gameIDWuwa: {
+		ID:                gameIDWuwa,
+		DefaultOutputPath: "src/data/wuwa.generated.js",
+		BasePatchesArray:  "WUWA_BASE_PATCHES",
+		ParseSheet:        parseSheetToPatchWuwa,
+		AuxSheetNames:     dataSheetCandidates,
+		Regions:           defaultServerRegions,
+		PatchStart:        defaultPatchStart,
+	},
+	gameIDZzz: {
+		ID:                gameIDZzz,
+		DefaultOutputPath: "src/data/zzz.generated.js",
+		BasePatchesArray:  "ZZZ_BASE_PATCHES",
+		ParseSheet:        parseSheetToPatchZzz,
+		AuxSheetNames:     dataSheetCandidates,
+		Regions:           defaultServerRegions,
+		PatchStart:        hoyoversePatchStart,
+	},
+	gameIDGenshin: {
+		ID:                gameIDGenshin,
+		DefaultOutputPath: "src/data/genshin.generated.js",
+		BasePatchesArray:  "GENSHIN_BASE_PATCHES",
+		ParseSheet:        parseSheetToPatchGenshin,
+		AuxSheetNames:     summarySheetCandidates,
+		ShopSheetNames:    append([]string{"Paimon's Bargains"}, shopSheetCandidates...),
+		Regions:           defaultServerRegions,
+		PatchStart:        hoyoversePatchStart,
+	},
+	gameIDHsr: {
+		ID:                gameIDHsr,
+		DefaultOutputPath: "src/data/hsr.generated.js",
+		BasePatchesArray:  "HSR_BASE_PATCHES",
+		ParseSheet:        parseSheetToPatchHsr,
+		AuxSheetNames:     dataSheetCandidates,
+		ShopSheetNames:    append([]string{"Embers Store", "Embers Exchange"}, shopSheetCandidates...),
+		Regions:           defaultServerRegions,
+		PatchStart:        hoyoversePatchStart,
+	},
+}
+
+var endfieldDataRowToSourceID = map[string]string{
+	"daily activity":           "dailyActivity",
+	"weekly routine":           "weekly",
+	"monumental etching":       "monumental",
+	"aic quota exchange":       "aicQuota",
+	"urgent recruit":           "urgentRecruit",
+	"hh dossier":               "hhDossier",
+	"permanent content":        "permanent",
+	"events":                   "events",
+	"mailbox & web events":     "mailbox",
+	"mailbox and web events":   "mailbox",
+	"originium supply pass":    "bpCrateM",
+	"protocol customized pass": "bpCrateL",
+	"monthly pass":             "monthly",
+	"f2p headhunt total":       "__totalF2P",
+	"total f2p":                "__totalF2P",
+	"total paid":               "__totalPaid",
+}
+
+var wuwaDataRowToSourceID = map[string]string{
+	"version events":        "events",
+	"permanent content":     "permanent",
+	"mailbox/miscellaneous": "mailbox",
+	"daily activity":        "dailyActivity",
+	"recurring sources":     "endgameModes",
+	"coral shop":            "coralShop",
+	"weapon pulls":          "weaponPulls",
+	"paid pioneer podcast":  "paidPodcast",
+	"lunite subscription":   "monthly",
+	"limited total f2p":     "__totalF2P",
+}
+
+var zzzDataRowToSourceID = map[string]string{
+	"events":                 "events",
+	"permanent content":      "permanent",
+	"mailbox & web events":   "mailbox",
+	"mailbox and web events": "mailbox",
+	"errands":                "errands",
+	"hollow zero":            "hollowZero",
+	"endgame modes":          "endgameModes",
+	"24-hour shop":           "shop24h",
+	"f2p battle pass":        "f2pBattlePass",
+	"paid battle pass":       "paidBattlePass",
+	"inter-knot membership":  "membership",
+	"f2p exclusive total":    "__totalF2P",
+}
+
+var hsrDataRowToSourceID = map[string]string{
+	"daily training":           "dailyTraining",
+	"weekly modes":             "weeklyModes",
+	"treasures lightward":      "treasuresLightward",
+	"embers store":             "embersStore",
+	"travel log events":        "travelLogEvents",
+	"permanent content":        "permanent",
+	"mailbox & web events":     "mailbox",
+	"mailbox and web events":   "mailbox",
+	"paid battle pass":         "paidBattlePass",
+	"supply pass":              "supplyPass",
+	"f2p limited total":        "__totalF2P",
+	"paid + f2p limited total": "__totalPaid",
+	"paid+f2p limited total":   "__totalPaid",
+}
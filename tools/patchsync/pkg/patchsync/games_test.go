@@ -1,11 +1,36 @@
-package main
+package patchsync
 
 import (
 	"encoding/csv"
+	"slices"
 	"strings"
 	"testing"
 )
 
+func TestParseWuwaDataSheetFindsHeaderBelowBanner(t *testing.T) {
+	csvText := csvLines(
+		"Wuthering Waves Pull Tracker,,",
+		"Patch,,3.3",
+		"Version Events,30.3,13.9",
+		"Permanent Content,28.6,3.7",
+		"Limited Total F2P,120.2,72.7",
+	)
+
+	pullsByPatch, err := parseWuwaDataSheet(csvText, []string{"3.2", "3.3"}, decimalAuto)
+	if err != nil {
+		t.Fatalf("parseWuwaDataSheet() error = %v", err)
+	}
+	if got := pullsByPatch["3.2"]["events"]; got != 30.3 {
+		t.Fatalf("pullsByPatch[3.2][events] = %v, want 30.3", got)
+	}
+	if got := pullsByPatch["3.3"]["__totalF2P"]; got != 72.7 {
+		t.Fatalf("pullsByPatch[3.3][__totalF2P] = %v, want 72.7", got)
+	}
+	if _, ok := pullsByPatch["30.3"]; ok {
+		t.Fatalf("pull values were taken for patch columns: %v", pullsByPatch)
+	}
+}
+
 func TestParseWuwaDataSheetInfersSparseVersionHeader(t *testing.T) {
 	csvText := "\n" +
 		"Version,,,,3.3\n" +
@@ -18,7 +43,7 @@ func TestParseWuwaDataSheetInfersSparseVersionHeader(t *testing.T) {
 		"Weapon Pulls,,11.0,17.0,7.0\n" +
 		"Limited Total F2P,,256.2,120.2,72.7\n"
 
-	pullsByPatch, err := parseWuwaDataSheet(csvText, []string{"1.0", "1.1", "3.3"})
+	pullsByPatch, err := parseWuwaDataSheet(csvText, []string{"1.0", "1.1", "3.3"}, decimalAuto)
 	if err != nil {
 		t.Fatalf("parseWuwaDataSheet() error = %v", err)
 	}
@@ -142,7 +167,7 @@ func TestParseSheetToPatchWuwa(t *testing.T) {
 	)
 
 	t.Run("valid patch", func(t *testing.T) {
-		patch, err := parseSheetToPatchWuwa("3.4", validCSV)
+		patch, err := parseSheetToPatchWuwa("3.4", validCSV, ParseOptions{})
 		if err != nil {
 			t.Fatalf("parseSheetToPatchWuwa() error = %v", err)
 		}
@@ -182,7 +207,7 @@ func TestParseSheetToPatchWuwa(t *testing.T) {
 			"",
 			"Version Events,5000,30,5,10",
 		)
-		_, err := parseSheetToPatchWuwa("3.4", csv)
+		_, err := parseSheetToPatchWuwa("3.4", csv, ParseOptions{})
 		if err == nil || !strings.Contains(err.Error(), "missing required aggregate rows") {
 			t.Fatalf("expected missing rows error, got %v", err)
 		}
@@ -203,7 +228,7 @@ func TestParseSheetToPatchWuwa(t *testing.T) {
 			"Total F2P,99999,45,15,0", // oroberyl too high
 			"Total Paid,15280,47,15,0",
 		)
-		_, err := parseSheetToPatchWuwa("3.4", csv)
+		_, err := parseSheetToPatchWuwa("3.4", csv, ParseOptions{})
 		if err == nil || !strings.Contains(err.Error(), "f2p mismatch") {
 			t.Fatalf("expected f2p mismatch error, got %v", err)
 		}
@@ -221,7 +246,7 @@ func TestParseSheetToPatchWuwa(t *testing.T) {
 			"Total F2P,11000,45,15,0",
 			"Total Paid,15280,47,15,0",
 		)
-		_, err := parseSheetToPatchWuwa("3.4", csv)
+		_, err := parseSheetToPatchWuwa("3.4", csv, ParseOptions{})
 		if err == nil || !strings.Contains(err.Error(), "durationDays") {
 			t.Fatalf("expected durationDays error, got %v", err)
 		}
@@ -240,7 +265,7 @@ func TestParseSheetToPatchWuwa(t *testing.T) {
 			"Recurring Sources,3000,0,10,0",
 			"Lunite Subscription,0,21,0,0",
 		)
-		patch, err := parseSheetToPatchWuwa("3.4", csv)
+		patch, err := parseSheetToPatchWuwa("3.4", csv, ParseOptions{})
 		if err != nil {
 			t.Fatalf("parseSheetToPatchWuwa() error = %v", err)
 		}
@@ -265,7 +290,7 @@ func TestParseSheetToPatchWuwa(t *testing.T) {
 			"Total F2P,11000,45,15,0",
 			"Total Paid,15280,47,15,0",
 		)
-		patch, err := parseSheetToPatchWuwa("3.4", csv)
+		patch, err := parseSheetToPatchWuwa("3.4", csv, ParseOptions{})
 		if err != nil {
 			t.Fatalf("parseSheetToPatchWuwa() error = %v", err)
 		}
@@ -277,9 +302,45 @@ func TestParseSheetToPatchWuwa(t *testing.T) {
 
 	t.Run("less than 3 rows returns error", func(t *testing.T) {
 		csv := "only one row\n"
-		_, err := parseSheetToPatchWuwa("3.4", csv)
+		_, err := parseSheetToPatchWuwa("3.4", csv, ParseOptions{})
 		if err == nil || !strings.Contains(err.Error(), "no data rows") {
 			t.Fatalf("expected no data rows error, got %v", err)
 		}
 	})
 }
+
+func TestResolveGameProfileEnvMatrix(t *testing.T) {
+	t.Setenv("PATCHSYNC_SPREADSHEET_WUWA", "legacy-id")
+	t.Setenv("PATCHSYNC_WUWA_SPREADSHEET_ID", "")
+	t.Setenv("PATCHSYNC_WUWA_OUTPUT", "")
+	t.Setenv("PATCHSYNC_WUWA_DATA_SHEET", "")
+	profile, err := ResolveGameProfile(gameIDWuwa)
+	if err != nil {
+		t.Fatalf("ResolveGameProfile() error = %v", err)
+	}
+	if !slices.Equal(profile.DefaultSpreadsheetIDs, []string{"legacy-id"}) || profile.DefaultOutputPath != "src/data/wuwa.generated.js" {
+		t.Fatalf("legacy key not honoured: %+v", profile)
+	}
+
+	t.Setenv("PATCHSYNC_WUWA_SPREADSHEET_ID", "new-id,second-id")
+	t.Setenv("PATCHSYNC_WUWA_OUTPUT", "public/data/wuwa.js")
+	t.Setenv("PATCHSYNC_WUWA_DATA_SHEET", "Pull Totals")
+	t.Setenv("PATCHSYNC_WUWA_SUMMARY_SHEET", "Ignored")
+	profile, _ = ResolveGameProfile(gameIDWuwa)
+	if !slices.Equal(profile.DefaultSpreadsheetIDs, []string{"new-id", "second-id"}) {
+		t.Fatalf("spreadsheet ids = %v", profile.DefaultSpreadsheetIDs)
+	}
+	if profile.DefaultOutputPath != "public/data/wuwa.js" || !slices.Equal(profile.AuxSheetNames, []string{"Pull Totals"}) {
+		t.Fatalf("unexpected output %q / aux sheets %v", profile.DefaultOutputPath, profile.AuxSheetNames)
+	}
+
+	t.Setenv("PATCHSYNC_GENSHIN_DATA_SHEET", "Ignored")
+	t.Setenv("PATCHSYNC_GENSHIN_SUMMARY_SHEET", "Primos")
+	genshin, _ := ResolveGameProfile(gameIDGenshin)
+	if !slices.Equal(genshin.AuxSheetNames, []string{"Primos"}) {
+		t.Fatalf("genshin aux sheets = %v", genshin.AuxSheetNames)
+	}
+	if endfield, _ := ResolveGameProfile(gameIDEndfield); !slices.Equal(endfield.AuxSheetNames, dataSheetCandidates) {
+		t.Fatalf("other games must keep their defaults, got %v", endfield.AuxSheetNames)
+	}
+}
@@ -0,0 +1,170 @@
+package patchsync
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGitLabTarget = "main"
+	defaultGitLabRemote = "origin"
+)
+
+// GitLabOptions configure merge requests for the branches of syncs run with --create-branch. The token is only read from
+// PATCHSYNC_GITLAB_TOKEN; Project is the numeric id or the full path ("group/bookkeeper").
+type GitLabOptions struct {
+	URL     string
+	Project string
+	Token   string
+	Target  string
+	Remote  string
+}
+
+func registerGitLabFlags(fs *flag.FlagSet, opts *GitLabOptions) {
+	opts.Token = os.Getenv("PATCHSYNC_GITLAB_TOKEN")
+	fs.StringVar(&opts.URL, "gitlab-url", os.Getenv("PATCHSYNC_GITLAB_URL"), "GitLab base URL; with --create-branch the sync branch is pushed and opened as a merge request")
+	fs.StringVar(&opts.Project, "gitlab-project", os.Getenv("PATCHSYNC_GITLAB_PROJECT"), "GitLab project id or path for merge requests")
+	fs.StringVar(&opts.Target, "gitlab-target", defaultGitLabTarget, "Target branch of merge requests")
+	fs.StringVar(&opts.Remote, "gitlab-remote", defaultGitLabRemote, "Git remote the sync branch is pushed to")
+}
+
+func (o GitLabOptions) enabled() bool {
+	return strings.TrimSpace(o.URL) != ""
+}
+
+func (o GitLabOptions) validate() error {
+	if !o.enabled() {
+		return nil
+	}
+	parsed, err := url.Parse(o.URL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("invalid --gitlab-url %q", o.URL)
+	}
+	if strings.TrimSpace(o.Project) == "" {
+		return errors.New("--gitlab-project is required with --gitlab-url")
+	}
+	if o.Token == "" {
+		return errors.New("PATCHSYNC_GITLAB_TOKEN is required with --gitlab-url")
+	}
+	return nil
+}
+
+// buildMergeRequestDescription renders the change report of a sync as GitLab Markdown.
+func buildMergeRequestDescription(result SyncResult) string {
+	var body strings.Builder
+	fmt.Fprintf(&body, "Generated by patchsync from spreadsheet `%s`.\n\n", result.Meta.SpreadsheetID)
+	fmt.Fprintf(&body, "- Added: %d\n- Updated: %d\n- Removed: %d\n", result.Stats.Added, result.Stats.Updated, result.Stats.Removed)
+	if deltas := formatRewardsDelta(result.Stats.RewardsDelta); len(deltas) > 0 {
+		fmt.Fprintf(&body, "- Rewards: %s\n", strings.Join(deltas, ", "))
+	}
+	if len(result.Stats.Patches) > 0 {
+		body.WriteString("\n| Patch | Change | Pulls |\n| --- | --- | --- |\n")
+		for _, change := range result.Stats.Patches {
+			label := change.Patch
+			if change.WIP {
+				label += " (WIP)"
+			}
+			fmt.Fprintf(&body, "| %s | %s | %+g |\n", label, change.ChangeType, change.PullsDelta)
+		}
+	}
+	if len(result.SkippedPatches) > 0 {
+		fmt.Fprintf(&body, "\nSkipped: %s\n", strings.Join(result.SkippedPatches, ", "))
+	}
+	for _, failure := range result.SheetFailures {
+		fmt.Fprintf(&body, "\nFailed sheet %s (%s, %s): %s\n", failure.Sheet, failure.Stage, failure.Code, failure.Error)
+	}
+	return body.String()
+}
+
+type gitLabClient struct {
+	opts   GitLabOptions
+	client *http.Client
+}
+
+func newGitLabClient(opts GitLabOptions) *gitLabClient {
+	return &gitLabClient{opts: opts, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+type gitLabMergeRequest struct {
+	SourceBranch       string `json:"source_branch"`
+	TargetBranch       string `json:"target_branch"`
+	Title              string `json:"title"`
+	Description        string `json:"description"`
+	RemoveSourceBranch bool   `json:"remove_source_branch"`
+}
+
+// openMergeRequest creates the merge request and returns its web URL.
+func (c *gitLabClient) openMergeRequest(ctx context.Context, request gitLabMergeRequest) (string, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("marshal merge request: %w", err)
+	}
+	endpoint := strings.TrimRight(c.opts.URL, "/") + "/api/v4/projects/" + url.PathEscape(c.opts.Project) + "/merge_requests"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("PRIVATE-TOKEN", c.opts.Token)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("create merge request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("create merge request: %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	var created struct {
+		WebURL string `json:"web_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("parse merge request response: %w", err)
+	}
+	return created.WebURL, nil
+}
+
+// openSyncMergeRequest pushes the branch a sync committed to and opens a merge request with the change
+// report. Syncs that did not commit anything are left alone.
+func openSyncMergeRequest(ctx context.Context, cfg SyncConfig, result *SyncResult) error {
+	if !cfg.GitLab.enabled() || result.Commit == "" {
+		return nil
+	}
+	logs := syncLog{requestID: cfg.RequestID, clock: clockOrSystem(cfg.Clock), lines: result.Logs}
+	defer func() { result.Logs = logs.lines }()
+
+	root, err := resolveRepoRoot(cfg.RepoRoot)
+	if err != nil {
+		return withErrorCode(errCodeGit, err)
+	}
+	remote := cmp.Or(strings.TrimSpace(cfg.GitLab.Remote), defaultGitLabRemote)
+	if _, err := runGit(root, "push", remote, result.BranchName); err != nil {
+		return withErrorCode(errCodeGit, err)
+	}
+	appendSyncLog(&logs, "pushed branch %s to %s", result.BranchName, remote)
+
+	webURL, err := newGitLabClient(cfg.GitLab).openMergeRequest(ctx, gitLabMergeRequest{
+		SourceBranch:       result.BranchName,
+		TargetBranch:       cmp.Or(strings.TrimSpace(cfg.GitLab.Target), defaultGitLabTarget),
+		Title:              syncCommitTitle(result.GameID, result.Patches),
+		Description:        buildMergeRequestDescription(*result),
+		RemoveSourceBranch: true,
+	})
+	if err != nil {
+		return withErrorCode(errCodeGitLab, err)
+	}
+	result.MergeRequest = webURL
+	appendSyncLog(&logs, "opened merge request %s", webURL)
+	return nil
+}
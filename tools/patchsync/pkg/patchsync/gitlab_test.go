@@ -0,0 +1,98 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunSyncOpensGitLabMergeRequest(t *testing.T) {
+	dir := initSyncRepo(t)
+	remote := t.TempDir()
+	gitOutput(t, remote, "init", "--bare", "-q")
+	gitOutput(t, dir, "remote", "add", "origin", remote)
+
+	var received gitLabMergeRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/games%2Fbookkeeper/merge_requests" || r.Header.Get("PRIVATE-TOKEN") != "glpat-secret" {
+			http.Error(w, "unexpected request "+r.URL.EscapedPath(), http.StatusNotFound)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decode merge request: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"iid":7,"web_url":"https://gitlab.example.com/games/bookkeeper/-/merge_requests/7"}`))
+	}))
+	defer server.Close()
+
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "logs", "hashes.json"),
+		CreateBranch:    true,
+		Clock:           FixedClock(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+		GitLab:          GitLabOptions{URL: server.URL, Project: "games/bookkeeper", Token: "glpat-secret"},
+	}
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	if result.MergeRequest != "https://gitlab.example.com/games/bookkeeper/-/merge_requests/7" {
+		t.Fatalf("MergeRequest = %q", result.MergeRequest)
+	}
+	if received.SourceBranch != result.BranchName || received.TargetBranch != "main" || !received.RemoveSourceBranch {
+		t.Fatalf("unexpected merge request %+v", received)
+	}
+	if received.Title != "Sync wuthering-waves patches 3.4" || !strings.Contains(received.Description, "| 3.4 | added |") {
+		t.Fatalf("unexpected merge request text %q / %q", received.Title, received.Description)
+	}
+
+	committed := gitOutput(t, remote, "show", "--name-only", "--format=%s", result.BranchName)
+	if committed != "Sync wuthering-waves patches 3.4\n\nwuwa.generated.js" {
+		t.Fatalf("unexpected pushed commit:\n%s", committed)
+	}
+}
+
+func TestGitLabMergeRequestErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":["Another open merge request already exists for this source branch"]}`, http.StatusConflict)
+	}))
+	defer server.Close()
+	client := newGitLabClient(GitLabOptions{URL: server.URL, Project: "42", Token: "glpat-secret"})
+	_, err := client.openMergeRequest(t.Context(), gitLabMergeRequest{SourceBranch: "data/sheets-1", TargetBranch: "main"})
+	if err == nil || !strings.Contains(err.Error(), "409 Conflict") || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+}
+
+func TestGitLabOptionsValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		opts GitLabOptions
+		want string
+	}{
+		{name: "disabled", opts: GitLabOptions{}},
+		{name: "valid", opts: GitLabOptions{URL: "https://gitlab.example.com", Project: "games/bookkeeper", Token: "glpat"}},
+		{name: "bad url", opts: GitLabOptions{URL: "gitlab.example.com", Project: "1", Token: "glpat"}, want: "invalid --gitlab-url"},
+		{name: "no project", opts: GitLabOptions{URL: "https://gitlab.example.com", Token: "glpat"}, want: "--gitlab-project"},
+		{name: "no token", opts: GitLabOptions{URL: "https://gitlab.example.com", Project: "1"}, want: "PATCHSYNC_GITLAB_TOKEN"},
+	}
+	for _, tc := range cases {
+		err := tc.opts.validate()
+		if tc.want == "" && err != nil {
+			t.Fatalf("%s: unexpected error %v", tc.name, err)
+		}
+		if tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)) {
+			t.Fatalf("%s: expected error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
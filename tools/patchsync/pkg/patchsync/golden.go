@@ -0,0 +1,244 @@
+package patchsync
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const goldenDiffContext = 3
+
+// goldenLCSLimit caps the line-by-line diff; larger changed regions are printed as one removed and one added block.
+const goldenLCSLimit = 4_000_000
+
+// goldenSyncConfig builds an offline backfill over fixturesDir, which holds either fixtures saved by --record-fixtures
+// or <spreadsheet id>/<sheet>.csv directories. Time is pinned to the Unix epoch so expectations never drift.
+func goldenSyncConfig(gameID, fixturesDir, spreadsheetID, overridesDir, workDir string) (SyncConfig, error) {
+	entries, err := os.ReadDir(fixturesDir)
+	if err != nil {
+		return SyncConfig{}, fmt.Errorf("read fixtures: %w", err)
+	}
+	cfg := SyncConfig{
+		GameID:          gameID,
+		SpreadsheetID:   spreadsheetID,
+		OutputPath:      filepath.Join(workDir, "golden.generated.js"),
+		BasePatchesPath: filepath.Join(workDir, "patches.js"),
+		OverridesDir:    overridesDir,
+		SheetHashPath:   filepath.Join(workDir, "hashes.json"),
+		Backfill:        true,
+		DryRun:          true,
+		Clock:           FixedClock(time.Unix(0, 0).UTC()),
+	}
+	spreadsheetDirs := []string{}
+	recorded := false
+	for _, entry := range entries {
+		if entry.IsDir() {
+			spreadsheetDirs = append(spreadsheetDirs, entry.Name())
+		} else if strings.HasSuffix(entry.Name(), ".json") {
+			recorded = true
+		}
+	}
+	if recorded {
+		cfg.ReplayFixtures = fixturesDir
+		return cfg, nil
+	}
+	cfg.SheetSource = sheetSourceLocal
+	cfg.LocalSheetsDir = fixturesDir
+	if strings.TrimSpace(cfg.SpreadsheetID) == "" {
+		if len(spreadsheetDirs) != 1 {
+			sort.Strings(spreadsheetDirs)
+			return SyncConfig{}, fmt.Errorf("found %d spreadsheet directories in %s (%s); pick one with --spreadsheet-id", len(spreadsheetDirs), fixturesDir, strings.Join(spreadsheetDirs, ", "))
+		}
+		cfg.SpreadsheetID = spreadsheetDirs[0]
+	}
+	return cfg, nil
+}
+
+// renderGoldenOutput runs the offline sync and returns the generated file it would write.
+func renderGoldenOutput(ctx context.Context, cfg SyncConfig) (string, error) {
+	result, err := RunSync(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	if len(result.AllPatches) == 0 {
+		return "", errors.New("sync produced no patches")
+	}
+	return renderGeneratedFile(result.AllPatches, result.Meta)
+}
+
+type lineEdit struct {
+	op   byte
+	text string
+}
+
+func diffLines(want, got []string) []lineEdit {
+	prefix := 0
+	for prefix < len(want) && prefix < len(got) && want[prefix] == got[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(want)-prefix && suffix < len(got)-prefix && want[len(want)-1-suffix] == got[len(got)-1-suffix] {
+		suffix++
+	}
+	edits := make([]lineEdit, 0, len(want)+len(got))
+	for _, line := range want[:prefix] {
+		edits = append(edits, lineEdit{' ', line})
+	}
+	a, b := want[prefix:len(want)-suffix], got[prefix:len(got)-suffix]
+	if len(a)*len(b) > goldenLCSLimit {
+		for _, line := range a {
+			edits = append(edits, lineEdit{'-', line})
+		}
+		for _, line := range b {
+			edits = append(edits, lineEdit{'+', line})
+		}
+	} else {
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(a) || j < len(b) {
+			switch {
+			case i < len(a) && j < len(b) && a[i] == b[j]:
+				edits = append(edits, lineEdit{' ', a[i]})
+				i++
+				j++
+			case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+				edits = append(edits, lineEdit{'-', a[i]})
+				i++
+			default:
+				edits = append(edits, lineEdit{'+', b[j]})
+				j++
+			}
+		}
+	}
+	for _, line := range want[len(want)-suffix:] {
+		edits = append(edits, lineEdit{' ', line})
+	}
+	return edits
+}
+
+// writeLineDiff prints a unified-style diff of want and got with a few lines of context and reports whether they differ.
+func writeLineDiff(w io.Writer, wantName, gotName, want, got string) bool {
+	if want == got {
+		return false
+	}
+	edits := diffLines(strings.Split(want, "\n"), strings.Split(got, "\n"))
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", wantName, gotName)
+	for start := 0; start < len(edits); {
+		if edits[start].op == ' ' {
+			start++
+			continue
+		}
+		from := max(start-goldenDiffContext, 0)
+		end := start
+		for end < len(edits) {
+			if edits[end].op != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(edits) && edits[next].op == ' ' {
+				next++
+			}
+			if next == len(edits) || next-end > 2*goldenDiffContext {
+				break
+			}
+			end = next
+		}
+		to := min(end+goldenDiffContext, len(edits))
+		wantLine, gotLine := 1, 1
+		for _, edit := range edits[:from] {
+			if edit.op != '+' {
+				wantLine++
+			}
+			if edit.op != '-' {
+				gotLine++
+			}
+		}
+		fmt.Fprintf(w, "@@ %s:%d %s:%d @@\n", wantName, wantLine, gotName, gotLine)
+		for _, edit := range edits[from:to] {
+			fmt.Fprintf(w, "%c%s\n", edit.op, edit.text)
+		}
+		start = to
+	}
+	return true
+}
+
+func runGoldenCommand(args []string) int {
+	fs := flag.NewFlagSet("golden", flag.ContinueOnError)
+	var (
+		gameID        string
+		fixturesDir   string
+		expectPath    string
+		spreadsheetID string
+		overridesDir  string
+		update        bool
+	)
+	fs.StringVar(&gameID, "game", defaultGameID, fmt.Sprintf("Game id (%s)", strings.Join(AvailableGameIDs(), ", ")))
+	fs.StringVar(&fixturesDir, "fixtures", "", "Fixtures saved by --record-fixtures, or a directory of <spreadsheet id>/<sheet>.csv files")
+	fs.StringVar(&expectPath, "expect", "", "Checked-in generated file the sync output must match")
+	fs.StringVar(&spreadsheetID, "spreadsheet-id", "", "Spreadsheet ID to sync (defaults to the only CSV directory, or the game's spreadsheet for recorded fixtures)")
+	fs.StringVar(&overridesDir, "overrides-dir", defaultOverridesDir, "Directory with <game>.json manual patch/source overrides")
+	fs.BoolVar(&update, "update", false, "Rewrite --expect with the current output instead of comparing")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if strings.TrimSpace(fixturesDir) == "" || strings.TrimSpace(expectPath) == "" {
+		fmt.Fprintln(os.Stderr, "golden failed: --fixtures and --expect are required")
+		return 2
+	}
+	workDir, err := os.MkdirTemp("", "patchsync-golden-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "golden failed: %v\n", err)
+		return 1
+	}
+	defer os.RemoveAll(workDir)
+	cfg, err := goldenSyncConfig(gameID, resolveFilePath(fixturesDir), extractSpreadsheetID(spreadsheetID), overridesDir, workDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "golden failed: %v\n", err)
+		return 2
+	}
+	got, err := renderGoldenOutput(context.Background(), cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "golden failed: %v\n", err)
+		return 1
+	}
+	expectPath = resolveOutputPath(expectPath)
+	if update {
+		if err := os.WriteFile(expectPath, []byte(got), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "golden failed: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Updated %s\n", expectPath)
+		return 0
+	}
+	want, err := os.ReadFile(expectPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "golden failed: %v (run with --update to create it)\n", err)
+		return 1
+	}
+	if writeLineDiff(os.Stdout, expectPath, "sync output", string(want), got) {
+		fmt.Fprintf(os.Stderr, "golden failed: %s does not match the sync output (run with --update to accept it)\n", expectPath)
+		return 1
+	}
+	fmt.Printf("%s matches the sync output\n", expectPath)
+	return 0
+}
@@ -0,0 +1,77 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteLineDiffShowsChangedLinesWithContext(t *testing.T) {
+	want := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	got := "a\nb\nc\nd\nE\nf\ng\nh\ni\n"
+	var sb strings.Builder
+	if !writeLineDiff(&sb, "want.js", "got", want, got) {
+		t.Fatalf("expected a difference")
+	}
+	expected := strings.Join([]string{
+		"--- want.js",
+		"+++ got",
+		"@@ want.js:2 got:2 @@",
+		" b",
+		" c",
+		" d",
+		"-e",
+		"+E",
+		" f",
+		" g",
+		" h",
+		"+i",
+		" ",
+		"",
+	}, "\n")
+	if sb.String() != expected {
+		t.Fatalf("diff =\n%s\nwant\n%s", sb.String(), expected)
+	}
+	if writeLineDiff(&sb, "want.js", "got", want, want) {
+		t.Fatalf("expected identical input to report no difference")
+	}
+}
+
+func TestGoldenOutputIsStableForCSVFixtures(t *testing.T) {
+	fixturesDir := filepath.Join(t.TempDir(), "fixtures")
+	sheetDir := filepath.Join(fixturesDir, "golden-sheet")
+	if err := os.MkdirAll(sheetDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range []string{"3.4", "3.5"} {
+		if err := os.WriteFile(filepath.Join(sheetDir, version+".csv"), []byte(wuwaSheetCSV(version)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	render := func() string {
+		cfg, err := goldenSyncConfig(gameIDWuwa, fixturesDir, "", filepath.Join(t.TempDir(), "overrides"), t.TempDir())
+		if err != nil {
+			t.Fatalf("goldenSyncConfig() error = %v", err)
+		}
+		if cfg.SpreadsheetID != "golden-sheet" || cfg.SheetSource != sheetSourceLocal {
+			t.Fatalf("cfg = %+v, want the local golden-sheet spreadsheet", cfg)
+		}
+		output, err := renderGoldenOutput(t.Context(), cfg)
+		if err != nil {
+			t.Fatalf("renderGoldenOutput() error = %v", err)
+		}
+		return output
+	}
+	first := render()
+	for _, want := range []string{"export const GENERATED_PATCHES = ", `"generatedAt": "1970-01-01T00:00:00Z"`, `"3.5"`} {
+		if !strings.Contains(first, want) {
+			t.Fatalf("golden output is missing %q:\n%s", want, first)
+		}
+	}
+	var sb strings.Builder
+	if writeLineDiff(&sb, "first", "second", first, render()) {
+		t.Fatalf("golden output changed between runs:\n%s", sb.String())
+	}
+}
@@ -0,0 +1,700 @@
+package patchsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// graphqlSchema lists the queryable fields per type; an empty value marks a scalar field.
+var graphqlSchema = map[string]map[string]string{
+	"Query": {
+		"games":   "",
+		"patches": "Patch",
+		"patch":   "Patch",
+		"changes": "Change",
+	},
+	"Patch": {
+		"id":           "",
+		"patch":        "",
+		"versionName":  "",
+		"startDate":    "",
+		"durationDays": "",
+		"tags":         "",
+		"completeness": "",
+		"notes":        "",
+		"sources":      "Source",
+		"dailyIncome":  "",
+	},
+	"Source": {
+		"id":           "",
+		"label":        "",
+		"gate":         "",
+		"optionKey":    "",
+		"countInPulls": "",
+		"pulls":        "",
+		"rewards":      "",
+		"costs":        "",
+		"scalers":      "",
+		"bpCrateModel": "",
+	},
+	"Change": {
+		"timestamp":      "",
+		"gameId":         "",
+		"spreadsheetId":  "",
+		"outputPath":     "",
+		"generatedAt":    "",
+		"updatedPatches": "PatchChange",
+	},
+	"PatchChange": {
+		"patch":          "",
+		"changeType":     "",
+		"changedSources": "",
+	},
+}
+
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type graphqlError struct {
+	Message string `json:"message"`
+}
+
+type graphqlResponse struct {
+	Data   any            `json:"data,omitempty"`
+	Errors []graphqlError `json:"errors,omitempty"`
+}
+
+type graphqlField struct {
+	Alias      string
+	Name       string
+	Args       map[string]any
+	Selections []graphqlField
+}
+
+func (f graphqlField) responseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// graphqlObject keeps response fields in selection order.
+type graphqlObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func (o *graphqlObject) set(key string, value any) {
+	if o.values == nil {
+		o.values = map[string]any{}
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o graphqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for idx, key := range o.keys {
+		if idx > 0 {
+			buf.WriteByte(',')
+		}
+		keyJSON, _ := json.Marshal(key)
+		buf.Write(keyJSON)
+		buf.WriteByte(':')
+		valueJSON, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(valueJSON)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type graphqlToken struct {
+	kind  byte // 'n' name, 's' string, 'd' number, 'p' punctuation, 0 end
+	value string
+}
+
+func tokenizeGraphQL(query string) ([]graphqlToken, error) {
+	tokens := make([]graphqlToken, 0, 64)
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		ch := runes[i]
+		switch {
+		case unicode.IsSpace(ch) || ch == ',' || ch == '\uFEFF':
+			i++
+		case ch == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case strings.ContainsRune("{}():$![]=", ch):
+			tokens = append(tokens, graphqlToken{kind: 'p', value: string(ch)})
+			i++
+		case ch == '"':
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, errors.New("unterminated string")
+				}
+				if runes[i] == '"' {
+					i++
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					default:
+						sb.WriteRune(runes[i])
+					}
+					i++
+					continue
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, graphqlToken{kind: 's', value: sb.String()})
+		case ch == '-' || unicode.IsDigit(ch):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune(".eE+-", runes[i])) {
+				i++
+			}
+			tokens = append(tokens, graphqlToken{kind: 'd', value: string(runes[start:i])})
+		case ch == '_' || unicode.IsLetter(ch):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, graphqlToken{kind: 'n', value: string(runes[start:i])})
+		default:
+			return nil, fmt.Errorf("unexpected character %q", ch)
+		}
+	}
+	return tokens, nil
+}
+
+type graphqlParser struct {
+	tokens    []graphqlToken
+	pos       int
+	variables map[string]any
+}
+
+func (p *graphqlParser) peek() graphqlToken {
+	if p.pos >= len(p.tokens) {
+		return graphqlToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *graphqlParser) next() graphqlToken {
+	token := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return token
+}
+
+func (p *graphqlParser) isPunct(value string) bool {
+	token := p.peek()
+	return token.kind == 'p' && token.value == value
+}
+
+func (p *graphqlParser) expectPunct(value string) error {
+	token := p.next()
+	if token.kind != 'p' || token.value != value {
+		return fmt.Errorf("expected %q, got %q", value, token.value)
+	}
+	return nil
+}
+
+func (p *graphqlParser) expectName() (string, error) {
+	token := p.next()
+	if token.kind != 'n' {
+		return "", fmt.Errorf("expected name, got %q", token.value)
+	}
+	return token.value, nil
+}
+
+func parseGraphQLQuery(query string, variables map[string]any) ([]graphqlField, error) {
+	tokens, err := tokenizeGraphQL(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &graphqlParser{tokens: tokens, variables: map[string]any{}}
+	for name, value := range variables {
+		p.variables[name] = value
+	}
+	if token := p.peek(); token.kind == 'n' {
+		switch token.value {
+		case "query":
+			p.next()
+		case "mutation", "subscription":
+			return nil, fmt.Errorf("%s operations are not supported", token.value)
+		default:
+			return nil, fmt.Errorf("unexpected %q", token.value)
+		}
+		if p.peek().kind == 'n' {
+			p.next()
+		}
+		if p.isPunct("(") {
+			if err := p.parseVariableDefinitions(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	fields, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != 0 {
+		return nil, errors.New("only a single operation is supported")
+	}
+	return fields, nil
+}
+
+func (p *graphqlParser) parseVariableDefinitions() error {
+	if err := p.expectPunct("("); err != nil {
+		return err
+	}
+	for !p.isPunct(")") {
+		if err := p.expectPunct("$"); err != nil {
+			return err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return err
+		}
+		for p.isPunct("[") || p.isPunct("]") || p.isPunct("!") || p.peek().kind == 'n' {
+			p.next()
+		}
+		if p.isPunct("=") {
+			p.next()
+			value, err := p.parseValue()
+			if err != nil {
+				return err
+			}
+			if _, ok := p.variables[name]; !ok {
+				p.variables[name] = value
+			}
+		}
+		if p.peek().kind == 0 {
+			return errors.New("unterminated variable definitions")
+		}
+	}
+	return p.expectPunct(")")
+}
+
+func (p *graphqlParser) parseSelectionSet() ([]graphqlField, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	fields := make([]graphqlField, 0, 8)
+	for !p.isPunct("}") {
+		if p.peek().kind == 0 {
+			return nil, errors.New("unterminated selection set")
+		}
+		field, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	p.next()
+	return fields, nil
+}
+
+func (p *graphqlParser) parseField() (graphqlField, error) {
+	name, err := p.expectName()
+	if err != nil {
+		return graphqlField{}, err
+	}
+	field := graphqlField{Name: name, Args: map[string]any{}}
+	if p.isPunct(":") {
+		p.next()
+		field.Alias = name
+		if field.Name, err = p.expectName(); err != nil {
+			return graphqlField{}, err
+		}
+	}
+	if p.isPunct("(") {
+		p.next()
+		for !p.isPunct(")") {
+			argName, err := p.expectName()
+			if err != nil {
+				return graphqlField{}, err
+			}
+			if err := p.expectPunct(":"); err != nil {
+				return graphqlField{}, err
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return graphqlField{}, err
+			}
+			field.Args[argName] = value
+		}
+		p.next()
+	}
+	if p.isPunct("{") {
+		if field.Selections, err = p.parseSelectionSet(); err != nil {
+			return graphqlField{}, err
+		}
+	}
+	return field, nil
+}
+
+func (p *graphqlParser) parseValue() (any, error) {
+	token := p.next()
+	switch token.kind {
+	case 's':
+		return token.value, nil
+	case 'd':
+		number, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", token.value)
+		}
+		return number, nil
+	case 'n':
+		switch token.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return token.value, nil
+	case 'p':
+		switch token.value {
+		case "$":
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			return p.variables[name], nil
+		case "[":
+			values := []any{}
+			for !p.isPunct("]") {
+				if p.peek().kind == 0 {
+					return nil, errors.New("unterminated list")
+				}
+				value, err := p.parseValue()
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, value)
+			}
+			p.next()
+			return values, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q", token.value)
+}
+
+func graphqlStringArg(field graphqlField, name string) string {
+	value, _ := field.Args[name].(string)
+	return strings.TrimSpace(value)
+}
+
+func graphqlIntArg(field graphqlField, name string) int {
+	value, _ := field.Args[name].(float64)
+	return int(value)
+}
+
+func toGraphQLValue(value any) (any, error) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(body, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+func patchInVersionRange(patchID, from, to string) bool {
+	major, minor, ok := versionSortKey(patchID)
+	if !ok {
+		return from == "" && to == ""
+	}
+	if fromMajor, fromMinor, fromOK := versionSortKey(from); fromOK {
+		if major < fromMajor || (major == fromMajor && minor < fromMinor) {
+			return false
+		}
+	}
+	if toMajor, toMinor, toOK := versionSortKey(to); toOK {
+		if major > toMajor || (major == toMajor && minor > toMinor) {
+			return false
+		}
+	}
+	return true
+}
+
+func filterSourcesByGate(sources []Source, gate string) []Source {
+	if gate == "" {
+		return sources
+	}
+	filtered := make([]Source, 0, len(sources))
+	for _, src := range sources {
+		if strings.EqualFold(src.Gate, gate) {
+			filtered = append(filtered, src)
+		}
+	}
+	return filtered
+}
+
+func loadGraphQLPatches(cfg SyncConfig, field graphqlField) ([]generatedPatch, error) {
+	profile, err := ResolveGameProfile(graphqlStringArg(field, "game"))
+	if err != nil {
+		return nil, err
+	}
+	patches, err := readGeneratedPatches(resolveFilePath(configuredOutputPath(cfg, profile)))
+	if err != nil {
+		return nil, err
+	}
+	from := graphqlStringArg(field, "from")
+	to := graphqlStringArg(field, "to")
+	gate := graphqlStringArg(field, "gate")
+	id := canonicalPatchID(graphqlStringArg(field, "id"))
+	result := make([]generatedPatch, 0, len(patches))
+	for _, patch := range patches {
+		patchID := patchIDOrFallback(patch)
+		if id != "" && patchID != id {
+			continue
+		}
+		if !patchInVersionRange(patchID, from, to) {
+			continue
+		}
+		patch.Sources = filterSourcesByGate(patch.Sources, gate)
+		result = append(result, toGeneratedPatch(patch, profile.ID))
+	}
+	return result, nil
+}
+
+func resolveGraphQLRoot(cfg SyncConfig, field graphqlField) (any, error) {
+	switch field.Name {
+	case "games":
+		return toGraphQLValue(AvailableGameIDs())
+	case "patches":
+		patches, err := loadGraphQLPatches(cfg, field)
+		if err != nil {
+			return nil, err
+		}
+		return toGraphQLValue(patches)
+	case "patch":
+		if graphqlStringArg(field, "id") == "" {
+			return nil, errors.New("patch requires an id argument")
+		}
+		patches, err := loadGraphQLPatches(cfg, field)
+		if err != nil {
+			return nil, err
+		}
+		if len(patches) == 0 {
+			return nil, nil
+		}
+		return toGraphQLValue(patches[0])
+	case "changes":
+		records, err := readFullChangeLog(defaultChangeLogPath)
+		if err != nil {
+			return nil, err
+		}
+		game := graphqlStringArg(field, "game")
+		filtered := make([]syncChangeLogRecord, 0, len(records))
+		for idx := len(records) - 1; idx >= 0; idx-- {
+			if game != "" && records[idx].GameID != game {
+				continue
+			}
+			filtered = append(filtered, records[idx])
+		}
+		if limit := graphqlIntArg(field, "limit"); limit > 0 && limit < len(filtered) {
+			filtered = filtered[:limit]
+		}
+		return toGraphQLValue(filtered)
+	}
+	return nil, fmt.Errorf("cannot query field %q on type Query", field.Name)
+}
+
+func selectGraphQLValue(typeName string, value any, selections []graphqlField) (any, error) {
+	switch typed := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		items := make([]any, 0, len(typed))
+		for _, item := range typed {
+			selected, err := selectGraphQLValue(typeName, item, selections)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, selected)
+		}
+		return items, nil
+	case map[string]any:
+		return selectGraphQLObject(typeName, typed, selections)
+	}
+	return nil, fmt.Errorf("expected object of type %s", typeName)
+}
+
+func selectGraphQLObject(typeName string, object map[string]any, selections []graphqlField) (graphqlObject, error) {
+	result := graphqlObject{}
+	for _, field := range selections {
+		if field.Name == "__typename" {
+			result.set(field.responseKey(), typeName)
+			continue
+		}
+		childType, known := graphqlSchema[typeName][field.Name]
+		if !known {
+			return graphqlObject{}, fmt.Errorf("cannot query field %q on type %s", field.Name, typeName)
+		}
+		value := object[field.Name]
+		if typeName == "Patch" && field.Name == "sources" {
+			if gate := graphqlStringArg(field, "gate"); gate != "" {
+				value = filterGraphQLSourcesByGate(value, gate)
+			}
+		}
+		if childType == "" {
+			if len(field.Selections) > 0 {
+				return graphqlObject{}, fmt.Errorf("field %q of type %s must not have a selection", field.Name, typeName)
+			}
+			result.set(field.responseKey(), value)
+			continue
+		}
+		if len(field.Selections) == 0 {
+			return graphqlObject{}, fmt.Errorf("field %q of type %s must have a selection of subfields", field.Name, typeName)
+		}
+		selected, err := selectGraphQLValue(childType, value, field.Selections)
+		if err != nil {
+			return graphqlObject{}, err
+		}
+		result.set(field.responseKey(), selected)
+	}
+	return result, nil
+}
+
+func filterGraphQLSourcesByGate(value any, gate string) any {
+	items, ok := value.([]any)
+	if !ok {
+		return value
+	}
+	filtered := make([]any, 0, len(items))
+	for _, item := range items {
+		object, _ := item.(map[string]any)
+		if itemGate, _ := object["gate"].(string); strings.EqualFold(itemGate, gate) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+func executeGraphQL(cfg SyncConfig, req graphqlRequest) graphqlResponse {
+	fields, err := parseGraphQLQuery(req.Query, req.Variables)
+	if err != nil {
+		return graphqlResponse{Errors: []graphqlError{{Message: "syntax error: " + err.Error()}}}
+	}
+	data := graphqlObject{}
+	errs := make([]graphqlError, 0)
+	for _, field := range fields {
+		if field.Name == "__typename" {
+			data.set(field.responseKey(), "Query")
+			continue
+		}
+		childType, known := graphqlSchema["Query"][field.Name]
+		if !known {
+			errs = append(errs, graphqlError{Message: fmt.Sprintf("cannot query field %q on type Query", field.Name)})
+			continue
+		}
+		value, err := resolveGraphQLRoot(cfg, field)
+		if err == nil && childType == "" && len(field.Selections) > 0 {
+			err = fmt.Errorf("field %q must not have a selection", field.Name)
+		}
+		if err == nil && childType != "" {
+			if len(field.Selections) == 0 {
+				err = fmt.Errorf("field %q must have a selection of subfields", field.Name)
+			} else {
+				value, err = selectGraphQLValue(childType, value, field.Selections)
+			}
+		}
+		if err != nil {
+			errs = append(errs, graphqlError{Message: fmt.Sprintf("%s: %v", field.responseKey(), err)})
+			data.set(field.responseKey(), nil)
+			continue
+		}
+		data.set(field.responseKey(), value)
+	}
+	return graphqlResponse{Data: data, Errors: errs}
+}
+
+func registerGraphQL(mux *http.ServeMux, allowedOrigins map[string]struct{}, cfg SyncConfig) {
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		if !withCORS(w, r, allowedOrigins) {
+			writeJSON(w, http.StatusForbidden, syncResponse{
+				OK:      false,
+				Message: "origin is not allowed",
+			})
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var req graphqlRequest
+		switch r.Method {
+		case http.MethodGet:
+			req.Query = r.URL.Query().Get("query")
+			if rawVariables := r.URL.Query().Get("variables"); rawVariables != "" {
+				if err := json.Unmarshal([]byte(rawVariables), &req.Variables); err != nil {
+					writeGraphQLJSON(w, http.StatusBadRequest, graphqlResponse{Errors: []graphqlError{{Message: "invalid variables"}}})
+					return
+				}
+			}
+		case http.MethodPost:
+			if err := parseSyncRequestBody(r, &req, cfg.maxBodyBytes()); err != nil {
+				status, message := http.StatusBadRequest, "invalid JSON body"
+				if errors.Is(err, errRequestBodyTooLarge) {
+					status, message = http.StatusRequestEntityTooLarge, err.Error()
+				}
+				writeGraphQLJSON(w, status, graphqlResponse{Errors: []graphqlError{{Message: message}}})
+				return
+			}
+		default:
+			writeJSON(w, http.StatusMethodNotAllowed, syncResponse{
+				OK:      false,
+				Message: "method not allowed",
+			})
+			return
+		}
+		if strings.TrimSpace(req.Query) == "" {
+			writeGraphQLJSON(w, http.StatusBadRequest, graphqlResponse{Errors: []graphqlError{{Message: "query is required"}}})
+			return
+		}
+		writeGraphQLJSON(w, http.StatusOK, executeGraphQL(cfg, req))
+	})
+}
+
+func writeGraphQLJSON(w http.ResponseWriter, statusCode int, payload graphqlResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(payload)
+}
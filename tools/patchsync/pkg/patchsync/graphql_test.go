@@ -0,0 +1,75 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGraphQLSelection(t *testing.T) {
+	query := `query Patches($gate: String = "monthly") {
+		patches(game: "wuthering-waves", from: "2.0") {
+			patch
+			monthly: sources(gate: $gate) { id gate }
+		}
+	}`
+	fields, err := parseGraphQLQuery(query, nil)
+	if err != nil {
+		t.Fatalf("parseGraphQLQuery() error = %v", err)
+	}
+	if len(fields) != 1 || fields[0].Name != "patches" || graphqlStringArg(fields[0], "from") != "2.0" {
+		t.Fatalf("unexpected root fields: %+v", fields)
+	}
+	sourcesField := fields[0].Selections[1]
+	if sourcesField.Alias != "monthly" || graphqlStringArg(sourcesField, "gate") != "monthly" {
+		t.Fatalf("expected aliased sources with default variable, got %+v", sourcesField)
+	}
+
+	patch := toGeneratedPatch(Patch{
+		ID:    "2.1",
+		Patch: "2.1",
+		Sources: []Source{
+			source("events", "Version Events", "always", nil, true, Rewards{Oroberyl: 5000}),
+			source("monthly", "Lunite Subscription", "monthly", nil, true, Rewards{Oroberyl: 2700}),
+		},
+	}, gameIDWuwa)
+	value, err := toGraphQLValue([]generatedPatch{patch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	selected, err := selectGraphQLValue("Patch", value, fields[0].Selections)
+	if err != nil {
+		t.Fatalf("selectGraphQLValue() error = %v", err)
+	}
+	body, err := json.Marshal(selected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"patch":"2.1","monthly":[{"id":"monthly","gate":"monthly"}]}]`
+	if string(body) != want {
+		t.Fatalf("selection = %s, want %s", body, want)
+	}
+
+	if _, err := selectGraphQLValue("Patch", value, []graphqlField{{Name: "bogus"}}); err == nil {
+		t.Fatalf("expected error for unknown field")
+	}
+	if _, err := parseGraphQLQuery(`mutation { patches }`, nil); err == nil {
+		t.Fatalf("expected mutations to be rejected")
+	}
+}
+
+func TestPatchInVersionRange(t *testing.T) {
+	cases := []struct {
+		id, from, to string
+		want         bool
+	}{
+		{"2.1", "", "", true},
+		{"2.1", "2.0", "2.1", true},
+		{"1.9", "2.0", "", false},
+		{"2.10", "", "2.9", false},
+	}
+	for _, tc := range cases {
+		if got := patchInVersionRange(tc.id, tc.from, tc.to); got != tc.want {
+			t.Errorf("patchInVersionRange(%q, %q, %q) = %t, want %t", tc.id, tc.from, tc.to, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package patchsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type readinessCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// checkDirWritable reports whether a sync could write into dir. A missing dir is judged by its nearest existing
+// parent, which the sync would create it under; the check itself creates no directories.
+func checkDirWritable(dir string) error {
+	existing := filepath.Clean(dir)
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", existing)
+			}
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return err
+		}
+		existing = parent
+	}
+	probe, err := os.CreateTemp(existing, ".patchsync-ready-*")
+	if err != nil {
+		return err
+	}
+	name := probe.Name()
+	probe.Close()
+	return os.Remove(name)
+}
+
+func spreadsheetProbeURL(spreadsheetID string) string {
+	if isPublishedSpreadsheetID(spreadsheetID) {
+		return fmt.Sprintf("https://docs.google.com/spreadsheets/d/e/%s/pubhtml", url.PathEscape(strings.TrimSpace(spreadsheetID)))
+	}
+	return fmt.Sprintf("https://docs.google.com/spreadsheets/d/%s/edit", url.PathEscape(strings.TrimSpace(spreadsheetID)))
+}
+
+// checkReadiness reports whether serve mode can actually sync. The spreadsheet probe hits Google, so it only runs on request.
+func checkReadiness(ctx context.Context, cfg SyncConfig, probe bool) []readinessCheck {
+	configured := []GameProfile{}
+	missing := []string{}
+	for _, gameID := range AvailableGameIDs() {
+		profile, err := ResolveGameProfile(gameID)
+		if err != nil || len(profile.DefaultSpreadsheetIDs) == 0 {
+			missing = append(missing, gameID)
+			continue
+		}
+		configured = append(configured, profile)
+	}
+	profilesCheck := readinessCheck{Name: "profiles", OK: len(configured) > 0}
+	switch {
+	case len(configured) == 0:
+		profilesCheck.Detail = "no game has a spreadsheet id configured"
+	case len(missing) > 0:
+		profilesCheck.Detail = "not configured: " + strings.Join(missing, ", ")
+	}
+	checks := []readinessCheck{profilesCheck}
+
+	outputCheck := readinessCheck{Name: "output", OK: true}
+	failures := []string{}
+	for _, profile := range configured {
+		outputPath := configuredOutputPath(cfg, profile)
+		dir := filepath.Dir(resolveOutputPath(outputPath))
+		if err := checkDirWritable(dir); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", profile.ID, err))
+		}
+	}
+	if strings.TrimSpace(cfg.APIDir) != "" {
+		if err := checkDirWritable(resolveAPIDir(cfg.APIDir)); err != nil {
+			failures = append(failures, fmt.Sprintf("api-dir: %v", err))
+		}
+	}
+	if len(failures) > 0 {
+		outputCheck.OK = false
+		outputCheck.Detail = strings.Join(failures, "; ")
+	}
+	checks = append(checks, outputCheck)
+
+	if probe {
+		timeout := cfg.ClientTimeout
+		if timeout <= 0 {
+			timeout = 20 * time.Second
+		}
+		client, clientErr := newOutboundClient(cfg.Outbound, timeout)
+		for _, profile := range configured {
+			spreadsheetID := profile.DefaultSpreadsheetIDs[0]
+			check := readinessCheck{Name: "spreadsheet:" + profile.ID, OK: true}
+			if clientErr != nil {
+				check.OK = false
+				check.Detail = clientErr.Error()
+			} else if _, err := fetchText(ctx, client, spreadsheetProbeURL(spreadsheetID)); err != nil {
+				check.OK = false
+				check.Detail = err.Error()
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// withoutDetails drops the error text from checks, which can name spreadsheet ids, proxies and local paths.
+func withoutDetails(checks []readinessCheck) []readinessCheck {
+	public := make([]readinessCheck, len(checks))
+	for idx, check := range checks {
+		public[idx] = readinessCheck{Name: check.Name, OK: check.OK}
+	}
+	return public
+}
+
+// registerHealthAPI adds the liveness and readiness checks. /readyz is public, so without a read token it only
+// reports which checks passed; the spreadsheet probe (?probe=true) fetches from Google and needs the token.
+func registerHealthAPI(mux *http.ServeMux, allowedOrigins map[string]struct{}, tokens *authTokens, cfg SyncConfig) {
+	liveness := func(w http.ResponseWriter, r *http.Request) {
+		if !withCORS(w, r, allowedOrigins) {
+			writeJSON(w, http.StatusForbidden, syncResponse{
+				OK:      false,
+				Message: "origin is not allowed",
+			})
+			return
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, http.StatusOK, syncResponse{
+			OK:      true,
+			Message: "patchsync service is running",
+		})
+	}
+	mux.HandleFunc("/health", liveness)
+	mux.HandleFunc("/healthz", liveness)
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !withCORS(w, r, allowedOrigins) {
+			writeJSON(w, http.StatusForbidden, syncResponse{
+				OK:      false,
+				Message: "origin is not allowed",
+			})
+			return
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		probe, _ := strconv.ParseBool(r.URL.Query().Get("probe"))
+		authorized := tokens.authorize(r, scopeRead)
+		if probe && !authorized {
+			writeJSON(w, http.StatusUnauthorized, syncResponse{
+				OK:      false,
+				Message: "unauthorized",
+			})
+			return
+		}
+		checks := checkReadiness(r.Context(), cfg, probe)
+		if !authorized {
+			checks = withoutDetails(checks)
+		}
+		for _, check := range checks {
+			if !check.OK {
+				writeJSON(w, http.StatusServiceUnavailable, syncResponse{
+					OK:      false,
+					Message: "patchsync service is not ready",
+					Checks:  checks,
+				})
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, syncResponse{
+			OK:      true,
+			Message: "patchsync service is ready",
+			Checks:  checks,
+		})
+	})
+}
@@ -0,0 +1,95 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadyzReportsMissingConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, gameID := range AvailableGameIDs() {
+		t.Setenv(spreadsheetEnvKeyForGame(gameID), "")
+		t.Setenv(legacySpreadsheetEnvKey(gameID), "")
+	}
+	mux := http.NewServeMux()
+	registerHealthAPI(mux, map[string]struct{}{}, nil, SyncConfig{})
+
+	live := httptest.NewRecorder()
+	mux.ServeHTTP(live, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if live.Code != http.StatusOK {
+		t.Fatalf("/healthz code = %d, want 200", live.Code)
+	}
+	notReady := httptest.NewRecorder()
+	mux.ServeHTTP(notReady, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if notReady.Code != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz without spreadsheets code = %d, want 503", notReady.Code)
+	}
+
+	t.Setenv("PATCHSYNC_SPREADSHEET_WUWA", "sheet-id")
+	checks := checkReadiness(t.Context(), SyncConfig{}, false)
+	if len(checks) != 2 || !checks[0].OK || !checks[1].OK {
+		t.Fatalf("unexpected checks %+v", checks)
+	}
+	ready := httptest.NewRecorder()
+	mux.ServeHTTP(ready, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if ready.Code != http.StatusOK {
+		t.Fatalf("/readyz code = %d, want 200: %s", ready.Code, ready.Body.String())
+	}
+}
+
+func TestReadyzHidesDetailsAndGatesProbe(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("PATCHSYNC_SPREADSHEET_WUWA", "sheet-id")
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := newAuthTokens("", "read:reader", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	registerHealthAPI(mux, map[string]struct{}{}, tokens, SyncConfig{GameID: gameIDWuwa, OutputPath: filepath.Join(blocker, "out", "wuwa.generated.js")})
+	get := func(path, token string) (int, syncResponse) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("X-Patchsync-Token", token)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var resp syncResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	code, resp := get("/readyz", "")
+	if code != http.StatusServiceUnavailable || len(resp.Checks) < 2 || resp.Checks[1].OK {
+		t.Fatalf("public /readyz = %d %+v, want the output check failing", code, resp)
+	}
+	for _, check := range resp.Checks {
+		if check.Detail != "" {
+			t.Fatalf("public /readyz leaked detail %q for %s", check.Detail, check.Name)
+		}
+	}
+	if _, resp := get("/readyz", "reader"); resp.Checks[1].Detail == "" {
+		t.Fatalf("authorized /readyz = %+v, want the output failure explained", resp.Checks)
+	}
+	if code, _ := get("/readyz?probe=true", ""); code != http.StatusUnauthorized {
+		t.Fatalf("/readyz?probe=true without a token = %d, want 401", code)
+	}
+}
+
+func TestCheckDirWritableCreatesNothing(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing", "nested")
+	if err := checkDirWritable(dir); err != nil {
+		t.Fatalf("checkDirWritable() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(dir)); !os.IsNotExist(err) {
+		t.Fatalf("checkDirWritable() created %s", filepath.Dir(dir))
+	}
+}
@@ -0,0 +1,183 @@
+package patchsync
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// diffPatchPair returns the field and source differences between two versions of one patch, or nil when equal.
+func diffPatchPair(previous, next Patch, gameID string) *patchDiff {
+	id := patchIDOrFallback(next)
+	diffs := comparePatchSets(map[string]Patch{id: previous}, map[string]Patch{id: next}, gameID)
+	if len(diffs) == 0 {
+		return nil
+	}
+	return &diffs[0]
+}
+
+type historyQuery struct {
+	GameID string
+	Patch  string
+	Source string
+	Since  time.Time
+	Limit  int
+}
+
+type historyEntry struct {
+	Timestamp string
+	Entry     patchChangeLogEntry
+}
+
+func parseHistorySince(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, raw); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q (use YYYY-MM-DD or RFC3339)", raw)
+	}
+	return parsed, nil
+}
+
+// queryChangeHistory returns matching change log entries, newest first. With a source set, only entries that
+// changed that source are kept and their details are narrowed to it.
+func queryChangeHistory(records []syncChangeLogRecord, query historyQuery) []historyEntry {
+	patchID := canonicalPatchID(query.Patch)
+	entries := []historyEntry{}
+	for idx := len(records) - 1; idx >= 0; idx-- {
+		record := records[idx]
+		if query.GameID != "" && record.GameID != query.GameID {
+			continue
+		}
+		if !query.Since.IsZero() {
+			ts, err := time.Parse(time.RFC3339, record.Timestamp)
+			if err != nil || ts.Before(query.Since) {
+				continue
+			}
+		}
+		for _, entry := range record.UpdatedPatches {
+			if patchID != "" && entry.Patch != patchID {
+				continue
+			}
+			if query.Source != "" {
+				if !slices.Contains(entry.ChangedSources, query.Source) {
+					continue
+				}
+				entry.Fields = nil
+				entry.Sources = slices.DeleteFunc(slices.Clone(entry.Sources), func(diff sourceDiff) bool {
+					return diff.SourceID != query.Source
+				})
+			}
+			entries = append(entries, historyEntry{Timestamp: record.Timestamp, Entry: entry})
+			if query.Limit > 0 && len(entries) >= query.Limit {
+				return entries
+			}
+		}
+	}
+	return entries
+}
+
+func writeHistoryReport(w io.Writer, entries []historyEntry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No matching changes.")
+		return
+	}
+	for _, item := range entries {
+		entry := item.Entry
+		fmt.Fprintf(w, "%s  %s %s", item.Timestamp, entry.Patch, entry.ChangeType)
+		if entry.ChangeType == "updated" && len(entry.Fields) == 0 && len(entry.Sources) == 0 && len(entry.ChangedSources) > 0 {
+			fmt.Fprintf(w, " (%s; no values recorded)", strings.Join(entry.ChangedSources, ", "))
+		}
+		fmt.Fprintln(w)
+		for _, field := range entry.Fields {
+			fmt.Fprintf(w, "    %s: %s -> %s\n", field.Field, field.A, field.B)
+		}
+		for _, source := range entry.Sources {
+			switch source.Status {
+			case "onlyA":
+				fmt.Fprintf(w, "    %s: removed\n", source.SourceID)
+			case "onlyB":
+				fmt.Fprintf(w, "    %s: added\n", source.SourceID)
+			default:
+				for _, field := range source.Fields {
+					fmt.Fprintf(w, "    %s %s: %s -> %s\n", source.SourceID, field.Field, field.A, field.B)
+				}
+			}
+		}
+	}
+}
+
+func runHistoryCommand(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	var (
+		gameID   string
+		patch    string
+		sourceID string
+		sinceRaw string
+		limit    int
+		logPath  string
+		dbPath   string
+		rebuild  bool
+	)
+	fs.StringVar(&gameID, "game", defaultGameID, fmt.Sprintf("Game id (%s)", strings.Join(AvailableGameIDs(), ", ")))
+	fs.StringVar(&patch, "patch", "", "Only show changes to this patch, for example 2.1")
+	fs.StringVar(&sourceID, "source", "", "Only show changes to this source id")
+	fs.StringVar(&sinceRaw, "since", "", "Only show changes at or after this date (YYYY-MM-DD or RFC3339)")
+	fs.IntVar(&limit, "limit", 0, "Show at most N changes, newest first (0 shows all)")
+	fs.StringVar(&logPath, "change-log", defaultChangeLogPath, "Change log to read; rotated segments next to it are included")
+	fs.StringVar(&dbPath, "db", "", "Read the SQLite mirror written by --history-db instead of the change log")
+	fs.BoolVar(&rebuild, "rebuild", false, "With --db, first refill the mirror from the change log")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	profile, err := ResolveGameProfile(gameID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "history failed: %v\n", err)
+		return 2
+	}
+	since, err := parseHistorySince(sinceRaw)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "history failed: %v\n", err)
+		return 2
+	}
+	if rebuild && strings.TrimSpace(dbPath) == "" {
+		fmt.Fprintln(os.Stderr, "history failed: --rebuild needs --db")
+		return 2
+	}
+	query := historyQuery{
+		GameID: profile.ID,
+		Patch:  patch,
+		Source: strings.TrimSpace(sourceID),
+		Since:  since,
+		Limit:  limit,
+	}
+	var records []syncChangeLogRecord
+	if strings.TrimSpace(dbPath) != "" {
+		if rebuild {
+			count, rebuildErr := rebuildHistoryDB(dbPath, logPath)
+			if rebuildErr != nil {
+				fmt.Fprintf(os.Stderr, "history failed: rebuild %s: %v\n", dbPath, rebuildErr)
+				return 1
+			}
+			fmt.Fprintf(os.Stderr, "rebuilt %s from %d change log records\n", dbPath, count)
+		}
+		records, err = readHistoryDB(dbPath, query)
+	} else {
+		records, err = readFullChangeLog(logPath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "history failed: %v\n", err)
+		return 1
+	}
+	writeHistoryReport(os.Stdout, queryChangeHistory(records, query))
+	return 0
+}
@@ -0,0 +1,63 @@
+package patchsync
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChangeHistoryRecordsValuesAcrossSyncs(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		Clock:           FixedClock(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)),
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+	}
+	if _, err := RunSync(t.Context(), cfg); err != nil {
+		t.Fatalf("first RunSync() error = %v", err)
+	}
+	cfg.Clock = FixedClock(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	cfg.Fetcher = fakeSheetFetcher{sheets: map[string]string{
+		"3.4": strings.NewReplacer(
+			"Version Events,5000,30", "Version Events,5000,31",
+			"Total F2P,11000,45", "Total F2P,11000,46",
+			"Total Paid,15280,47", "Total Paid,15280,48",
+		).Replace(wuwaSheetCSV("3.4")),
+	}}
+	if _, err := RunSync(t.Context(), cfg); err != nil {
+		t.Fatalf("second RunSync() error = %v", err)
+	}
+
+	records, err := readFullChangeLog(defaultChangeLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := queryChangeHistory(records, historyQuery{GameID: gameIDWuwa, Patch: "3.4"})
+	if len(entries) != 2 || entries[0].Entry.ChangeType != "updated" || entries[1].Entry.ChangeType != "added" {
+		t.Fatalf("history = %+v, want updated then added", entries)
+	}
+	updated := entries[0].Entry
+	if len(updated.ChangedSources) == 0 || len(updated.Sources) == 0 {
+		t.Fatalf("updated entry has no source values: %+v", updated)
+	}
+
+	sourceID := updated.ChangedSources[0]
+	since, _ := parseHistorySince("2026-02-15")
+	narrowed := queryChangeHistory(records, historyQuery{GameID: gameIDWuwa, Patch: "3.4", Source: sourceID, Since: since})
+	if len(narrowed) != 1 {
+		t.Fatalf("narrowed history = %+v, want one entry", narrowed)
+	}
+	var sb strings.Builder
+	writeHistoryReport(&sb, narrowed)
+	if report := sb.String(); !strings.Contains(report, "2026-03-01T00:00:00Z  3.4 updated") || !strings.Contains(report, "30 -> 31") {
+		t.Fatalf("report does not show the change:\n%s", report)
+	}
+}
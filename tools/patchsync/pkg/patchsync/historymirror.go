@@ -0,0 +1,112 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"endfield-bookkeeper/tools/patchsync/pkg/historydb"
+)
+
+// historyDBMu serializes writes to the SQLite history mirror, which /sync-all appends to from several goroutines.
+var historyDBMu sync.Mutex
+
+func historyDBRecord(record syncChangeLogRecord) (historydb.Record, error) {
+	converted := historydb.Record{Timestamp: record.Timestamp, GameID: record.GameID, SpreadsheetID: record.SpreadsheetID}
+	for _, entry := range record.UpdatedPatches {
+		raw, err := json.Marshal(entry)
+		if err != nil {
+			return historydb.Record{}, err
+		}
+		converted.Changes = append(converted.Changes, historydb.Change{
+			Patch:      entry.Patch,
+			ChangeType: entry.ChangeType,
+			Sources:    entry.ChangedSources,
+			Entry:      raw,
+		})
+	}
+	return converted, nil
+}
+
+// mirrorChangeLogRecord adds record to the history database at path, next to the JSONL change log.
+func mirrorChangeLogRecord(path string, record syncChangeLogRecord) error {
+	converted, err := historyDBRecord(record)
+	if err != nil {
+		return err
+	}
+	historyDBMu.Lock()
+	defer historyDBMu.Unlock()
+	db, err := historydb.Open(resolveOutputPath(path))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Add(converted)
+}
+
+// rebuildHistoryDB replaces the content of the history database with the full change log, rotated segments
+// included, and returns the number of records stored.
+func rebuildHistoryDB(dbPath, logPath string) (int, error) {
+	records, err := readFullChangeLog(logPath)
+	if err != nil {
+		return 0, err
+	}
+	converted := make([]historydb.Record, 0, len(records))
+	for _, record := range records {
+		next, err := historyDBRecord(record)
+		if err != nil {
+			return 0, err
+		}
+		converted = append(converted, next)
+	}
+	historyDBMu.Lock()
+	defer historyDBMu.Unlock()
+	db, err := historydb.Open(resolveOutputPath(dbPath))
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	return len(converted), db.Replace(converted)
+}
+
+// readHistoryDB returns the changes matching query from the history database as change log records, oldest
+// first and one entry each, ready for queryChangeHistory.
+func readHistoryDB(dbPath string, query historyQuery) ([]syncChangeLogRecord, error) {
+	path := resolveFilePath(dbPath)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no history database at %s (sync with --history-db, or add --rebuild)", dbPath)
+	}
+	historyDBMu.Lock()
+	defer historyDBMu.Unlock()
+	db, err := historydb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	results, err := db.Query(historydb.Query{
+		GameID: query.GameID,
+		Patch:  canonicalPatchID(query.Patch),
+		Source: query.Source,
+		Since:  query.Since,
+		Limit:  query.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	records := make([]syncChangeLogRecord, len(results))
+	for idx, result := range results {
+		var entry patchChangeLogEntry
+		if err := json.Unmarshal(result.Change.Entry, &entry); err != nil {
+			return nil, fmt.Errorf("history database entry: %w", err)
+		}
+		records[len(results)-1-idx] = syncChangeLogRecord{
+			Timestamp:      result.Timestamp,
+			GameID:         result.GameID,
+			SpreadsheetID:  result.SpreadsheetID,
+			UpdatedPatches: []patchChangeLogEntry{entry},
+		}
+	}
+	return records, nil
+}
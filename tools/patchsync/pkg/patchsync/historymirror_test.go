@@ -0,0 +1,73 @@
+package patchsync
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryDBMirrorsChangeLog(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	dbPath := filepath.Join(dir, "history.db")
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		HistoryDB:       dbPath,
+		Clock:           FixedClock(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)),
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+	}
+	if _, err := RunSync(t.Context(), cfg); err != nil {
+		t.Fatalf("first RunSync() error = %v", err)
+	}
+	cfg.Clock = FixedClock(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	cfg.Fetcher = fakeSheetFetcher{sheets: map[string]string{
+		"3.4": strings.NewReplacer(
+			"Version Events,5000,30", "Version Events,5000,31",
+			"Total F2P,11000,45", "Total F2P,11000,46",
+			"Total Paid,15280,47", "Total Paid,15280,48",
+		).Replace(wuwaSheetCSV("3.4")),
+	}}
+	if _, err := RunSync(t.Context(), cfg); err != nil {
+		t.Fatalf("second RunSync() error = %v", err)
+	}
+
+	records, err := readFullChangeLog(defaultChangeLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	since, _ := parseHistorySince("2026-02-15")
+	for _, query := range []historyQuery{
+		{GameID: gameIDWuwa, Patch: "3.4"},
+		{GameID: gameIDWuwa, Patch: "3.4 (STC)", Source: "events", Since: since},
+		{GameID: gameIDWuwa, Limit: 1},
+	} {
+		mirrored, err := readHistoryDB(dbPath, query)
+		if err != nil {
+			t.Fatalf("readHistoryDB(%+v) error = %v", query, err)
+		}
+		want := queryChangeHistory(records, query)
+		if got := queryChangeHistory(mirrored, query); len(want) == 0 || !reflect.DeepEqual(got, want) {
+			t.Fatalf("history from the database = %+v, want %+v", got, want)
+		}
+	}
+
+	rebuiltPath := filepath.Join(dir, "rebuilt.db")
+	if count, err := rebuildHistoryDB(rebuiltPath, defaultChangeLogPath); err != nil || count != 2 {
+		t.Fatalf("rebuildHistoryDB() = %d, %v; want 2 records", count, err)
+	}
+	rebuilt, err := readHistoryDB(rebuiltPath, historyQuery{GameID: gameIDWuwa})
+	if err != nil || len(rebuilt) != 2 {
+		t.Fatalf("rebuilt history = %+v, %v", rebuilt, err)
+	}
+	if _, err := readHistoryDB(filepath.Join(dir, "missing.db"), historyQuery{}); err == nil {
+		t.Fatal("readHistoryDB() of a missing file succeeded")
+	}
+}
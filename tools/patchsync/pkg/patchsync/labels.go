@@ -0,0 +1,94 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const defaultTranslationsDir = "tools/patchsync/translations"
+
+var localePattern = regexp.MustCompile(`^[a-z]{2}(?:-[A-Za-z0-9]{2,8})?$`)
+
+func translationsPathForGame(dir, gameID string) string {
+	if strings.TrimSpace(dir) == "" {
+		dir = defaultTranslationsDir
+	}
+	return filepath.Join(resolveFilePath(dir), gameID+".json")
+}
+
+// readSourceTranslations reads {"<locale>": {"<source id or English label>": "<label>"}}. A missing file
+// means the game has no translations.
+func readSourceTranslations(path string) (map[string]map[string]string, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	translations := map[string]map[string]string{}
+	if err := json.Unmarshal(body, &translations); err != nil {
+		return nil, fmt.Errorf("parse translations file %s: %w", path, err)
+	}
+	for locale := range translations {
+		if !localePattern.MatchString(locale) {
+			return nil, fmt.Errorf("translations file %s: invalid locale %q (use codes like en, ru, zh)", path, locale)
+		}
+	}
+	return translations, nil
+}
+
+// buildSourceLabels maps every source ID of patches to its label per locale. English comes from the sheets
+// (the newest patch wins) unless the file overrides it; other locales only list the sources they translate.
+// The second result lists translation keys that matched no source.
+func buildSourceLabels(patches []Patch, translations map[string]map[string]string) (map[string]map[string]string, []string) {
+	if len(translations) == 0 {
+		return nil, nil
+	}
+	sheetLabels := map[string]string{}
+	for _, patch := range patches {
+		for _, src := range patch.Sources {
+			if src.Label != "" {
+				sheetLabels[src.ID] = src.Label
+			}
+		}
+	}
+	english := maps.Clone(sheetLabels)
+	labels := map[string]map[string]string{"en": english}
+	used := map[string]struct{}{}
+	for locale, entries := range translations {
+		localized := map[string]string{}
+		for id, label := range sheetLabels {
+			switch {
+			case entries[id] != "":
+				localized[id] = entries[id]
+				used[locale+"/"+id] = struct{}{}
+			case entries[label] != "":
+				localized[id] = entries[label]
+				used[locale+"/"+label] = struct{}{}
+			}
+		}
+		if locale == "en" {
+			maps.Copy(english, localized)
+			continue
+		}
+		labels[locale] = localized
+	}
+	unused := []string{}
+	for locale, entries := range translations {
+		for key := range entries {
+			if _, ok := used[locale+"/"+key]; !ok {
+				unused = append(unused, locale+"/"+key)
+			}
+		}
+	}
+	sort.Strings(unused)
+	return labels, unused
+}
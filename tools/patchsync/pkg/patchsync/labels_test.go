@@ -0,0 +1,60 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuildSourceLabels(t *testing.T) {
+	patches := []Patch{
+		{Patch: "1.0", Sources: []Source{
+			source("monumental", "Monument Etching", "always", nil, true, zeroRewards()),
+			source("events", "Events", "always", nil, true, zeroRewards()),
+		}},
+		{Patch: "1.1", Sources: []Source{
+			source("monumental", "Monumental Etching", "always", nil, true, zeroRewards()),
+		}},
+	}
+	if labels, _ := buildSourceLabels(patches, nil); labels != nil {
+		t.Fatalf("labels without translations = %v, want nil", labels)
+	}
+	labels, unused := buildSourceLabels(patches, map[string]map[string]string{
+		"ru": {"monumental": "Монументальная гравировка", "Events": "События"},
+		"zh": {"monumental": "纪念铭刻", "retired": "旧"},
+		"en": {"events": "Limited Events"},
+	})
+	want := map[string]map[string]string{
+		"en": {"monumental": "Monumental Etching", "events": "Limited Events"},
+		"ru": {"monumental": "Монументальная гравировка", "events": "События"},
+		"zh": {"monumental": "纪念铭刻"},
+	}
+	if !reflect.DeepEqual(labels, want) {
+		t.Fatalf("labels = %v, want %v", labels, want)
+	}
+	if want := []string{"zh/retired"}; !reflect.DeepEqual(unused, want) {
+		t.Fatalf("unused = %v, want %v", unused, want)
+	}
+}
+
+func TestReadSourceTranslations(t *testing.T) {
+	dir := t.TempDir()
+	if translations, err := readSourceTranslations(translationsPathForGame(dir, gameIDWuwa)); err != nil || translations != nil {
+		t.Fatalf("missing file = %v, %v", translations, err)
+	}
+	path := translationsPathForGame(dir, gameIDWuwa)
+	if err := os.WriteFile(path, []byte(`{"ru":{"events":"События"},"zh-Hans":{}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	translations, err := readSourceTranslations(path)
+	if err != nil || translations["ru"]["events"] != "События" {
+		t.Fatalf("readSourceTranslations() = %v, %v", translations, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bad.json"), []byte(`{"Russian":{}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSourceTranslations(filepath.Join(dir, "bad.json")); err == nil {
+		t.Fatalf("expected an invalid locale to be rejected")
+	}
+}
@@ -0,0 +1,359 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultLedgerPath = "tools/patchsync/ledger.json"
+
+var ledgerMu sync.Mutex
+
+type ledgerAdjustment struct {
+	ID       int     `json:"id"`
+	Date     string  `json:"date"`
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+	Note     string  `json:"note,omitempty"`
+}
+
+type ledgerEntry struct {
+	AsOf           string             `json:"asOf"`
+	Balances       map[string]float64 `json:"balances"`
+	MonthlySub     bool               `json:"monthlySub"`
+	BattlePassTier int                `json:"battlePassTier"`
+	Pity           int                `json:"pity"`
+	Guaranteed     bool               `json:"guaranteed"`
+	Adjustments    []ledgerAdjustment `json:"adjustments"`
+	NextID         int                `json:"nextId"`
+	UpdatedAt      string             `json:"updatedAt"`
+}
+
+type ledgerView struct {
+	GameID    string             `json:"gameId"`
+	Effective map[string]float64 `json:"effective"`
+	ledgerEntry
+}
+
+type ledgerUpdateRequest struct {
+	AsOf           string             `json:"asOf"`
+	Balances       map[string]float64 `json:"balances"`
+	MonthlySub     *bool              `json:"monthlySub"`
+	BattlePassTier *int               `json:"battlePassTier"`
+	Pity           *int               `json:"pity"`
+	Guaranteed     *bool              `json:"guaranteed"`
+}
+
+type ledgerAdjustmentRequest struct {
+	Date     string  `json:"date"`
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+	Note     string  `json:"note"`
+}
+
+func readLedgerFile(path string) (map[string]ledgerEntry, error) {
+	result := map[string]ledgerEntry{}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return result, nil
+		}
+		return nil, err
+	}
+	if strings.TrimSpace(string(body)) == "" {
+		return result, nil
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parse ledger file: %w", err)
+	}
+	return result, nil
+}
+
+func writeLedgerFile(path string, ledger map[string]ledgerEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create ledger directory: %w", err)
+	}
+	if err := writeJSONFile(path, ledger); err != nil {
+		return fmt.Errorf("write ledger: %w", err)
+	}
+	return nil
+}
+
+func readLedgerEntry(path, gameID string) (ledgerEntry, error) {
+	ledgerMu.Lock()
+	defer ledgerMu.Unlock()
+	ledger, err := readLedgerFile(path)
+	if err != nil {
+		return ledgerEntry{}, err
+	}
+	return ledger[gameID], nil
+}
+
+func updateLedgerEntry(path, gameID string, now time.Time, update func(entry *ledgerEntry) error) (ledgerEntry, error) {
+	ledgerMu.Lock()
+	defer ledgerMu.Unlock()
+	ledger, err := readLedgerFile(path)
+	if err != nil {
+		return ledgerEntry{}, err
+	}
+	entry := ledger[gameID]
+	if err := update(&entry); err != nil {
+		return ledgerEntry{}, err
+	}
+	entry.UpdatedAt = now.UTC().Format(time.RFC3339)
+	ledger[gameID] = entry
+	if err := writeLedgerFile(path, ledger); err != nil {
+		return ledgerEntry{}, err
+	}
+	return entry, nil
+}
+
+// ledgerCurrencyKey returns the key the ledger keeps a currency under: the game's currency key for the reward
+// field key maps to. "Astrite", "astrite", and "oroberyl" are then one WuWa balance instead of three.
+func ledgerCurrencyKey(gameID, key string) (string, error) {
+	probe := Rewards{}
+	field := probe.mappedField(key)
+	if field == nil {
+		return "", fmt.Errorf("unknown currency %q", key)
+	}
+	*field = 1
+	for _, currency := range currenciesForGame(gameID) {
+		if currency.value(probe) != 0 {
+			return currency.Key, nil
+		}
+	}
+	return normalizeRewardKey(key), nil
+}
+
+func normalizeLedgerDate(raw string, fallback time.Time) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return fallback.Format(isoDateLayout), nil
+	}
+	parsed, err := time.Parse(isoDateLayout, trimmed)
+	if err != nil {
+		return "", fmt.Errorf("date %q must be YYYY-MM-DD", raw)
+	}
+	return parsed.Format(isoDateLayout), nil
+}
+
+// Adjustments dated before the balance snapshot are already reflected in it. Keys are folded with
+// ledgerCurrencyKey, which also merges balances a ledger file recorded under two names.
+func (e ledgerEntry) effectiveBalances(gameID string) map[string]float64 {
+	effective := map[string]float64{}
+	add := func(key string, value float64) {
+		if canonical, err := ledgerCurrencyKey(gameID, key); err == nil {
+			key = canonical
+		}
+		effective[key] += value
+	}
+	for key, value := range e.Balances {
+		add(key, value)
+	}
+	for _, adjustment := range e.Adjustments {
+		if adjustment.Date < e.AsOf {
+			continue
+		}
+		add(adjustment.Currency, adjustment.Amount)
+	}
+	return effective
+}
+
+func applyLedgerUpdate(entry *ledgerEntry, gameID string, req ledgerUpdateRequest, now time.Time) error {
+	if req.Balances != nil {
+		balances := make(map[string]float64, len(req.Balances))
+		for key, value := range req.Balances {
+			canonical, err := ledgerCurrencyKey(gameID, key)
+			if err != nil {
+				return err
+			}
+			balances[canonical] += value
+		}
+		asOf, err := normalizeLedgerDate(req.AsOf, now)
+		if err != nil {
+			return err
+		}
+		entry.Balances = balances
+		entry.AsOf = asOf
+	}
+	if req.MonthlySub != nil {
+		entry.MonthlySub = *req.MonthlySub
+	}
+	if req.BattlePassTier != nil {
+		entry.BattlePassTier = normalizeBattlePassTier(*req.BattlePassTier)
+	}
+	if req.Pity != nil {
+		if *req.Pity < 0 {
+			return errors.New("pity must not be negative")
+		}
+		entry.Pity = *req.Pity
+	}
+	if req.Guaranteed != nil {
+		entry.Guaranteed = *req.Guaranteed
+	}
+	return nil
+}
+
+func addLedgerAdjustment(entry *ledgerEntry, gameID string, req ledgerAdjustmentRequest, now time.Time) error {
+	currency, err := ledgerCurrencyKey(gameID, req.Currency)
+	if err != nil {
+		return err
+	}
+	date, err := normalizeLedgerDate(req.Date, now)
+	if err != nil {
+		return err
+	}
+	entry.NextID++
+	entry.Adjustments = append(entry.Adjustments, ledgerAdjustment{
+		ID:       entry.NextID,
+		Date:     date,
+		Currency: currency,
+		Amount:   req.Amount,
+		Note:     strings.TrimSpace(req.Note),
+	})
+	return nil
+}
+
+func removeLedgerAdjustment(entry *ledgerEntry, id int) error {
+	for idx, adjustment := range entry.Adjustments {
+		if adjustment.ID == id {
+			entry.Adjustments = append(entry.Adjustments[:idx], entry.Adjustments[idx+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("adjustment %d not found", id)
+}
+
+func applyLedgerToPlan(req *planRequest, entry ledgerEntry) {
+	req.Owned = entry.effectiveBalances(req.GameID)
+	req.Pity = entry.Pity
+	req.MonthlySub = entry.MonthlySub
+	req.BattlePassTier = entry.BattlePassTier
+	if strings.TrimSpace(req.StartDate) == "" {
+		req.StartDate = entry.AsOf
+	}
+}
+
+func registerLedgerAPI(mux *http.ServeMux, allowedOrigins map[string]struct{}, tokens *authTokens, ledgerPath string, cfg SyncConfig) {
+	clock := clockOrSystem(cfg.Clock)
+	// guard runs the shared CORS/auth checks and resolves the game from the path.
+	guard := func(w http.ResponseWriter, r *http.Request) (string, bool) {
+		scope := scopeSync
+		if r.Method == http.MethodGet {
+			scope = scopeRead
+		}
+		if !withCORS(w, r, allowedOrigins) {
+			writeJSON(w, http.StatusForbidden, syncResponse{
+				OK:      false,
+				Message: "origin is not allowed",
+			})
+			return "", false
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, POST, DELETE, OPTIONS")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return "", false
+		}
+		if !tokens.authorize(r, scope) {
+			writeJSON(w, http.StatusUnauthorized, syncResponse{
+				OK:      false,
+				Message: "unauthorized",
+			})
+			return "", false
+		}
+		profile, err := ResolveGameProfile(r.PathValue("game"))
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, syncResponse{
+				OK:      false,
+				Message: err.Error(),
+			})
+			return "", false
+		}
+		return profile.ID, true
+	}
+	respond := func(w http.ResponseWriter, gameID string, entry ledgerEntry, err error) {
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, syncResponse{
+				OK:      false,
+				Message: err.Error(),
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, syncResponse{
+			OK:      true,
+			Message: "ledger loaded",
+			GameID:  gameID,
+			Ledger:  &ledgerView{GameID: gameID, Effective: entry.effectiveBalances(gameID), ledgerEntry: entry},
+		})
+	}
+
+	mux.HandleFunc("OPTIONS /ledger/{game}/{rest...}", func(w http.ResponseWriter, r *http.Request) {
+		guard(w, r)
+	})
+	mux.HandleFunc("OPTIONS /ledger/{game}", func(w http.ResponseWriter, r *http.Request) {
+		guard(w, r)
+	})
+	mux.HandleFunc("GET /ledger/{game}", func(w http.ResponseWriter, r *http.Request) {
+		gameID, ok := guard(w, r)
+		if !ok {
+			return
+		}
+		entry, err := readLedgerEntry(ledgerPath, gameID)
+		respond(w, gameID, entry, err)
+	})
+	mux.HandleFunc("PUT /ledger/{game}", func(w http.ResponseWriter, r *http.Request) {
+		gameID, ok := guard(w, r)
+		if !ok {
+			return
+		}
+		var req ledgerUpdateRequest
+		if err := parseSyncRequestBody(r, &req, cfg.maxBodyBytes()); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		now := clock.Now().UTC()
+		entry, err := updateLedgerEntry(ledgerPath, gameID, now, func(entry *ledgerEntry) error {
+			return applyLedgerUpdate(entry, gameID, req, now)
+		})
+		respond(w, gameID, entry, err)
+	})
+	mux.HandleFunc("POST /ledger/{game}/adjustments", func(w http.ResponseWriter, r *http.Request) {
+		gameID, ok := guard(w, r)
+		if !ok {
+			return
+		}
+		var req ledgerAdjustmentRequest
+		if err := parseSyncRequestBody(r, &req, cfg.maxBodyBytes()); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		now := clock.Now().UTC()
+		entry, err := updateLedgerEntry(ledgerPath, gameID, now, func(entry *ledgerEntry) error {
+			return addLedgerAdjustment(entry, gameID, req, now)
+		})
+		respond(w, gameID, entry, err)
+	})
+	mux.HandleFunc("DELETE /ledger/{game}/adjustments/{id}", func(w http.ResponseWriter, r *http.Request) {
+		gameID, ok := guard(w, r)
+		if !ok {
+			return
+		}
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			respond(w, gameID, ledgerEntry{}, errors.New("adjustment id must be an integer"))
+			return
+		}
+		entry, err := updateLedgerEntry(ledgerPath, gameID, clock.Now(), func(entry *ledgerEntry) error {
+			return removeLedgerAdjustment(entry, id)
+		})
+		respond(w, gameID, entry, err)
+	})
+}
@@ -0,0 +1,97 @@
+package patchsync
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLedgerUpdateAndAdjustments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	monthly := true
+	tier := 3
+	_, err := updateLedgerEntry(path, gameIDWuwa, now, func(entry *ledgerEntry) error {
+		return applyLedgerUpdate(entry, gameIDWuwa, ledgerUpdateRequest{
+			Balances:       map[string]float64{"astrite": 3200, "radiantTide": 4},
+			MonthlySub:     &monthly,
+			BattlePassTier: &tier,
+		}, now)
+	})
+	if err != nil {
+		t.Fatalf("update error = %v", err)
+	}
+	for _, req := range []ledgerAdjustmentRequest{
+		{Date: "2025-02-20", Currency: "astrite", Amount: 999},
+		{Currency: "astrite", Amount: -1600, Note: "pulled"},
+		{Date: "2025-03-05", Currency: "radiantTide", Amount: 2},
+	} {
+		if _, err := updateLedgerEntry(path, gameIDWuwa, now, func(entry *ledgerEntry) error {
+			return addLedgerAdjustment(entry, gameIDWuwa, req, now)
+		}); err != nil {
+			t.Fatalf("add adjustment error = %v", err)
+		}
+	}
+
+	entry, err := readLedgerEntry(path, gameIDWuwa)
+	if err != nil {
+		t.Fatalf("readLedgerEntry() error = %v", err)
+	}
+	if entry.AsOf != "2025-03-01" || !entry.MonthlySub || entry.BattlePassTier != 3 || len(entry.Adjustments) != 3 {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	// The adjustment dated before the snapshot is already part of the recorded balance.
+	effective := entry.effectiveBalances(gameIDWuwa)
+	if effective["astrite"] != 1600 || effective["radiantTide"] != 6 {
+		t.Fatalf("effective = %v, want astrite 1600 and radiantTide 6", effective)
+	}
+
+	if _, err := updateLedgerEntry(path, gameIDWuwa, now, func(entry *ledgerEntry) error {
+		return removeLedgerAdjustment(entry, 2)
+	}); err != nil {
+		t.Fatalf("remove adjustment error = %v", err)
+	}
+	entry, _ = readLedgerEntry(path, gameIDWuwa)
+	if got := entry.effectiveBalances(gameIDWuwa)["astrite"]; got != 3200 {
+		t.Fatalf("astrite after removal = %v, want 3200", got)
+	}
+	if err := removeLedgerAdjustment(&entry, 2); err == nil {
+		t.Fatalf("expected missing adjustment error")
+	}
+	if err := addLedgerAdjustment(&entry, gameIDWuwa, ledgerAdjustmentRequest{Currency: "gold", Amount: 1}, now); err == nil {
+		t.Fatalf("expected unknown currency error")
+	}
+
+	req := planRequest{GameID: gameIDWuwa, Owned: map[string]float64{"astrite": 1}}
+	applyLedgerToPlan(&req, entry)
+	if req.Owned["astrite"] != 3200 || req.StartDate != "2025-03-01" || !req.MonthlySub || req.BattlePassTier != 3 {
+		t.Fatalf("plan request not filled from ledger: %+v", req)
+	}
+}
+
+func TestLedgerFoldsCurrencyNames(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	entry := ledgerEntry{}
+	if err := applyLedgerUpdate(&entry, gameIDWuwa, ledgerUpdateRequest{Balances: map[string]float64{"astrite": 1000, "Astrite": 600}}, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := addLedgerAdjustment(&entry, gameIDWuwa, ledgerAdjustmentRequest{Currency: "oroberyl", Amount: 400}, now); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Adjustments[0].Currency != "astrite" {
+		t.Fatalf("adjustment currency = %q, want astrite", entry.Adjustments[0].Currency)
+	}
+	if effective := entry.effectiveBalances(gameIDWuwa); len(effective) != 1 || effective["astrite"] != 2000 {
+		t.Fatalf("effective = %v, want astrite 2000", effective)
+	}
+
+	// Ledgers written before the keys were folded can hold both names.
+	entry = ledgerEntry{AsOf: "2025-03-01", Balances: map[string]float64{"astrite": 1000, "oroberyl": 500}}
+	if effective := entry.effectiveBalances(gameIDWuwa); effective["astrite"] != 1500 {
+		t.Fatalf("effective of a mixed ledger = %v, want astrite 1500", effective)
+	}
+	owned, err := ownedRewardsFromMap(map[string]float64{"astrite": 1000, "oroberyl": 500})
+	if err != nil || owned.Oroberyl != 1500 {
+		t.Fatalf("ownedRewardsFromMap() = %+v, %v; want 1500 oroberyl", owned, err)
+	}
+}
@@ -0,0 +1,130 @@
+package patchsync
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxBodyBytes = 1 << 20
+	defaultSyncRate     = 6.0
+	defaultSyncBurst    = 3
+)
+
+var errRequestBodyTooLarge = errors.New("request body too large")
+
+// bodyTooLargeError matches errRequestBodyTooLarge and names the limit that was exceeded.
+type bodyTooLargeError struct {
+	limit int64
+}
+
+func (e bodyTooLargeError) Error() string {
+	return fmt.Sprintf("request body exceeds %d bytes", e.limit)
+}
+
+func (e bodyTooLargeError) Is(target error) bool {
+	return target == errRequestBodyTooLarge
+}
+
+// maxBodyBytes is the largest accepted request body or socket message; zero means defaultMaxBodyBytes.
+func (cfg SyncConfig) maxBodyBytes() int64 {
+	if cfg.MaxBodyBytes <= 0 {
+		return defaultMaxBodyBytes
+	}
+	return cfg.MaxBodyBytes
+}
+
+func writeBodyError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errRequestBodyTooLarge) {
+		writeJSON(w, http.StatusRequestEntityTooLarge, syncResponse{
+			OK:      false,
+			Message: err.Error(),
+		})
+		return
+	}
+	writeJSON(w, http.StatusBadRequest, syncResponse{
+		OK:      false,
+		Message: "invalid JSON body",
+	})
+}
+
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a per-client token bucket. A zero rate disables limiting.
+type rateLimiter struct {
+	mu        sync.Mutex
+	perMinute float64
+	burst     float64
+	buckets   map[string]*rateBucket
+	now       func() time.Time
+}
+
+func newRateLimiter(perMinute float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		perMinute: perMinute,
+		burst:     math.Max(1, float64(burst)),
+		buckets:   map[string]*rateBucket{},
+		now:       time.Now,
+	}
+}
+
+// allow takes a token for key and otherwise reports how long until the next one is available.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	if l == nil || l.perMinute <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	perSecond := l.perMinute / 60
+	bucket, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= 4096 {
+			l.pruneLocked(now, perSecond)
+		}
+		bucket = &rateBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*perSecond)
+	bucket.last = now
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second))
+	return false, wait
+}
+
+func (l *rateLimiter) pruneLocked(now time.Time, perSecond float64) {
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*perSecond >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func writeRateLimited(w http.ResponseWriter, wait time.Duration) {
+	seconds := max(1, int(math.Ceil(wait.Seconds())))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeJSON(w, http.StatusTooManyRequests, syncResponse{
+		OK:      false,
+		Message: fmt.Sprintf("rate limit exceeded; retry in %ds", seconds),
+	})
+}
@@ -0,0 +1,58 @@
+package patchsync
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterRefillsPerClient(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(6, 2)
+	limiter.now = func() time.Time { return now }
+	for idx := 0; idx < 2; idx++ {
+		if ok, _ := limiter.allow("10.0.0.1"); !ok {
+			t.Fatalf("request %d within burst was rejected", idx+1)
+		}
+	}
+	ok, wait := limiter.allow("10.0.0.1")
+	if ok || wait != 10*time.Second {
+		t.Fatalf("third request ok=%v wait=%v, want rejected with 10s wait", ok, wait)
+	}
+	if ok, _ := limiter.allow("10.0.0.2"); !ok {
+		t.Fatalf("another client should have its own bucket")
+	}
+	now = now.Add(10 * time.Second)
+	if ok, _ := limiter.allow("10.0.0.1"); !ok {
+		t.Fatalf("request after refill was rejected")
+	}
+	if ok, _ := newRateLimiter(0, 1).allow("10.0.0.1"); !ok {
+		t.Fatalf("zero rate should disable limiting")
+	}
+
+	recorder := httptest.NewRecorder()
+	writeRateLimited(recorder, 1500*time.Millisecond)
+	if recorder.Code != http.StatusTooManyRequests || recorder.Header().Get("Retry-After") != "2" {
+		t.Fatalf("code=%d retry-after=%q", recorder.Code, recorder.Header().Get("Retry-After"))
+	}
+}
+
+func TestParseSyncRequestBodyTooLarge(t *testing.T) {
+	cfg := SyncConfig{MaxBodyBytes: 16}
+	var req syncRequest
+	err := parseSyncRequestBody(httptest.NewRequest(http.MethodPost, "/sync", strings.NewReader(`{"gameId": "wuthering-waves"}`)), &req, cfg.maxBodyBytes())
+	if !errors.Is(err, errRequestBodyTooLarge) {
+		t.Fatalf("err = %v, want errRequestBodyTooLarge", err)
+	}
+	recorder := httptest.NewRecorder()
+	writeBodyError(recorder, err)
+	if recorder.Code != http.StatusRequestEntityTooLarge || !strings.Contains(recorder.Body.String(), "exceeds 16 bytes") {
+		t.Fatalf("code = %d body = %s, want 413 naming the limit", recorder.Code, recorder.Body.String())
+	}
+	if err := parseSyncRequestBody(httptest.NewRequest(http.MethodPost, "/sync", strings.NewReader(`{"dryRun":true}`)), &req, cfg.maxBodyBytes()); err != nil || !req.DryRun {
+		t.Fatalf("small body err=%v dryRun=%v", err, req.DryRun)
+	}
+}
@@ -0,0 +1,106 @@
+package patchsync
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Merge policies decide, per field, what a resync does when a sheet patch replaces one already in the generated
+// file: take the sheet value, keep the existing one, or combine both.
+const (
+	mergeFromSheet    = "sheet"
+	mergeKeepExisting = "existing"
+	mergeUnion        = "union"
+)
+
+var mergePolicyFields = map[string][]string{
+	"versionName":  {mergeFromSheet, mergeKeepExisting},
+	"startDate":    {mergeFromSheet, mergeKeepExisting},
+	"durationDays": {mergeFromSheet, mergeKeepExisting},
+	"tags":         {mergeFromSheet, mergeKeepExisting, mergeUnion},
+	"notes":        {mergeFromSheet, mergeKeepExisting, mergeUnion},
+	"sourceLabels": {mergeFromSheet, mergeKeepExisting},
+	"sourceNotes":  {mergeFromSheet, mergeKeepExisting},
+}
+
+// mergePolicies is the "merge" block of an overrides file, mapping a field to its policy. Fields it does not
+// list, and everything else (rewards, pulls, gates), come from the sheet.
+type mergePolicies map[string]string
+
+func (policies mergePolicies) validate() error {
+	for field, policy := range policies {
+		allowed, ok := mergePolicyFields[field]
+		if !ok {
+			fields := make([]string, 0, len(mergePolicyFields))
+			for name := range mergePolicyFields {
+				fields = append(fields, name)
+			}
+			sort.Strings(fields)
+			return fmt.Errorf("unknown merge field %q (use %s)", field, strings.Join(fields, ", "))
+		}
+		if !slices.Contains(allowed, policy) {
+			return fmt.Errorf("merge policy %q is not valid for %s (use %s)", policy, field, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+func (policies mergePolicies) keepsExisting(field string) bool {
+	return policies[field] == mergeKeepExisting
+}
+
+// mergeWithExisting applies policies to a freshly parsed patch. "existing" only keeps values that are set, so
+// a field the previous output left empty is still filled from the sheet.
+func mergeWithExisting(existing, parsed Patch, policies mergePolicies) Patch {
+	merged := parsed
+	if policies.keepsExisting("versionName") && strings.TrimSpace(existing.VersionName) != "" {
+		merged.VersionName = existing.VersionName
+	}
+	if policies.keepsExisting("startDate") && strings.TrimSpace(existing.StartDate) != "" {
+		merged.StartDate = existing.StartDate
+	}
+	if policies.keepsExisting("durationDays") && existing.DurationDays > 0 {
+		merged.DurationDays = existing.DurationDays
+	}
+	switch policies["tags"] {
+	case mergeKeepExisting:
+		if len(existing.Tags) > 0 {
+			merged.Tags = slices.Clone(existing.Tags)
+		}
+	case mergeUnion:
+		merged.Tags = mergeTagLists(existing.Tags, parsed.Tags)
+	}
+	if !isParserNotes(existing.Notes) {
+		switch policies["notes"] {
+		case mergeKeepExisting:
+			merged.Notes = existing.Notes
+		case mergeUnion:
+			merged.Notes = existing.Notes
+			if !isParserNotes(parsed.Notes) {
+				appendPatchNotes(&merged, strings.Split(parsed.Notes, "\n"))
+			}
+		}
+	}
+
+	keepLabels, keepNotes := policies.keepsExisting("sourceLabels"), policies.keepsExisting("sourceNotes")
+	if !keepLabels && !keepNotes {
+		return merged
+	}
+	existingSources := sourceByID(existing)
+	merged.Sources = slices.Clone(parsed.Sources)
+	for idx := range merged.Sources {
+		previous, ok := existingSources[merged.Sources[idx].ID]
+		if !ok {
+			continue
+		}
+		if keepLabels && strings.TrimSpace(previous.Label) != "" {
+			merged.Sources[idx].Label = previous.Label
+		}
+		if keepNotes && strings.TrimSpace(previous.Notes) != "" {
+			merged.Sources[idx].Notes = previous.Notes
+		}
+	}
+	return merged
+}
@@ -0,0 +1,131 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeWithExisting(t *testing.T) {
+	existing := Patch{
+		ID: "3.4", Patch: "3.4", VersionName: "Curated Name", StartDate: "2025-01-03", DurationDays: 42,
+		Tags:  []string{"rerun"},
+		Notes: "Hand-written note",
+		Sources: []Source{
+			{ID: "events", Label: "Version Events (curated)", Notes: "checked in game", Rewards: Rewards{Oroberyl: 5000}},
+		},
+	}
+	parsed := Patch{
+		ID: "3.4", Patch: "3.4", VersionName: "Version 3.4", StartDate: "2025-01-04", DurationDays: 43,
+		Tags:  []string{tagWIP},
+		Notes: "Sheet footnote",
+		Sources: []Source{
+			{ID: "events", Label: "Version Events", Rewards: Rewards{Oroberyl: 5200}},
+			{ID: "permanent", Label: "Permanent Content", Notes: "new row"},
+		},
+	}
+
+	if got := mergeWithExisting(existing, parsed, nil); !reflect.DeepEqual(got, parsed) {
+		t.Fatalf("without policies = %+v, want the sheet patch", got)
+	}
+
+	got := mergeWithExisting(existing, parsed, mergePolicies{
+		"versionName":  mergeKeepExisting,
+		"tags":         mergeUnion,
+		"notes":        mergeUnion,
+		"sourceLabels": mergeKeepExisting,
+		"sourceNotes":  mergeKeepExisting,
+	})
+	if got.VersionName != "Curated Name" || got.StartDate != "2025-01-04" || got.DurationDays != 43 {
+		t.Fatalf("patch fields = %q %q %d", got.VersionName, got.StartDate, got.DurationDays)
+	}
+	if want := []string{"rerun", tagWIP}; !reflect.DeepEqual(got.Tags, want) {
+		t.Fatalf("tags = %v, want %v", got.Tags, want)
+	}
+	if got.Notes != "Hand-written note\nSheet footnote" {
+		t.Fatalf("notes = %q", got.Notes)
+	}
+	events := got.Sources[0]
+	if events.Label != "Version Events (curated)" || events.Notes != "checked in game" || events.Rewards.Oroberyl != 5200 {
+		t.Fatalf("events source = %+v, want curated label and notes with sheet rewards", events)
+	}
+	if got.Sources[1].Notes != "new row" {
+		t.Fatalf("new source notes = %q", got.Sources[1].Notes)
+	}
+	if parsed.Sources[0].Label != "Version Events" {
+		t.Fatalf("merge modified the parsed patch: %+v", parsed.Sources[0])
+	}
+
+	placeholder := existing
+	placeholder.Notes = "Generated from Google Sheets by patchsync"
+	if got := mergeWithExisting(placeholder, parsed, mergePolicies{"notes": mergeKeepExisting}); got.Notes != "Sheet footnote" {
+		t.Fatalf("notes kept from a parser placeholder = %q", got.Notes)
+	}
+}
+
+func TestMergePoliciesValidate(t *testing.T) {
+	if err := (mergePolicies{"tags": mergeUnion, "startDate": mergeFromSheet}).validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	for _, policies := range []mergePolicies{{"rewards": mergeKeepExisting}, {"startDate": mergeUnion}} {
+		if err := policies.validate(); err == nil {
+			t.Errorf("validate(%v) succeeded, want an error", policies)
+		}
+	}
+}
+
+func TestRunSyncKeepsCuratedNotes(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	overridesDir := filepath.Join(dir, "overrides")
+	if err := os.MkdirAll(overridesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(overridesDir, gameIDWuwa+".json"), []byte(`{"merge":{"notes":"existing"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    overridesDir,
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+	}
+	if _, err := RunSync(t.Context(), cfg); err != nil {
+		t.Fatalf("initial RunSync() error = %v", err)
+	}
+	body, err := os.ReadFile(cfg.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	curated := strings.Replace(string(body), `"notes": "Generated from Wuthering Waves Google Sheets by patchsync"`, `"notes": "Curated by hand"`, 1)
+	if curated == string(body) {
+		t.Fatal("generated file has no parser notes to replace")
+	}
+	if err := os.WriteFile(cfg.OutputPath, []byte(curated), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Fetcher = fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4") + "\n* Livestream codes not included\n"}}
+	if _, err := RunSync(t.Context(), cfg); err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	patches, err := readGeneratedPatches(cfg.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 1 || patches[0].Notes != "Curated by hand" {
+		t.Fatalf("patches after resync = %+v, want the curated notes kept", patches)
+	}
+
+	if err := os.WriteFile(filepath.Join(overridesDir, gameIDWuwa+".json"), []byte(`{"merge":{"notes":"always"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RunSync(t.Context(), cfg); errorCode(err) != errCodeConfig {
+		t.Fatalf("RunSync() with an invalid merge policy = %v, want %s", err, errCodeConfig)
+	}
+}
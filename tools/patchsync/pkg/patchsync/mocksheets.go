@@ -0,0 +1,160 @@
+package patchsync
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultMockSheetsAddr = "127.0.0.1:8790"
+
+// MockSheetsHandler serves <dir>/<spreadsheet id>/<sheet>.csv under the same URL shapes as Google Sheets:
+// the edit page with tab captions and gids, gviz and per-gid export CSV, and for 2PACX ids the pubhtml page
+// and per-gid CSV.
+// Point a sync at it with SyncConfig.GoogleBaseURL to test parsing and tab discovery without the network.
+func MockSheetsHandler(dir string) http.Handler {
+	sheets := localSheetFetcher{dir: dir}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /spreadsheets/d/{id}/edit", func(w http.ResponseWriter, r *http.Request) {
+		names, err := mockSheetNames(r.Context(), sheets, r.PathValue("id"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("<!DOCTYPE html><html><body><div class=\"docs-sheet-tab-bar\">\n")
+		for gid, name := range names {
+			fmt.Fprintf(&sb, "<div class=\"docs-sheet-tab\" id=\"%s%d\"><span class=\"docs-sheet-tab-caption\">%s</span></div>\n", sheetTabButtonIDPrefix, gid, html.EscapeString(name))
+		}
+		sb.WriteString("</div></body></html>\n")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, sb.String())
+	})
+	mux.HandleFunc("GET /spreadsheets/d/{id}/gviz/tq", func(w http.ResponseWriter, r *http.Request) {
+		serveMockCSV(w, r, sheets, r.PathValue("id"), r.URL.Query().Get("sheet"))
+	})
+	mux.HandleFunc("GET /spreadsheets/d/{id}/export", func(w http.ResponseWriter, r *http.Request) {
+		serveMockCSVByGID(w, r, sheets, r.PathValue("id"))
+	})
+	mux.HandleFunc("GET /spreadsheets/d/e/{id}/pubhtml", func(w http.ResponseWriter, r *http.Request) {
+		names, err := mockSheetNames(r.Context(), sheets, r.PathValue("id"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("<!DOCTYPE html><html><body><script>var items = [];\n")
+		for gid, name := range names {
+			fmt.Fprintf(&sb, "items.push({name: %s, pageUrl: \"#\", gid: \"%d\", initialSheet: %t});\n", strconv.Quote(name), gid, gid == 0)
+		}
+		sb.WriteString("</script></body></html>\n")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, sb.String())
+	})
+	mux.HandleFunc("GET /spreadsheets/d/e/{id}/pub", func(w http.ResponseWriter, r *http.Request) {
+		serveMockCSVByGID(w, r, sheets, r.PathValue("id"))
+	})
+	return mux
+}
+
+// mockSheetNames lists every .csv tab of a spreadsheet, not just version-like ones; the list index is its gid.
+func mockSheetNames(ctx context.Context, sheets localSheetFetcher, spreadsheetID string) ([]string, error) {
+	names, err := sheets.ListTabs(ctx, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func serveMockCSVByGID(w http.ResponseWriter, r *http.Request, sheets localSheetFetcher, spreadsheetID string) {
+	names, err := mockSheetNames(r.Context(), sheets, spreadsheetID)
+	gid, gidErr := strconv.Atoi(r.URL.Query().Get("gid"))
+	if err != nil || gidErr != nil || gid < 0 || gid >= len(names) {
+		http.NotFound(w, r)
+		return
+	}
+	serveMockCSV(w, r, sheets, spreadsheetID, names[gid])
+}
+
+func serveMockCSV(w http.ResponseWriter, r *http.Request, sheets localSheetFetcher, spreadsheetID, sheetName string) {
+	body, err := sheets.FetchCSV(r.Context(), spreadsheetID, sheetName)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	fmt.Fprint(w, body)
+}
+
+// googleBaseURLTransport sends requests for Google Sheets hosts to base instead, for syncs against MockSheetsHandler.
+type googleBaseURLTransport struct {
+	base *url.URL
+	next http.RoundTripper
+}
+
+// newGoogleBaseURLTransport returns next unchanged when rawBase is empty.
+func newGoogleBaseURLTransport(rawBase string, next http.RoundTripper) (http.RoundTripper, error) {
+	rawBase = strings.TrimSpace(rawBase)
+	if rawBase == "" {
+		return next, nil
+	}
+	base, err := url.Parse(rawBase)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid Google base URL %q", rawBase)
+	}
+	return googleBaseURLTransport{base: base, next: next}, nil
+}
+
+func (t googleBaseURLTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	switch req.URL.Host {
+	case "docs.google.com", "spreadsheets.google.com":
+		redirected := req.Clone(req.Context())
+		redirected.URL.Scheme = t.base.Scheme
+		redirected.URL.Host = t.base.Host
+		redirected.URL.Path = strings.TrimSuffix(t.base.Path, "/") + req.URL.Path
+		redirected.URL.RawPath = ""
+		if req.URL.RawPath != "" {
+			redirected.URL.RawPath = strings.TrimSuffix(t.base.EscapedPath(), "/") + req.URL.RawPath
+		}
+		redirected.Host = ""
+		return next.RoundTrip(redirected)
+	}
+	return next.RoundTrip(req)
+}
+
+func runMockServeCommand(args []string) int {
+	fs := flag.NewFlagSet("mock-serve", flag.ContinueOnError)
+	var (
+		dir  string
+		addr string
+	)
+	fs.StringVar(&dir, "dir", "", "Directory with <spreadsheet id>/<sheet>.csv files to serve")
+	fs.StringVar(&addr, "addr", defaultMockSheetsAddr, "HTTP bind address")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if strings.TrimSpace(dir) == "" {
+		fmt.Fprintln(os.Stderr, "mock-serve needs --dir")
+		return 2
+	}
+	dir = resolveFilePath(dir)
+	fmt.Printf("Serving mock spreadsheets from %s on http://%s\n", dir, addr)
+	fmt.Printf("Sync against it with --google-base-url http://%s\n", addr)
+	if err := http.ListenAndServe(addr, MockSheetsHandler(dir)); err != nil {
+		fmt.Fprintf(os.Stderr, "mock-serve failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
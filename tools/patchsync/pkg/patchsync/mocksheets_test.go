@@ -0,0 +1,68 @@
+package patchsync
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRunSyncAgainstMockSheetsServer(t *testing.T) {
+	dir := t.TempDir()
+	sheetsDir := filepath.Join(dir, "sheets")
+	const gvizID = "1MockGvizSpreadsheet0123456789abcdefghijklmn"
+	const publishedID = "2PACX-mock-published-spreadsheet"
+	files := map[string]string{
+		filepath.Join(gvizID, "3.4.csv"):       wuwaSheetCSV("3.4"),
+		filepath.Join(gvizID, "3.5.csv"):       wuwaSheetCSV("3.5"),
+		filepath.Join(gvizID, "Notes.csv"):     "not a patch",
+		filepath.Join(publishedID, "3.6.csv"):  wuwaSheetCSV("3.6"),
+		filepath.Join(publishedID, "Info.csv"): "not a patch",
+	}
+	for name, body := range files {
+		path := filepath.Join(sheetsDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server := httptest.NewServer(MockSheetsHandler(sheetsDir))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		spreadsheetID string
+		want          []string
+	}{
+		{gvizID, []string{"3.4", "3.5"}},
+		{publishedID, []string{"3.6"}},
+	} {
+		result, err := RunSync(t.Context(), SyncConfig{
+			GameID:          gameIDWuwa,
+			SpreadsheetID:   tc.spreadsheetID,
+			OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+			BasePatchesPath: filepath.Join(dir, "patches.js"),
+			OverridesDir:    filepath.Join(dir, "overrides"),
+			SheetHashPath:   filepath.Join(dir, "hashes.json"),
+			DryRun:          true,
+			GoogleBaseURL:   server.URL,
+		})
+		if err != nil {
+			t.Fatalf("RunSync(%s) error = %v", tc.spreadsheetID, err)
+		}
+		if got := patchNamesFromPatches(result.Patches); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("RunSync(%s) patches = %v, want %v", tc.spreadsheetID, got, tc.want)
+		}
+	}
+}
+
+func TestNewGoogleBaseURLTransportRejectsRelativeURLs(t *testing.T) {
+	if _, err := newGoogleBaseURLTransport("localhost:8790", nil); err == nil {
+		t.Fatalf("expected a base URL without scheme to be rejected")
+	}
+	if transport, err := newGoogleBaseURLTransport(" ", nil); err != nil || transport != nil {
+		t.Fatalf("newGoogleBaseURLTransport(empty) = %v, %v", transport, err)
+	}
+}
@@ -0,0 +1,44 @@
+package patchsync
+
+import "strings"
+
+// Sheets write corrections as negative cells: "-160", "−160", or "(160)". A negative row subtracts from the
+// section total it belongs to, and a negative aggregate row is kept as is. Either way the source is marked
+// NetIncome, so readers know its rewards are a net amount rather than a plain sum of what was handed out.
+
+// splitNumberSign strips the sign from a numeric cell, returning the unsigned text and -1 or 1. Cells in
+// parentheses are accounting-style negatives.
+func splitNumberSign(value string) (string, float64) {
+	if len(value) > 2 && strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		return value[1 : len(value)-1], -1
+	}
+	for _, minus := range []string{"-", "−"} {
+		if rest, ok := strings.CutPrefix(value, minus); ok {
+			return rest, -1
+		}
+	}
+	return strings.TrimPrefix(value, "+"), 1
+}
+
+func (r Rewards) hasNegative() bool {
+	return r.Oroberyl < 0 || r.Origeometry < 0 || r.Chartered < 0 ||
+		r.Basic < 0 || r.Firewalker < 0 || r.Messenger < 0 ||
+		r.Hues < 0 || r.Arsenal < 0
+}
+
+// addSectionRow adds one row to a section total and records the section's source in netSources when the row
+// is a correction.
+func addSectionRow(total *Rewards, row Rewards, sourceID string, netSources map[string]bool) {
+	total.add(row)
+	if row.hasNegative() {
+		netSources[sourceID] = true
+	}
+}
+
+func markNetIncomeSources(sources []Source, netSources map[string]bool) {
+	for idx := range sources {
+		if netSources[sources[idx].ID] {
+			sources[idx].NetIncome = true
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseNumberNegatives(t *testing.T) {
+	tests := map[string]float64{
+		"-160":   -160,
+		"−160":   -160,
+		"(160)":  -160,
+		"+160":   160,
+		"-1.500": -1500,
+		"-1,500": -1500,
+		"-12,5":  -12.5,
+		"-":      0,
+	}
+	for raw, want := range tests {
+		if got := parseNumber(raw); got != want {
+			t.Fatalf("parseNumber(%q) = %v, want %v", raw, got, want)
+		}
+	}
+	if got, ok := parseDataPullValue("(2,5)"); !ok || got != -2.5 {
+		t.Fatalf("parseDataPullValue((2,5)) = %v, %v; want -2.5", got, ok)
+	}
+}
+
+func TestNegativeRowsSubtractFromSectionTotal(t *testing.T) {
+	csvText := csvLines(
+		"2.0 Patch,Oroberyl,Origeometry,Chartered HH Permit,Basic HH Permit,Arsenal Tickets,Version Length",
+		"Events,1000,0,5,0,0,42",
+		"Permanent Content,,,,,",
+		"Story Quest,2000,10,0,0,0",
+		"Compensation correction,-300,0,0,0,0",
+		"Mailbox & Web Events,,,,,",
+		"Maintenance,600,0,0,0,0",
+		"Recurring Sources,,,,,",
+		"Daily Activity,4200,0,0,0,0",
+	)
+	patch, err := parseSheetToPatch("2.0", csvText, ParseOptions{})
+	if err != nil {
+		t.Fatalf("parseSheetToPatch() error = %v", err)
+	}
+	sources := map[string]Source{}
+	for _, src := range patch.Sources {
+		sources[src.ID] = src
+	}
+	if got := sources["permanent"]; got.Rewards.Oroberyl != 1700 || !got.NetIncome {
+		t.Fatalf("permanent = %v oroberyl, netIncome %v; want 1700 and true", got.Rewards.Oroberyl, got.NetIncome)
+	}
+	if sources["mailbox"].NetIncome || sources["events"].NetIncome {
+		t.Fatal("sources without corrections marked netIncome")
+	}
+
+	body, err := json.Marshal(toGeneratedPatch(patch, gameIDEndfield))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(body), `"netIncome":true`) != 1 {
+		t.Fatalf("generated patch = %s, want one netIncome source", body)
+	}
+}
+
+func TestNegativeAggregateRowMarksNetIncome(t *testing.T) {
+	if src := source("events", "Events", "always", nil, true, Rewards{Oroberyl: -50}); !src.NetIncome {
+		t.Fatal("negative aggregate rewards not marked netIncome")
+	}
+}
@@ -0,0 +1,172 @@
+package patchsync
+
+import (
+	"encoding/csv"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var notesColumnHeaders = []string{"notes", "note", "comments", "comment", "remarks"}
+
+// footnotePattern matches rows such as "* event extended by 3 days" or "Note: ..." below a table.
+var footnotePattern = regexp.MustCompile(`(?i)^\s*(?:\*+|†|notes?\s*:|nb\s*:)\s*`)
+
+var footnoteVersionPattern = regexp.MustCompile(`\b[vV]?(\d+\.\d+)\b`)
+
+// sheetNotes holds the comments of one version sheet: notes keyed by normalized row label from a notes
+// column, and free-standing footnotes. Labels keeps each row label as written.
+type sheetNotes struct {
+	Rows      map[string]string
+	Labels    map[string]string
+	RowOrder  []string
+	Footnotes []string
+}
+
+func readNoteRecords(csvText string) [][]string {
+	reader := csv.NewReader(strings.NewReader(csvText))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, _ := reader.ReadAll()
+	return records
+}
+
+// footnoteText returns the footnote of a row whose only content is its first cell, without the marker.
+func footnoteText(record []string) (string, bool) {
+	first := -1
+	for idx := range record {
+		if getCell(record, idx) == "" {
+			continue
+		}
+		if first >= 0 {
+			return "", false
+		}
+		first = idx
+	}
+	if first < 0 || !footnotePattern.MatchString(record[first]) {
+		return "", false
+	}
+	text := strings.TrimSpace(footnotePattern.ReplaceAllString(record[first], ""))
+	return text, text != ""
+}
+
+func parseSheetNotes(csvText string) sheetNotes {
+	notes := sheetNotes{Rows: map[string]string{}, Labels: map[string]string{}}
+	notesCol := -1
+	for _, record := range readNoteRecords(csvText) {
+		if text, ok := footnoteText(record); ok {
+			notes.Footnotes = append(notes.Footnotes, text)
+			continue
+		}
+		if notesCol < 0 {
+			for idx := 1; idx < len(record); idx++ {
+				if slices.Contains(notesColumnHeaders, normalizeName(record[idx])) {
+					notesCol = idx
+					break
+				}
+			}
+			continue
+		}
+		note := getCell(record, notesCol)
+		if note == "" {
+			continue
+		}
+		label, written := "", ""
+		for _, cell := range record[:notesCol] {
+			if label = normalizeName(cell); label != "" {
+				written = strings.TrimSpace(cell)
+				break
+			}
+		}
+		if label == "" {
+			notes.Footnotes = append(notes.Footnotes, note)
+			continue
+		}
+		if _, exists := notes.Rows[label]; !exists {
+			notes.RowOrder = append(notes.RowOrder, label)
+		}
+		notes.Rows[label] = note
+		notes.Labels[label] = written
+	}
+	return notes
+}
+
+func dataRowSourceIDs(gameID string) map[string]string {
+	switch gameID {
+	case gameIDEndfield:
+		return endfieldDataRowToSourceID
+	case gameIDWuwa:
+		return wuwaDataRowToSourceID
+	case gameIDZzz:
+		return zzzDataRowToSourceID
+	case gameIDHsr:
+		return hsrDataRowToSourceID
+	}
+	return nil
+}
+
+// isParserNotes reports whether notes is still the "Generated from ... by patchsync" placeholder a parser sets.
+func isParserNotes(notes string) bool {
+	return notes == "" || (strings.HasPrefix(notes, "Generated from ") && strings.HasSuffix(notes, " by patchsync"))
+}
+
+// appendPatchNotes adds lines to patch.Notes, replacing the parser placeholder.
+func appendPatchNotes(patch *Patch, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	existing := []string{}
+	if !isParserNotes(patch.Notes) {
+		existing = strings.Split(patch.Notes, "\n")
+	}
+	for _, line := range lines {
+		if !slices.Contains(existing, line) {
+			existing = append(existing, line)
+		}
+	}
+	patch.Notes = strings.Join(existing, "\n")
+}
+
+// applySheetNotes attaches notes-column comments to the sources their rows describe, matched by label or the
+// game's Data sheet row names. Comments on other rows and footnotes become patch notes.
+func applySheetNotes(gameID string, patch *Patch, csvText string) {
+	notes := parseSheetNotes(csvText)
+	rowSourceIDs := dataRowSourceIDs(gameID)
+	matched := map[string]struct{}{}
+	for idx := range patch.Sources {
+		src := &patch.Sources[idx]
+		for _, label := range notes.RowOrder {
+			if label == normalizeName(src.Label) || rowSourceIDs[label] == src.ID {
+				src.Notes = notes.Rows[label]
+				matched[label] = struct{}{}
+				break
+			}
+		}
+	}
+	lines := []string{}
+	for _, label := range notes.RowOrder {
+		if _, ok := matched[label]; !ok {
+			lines = append(lines, notes.Labels[label]+": "+notes.Rows[label])
+		}
+	}
+	appendPatchNotes(patch, append(lines, notes.Footnotes...))
+}
+
+// parseDataSheetFootnotes returns the Data sheet footnotes that name a patch version, keyed by patch ID.
+// Footnotes without a version are left out, since they cannot be attributed to one patch.
+func parseDataSheetFootnotes(csvText string) map[string][]string {
+	result := map[string][]string{}
+	for _, record := range readNoteRecords(csvText) {
+		text, ok := footnoteText(record)
+		if !ok {
+			continue
+		}
+		for _, match := range footnoteVersionPattern.FindAllStringSubmatch(text, -1) {
+			patchID := canonicalPatchID(match[1])
+			if !slices.Contains(result[patchID], text) {
+				result[patchID] = append(result[patchID], text)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,77 @@
+package patchsync
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplySheetNotes(t *testing.T) {
+	csvText := csvLines(
+		"Version 3.4 (03.01.2026)",
+		"Version Length,42",
+		"Source,Astrite,Radiant,Forging,Lustrous,Notes",
+		"Version Events,5000,30,5,10,event extended by 3 days",
+		"Permanent Content,2000,10,0,0,",
+		"Mailbox/Miscellaneous,1000,5,0,0,",
+		"Recurring Sources,3000,0,10,0,Tower reset moved",
+		"Paid Pioneer Podcast,500,2,0,0,",
+		"Lunite Subscription,3780,21,0,0,",
+		"Total F2P,11000,45,15,0,estimate",
+		"Total Paid,15280,47,15,0,",
+		"",
+		"* Livestream codes not included",
+	)
+	patch, err := parseSheetToPatchWuwa("3.4", csvText, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	applySheetNotes(gameIDWuwa, &patch, csvText)
+
+	notesBySource := map[string]string{}
+	for _, src := range patch.Sources {
+		if src.Notes != "" {
+			notesBySource[src.ID] = src.Notes
+		}
+	}
+	if want := map[string]string{"events": "event extended by 3 days", "endgameModes": "Tower reset moved"}; !reflect.DeepEqual(notesBySource, want) {
+		t.Fatalf("source notes = %v, want %v", notesBySource, want)
+	}
+	if want := "Total F2P: estimate\nLivestream codes not included"; patch.Notes != want {
+		t.Fatalf("patch notes = %q, want %q", patch.Notes, want)
+	}
+
+	plain, err := parseSheetToPatchWuwa("3.4", wuwaSheetCSV("3.4"), ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	applySheetNotes(gameIDWuwa, &plain, wuwaSheetCSV("3.4"))
+	if !isParserNotes(plain.Notes) {
+		t.Fatalf("sheet without notes replaced the placeholder: %q", plain.Notes)
+	}
+}
+
+func TestParseDataSheetFootnotes(t *testing.T) {
+	csvText := csvLines(
+		"Version,3.3,3.4",
+		"Version Events,100,120",
+		"",
+		"* 3.4: event extended by 3 days",
+		"Note: v3.3 and 3.4 include compensation",
+		"* numbers are estimates",
+	)
+	got := parseDataSheetFootnotes(csvText)
+	want := map[string][]string{
+		"3.3": {"v3.3 and 3.4 include compensation"},
+		"3.4": {"3.4: event extended by 3 days", "v3.3 and 3.4 include compensation"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseDataSheetFootnotes() = %v, want %v", got, want)
+	}
+
+	patch := Patch{Notes: "Generated from Wuthering Waves Google Sheets by patchsync"}
+	appendPatchNotes(&patch, got["3.4"])
+	appendPatchNotes(&patch, got["3.3"])
+	if want := "3.4: event extended by 3 days\nv3.3 and 3.4 include compensation"; patch.Notes != want {
+		t.Fatalf("patch notes = %q, want %q", patch.Notes, want)
+	}
+}
@@ -0,0 +1,275 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNotifyStatePath    = "tools/patchsync/logs/notify-state.json"
+	defaultNotifyFailureLimit = 2
+
+	notifyChanges = "changes"
+	notifyNoop    = "noop"
+	notifyFailure = "failure"
+)
+
+// NotifyOptions configure the sinks told about sync outcomes. Nothing is sent unless a sink is configured.
+// FailureThreshold is the number of consecutive failed syncs of a game before a failure is reported; the
+// counts are kept in StatePath so they carry over between scheduled runs.
+type NotifyOptions struct {
+	SMTP             SMTPOptions
+	Slack            SlackOptions
+	FailureThreshold int
+	StatePath        string
+}
+
+// notifyEvent is one sync outcome handed to the sinks.
+type notifyEvent struct {
+	Kind       string
+	GameID     string
+	Time       time.Time
+	Patches    []string
+	Stats      syncStats
+	OutputPath string
+	Error      string
+	Code       string
+	Failures   int
+}
+
+type notifier interface {
+	notify(event notifyEvent) error
+}
+
+var notifyChangeTypes = []string{"added", "updated", "removed"}
+
+// NotifyFilter narrows the events one sink receives. Games and Changes match any listed entry; MinPullsDelta
+// keeps only changes to published (non-WIP) patches whose total pulls moved by more than that. Changes and
+// MinPullsDelta apply to "changes" events only. An empty filter lets everything through.
+type NotifyFilter struct {
+	Games         []string
+	Changes       []string
+	MinPullsDelta float64
+}
+
+func registerNotifyFilterFlags(fs *flag.FlagSet, sink string, filter *NotifyFilter) {
+	fs.Var(commaListValue{values: &filter.Games}, sink+"-games", "Comma-separated game ids notified through "+sink+" (default: all)")
+	fs.Var(commaListValue{values: &filter.Changes}, sink+"-changes", "Comma-separated change types notified through "+sink+": added, updated, removed (default: all)")
+	fs.Float64Var(&filter.MinPullsDelta, sink+"-min-pulls", 0, "Only notify "+sink+" when a non-WIP patch's total pulls change by more than this")
+}
+
+func (f *NotifyFilter) normalize(sink string) error {
+	for idx, raw := range f.Games {
+		profile, err := ResolveGameProfile(raw)
+		if err != nil {
+			return fmt.Errorf("--%s-games: %w", sink, err)
+		}
+		f.Games[idx] = profile.ID
+	}
+	for _, change := range f.Changes {
+		if !slices.Contains(notifyChangeTypes, change) {
+			return fmt.Errorf("unknown --%s-changes entry %q (use %s)", sink, change, strings.Join(notifyChangeTypes, ", "))
+		}
+	}
+	if f.MinPullsDelta < 0 {
+		return fmt.Errorf("--%s-min-pulls must not be negative", sink)
+	}
+	return nil
+}
+
+func (f NotifyFilter) allows(event notifyEvent) bool {
+	if len(f.Games) > 0 && !slices.Contains(f.Games, event.GameID) {
+		return false
+	}
+	if event.Kind != notifyChanges || (len(f.Changes) == 0 && f.MinPullsDelta == 0) {
+		return true
+	}
+	for _, change := range event.Stats.Patches {
+		if len(f.Changes) > 0 && !slices.Contains(f.Changes, change.ChangeType) {
+			continue
+		}
+		if f.MinPullsDelta > 0 && (change.WIP || math.Abs(change.PullsDelta) <= f.MinPullsDelta) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+type filteredNotifier struct {
+	filter NotifyFilter
+	sink   notifier
+}
+
+func (n filteredNotifier) notify(event notifyEvent) error {
+	if !n.filter.allows(event) {
+		return nil
+	}
+	return n.sink.notify(event)
+}
+
+// commaListValue is a flag.Value for comma-separated lists; setting it replaces the default.
+type commaListValue struct {
+	values *[]string
+}
+
+func (v commaListValue) String() string {
+	if v.values == nil {
+		return ""
+	}
+	return strings.Join(*v.values, ",")
+}
+
+func (v commaListValue) Set(raw string) error {
+	*v.values = uniqueStrings(strings.Split(raw, ","))
+	return nil
+}
+
+func registerNotifyFlags(fs *flag.FlagSet, opts *NotifyOptions) {
+	registerSMTPFlags(fs, &opts.SMTP)
+	registerSlackFlags(fs, &opts.Slack)
+	fs.IntVar(&opts.FailureThreshold, "notify-failures", defaultNotifyFailureLimit, "Consecutive failed syncs of a game before a failure notification is sent")
+	fs.StringVar(&opts.StatePath, "notify-state", defaultNotifyStatePath, "File keeping consecutive failure counts between runs")
+}
+
+// validate checks the options and canonicalizes the game ids of the sink filters.
+func (o *NotifyOptions) validate() error {
+	if o.FailureThreshold < 1 {
+		return errors.New("--notify-failures must be at least 1")
+	}
+	if err := o.SMTP.validate(); err != nil {
+		return err
+	}
+	if err := o.SMTP.Filter.normalize("smtp"); err != nil {
+		return err
+	}
+	if err := o.Slack.validate(); err != nil {
+		return err
+	}
+	return o.Slack.Filter.normalize("slack")
+}
+
+func (o NotifyOptions) notifiers() []notifier {
+	sinks := []notifier{}
+	if o.SMTP.enabled() {
+		sinks = append(sinks, filteredNotifier{filter: o.SMTP.Filter, sink: newSMTPNotifier(o.SMTP)})
+	}
+	if o.Slack.enabled() {
+		sinks = append(sinks, filteredNotifier{filter: o.Slack.Filter, sink: newSlackNotifier(o.Slack)})
+	}
+	return sinks
+}
+
+var notifyStateMu sync.Mutex
+
+// recordSyncOutcome updates the consecutive failure count of gameID and returns the new count.
+func recordSyncOutcome(path, gameID string, failed bool) (int, error) {
+	notifyStateMu.Lock()
+	defer notifyStateMu.Unlock()
+	counts := map[string]int{}
+	body, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &counts); err != nil {
+			return 0, fmt.Errorf("parse notify state: %w", err)
+		}
+	}
+	previous := counts[gameID]
+	if failed {
+		counts[gameID]++
+	} else {
+		delete(counts, gameID)
+	}
+	if counts[gameID] == previous {
+		return previous, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+	return counts[gameID], writeJSONFile(path, counts)
+}
+
+// syncNotifyEvent turns a sync outcome into the event to send, or returns false when there is nothing to
+// report: a failure below the threshold, or after it was already reported.
+func syncNotifyEvent(opts NotifyOptions, gameID string, result SyncResult, syncErr error, now time.Time) (notifyEvent, bool, error) {
+	statePath := opts.StatePath
+	if strings.TrimSpace(statePath) == "" {
+		statePath = defaultNotifyStatePath
+	}
+	failures, err := recordSyncOutcome(resolveOutputPath(statePath), gameID, syncErr != nil)
+	if err != nil {
+		return notifyEvent{}, false, err
+	}
+	event := notifyEvent{GameID: gameID, Time: now}
+	if syncErr != nil {
+		if failures != max(1, opts.FailureThreshold) {
+			return notifyEvent{}, false, nil
+		}
+		event.Kind = notifyFailure
+		event.Error = syncErr.Error()
+		event.Code = errorCode(syncErr)
+		event.Failures = failures
+		return event, true, nil
+	}
+	event.Kind = notifyNoop
+	if result.ChangeCount > 0 {
+		event.Kind = notifyChanges
+	}
+	event.Patches = patchNamesFromPatches(result.Patches)
+	event.Stats = result.Stats
+	event.OutputPath = result.OutputPath
+	return event, true, nil
+}
+
+// notifySyncOutcome reports a finished sync to the configured sinks. Dry runs are not reported, and a sink
+// that fails only costs a log line.
+func notifySyncOutcome(cfg SyncConfig, result *SyncResult, syncErr error) {
+	sinks := cfg.Notify.notifiers()
+	if cfg.DryRun || len(sinks) == 0 {
+		return
+	}
+	clock := clockOrSystem(cfg.Clock)
+	logs := syncLog{requestID: cfg.RequestID, clock: clock, lines: result.Logs}
+	gameID := cfg.GameID
+	if profile, err := ResolveGameProfile(gameID); err == nil {
+		gameID = profile.ID
+	}
+	event, ok, err := syncNotifyEvent(cfg.Notify, gameID, *result, syncErr, clock.Now())
+	if err != nil {
+		appendSyncLog(&logs, "notify state update failed: %v", err)
+	}
+	if ok {
+		for _, sink := range sinks {
+			if sendErr := sink.notify(event); sendErr != nil {
+				appendSyncLog(&logs, "notification failed: %v", sendErr)
+			}
+		}
+	}
+	result.Logs = logs.lines
+}
+
+// formatRewardsDelta renders reward changes as "currency +N" entries sorted by currency.
+func formatRewardsDelta(delta map[string]float64) []string {
+	currencies := make([]string, 0, len(delta))
+	for currency := range delta {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	entries := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		entries = append(entries, fmt.Sprintf("%s %+g", currency, delta[currency]))
+	}
+	return entries
+}
@@ -0,0 +1,90 @@
+package patchsync
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSyncNotifyEventReportsRepeatedFailuresOnce(t *testing.T) {
+	opts := NotifyOptions{FailureThreshold: 2, StatePath: filepath.Join(t.TempDir(), "notify-state.json")}
+	now := time.Date(2025, 6, 2, 5, 0, 0, 0, time.UTC)
+	failed := withErrorCode(errCodeSpreadsheetUnreachable, errors.New("fetch failed"))
+
+	kinds := []string{}
+	for _, syncErr := range []error{failed, failed, failed, nil, failed} {
+		event, ok, err := syncNotifyEvent(opts, gameIDWuwa, SyncResult{}, syncErr, now)
+		if err != nil {
+			t.Fatalf("syncNotifyEvent() error = %v", err)
+		}
+		if ok {
+			kinds = append(kinds, event.Kind)
+		}
+		if ok && event.Kind == notifyFailure && (event.Failures != 2 || event.Code != errCodeSpreadsheetUnreachable) {
+			t.Fatalf("unexpected failure event %+v", event)
+		}
+	}
+	// The third failure was already reported with the second; the success resets the count.
+	if len(kinds) != 2 || kinds[0] != notifyFailure || kinds[1] != notifyNoop {
+		t.Fatalf("event kinds = %v, want [failure noop]", kinds)
+	}
+
+	result := SyncResult{Patches: []Patch{{ID: "2.4", Patch: "2.4"}}, ChangeCount: 1, Stats: syncStats{Added: 1}}
+	event, ok, err := syncNotifyEvent(opts, gameIDWuwa, result, nil, now)
+	if err != nil || !ok || event.Kind != notifyChanges || len(event.Patches) != 1 || event.Stats.Added != 1 {
+		t.Fatalf("unexpected changes event %+v ok=%v err=%v", event, ok, err)
+	}
+}
+
+func TestNotifyOptionsValidate(t *testing.T) {
+	empty := NotifyOptions{FailureThreshold: 2}
+	if err := empty.validate(); err != nil {
+		t.Fatalf("empty options should be valid: %v", err)
+	}
+	noThreshold := NotifyOptions{}
+	if err := noThreshold.validate(); err == nil {
+		t.Fatalf("expected threshold error")
+	}
+	noRecipients := NotifyOptions{FailureThreshold: 2, SMTP: SMTPOptions{Host: "smtp.example.com:587", From: "a@example.com"}}
+	if err := noRecipients.validate(); err == nil {
+		t.Fatalf("expected missing recipients error")
+	}
+	unknownGame := NotifyOptions{FailureThreshold: 2, Slack: SlackOptions{Filter: NotifyFilter{Games: []string{"wuwa"}}}}
+	if err := unknownGame.validate(); err == nil {
+		t.Fatalf("expected unknown game error")
+	}
+	badChange := NotifyOptions{FailureThreshold: 2, SMTP: SMTPOptions{Filter: NotifyFilter{Changes: []string{"renamed"}}}}
+	if err := badChange.validate(); err == nil {
+		t.Fatalf("expected unknown change type error")
+	}
+}
+
+func TestNotifyFilterAllows(t *testing.T) {
+	tagOnly := notifyEvent{Kind: notifyChanges, GameID: gameIDWuwa, Stats: syncStats{Updated: 1, Patches: []patchPullsDelta{
+		{Patch: "2.4", ChangeType: "updated", PullsDelta: 0},
+	}}}
+	wipOnly := notifyEvent{Kind: notifyChanges, GameID: gameIDWuwa, Stats: syncStats{Added: 1, Patches: []patchPullsDelta{
+		{Patch: "2.5", ChangeType: "added", WIP: true, PullsDelta: 80},
+	}}}
+	rewardChange := notifyEvent{Kind: notifyChanges, GameID: gameIDWuwa, Stats: syncStats{Updated: 1, Patches: []patchPullsDelta{
+		{Patch: "2.4", ChangeType: "updated", PullsDelta: -2.5},
+	}}}
+	failure := notifyEvent{Kind: notifyFailure, GameID: gameIDWuwa}
+
+	magnitude := NotifyFilter{MinPullsDelta: 1}
+	if magnitude.allows(tagOnly) || magnitude.allows(wipOnly) || !magnitude.allows(rewardChange) || !magnitude.allows(failure) {
+		t.Fatalf("magnitude filter should only pass published pull changes and failures")
+	}
+	addedOnly := NotifyFilter{Changes: []string{"added"}}
+	if addedOnly.allows(rewardChange) || !addedOnly.allows(wipOnly) {
+		t.Fatalf("change type filter should only pass added patches")
+	}
+	genshinOnly := NotifyFilter{Games: []string{gameIDGenshin}}
+	if genshinOnly.allows(failure) || genshinOnly.allows(rewardChange) {
+		t.Fatalf("game filter should drop other games")
+	}
+	if !(NotifyFilter{}).allows(tagOnly) {
+		t.Fatalf("empty filter should let everything through")
+	}
+}
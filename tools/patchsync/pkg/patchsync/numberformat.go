@@ -0,0 +1,109 @@
+package patchsync
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Decimal separators for numeric cells. With auto, parseNumber guesses per cell, which misreads "1,500" on a
+// sheet that writes decimals with a comma. dot and comma remove the guesswork: the other mark and spaces are
+// thousands separators.
+const (
+	decimalAuto  = "auto"
+	decimalDot   = "dot"
+	decimalComma = "comma"
+)
+
+func parseDecimalSeparator(raw string) (string, error) {
+	switch separator := strings.ToLower(strings.TrimSpace(raw)); separator {
+	case "":
+		return decimalAuto, nil
+	case decimalAuto, decimalDot, decimalComma:
+		return separator, nil
+	case ".":
+		return decimalDot, nil
+	case ",":
+		return decimalComma, nil
+	default:
+		return "", fmt.Errorf("invalid decimal separator %q (use %s, %s, or %s)", raw, decimalAuto, decimalDot, decimalComma)
+	}
+}
+
+// explicitDecimalText rewrites an unsigned numeric cell for strconv.ParseFloat under a known separator. It
+// reports false for auto, which leaves the cell to the caller's heuristic.
+func explicitDecimalText(value, separator string) (string, bool) {
+	switch separator {
+	case decimalDot:
+		return strings.ReplaceAll(value, ",", ""), true
+	case decimalComma:
+		return strings.ReplaceAll(strings.ReplaceAll(value, ".", ""), ",", "."), true
+	}
+	return value, false
+}
+
+var exponentPattern = regexp.MustCompile(`^(.*\d)[eE]([+-]?\d{1,3})$`)
+
+// splitExponent splits scientific notation such as "1.6E+03", which published CSVs use for some cells, into the
+// mantissa, left to the separator rules, and an "e+03" suffix for strconv.ParseFloat.
+func splitExponent(value string) (string, string) {
+	match := exponentPattern.FindStringSubmatch(value)
+	if match == nil {
+		return value, ""
+	}
+	return match[1], "e" + match[2]
+}
+
+// mantissaSeparator is the separator for the mantissa of a number in scientific notation. A mantissa has no
+// thousands groups, so whichever mark it contains is the decimal one.
+func mantissaSeparator(mantissa string) string {
+	if strings.Contains(mantissa, ",") {
+		return decimalComma
+	}
+	return decimalDot
+}
+
+// Pull values in a Data sheet have one or two decimals, which a thousands group never has.
+var (
+	decimalCommaCellPattern = regexp.MustCompile(`^[-−(]?\d+,\d{1,2}\)?$`)
+	decimalDotCellPattern   = regexp.MustCompile(`^[-−(]?\d+\.\d{1,2}\)?$`)
+)
+
+// detectDecimalSeparator reads the separator off the value cells of a Data sheet. It returns auto when the
+// sheet has no telling cells, or as many of each kind. Version headers such as "3.3" are not counted.
+func detectDecimalSeparator(csvText string) string {
+	records := orientDataSheetRecords(readNoteRecords(csvText))
+	_, headerIdx := findDataSheetHeader(records, nil)
+	commas, dots := 0, 0
+	for rowIdx, record := range records {
+		if rowIdx <= headerIdx {
+			continue
+		}
+		for idx := 1; idx < len(record); idx++ {
+			cell := getCell(record, idx)
+			switch {
+			case decimalCommaCellPattern.MatchString(cell):
+				commas++
+			case decimalDotCellPattern.MatchString(cell):
+				dots++
+			}
+		}
+	}
+	switch {
+	case commas > dots:
+		return decimalComma
+	case dots > commas:
+		return decimalDot
+	}
+	return decimalAuto
+}
+
+// number parses a numeric cell with the separator of opts. Formula errors are read as 0 with a warning, so a
+// broken reference in the sheet does not pass for a real zero.
+func (opts ParseOptions) number(raw string) float64 {
+	if marker, ok := formulaErrorMarker(raw); ok {
+		opts.warnf("formula error %s in a numeric cell, read as 0", marker)
+		return 0
+	}
+	return parseNumberWith(raw, opts.DecimalSeparator)
+}
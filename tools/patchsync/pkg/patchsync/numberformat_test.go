@@ -0,0 +1,88 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseNumberWithSeparator(t *testing.T) {
+	tests := []struct {
+		raw       string
+		separator string
+		want      float64
+	}{
+		{raw: "1,500", separator: decimalAuto, want: 1500},
+		{raw: "1,500", separator: decimalComma, want: 1.5},
+		{raw: "1,5", separator: decimalComma, want: 1.5},
+		{raw: "1.234,5", separator: decimalComma, want: 1234.5},
+		{raw: "1 234,5", separator: decimalComma, want: 1234.5},
+		{raw: "-1.500", separator: decimalComma, want: -1500},
+		{raw: "1,500", separator: decimalDot, want: 1500},
+		{raw: "1.500", separator: decimalDot, want: 1.5},
+		{raw: "1,234.5", separator: decimalDot, want: 1234.5},
+	}
+	for _, tt := range tests {
+		if got := parseNumberWith(tt.raw, tt.separator); got != tt.want {
+			t.Fatalf("parseNumberWith(%q, %s) = %v, want %v", tt.raw, tt.separator, got, tt.want)
+		}
+	}
+	if got, ok := parseDataPullValueWith("1.234,5", decimalComma); !ok || got != 1234.5 {
+		t.Fatalf("parseDataPullValueWith(1.234,5, comma) = %v, %v", got, ok)
+	}
+	if _, err := parseDecimalSeparator("semicolon"); err == nil {
+		t.Fatal("expected an error for an unknown separator")
+	}
+}
+
+func TestDetectDecimalSeparator(t *testing.T) {
+	tests := map[string]string{
+		csvLines("Version,3.3,3.4", `Version Events,"25,6","30,3"`, "Permanent Content,12,8"): decimalComma,
+		csvLines("Version,3.3,3.4", "Version Events,25.6,30.3", "Permanent Content,12,8"):     decimalDot,
+		csvLines("Version,3.3,3.4", "Version Events,25,30"):                                   decimalAuto,
+	}
+	for csvText, want := range tests {
+		if got := detectDecimalSeparator(csvText); got != want {
+			t.Fatalf("detectDecimalSeparator(%q) = %s, want %s", csvText, got, want)
+		}
+	}
+}
+
+func TestLoadSpreadsheetSourceUsesDataSheetSeparator(t *testing.T) {
+	fetcher := fakeSheetFetcher{sheets: map[string]string{
+		"Data": csvLines("Version,3.4", `Version Events,"25,6"`, `Permanent Content,"1.234,5"`),
+	}}
+	profile, err := ResolveGameProfile(gameIDWuwa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := syncLog{}
+	src, err := loadSpreadsheetSource(t.Context(), fetcher, gameIDWuwa, "fake", true, []string{"3.4"}, profile.AuxSheetNames, nil, decimalAuto, &logs)
+	if err != nil {
+		t.Fatalf("loadSpreadsheetSource() error = %v", err)
+	}
+	if src.DecimalSeparator != decimalComma || src.DataPulls["3.4"]["permanent"] != 1234.5 {
+		t.Fatalf("separator %s, pulls %v; want comma and 1234.5", src.DecimalSeparator, src.DataPulls["3.4"])
+	}
+
+	src, err = loadSpreadsheetSource(t.Context(), fetcher, gameIDWuwa, "fake", true, []string{"3.4"}, profile.AuxSheetNames, nil, decimalDot, &logs)
+	if err != nil {
+		t.Fatalf("loadSpreadsheetSource() error = %v", err)
+	}
+	if src.DecimalSeparator != decimalDot || src.DataPulls["3.4"]["events"] != 256 {
+		t.Fatalf("configured dot: separator %s, pulls %v", src.DecimalSeparator, src.DataPulls["3.4"])
+	}
+}
+
+func TestReadGameConfigDecimalSeparator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuwa.json")
+	if err := os.WriteFile(path, []byte(`{"decimalSeparator":","}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := readGameConfig(path, gameIDWuwa); err != nil || got.DecimalSeparator != decimalComma {
+		t.Fatalf("readGameConfig() separator = %q, %v; want comma", got.DecimalSeparator, err)
+	}
+	if got, err := readGameConfig(filepath.Join(t.TempDir(), "missing.json"), gameIDWuwa); err != nil || got.DecimalSeparator != decimalAuto {
+		t.Fatalf("readGameConfig(missing) separator = %q, %v; want auto", got.DecimalSeparator, err)
+	}
+}
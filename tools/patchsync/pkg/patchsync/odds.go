@@ -0,0 +1,193 @@
+package patchsync
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type pityRules struct {
+	BaseRate  float64 `json:"baseRate"`
+	SoftPity  int     `json:"softPity"`
+	SoftStep  float64 `json:"softStep"`
+	HardPity  int     `json:"hardPity"`
+	WinRate   float64 `json:"winRate"`
+	SparkPull int     `json:"sparkPull,omitempty"`
+}
+
+type oddsResult struct {
+	GameID      string    `json:"gameId"`
+	Pulls       int       `json:"pulls"`
+	Pity        int       `json:"pity"`
+	Guaranteed  bool      `json:"guaranteed"`
+	Probability float64   `json:"probability"`
+	Rules       pityRules `json:"rules"`
+}
+
+// Limited character banner rules. Soft pity starts after SoftPity pulls and adds SoftStep per pull.
+// SparkPull guarantees the limited unit on that pull of a fresh banner.
+var pityRulesByGame = map[string]pityRules{
+	gameIDEndfield: {BaseRate: 0.008, SoftPity: 65, SoftStep: 0.05, HardPity: 80, WinRate: 0.5, SparkPull: 120},
+	gameIDWuwa:     {BaseRate: 0.008, SoftPity: 65, SoftStep: 0.04, HardPity: 80, WinRate: 0.5},
+	gameIDZzz:      {BaseRate: 0.006, SoftPity: 73, SoftStep: 0.06, HardPity: 90, WinRate: 0.5},
+	gameIDGenshin:  {BaseRate: 0.006, SoftPity: 73, SoftStep: 0.06, HardPity: 90, WinRate: 0.5},
+	gameIDHsr:      {BaseRate: 0.006, SoftPity: 73, SoftStep: 0.06, HardPity: 90, WinRate: 0.5},
+}
+
+func (r pityRules) rateAt(pullNumber int) float64 {
+	if pullNumber >= r.HardPity {
+		return 1
+	}
+	rate := r.BaseRate
+	if pullNumber > r.SoftPity {
+		rate += r.SoftStep * float64(pullNumber-r.SoftPity)
+	}
+	return math.Min(1, rate)
+}
+
+func limitedOdds(rules pityRules, pulls, pity int, guaranteed bool) float64 {
+	if pulls <= 0 {
+		return 0
+	}
+	// Two hard pities always reach the limited unit, so longer runs need no simulation.
+	if pulls >= 2*rules.HardPity {
+		return 1
+	}
+	// missing[g][p] is the chance of not having the limited unit yet with pity p and guarantee state g.
+	missing := [2][]float64{make([]float64, rules.HardPity), make([]float64, rules.HardPity)}
+	start := 0
+	if guaranteed {
+		start = 1
+	}
+	missing[start][min(pity, rules.HardPity-1)] = 1
+	obtained := 0.0
+	for pull := 1; pull <= pulls; pull++ {
+		if rules.SparkPull > 0 && pull == rules.SparkPull {
+			return 1
+		}
+		next := [2][]float64{make([]float64, rules.HardPity), make([]float64, rules.HardPity)}
+		for g := 0; g < 2; g++ {
+			for p, mass := range missing[g] {
+				if mass == 0 {
+					continue
+				}
+				rate := rules.rateAt(p + 1)
+				if p+1 < rules.HardPity {
+					next[g][p+1] += mass * (1 - rate)
+				}
+				hit := mass * rate
+				if g == 1 {
+					obtained += hit
+					continue
+				}
+				obtained += hit * rules.WinRate
+				next[1][0] += hit * (1 - rules.WinRate)
+			}
+		}
+		missing = next
+	}
+	return math.Min(1, obtained)
+}
+
+func computeOdds(gameID string, pulls, pity int, guaranteed bool) (oddsResult, error) {
+	profile, err := ResolveGameProfile(gameID)
+	if err != nil {
+		return oddsResult{}, err
+	}
+	rules, ok := pityRulesByGame[profile.ID]
+	if !ok {
+		return oddsResult{}, fmt.Errorf("no pity rules for game %s", profile.ID)
+	}
+	if pulls < 0 || pity < 0 {
+		return oddsResult{}, errors.New("pulls and pity must not be negative")
+	}
+	if pity >= rules.HardPity {
+		return oddsResult{}, fmt.Errorf("pity must be below hard pity %d", rules.HardPity)
+	}
+	return oddsResult{
+		GameID:      profile.ID,
+		Pulls:       pulls,
+		Pity:        pity,
+		Guaranteed:  guaranteed,
+		Probability: math.Round(limitedOdds(rules, pulls, pity, guaranteed)*10000) / 10000,
+		Rules:       rules,
+	}, nil
+}
+
+func parseOddsQuery(r *http.Request) (string, int, int, bool, error) {
+	query := r.URL.Query()
+	parseIntParam := func(name string) (int, error) {
+		raw := strings.TrimSpace(query.Get(name))
+		if raw == "" {
+			return 0, nil
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be an integer", name)
+		}
+		return value, nil
+	}
+	pulls, err := parseIntParam("pulls")
+	if err != nil {
+		return "", 0, 0, false, err
+	}
+	pity, err := parseIntParam("pity")
+	if err != nil {
+		return "", 0, 0, false, err
+	}
+	guaranteed := false
+	if raw := strings.TrimSpace(query.Get("guaranteed")); raw != "" {
+		if guaranteed, err = strconv.ParseBool(raw); err != nil {
+			return "", 0, 0, false, errors.New("guaranteed must be true or false")
+		}
+	}
+	return query.Get("game"), pulls, pity, guaranteed, nil
+}
+
+func registerOddsAPI(mux *http.ServeMux, allowedOrigins map[string]struct{}) {
+	mux.HandleFunc("/odds", func(w http.ResponseWriter, r *http.Request) {
+		if !withCORS(w, r, allowedOrigins) {
+			writeJSON(w, http.StatusForbidden, syncResponse{
+				OK:      false,
+				Message: "origin is not allowed",
+			})
+			return
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, syncResponse{
+				OK:      false,
+				Message: "method not allowed",
+			})
+			return
+		}
+		gameID, pulls, pity, guaranteed, err := parseOddsQuery(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, syncResponse{
+				OK:      false,
+				Message: err.Error(),
+			})
+			return
+		}
+		odds, err := computeOdds(gameID, pulls, pity, guaranteed)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, syncResponse{
+				OK:      false,
+				Message: err.Error(),
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, syncResponse{
+			OK:      true,
+			Message: "odds computed",
+			GameID:  odds.GameID,
+			Odds:    &odds,
+		})
+	})
+}
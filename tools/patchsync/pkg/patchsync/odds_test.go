@@ -0,0 +1,40 @@
+package patchsync
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLimitedOdds(t *testing.T) {
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+	genshin := pityRulesByGame[gameIDGenshin]
+	if got := limitedOdds(genshin, 90, 0, true); !near(got, 1) {
+		t.Errorf("guaranteed at hard pity = %v, want 1", got)
+	}
+	if got := limitedOdds(genshin, 180, 0, false); !near(got, 1) {
+		t.Errorf("180 pulls from zero = %v, want 1", got)
+	}
+	if got := limitedOdds(genshin, 1, 89, false); !near(got, 0.5) {
+		t.Errorf("one pull at 89 pity without guarantee = %v, want 0.5", got)
+	}
+	if got := limitedOdds(genshin, 1_000_000_000, 0, false); got != 1 {
+		t.Errorf("a billion pulls = %v, want 1 without simulating them", got)
+	}
+	if got := limitedOdds(genshin, 0, 0, false); got != 0 {
+		t.Errorf("zero pulls = %v, want 0", got)
+	}
+	low := limitedOdds(genshin, 60, 0, false)
+	high := limitedOdds(genshin, 80, 0, false)
+	if !(low > 0 && low < high && high < 1) {
+		t.Errorf("expected increasing odds below certainty, got 60=%v 80=%v", low, high)
+	}
+
+	endfield := pityRulesByGame[gameIDEndfield]
+	if got := limitedOdds(endfield, 120, 0, false); got != 1 {
+		t.Errorf("endfield spark at 120 = %v, want 1", got)
+	}
+
+	if _, err := computeOdds(gameIDGenshin, 10, 90, false); err == nil {
+		t.Errorf("expected error for pity at hard pity")
+	}
+}
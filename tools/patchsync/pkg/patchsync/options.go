@@ -0,0 +1,61 @@
+package patchsync
+
+import "sort"
+
+// generatedOption is one GENERATED_OPTIONS entry: a toggle the frontend renders and the source IDs it gates.
+type generatedOption struct {
+	Key     string   `json:"key"`
+	Label   string   `json:"label"`
+	Default bool     `json:"default"`
+	Sources []string `json:"sources"`
+}
+
+// buildGeneratedOptions lists the profile's options in definition order, followed by any option keys the
+// patches use without a definition, so the frontend never sees a source it has no toggle for.
+func buildGeneratedOptions(gameID string, patches []Patch) []generatedOption {
+	gated := map[string]map[string]struct{}{}
+	for _, patch := range patches {
+		for _, src := range patch.Sources {
+			if src.OptionKey == nil || *src.OptionKey == "" {
+				continue
+			}
+			if gated[*src.OptionKey] == nil {
+				gated[*src.OptionKey] = map[string]struct{}{}
+			}
+			gated[*src.OptionKey][src.ID] = struct{}{}
+		}
+	}
+	sourceIDs := func(key string) []string {
+		ids := make([]string, 0, len(gated[key]))
+		for id := range gated[key] {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	options := []generatedOption{}
+	defined := map[string]struct{}{}
+	if profile, err := ResolveGameProfile(gameID); err == nil {
+		for _, option := range profile.Options {
+			defined[option.Key] = struct{}{}
+			options = append(options, generatedOption{
+				Key:     option.Key,
+				Label:   option.Label,
+				Default: option.Default,
+				Sources: sourceIDs(option.Key),
+			})
+		}
+	}
+	undefined := []string{}
+	for key := range gated {
+		if _, ok := defined[key]; !ok {
+			undefined = append(undefined, key)
+		}
+	}
+	sort.Strings(undefined)
+	for _, key := range undefined {
+		options = append(options, generatedOption{Key: key, Label: key, Sources: sourceIDs(key)})
+	}
+	return options
+}
@@ -0,0 +1,44 @@
+package patchsync
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildGeneratedOptions(t *testing.T) {
+	patches := []Patch{
+		{Patch: "1.0", Sources: []Source{
+			source("aicQuota", "AIC Quota Exchange", "always", ptr(optionIncludeAicQuotaExchange), true, zeroRewards()),
+			source("bpCrateL", "Crate [L]", "bp3", ptr(optionIncludeBpCrates), true, zeroRewards()),
+			source("events", "Events", "always", nil, true, zeroRewards()),
+		}},
+		{Patch: "1.1", Sources: []Source{
+			source("bpCrateM", "Crate [M]", "bp2", ptr(optionIncludeBpCrates), true, zeroRewards()),
+			source("bpCrateL", "Crate [L]", "bp3", ptr(optionIncludeBpCrates), true, zeroRewards()),
+			source("collab", "Collab", "always", ptr("includeCollab"), true, zeroRewards()),
+		}},
+	}
+	got := buildGeneratedOptions(gameIDEndfield, patches)
+	want := []generatedOption{
+		{Key: optionIncludeBpCrates, Label: "Include BP 60+ Crates [M]/[L]", Default: true, Sources: []string{"bpCrateL", "bpCrateM"}},
+		{Key: optionIncludeAicQuotaExchange, Label: "AIC Quota Exchange", Default: true, Sources: []string{"aicQuota"}},
+		{Key: optionIncludeUrgentRecruit, Label: "Urgent Recruit", Sources: []string{}},
+		{Key: optionIncludeHhDossier, Label: "HH Dossier", Sources: []string{}},
+		{Key: "includeCollab", Label: "includeCollab", Sources: []string{"collab"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("buildGeneratedOptions() = %+v\nwant %+v", got, want)
+	}
+	if got := buildGeneratedOptions(gameIDWuwa, nil); len(got) != 0 {
+		t.Fatalf("wuwa options = %+v, want none", got)
+	}
+
+	content, err := renderGeneratedFile(patches, GeneratedMeta{GameID: gameIDEndfield})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(content, "export const GENERATED_OPTIONS = [") || !strings.Contains(content, `"key": "includeHhDossier"`) {
+		t.Fatalf("generated file has no GENERATED_OPTIONS export:\n%s", content)
+	}
+}
@@ -0,0 +1,130 @@
+package patchsync
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOutboundProxySendsCredentials(t *testing.T) {
+	var gotAuth, gotURL string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Proxy-Authorization")
+		gotURL = r.URL.String()
+		fmt.Fprint(w, "via proxy")
+	}))
+	defer proxy.Close()
+
+	client, err := newOutboundClient(OutboundOptions{Proxy: "http://sync:s3cret@" + proxy.Listener.Addr().String()}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := fetchText(t.Context(), client, "http://docs.google.com/spreadsheets/d/abc/edit")
+	if err != nil || body != "via proxy" {
+		t.Fatalf("fetch through proxy = %q, %v", body, err)
+	}
+	if want := "Basic " + base64.StdEncoding.EncodeToString([]byte("sync:s3cret")); gotAuth != want {
+		t.Fatalf("Proxy-Authorization = %q, want %q", gotAuth, want)
+	}
+	if gotURL != "http://docs.google.com/spreadsheets/d/abc/edit" {
+		t.Fatalf("proxied URL = %q", gotURL)
+	}
+
+	for _, raw := range []string{"proxy:3128", "ftp://proxy:21"} {
+		if _, err := newOutboundTransport(OutboundOptions{Proxy: raw}); err == nil {
+			t.Errorf("expected proxy %q to be rejected", raw)
+		}
+	}
+}
+
+func TestOutboundCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, certPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		opts   OutboundOptions
+		wantOK bool
+	}{
+		{OutboundOptions{}, false},
+		{OutboundOptions{CABundle: bundle}, true},
+		{OutboundOptions{InsecureSkipVerify: true}, true},
+	} {
+		client, err := newOutboundClient(tc.opts, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = fetchText(t.Context(), client, server.URL)
+		if (err == nil) != tc.wantOK {
+			t.Errorf("fetch with %+v: err = %v, want ok = %v", tc.opts, err, tc.wantOK)
+		}
+	}
+
+	if _, err := newOutboundTransport(OutboundOptions{CABundle: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Fatalf("expected a missing CA bundle to fail")
+	}
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newOutboundTransport(OutboundOptions{CABundle: empty}); err == nil {
+		t.Fatalf("expected a CA bundle without certificates to fail")
+	}
+}
+
+func TestOutboundHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	client, err := newOutboundClient(OutboundOptions{}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fetchText(t.Context(), client, server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if ua := got.Get("User-Agent"); ua != defaultUserAgent {
+		t.Fatalf("default User-Agent = %q", ua)
+	}
+
+	opts := OutboundOptions{UserAgent: "bookkeeper-bot/2"}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(headerListValue{headers: &opts.Headers}, "request-headers", "")
+	if err := fs.Parse([]string{"--request-headers", "From: me@example.com, Accept-Language: en", "--request-headers", "X-Trace: 1"}); err != nil {
+		t.Fatal(err)
+	}
+	client, err = newOutboundClient(opts, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fetchText(t.Context(), client, server.URL); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"User-Agent": "bookkeeper-bot/2", "From": "me@example.com", "Accept-Language": "en", "X-Trace": "1"} {
+		if got.Get(name) != want {
+			t.Errorf("%s = %q, want %q", name, got.Get(name), want)
+		}
+	}
+
+	for _, raw := range []string{"From", "Bad Name: x", "X-Ok: a\nb"} {
+		if _, err := parseOutboundHeaders(raw); err == nil {
+			t.Errorf("expected header %q to be rejected", raw)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package patchsync
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	newlineLF   = "lf"
+	newlineCRLF = "crlf"
+	utf8BOM     = "\ufeff"
+)
+
+// OutputEncoding sets the line endings and byte order mark of the generated files, so editors that insist on
+// CRLF or a BOM do not rewrite every line. The zero value writes LF without a BOM.
+type OutputEncoding struct {
+	Newline string
+	BOM     bool
+}
+
+func parseNewline(raw string) (string, error) {
+	switch newline := strings.ToLower(strings.TrimSpace(raw)); newline {
+	case "", newlineLF:
+		return newlineLF, nil
+	case newlineCRLF:
+		return newline, nil
+	default:
+		return "", fmt.Errorf("invalid newline %q (use %s or %s)", raw, newlineLF, newlineCRLF)
+	}
+}
+
+func (enc OutputEncoding) validate() error {
+	_, err := parseNewline(enc.Newline)
+	return err
+}
+
+// apply converts content rendered with LF line endings. validate has already rejected unknown newlines.
+func (enc OutputEncoding) apply(content string) string {
+	if newline, _ := parseNewline(enc.Newline); newline == newlineCRLF {
+		content = strings.ReplaceAll(content, "\n", "\r\n")
+	}
+	if enc.BOM {
+		content = utf8BOM + content
+	}
+	return content
+}
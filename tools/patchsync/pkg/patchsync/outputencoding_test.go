@@ -0,0 +1,39 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteGeneratedContentEncoding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuwa.generated.js")
+	meta := GeneratedMeta{GameID: gameIDWuwa, GeneratedAt: "2026-03-08T10:00:00Z", Sheets: []string{}}
+	patches := []Patch{{ID: "3.4", Patch: "3.4", Notes: "first line"}}
+	if _, err := writeGeneratedContent(path, patches, meta, OutputEncoding{Newline: "CRLF", BOM: true}); err != nil {
+		t.Fatalf("writeGeneratedContent() error = %v", err)
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(body), utf8BOM+"// Auto-generated") {
+		t.Fatalf("generated file does not start with a BOM: %q", body[:20])
+	}
+	if lines := strings.Count(string(body), "\n"); lines == 0 || strings.Count(string(body), "\r\n") != lines {
+		t.Fatal("generated file has bare LF line endings")
+	}
+
+	read, err := readGeneratedPatches(path)
+	if err != nil || len(read) != 1 || read[0].ID != "3.4" || read[0].Notes != "first line" {
+		t.Fatalf("readGeneratedPatches() = %+v, %v; want the written patch back", read, err)
+	}
+	if readMeta, err := readGeneratedMeta(path); err != nil || readMeta.GeneratedAt != meta.GeneratedAt {
+		t.Fatalf("readGeneratedMeta() = %+v, %v", readMeta, err)
+	}
+
+	if err := (OutputEncoding{Newline: "cr"}).validate(); err == nil {
+		t.Fatal("expected an error for an unknown newline")
+	}
+}
@@ -0,0 +1,221 @@
+package patchsync
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOutputHistoryDir  = "tools/patchsync/logs/history"
+	defaultOutputHistoryKeep = 20
+	// outputHistoryLayout is the generatedAt of a kept version in a form that is a valid file name everywhere.
+	outputHistoryLayout = "20060102T150405Z"
+)
+
+// OutputHistory keeps the versions a sync or manual edit replaced as <Dir>/<game>/<generatedAt>.js.
+type OutputHistory struct {
+	Dir  string // history root (empty disables it)
+	Keep int    // versions to keep per game, oldest are deleted first (0 keeps all)
+}
+
+func defaultOutputHistory() OutputHistory {
+	return OutputHistory{Dir: defaultOutputHistoryDir, Keep: defaultOutputHistoryKeep}
+}
+
+type outputVersion struct {
+	GeneratedAt time.Time
+	Path        string
+}
+
+// generatedAtOf reads the generatedAt of a generated file, falling back to its modification time for files
+// written before the meta block carried one.
+func generatedAtOf(path string, body []byte) (time.Time, error) {
+	if meta, err := parseGeneratedMeta(body); err == nil {
+		if generatedAt, parseErr := time.Parse(time.RFC3339, meta.GeneratedAt); parseErr == nil {
+			return generatedAt.UTC(), nil
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime().UTC().Truncate(time.Second), nil
+}
+
+// rotateOutputHistory copies the generated file at outputPath into the game's history before it is
+// overwritten and prunes versions beyond history.Keep. It returns the kept path, or "" when there was nothing
+// to keep or that version is already kept.
+func rotateOutputHistory(outputPath, gameID string, history OutputHistory) (string, error) {
+	if strings.TrimSpace(history.Dir) == "" {
+		return "", nil
+	}
+	body, err := os.ReadFile(outputPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	generatedAt, err := generatedAtOf(outputPath, body)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(resolveOutputPath(history.Dir), gameID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create history dir: %w", err)
+	}
+	path := filepath.Join(dir, generatedAt.Format(outputHistoryLayout)+".js")
+	if _, statErr := os.Stat(path); statErr == nil {
+		return "", nil
+	}
+	if err := os.WriteFile(path, body, 0o644); err != nil {
+		return "", fmt.Errorf("keep previous output: %w", err)
+	}
+	return path, pruneOutputHistory(dir, history.Keep)
+}
+
+// outputHistoryVersions lists the kept versions in dir, oldest first. Files not named by generatedAt are ignored.
+func outputHistoryVersions(dir string) ([]outputVersion, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	versions := []outputVersion{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".js")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		generatedAt, parseErr := time.Parse(outputHistoryLayout, name)
+		if parseErr != nil {
+			continue
+		}
+		versions = append(versions, outputVersion{GeneratedAt: generatedAt, Path: filepath.Join(dir, entry.Name())})
+	}
+	sortOutputVersions(versions)
+	return versions, nil
+}
+
+func sortOutputVersions(versions []outputVersion) {
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].GeneratedAt.Before(versions[j].GeneratedAt) })
+}
+
+func pruneOutputHistory(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	versions, err := outputHistoryVersions(dir)
+	if err != nil {
+		return err
+	}
+	for len(versions) > keep {
+		if err := os.Remove(versions[0].Path); err != nil {
+			return fmt.Errorf("prune output history: %w", err)
+		}
+		versions = versions[1:]
+	}
+	return nil
+}
+
+// outputVersionAt returns the newest version generated at or before at: a kept one, or the current output
+// when that is newer than every kept version.
+func outputVersionAt(versions []outputVersion, at time.Time) (outputVersion, bool) {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !versions[i].GeneratedAt.After(at) {
+			return versions[i], true
+		}
+	}
+	return outputVersion{}, false
+}
+
+// parseShowAt reads --at. A bare date means the end of that day (UTC), so "2026-03-10" shows what was live
+// by the end of the 10th.
+func parseShowAt(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if parsed, err := time.Parse(time.RFC3339, raw); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --at %q (use YYYY-MM-DD or RFC3339)", raw)
+	}
+	return parsed.Add(24*time.Hour - time.Second), nil
+}
+
+func writeOutputVersionList(w io.Writer, versions []outputVersion) {
+	if len(versions) == 0 {
+		fmt.Fprintln(w, "No generated versions found.")
+		return
+	}
+	for _, version := range versions {
+		fmt.Fprintf(w, "%s  %s\n", version.GeneratedAt.Format(time.RFC3339), version.Path)
+	}
+}
+
+func runShowCommand(args []string) int {
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	var (
+		gameID     string
+		atRaw      string
+		outputPath string
+		historyDir string
+	)
+	fs.StringVar(&gameID, "game", defaultGameID, fmt.Sprintf("Game id (%s)", strings.Join(AvailableGameIDs(), ", ")))
+	fs.StringVar(&atRaw, "at", "", "Print the generated file as it was at this time (YYYY-MM-DD or RFC3339); without it, list the versions")
+	fs.StringVar(&outputPath, "output", "", "Current output JS file path (optional; defaults by game)")
+	fs.StringVar(&historyDir, "history-dir", defaultOutputHistoryDir, "Directory with <game>/<generatedAt>.js versions kept by earlier writes")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	profile, err := ResolveGameProfile(gameID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "show failed: %v\n", err)
+		return 2
+	}
+	versions, err := outputHistoryVersions(filepath.Join(resolveOutputPath(historyDir), profile.ID))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "show failed: %v\n", err)
+		return 1
+	}
+	if strings.TrimSpace(outputPath) == "" {
+		outputPath = profile.DefaultOutputPath
+	}
+	outputPath = resolveOutputPath(outputPath)
+	if body, readErr := os.ReadFile(outputPath); readErr == nil {
+		if generatedAt, atErr := generatedAtOf(outputPath, body); atErr == nil {
+			versions = append(versions, outputVersion{GeneratedAt: generatedAt, Path: outputPath})
+			sortOutputVersions(versions)
+		}
+	}
+	if strings.TrimSpace(atRaw) == "" {
+		writeOutputVersionList(os.Stdout, versions)
+		return 0
+	}
+	at, err := parseShowAt(atRaw)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "show failed: %v\n", err)
+		return 2
+	}
+	version, ok := outputVersionAt(versions, at)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "show failed: no %s version generated at or before %s\n", profile.ID, at.Format(time.RFC3339))
+		return 1
+	}
+	body, err := os.ReadFile(version.Path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "show failed: %v\n", err)
+		return 1
+	}
+	_, _ = os.Stdout.Write(body)
+	return 0
+}
@@ -0,0 +1,88 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeGeneratedForTest(t *testing.T, path, generatedAt string) {
+	t.Helper()
+	meta := GeneratedMeta{GameID: gameIDWuwa, GeneratedAt: generatedAt, Sheets: []string{}}
+	if err := writeGeneratedFile(path, []Patch{{ID: "3.4", Patch: "3.4"}}, meta); err != nil {
+		t.Fatalf("writeGeneratedFile() error = %v", err)
+	}
+}
+
+func TestRotateOutputHistoryKeepsAndPrunesVersions(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "wuwa.generated.js")
+	history := OutputHistory{Dir: filepath.Join(dir, "history"), Keep: 2}
+
+	if kept, err := rotateOutputHistory(outputPath, gameIDWuwa, history); err != nil || kept != "" {
+		t.Fatalf("rotate without output = %q, %v; want nothing kept", kept, err)
+	}
+	for _, generatedAt := range []string{"2026-03-01T10:00:00Z", "2026-03-08T10:00:00Z", "2026-03-15T10:00:00Z"} {
+		writeGeneratedForTest(t, outputPath, generatedAt)
+		if _, err := rotateOutputHistory(outputPath, gameIDWuwa, history); err != nil {
+			t.Fatalf("rotateOutputHistory() error = %v", err)
+		}
+	}
+	if kept, err := rotateOutputHistory(outputPath, gameIDWuwa, history); err != nil || kept != "" {
+		t.Fatalf("rotating the same version again = %q, %v; want nothing kept", kept, err)
+	}
+
+	versions, err := outputHistoryVersions(filepath.Join(history.Dir, gameIDWuwa))
+	if err != nil {
+		t.Fatalf("outputHistoryVersions() error = %v", err)
+	}
+	names := []string{}
+	for _, version := range versions {
+		names = append(names, filepath.Base(version.Path))
+	}
+	if len(names) != 2 || names[0] != "20260308T100000Z.js" || names[1] != "20260315T100000Z.js" {
+		t.Fatalf("kept versions = %v, want the two newest", names)
+	}
+}
+
+func TestOutputVersionAt(t *testing.T) {
+	dir := t.TempDir()
+	historyDir := filepath.Join(dir, "history", gameIDWuwa)
+	outputPath := filepath.Join(dir, "wuwa.generated.js")
+	writeGeneratedForTest(t, outputPath, "2026-03-08T10:00:00Z")
+	if _, err := rotateOutputHistory(outputPath, gameIDWuwa, OutputHistory{Dir: filepath.Join(dir, "history")}); err != nil {
+		t.Fatalf("rotateOutputHistory() error = %v", err)
+	}
+	versions, err := outputHistoryVersions(historyDir)
+	if err != nil {
+		t.Fatalf("outputHistoryVersions() error = %v", err)
+	}
+	versions = append(versions, outputVersion{GeneratedAt: time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC), Path: outputPath})
+
+	at, err := parseShowAt("2026-03-10")
+	if err != nil {
+		t.Fatalf("parseShowAt() error = %v", err)
+	}
+	version, ok := outputVersionAt(versions, at)
+	if !ok || filepath.Base(version.Path) != "20260308T100000Z.js" {
+		t.Fatalf("version at %s = %+v, %t; want the 03-08 copy", at, version, ok)
+	}
+	body, err := os.ReadFile(version.Path)
+	if err != nil {
+		t.Fatalf("read kept version: %v", err)
+	}
+	if meta, _ := parseGeneratedMeta(body); meta.GeneratedAt != "2026-03-08T10:00:00Z" {
+		t.Fatalf("kept version generatedAt = %q", meta.GeneratedAt)
+	}
+
+	if version, ok := outputVersionAt(versions, time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)); !ok || version.Path != outputPath {
+		t.Fatalf("latest version = %+v, %t; want the current output", version, ok)
+	}
+	if _, ok := outputVersionAt(versions, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)); ok {
+		t.Fatal("expected no version before the first one")
+	}
+	if _, err := parseShowAt("last tuesday"); err == nil {
+		t.Fatal("expected an error for an unparseable --at")
+	}
+}
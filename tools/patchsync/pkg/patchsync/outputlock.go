@@ -0,0 +1,30 @@
+package patchsync
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// outputLocks holds one mutex per generated file. A sync and a manual patch edit both keep it from reading the
+// file to writing it back, so neither overwrites the other's change.
+var outputLocks = struct {
+	mu     sync.Mutex
+	byPath map[string]*sync.Mutex
+}{byPath: map[string]*sync.Mutex{}}
+
+// lockOutput locks the generated file at path and returns the unlock func.
+func lockOutput(path string) func() {
+	key := filepath.Clean(path)
+	if abs, err := filepath.Abs(key); err == nil {
+		key = abs
+	}
+	outputLocks.mu.Lock()
+	lock, ok := outputLocks.byPath[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		outputLocks.byPath[key] = lock
+	}
+	outputLocks.mu.Unlock()
+	lock.Lock()
+	return lock.Unlock
+}
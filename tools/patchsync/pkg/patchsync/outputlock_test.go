@@ -0,0 +1,96 @@
+package patchsync
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedSheetFetcher serves sheets from memory but holds the named sheet until release is closed.
+type gatedSheetFetcher struct {
+	fakeSheetFetcher
+	gate    string
+	started chan struct{}
+	release chan struct{}
+}
+
+func (f gatedSheetFetcher) FetchCSV(ctx context.Context, id string, sheetName string) (string, error) {
+	if sheetName == f.gate {
+		close(f.started)
+		<-f.release
+	}
+	return f.fakeSheetFetcher.FetchCSV(ctx, id, sheetName)
+}
+
+func TestSyncAndManualPatchKeepBothChanges(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "tools/patchsync/logs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fetcher := gatedSheetFetcher{
+		fakeSheetFetcher: fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+		gate:             "3.4",
+		started:          make(chan struct{}),
+		release:          make(chan struct{}),
+	}
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		AllowHistorical: true,
+		Fetcher:         fetcher,
+	}
+	tokens, err := newAuthTokens("", "sync:secret", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	registerPatchAPI(mux, nil, tokens, cfg)
+
+	var wg sync.WaitGroup
+	var syncErr error
+	wg.Go(func() {
+		_, syncErr = RunSync(t.Context(), cfg)
+	})
+	<-fetcher.started
+	var putCode int
+	wg.Go(func() {
+		patch := `{"versionName":"Manual","startDate":"2026-03-01","durationDays":42,"notes":"",
+			"sources":[{"id":"events","label":"Events","gate":"always","optionKey":null,"countInPulls":true,
+			"rewards":{"astrite":1600},"costs":{},"scalers":[]}]}`
+		req := httptest.NewRequest(http.MethodPut, "/patches/wuthering-waves/3.0", strings.NewReader(patch))
+		req.Header.Set("X-Patchsync-Token", "secret")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		putCode = rec.Code
+	})
+	// Give the PUT time to reach the generated file while the sync still holds the sheet.
+	time.Sleep(50 * time.Millisecond)
+	close(fetcher.release)
+	wg.Wait()
+
+	if syncErr != nil || putCode != http.StatusOK {
+		t.Fatalf("RunSync() error = %v, PUT = %d", syncErr, putCode)
+	}
+	patches, err := readGeneratedPatches(cfg.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, patch := range patches {
+		ids = append(ids, patchIDOrFallback(patch))
+	}
+	if strings.Join(ids, ",") != "3.0,3.4" {
+		t.Fatalf("generated patches = %v, want the manual 3.0 and the synced 3.4", ids)
+	}
+}
@@ -0,0 +1,190 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const defaultOverridesDir = "tools/patchsync/overrides"
+
+type sourceOverride struct {
+	Pulls   *float64           `json:"pulls"`
+	Rewards map[string]float64 `json:"rewards"`
+}
+
+type patchOverride struct {
+	StartDate *string                   `json:"startDate"`
+	Notes     *string                   `json:"notes"`
+	Tags      []string                  `json:"tags"`
+	Sources   map[string]sourceOverride `json:"sources"`
+}
+
+type gameOverrides struct {
+	Patches          map[string]patchOverride `json:"patches"`
+	Sheets           sheetPatterns            `json:"sheets"`
+	Merge            mergePolicies            `json:"merge"`
+	Aliases          map[string]string        `json:"aliases"`
+	Strictness       string                   `json:"strictness"`
+	DecimalSeparator string                   `json:"decimalSeparator"`
+	Bounds           currencyBounds           `json:"bounds"`
+	PullTolerance    *float64                 `json:"pullTolerance"`
+	Reconcile        string                   `json:"reconcile"`
+}
+
+func overridesPathForGame(dir, gameID string) string {
+	if strings.TrimSpace(dir) == "" {
+		dir = defaultOverridesDir
+	}
+	return filepath.Join(resolveFilePath(dir), gameID+".json")
+}
+
+func readGameOverridesFile(path string) (gameOverrides, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return gameOverrides{}, nil
+		}
+		return gameOverrides{}, err
+	}
+	var payload gameOverrides
+	decoder := json.NewDecoder(strings.NewReader(string(body)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil {
+		return gameOverrides{}, fmt.Errorf("parse overrides file %s: %w", path, err)
+	}
+	return payload, nil
+}
+
+// gameConfig is an overrides file after validation, with the defaults filled in for the keys it leaves out.
+type gameConfig struct {
+	Patches          map[string]patchOverride
+	Sheets           sheetPatterns
+	Merge            mergePolicies
+	Aliases          patchAliases
+	Strictness       string
+	DecimalSeparator string
+	Bounds           currencyBounds
+	PullTolerance    float64
+	Reconcile        string
+}
+
+// readGameConfig decodes the overrides file of a game once and validates all of it. A missing file gives the
+// defaults. Unreadable files fail with ERR_LOCAL_FILE, invalid settings with ERR_CONFIG.
+func readGameConfig(path, gameID string) (gameConfig, error) {
+	payload, err := readGameOverridesFile(path)
+	if err != nil {
+		return gameConfig{}, withErrorCode(errCodeLocalFile, err)
+	}
+	config, err := payload.resolve(gameID)
+	if err != nil {
+		return gameConfig{}, withErrorCode(errCodeConfig, fmt.Errorf("overrides file %s: %w", path, err))
+	}
+	return config, nil
+}
+
+func (payload gameOverrides) resolve(gameID string) (gameConfig, error) {
+	config := gameConfig{
+		Patches:       map[string]patchOverride{},
+		Sheets:        payload.Sheets,
+		Merge:         payload.Merge,
+		Bounds:        payload.Bounds,
+		PullTolerance: defaultPullTolerance,
+		Reconcile:     parseReconcileTarget(payload.Reconcile),
+	}
+	for rawID, override := range payload.Patches {
+		if patchID := canonicalPatchID(rawID); patchID != "" {
+			config.Patches[patchID] = override
+		}
+	}
+	if err := payload.Merge.validate(); err != nil {
+		return gameConfig{}, err
+	}
+	aliases, err := newPatchAliases(payload.Aliases)
+	if err != nil {
+		return gameConfig{}, err
+	}
+	config.Aliases = aliases
+	if config.Strictness, err = parseStrictnessLevel(payload.Strictness); err != nil {
+		return gameConfig{}, err
+	}
+	if config.DecimalSeparator, err = parseDecimalSeparator(payload.DecimalSeparator); err != nil {
+		return gameConfig{}, err
+	}
+	if err := payload.Bounds.validate(gameID); err != nil {
+		return gameConfig{}, err
+	}
+	if payload.PullTolerance != nil {
+		if *payload.PullTolerance < 0 {
+			return gameConfig{}, fmt.Errorf("pullTolerance %v is negative", *payload.PullTolerance)
+		}
+		config.PullTolerance = *payload.PullTolerance
+	}
+	return config, nil
+}
+
+// validate checks an override against the patch it is for, so applyPatchOverride changes nothing when any part
+// of the override is wrong.
+func (override patchOverride) validate(patch Patch) error {
+	if override.StartDate != nil {
+		if date := strings.TrimSpace(*override.StartDate); date != "" {
+			if _, err := time.Parse(isoDateLayout, date); err != nil {
+				return fmt.Errorf("override startDate %q must be YYYY-MM-DD", *override.StartDate)
+			}
+		}
+	}
+	sourceIDs := map[string]bool{}
+	for _, src := range patch.Sources {
+		sourceIDs[src.ID] = true
+	}
+	for _, sourceID := range slices.Sorted(maps.Keys(override.Sources)) {
+		if !sourceIDs[sourceID] {
+			return fmt.Errorf("override references unknown source %q", sourceID)
+		}
+		probe := Rewards{}
+		for _, key := range slices.Sorted(maps.Keys(override.Sources[sourceID].Rewards)) {
+			if probe.mappedField(key) == nil {
+				return fmt.Errorf("override for source %q references unknown currency %q", sourceID, key)
+			}
+		}
+	}
+	return nil
+}
+
+func applyPatchOverride(patch *Patch, override patchOverride) error {
+	if patch == nil {
+		return errors.New("patch is nil")
+	}
+	if err := override.validate(*patch); err != nil {
+		return err
+	}
+	if override.StartDate != nil {
+		patch.StartDate = strings.TrimSpace(*override.StartDate)
+	}
+	if override.Notes != nil {
+		patch.Notes = *override.Notes
+	}
+	if override.Tags != nil {
+		patch.Tags = mergeTagLists(nil, override.Tags)
+	}
+	for idx := range patch.Sources {
+		srcOverride, ok := override.Sources[patch.Sources[idx].ID]
+		if !ok {
+			continue
+		}
+		if srcOverride.Pulls != nil {
+			v := roundToTenth(*srcOverride.Pulls)
+			patch.Sources[idx].Pulls = &v
+		}
+		for key, value := range srcOverride.Rewards {
+			patch.Sources[idx].Rewards.setMappedValue(key, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyPatchOverride(t *testing.T) {
+	newPatch := func() Patch {
+		return Patch{
+			ID:        "2.3",
+			Patch:     "2.3",
+			StartDate: "",
+			Notes:     "Generated from Wuthering Waves Google Sheets by patchsync",
+			Sources: []Source{
+				source("events", "Version Events", "always", nil, true, Rewards{Oroberyl: 5000, Chartered: 30}),
+				source("permanent", "Permanent Content", "always", nil, true, Rewards{Oroberyl: 2000}),
+			},
+		}
+	}
+
+	t.Run("sets fields and game-specific currencies", func(t *testing.T) {
+		patch := newPatch()
+		startDate := "2025-04-29"
+		notes := "Events corrected manually"
+		pulls := 42.04
+		err := applyPatchOverride(&patch, patchOverride{
+			StartDate: &startDate,
+			Notes:     &notes,
+			Tags:      []string{"WIP", "WIP"},
+			Sources: map[string]sourceOverride{
+				"events": {
+					Pulls:   &pulls,
+					Rewards: map[string]float64{"astrite": 5200},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("applyPatchOverride() error = %v", err)
+		}
+		if patch.StartDate != startDate || patch.Notes != notes {
+			t.Errorf("startDate/notes = %q/%q, want %q/%q", patch.StartDate, patch.Notes, startDate, notes)
+		}
+		if len(patch.Tags) != 1 || patch.Tags[0] != "WIP" {
+			t.Errorf("tags = %v, want [WIP]", patch.Tags)
+		}
+		events := patch.Sources[0]
+		if events.Rewards.Oroberyl != 5200 || events.Rewards.Chartered != 30 {
+			t.Errorf("events rewards = %+v, want oroberyl 5200 and chartered untouched", events.Rewards)
+		}
+		if events.Pulls == nil || *events.Pulls != 42 {
+			t.Errorf("events pulls = %v, want 42", events.Pulls)
+		}
+		if patch.Sources[1].Pulls != nil {
+			t.Errorf("permanent pulls should stay unset")
+		}
+	})
+
+	t.Run("unknown source", func(t *testing.T) {
+		patch := newPatch()
+		err := applyPatchOverride(&patch, patchOverride{
+			Sources: map[string]sourceOverride{"coralShop": {}},
+		})
+		if err == nil || !strings.Contains(err.Error(), "unknown source") {
+			t.Fatalf("expected unknown source error, got %v", err)
+		}
+	})
+
+	t.Run("unknown currency", func(t *testing.T) {
+		patch := newPatch()
+		err := applyPatchOverride(&patch, patchOverride{
+			Sources: map[string]sourceOverride{
+				"events": {Rewards: map[string]float64{"gold": 1}},
+			},
+		})
+		if err == nil || !strings.Contains(err.Error(), "unknown currency") {
+			t.Fatalf("expected unknown currency error, got %v", err)
+		}
+	})
+
+	t.Run("invalid override leaves the patch alone", func(t *testing.T) {
+		patch := newPatch()
+		startDate, notes, pulls := "2025-04-29", "changed", 10.0
+		err := applyPatchOverride(&patch, patchOverride{
+			StartDate: &startDate,
+			Notes:     &notes,
+			Tags:      []string{"WIP"},
+			Sources: map[string]sourceOverride{
+				"events":    {Pulls: &pulls},
+				"permanent": {Rewards: map[string]float64{"gold": 1}},
+			},
+		})
+		if err == nil {
+			t.Fatal("expected unknown currency error")
+		}
+		if !reflect.DeepEqual(patch, newPatch()) {
+			t.Fatalf("patch changed by a failed override: %+v", patch)
+		}
+	})
+
+	t.Run("start date format", func(t *testing.T) {
+		patch := newPatch()
+		startDate := "29.04.2025"
+		err := applyPatchOverride(&patch, patchOverride{StartDate: &startDate})
+		if err == nil || !strings.Contains(err.Error(), "YYYY-MM-DD") {
+			t.Fatalf("expected start date error, got %v", err)
+		}
+	})
+}
+
+func TestReadGameConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuwa.json")
+	write := func(body string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"patches":{"v3.4":{"notes":"x"}},"strictness":"strict","pullTolerance":1,"reconcile":"Proportional"}`)
+	got, err := readGameConfig(path, gameIDWuwa)
+	if err != nil {
+		t.Fatalf("readGameConfig() error = %v", err)
+	}
+	if _, ok := got.Patches["3.4"]; !ok || got.Strictness != parseStrict || got.DecimalSeparator != decimalAuto || got.PullTolerance != 1 || got.Reconcile != reconcileProportional {
+		t.Fatalf("readGameConfig() = %+v", got)
+	}
+
+	for body, code := range map[string]string{
+		`{"strictness":"loose"}`:     errCodeConfig,
+		`{"pullTolerance":-1}`:       errCodeConfig,
+		`{"bounds":{"primogem":{}}}`: errCodeConfig,
+		`{"unknown":true}`:           errCodeLocalFile,
+	} {
+		write(body)
+		if _, err := readGameConfig(path, gameIDWuwa); errorCode(err) != code {
+			t.Fatalf("readGameConfig(%s) = %v, want %s", body, err, code)
+		}
+	}
+}
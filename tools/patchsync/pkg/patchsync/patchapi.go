@@ -0,0 +1,310 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	errManualPatchNotFound = errors.New("patch not found")
+
+	manualPatchGates     = []string{"always", "monthly", "bp2", "bp3"}
+	manualScalerUnits    = []string{"day", "cycle"}
+	manualScalerRounding = []string{"floor", "ceil", "round"}
+)
+
+// validateManualPatch applies the checks src/data/patches.js runs on load, so a manual edit can never produce
+// a file the site refuses.
+func validateManualPatch(patch Patch) error {
+	if patchIDOrFallback(patch) == "" {
+		return errors.New("patch id is required")
+	}
+	if start := strings.TrimSpace(patch.StartDate); start != "" {
+		if _, err := time.Parse(isoDateLayout, start); err != nil {
+			return fmt.Errorf("startDate %q must be YYYY-MM-DD", patch.StartDate)
+		}
+	}
+	if patch.DurationDays <= 0 {
+		return errors.New("durationDays must be positive")
+	}
+	if len(patch.Sources) == 0 {
+		return errors.New("sources must not be empty")
+	}
+	seen := map[string]struct{}{}
+	for idx, src := range patch.Sources {
+		sourceID := strings.TrimSpace(src.ID)
+		if sourceID == "" {
+			return fmt.Errorf("source %d: id is required", idx)
+		}
+		if _, dup := seen[sourceID]; dup {
+			return fmt.Errorf("source %s is listed twice", sourceID)
+		}
+		seen[sourceID] = struct{}{}
+		if strings.TrimSpace(src.Label) == "" {
+			return fmt.Errorf("source %s: label is required", sourceID)
+		}
+		if !slices.Contains(manualPatchGates, src.Gate) {
+			return fmt.Errorf("source %s: gate %q is not valid (use %s)", sourceID, src.Gate, strings.Join(manualPatchGates, ", "))
+		}
+		for _, scaler := range src.Scalers {
+			if scaler.Type != "per_duration" {
+				return fmt.Errorf("source %s: scaler type %q is not valid (use per_duration)", sourceID, scaler.Type)
+			}
+			if !slices.Contains(manualScalerUnits, scaler.Unit) {
+				return fmt.Errorf("source %s: scaler unit %q is not valid (use %s)", sourceID, scaler.Unit, strings.Join(manualScalerUnits, ", "))
+			}
+			if scaler.EveryDays <= 0 {
+				return fmt.Errorf("source %s: scaler everyDays must be positive", sourceID)
+			}
+			if !slices.Contains(manualScalerRounding, scaler.Rounding) {
+				return fmt.Errorf("source %s: scaler rounding %q is not valid (use %s)", sourceID, scaler.Rounding, strings.Join(manualScalerRounding, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// manualPatchChange is the outcome of one manual edit, shaped like the parts of a sync result it reports.
+type manualPatchChange struct {
+	Entry      patchChangeLogEntry
+	Stats      syncStats
+	OutputPath string
+	Logs       []string
+}
+
+// applyManualPatch replaces (next != nil) or deletes the patch patchID in the game's generated file, then
+// refreshes the static API and change log the way a sync does. Manual edits are written even when the
+// patch is frozen: they are how historical corrections are meant to be made.
+func applyManualPatch(cfg SyncConfig, gameID, patchID string, next *Patch) (manualPatchChange, error) {
+	profile, err := ResolveGameProfile(gameID)
+	if err != nil {
+		return manualPatchChange{}, withErrorCode(errCodeUnknownGame, err)
+	}
+	clock := clockOrSystem(cfg.Clock)
+	logs := syncLog{requestID: cfg.RequestID, clock: clock}
+	outputPath := resolveOutputPath(configuredOutputPath(cfg, profile))
+
+	defer lockOutput(outputPath)()
+	existing, err := readGeneratedPatches(outputPath)
+	if err != nil {
+		return manualPatchChange{}, withErrorCode(errCodeLocalFile, fmt.Errorf("read generated patches: %w", err))
+	}
+	var previous Patch
+	hadPrevious := false
+	kept := make([]Patch, 0, len(existing))
+	for _, patch := range existing {
+		if patchIDOrFallback(patch) == patchID {
+			previous, hadPrevious = patch, true
+			continue
+		}
+		kept = append(kept, patch)
+	}
+
+	entry := patchChangeLogEntry{Patch: patchID, ChangeType: "removed", ChangedSources: []string{}}
+	updated := kept
+	if next != nil {
+		regions := cfg.ServerRegions
+		if len(regions) == 0 {
+			regions = profile.Regions
+		}
+		startRaw := strings.TrimSpace(cfg.PatchStart)
+		if startRaw == "" {
+			startRaw = profile.PatchStart
+		}
+		if startRaw == "" {
+			startRaw = defaultPatchStart
+		}
+		start, startErr := parsePatchStart(startRaw)
+		if startErr != nil {
+			return manualPatchChange{}, withErrorCode(errCodeConfig, startErr)
+		}
+		patch := *next
+		patch.Tags = mergeTagLists(patch.Tags, nil)
+		patch.StartTimes = patchStartTimes(patch.StartDate, regions, start)
+		updated = mergePatchesByID(kept, []Patch{patch})
+		entry.ChangeType = "added"
+		if hadPrevious {
+			entry.ChangeType = "updated"
+			entry.ChangedSources = changedSourceIDs(previous, patch)
+			if diff := diffPatchPair(previous, patch, profile.ID); diff != nil {
+				entry.Fields = diff.Fields
+				entry.Sources = diff.Sources
+			}
+		}
+	} else if !hadPrevious {
+		return manualPatchChange{}, errManualPatchNotFound
+	}
+
+	meta, err := readGeneratedMeta(outputPath)
+	if err != nil || meta.GameID == "" {
+		meta = GeneratedMeta{GameID: profile.ID, Sheets: []string{}}
+	}
+	generatedAt := clock.Now().UTC().Format(time.RFC3339)
+	meta.GeneratedAt = generatedAt
+	change := manualPatchChange{
+		Entry:      entry,
+		Stats:      buildSyncStats(profile.ID, []patchChangeLogEntry{entry}, existing, updated),
+		OutputPath: outputPath,
+	}
+	if kept, historyErr := rotateOutputHistory(outputPath, profile.ID, cfg.OutputHistory); historyErr != nil {
+		appendSyncLog(&logs, "output history failed: %v", historyErr)
+	} else if kept != "" {
+		appendSyncLog(&logs, "previous output kept as %s", kept)
+	}
+	content, err := writeGeneratedContent(outputPath, updated, meta, cfg.OutputEncoding)
+	if err != nil {
+		return manualPatchChange{}, withErrorCode(errCodeWrite, err)
+	}
+	change.Stats.recordOutput(content)
+	appendSyncLog(&logs, "manual %s of patch %s written to %s", entry.ChangeType, patchID, outputPath)
+
+	if strings.TrimSpace(cfg.APIDir) != "" {
+		if apiErr := writeStaticAPI(cfg.APIDir, updated, meta); apiErr != nil {
+			appendSyncLog(&logs, "static api export failed: %v", apiErr)
+		}
+	}
+	record := syncChangeLogRecord{
+		Timestamp:      clock.Now().UTC().Format(time.RFC3339),
+		GameID:         profile.ID,
+		SpreadsheetID:  "manual",
+		OutputPath:     outputPath,
+		GeneratedAt:    generatedAt,
+		UpdatedPatches: []patchChangeLogEntry{entry},
+	}
+	if _, logErr := appendChangeLogRecordWithRetention(resolveOutputPath(defaultChangeLogPath), cfg.ChangeLogLimits, record, clock.Now()); logErr != nil {
+		appendSyncLog(&logs, "change log write failed: %v", logErr)
+	}
+	if strings.TrimSpace(cfg.HistoryDB) != "" {
+		if mirrorErr := mirrorChangeLogRecord(cfg.HistoryDB, record); mirrorErr != nil {
+			appendSyncLog(&logs, "history database write failed: %v", mirrorErr)
+		}
+	}
+	change.Logs = logs.lines
+	return change, nil
+}
+
+// registerPatchAPI adds PUT and DELETE /patches/{game}/{id} for maintaining patches by hand, for games or
+// versions the spreadsheets do not cover yet. Both need a sync-scoped token.
+func registerPatchAPI(mux *http.ServeMux, allowedOrigins map[string]struct{}, tokens *authTokens, cfg SyncConfig) {
+	// guard runs the shared CORS/auth checks and resolves the game and patch id from the path.
+	guard := func(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+		if !withCORS(w, r, allowedOrigins) {
+			writeJSON(w, http.StatusForbidden, syncResponse{
+				OK:      false,
+				Message: "origin is not allowed",
+			})
+			return "", "", false
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "PUT, DELETE, OPTIONS")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return "", "", false
+		}
+		if !tokens.authorize(r, scopeSync) {
+			writeJSON(w, http.StatusUnauthorized, syncResponse{
+				OK:      false,
+				Message: "unauthorized",
+			})
+			return "", "", false
+		}
+		profile, err := ResolveGameProfile(r.PathValue("game"))
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, syncResponse{
+				OK:      false,
+				Message: err.Error(),
+			})
+			return "", "", false
+		}
+		patchID := canonicalPatchID(r.PathValue("id"))
+		if patchID == "" {
+			writeJSON(w, http.StatusBadRequest, syncResponse{
+				OK:      false,
+				Message: "patch id is required",
+			})
+			return "", "", false
+		}
+		return profile.ID, patchID, true
+	}
+	respond := func(w http.ResponseWriter, gameID string, change manualPatchChange, err error) {
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, errManualPatchNotFound) {
+				status = http.StatusNotFound
+			}
+			writeJSON(w, status, syncResponse{
+				OK:      false,
+				Message: err.Error(),
+				Code:    errorCode(err),
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, syncResponse{
+			OK:          true,
+			Message:     fmt.Sprintf("patch %s %s", change.Entry.Patch, change.Entry.ChangeType),
+			GameID:      gameID,
+			Patches:     []string{change.Entry.Patch},
+			OutputPath:  change.OutputPath,
+			ChangeCount: 1,
+			Stats:       &change.Stats,
+			Logs:        change.Logs,
+		})
+	}
+
+	mux.HandleFunc("OPTIONS /patches/{game}/{id}", func(w http.ResponseWriter, r *http.Request) {
+		guard(w, r)
+	})
+	mux.HandleFunc("PUT /patches/{game}/{id}", func(w http.ResponseWriter, r *http.Request) {
+		gameID, patchID, ok := guard(w, r)
+		if !ok {
+			return
+		}
+		// dailyIncome is derived on write; accepting it lets a patch copied from the generated file be sent back.
+		var body struct {
+			Patch
+			DailyIncome json.RawMessage `json:"dailyIncome"`
+		}
+		if err := parseSyncRequestBody(r, &body, cfg.maxBodyBytes()); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		patch := body.Patch
+		for _, field := range []*string{&patch.ID, &patch.Patch} {
+			if strings.TrimSpace(*field) == "" {
+				*field = patchID
+			}
+		}
+		if canonicalPatchID(patch.ID) != patchID || canonicalPatchID(patch.Patch) != patchID {
+			writeJSON(w, http.StatusBadRequest, syncResponse{
+				OK:      false,
+				Message: fmt.Sprintf("payload id does not match patch %s in the path", patchID),
+			})
+			return
+		}
+		if err := validateManualPatch(patch); err != nil {
+			writeJSON(w, http.StatusBadRequest, syncResponse{
+				OK:      false,
+				Message: err.Error(),
+			})
+			return
+		}
+		patchCfg := cfg
+		patchCfg.RequestID = requestIDFromContext(r.Context())
+		change, err := applyManualPatch(patchCfg, gameID, patchID, &patch)
+		respond(w, gameID, change, err)
+	})
+	mux.HandleFunc("DELETE /patches/{game}/{id}", func(w http.ResponseWriter, r *http.Request) {
+		gameID, patchID, ok := guard(w, r)
+		if !ok {
+			return
+		}
+		patchCfg := cfg
+		patchCfg.RequestID = requestIDFromContext(r.Context())
+		change, err := applyManualPatch(patchCfg, gameID, patchID, nil)
+		respond(w, gameID, change, err)
+	})
+}
@@ -0,0 +1,84 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManualPatchAPI(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for _, sub := range []string{"src/data", "tools/patchsync/logs"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", sub, err)
+		}
+	}
+	tokens, err := newAuthTokens("", "sync:secret", "", false)
+	if err != nil {
+		t.Fatalf("newAuthTokens() error = %v", err)
+	}
+	mux := http.NewServeMux()
+	registerPatchAPI(mux, nil, tokens, SyncConfig{})
+	send := func(method, path, body string) (int, syncResponse) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-Patchsync-Token", "secret")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var resp syncResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	patch := `{"versionName":"Manual","startDate":"2026-03-01","durationDays":42,"notes":"",
+		"sources":[{"id":"events","label":"Events","gate":"always","optionKey":null,"countInPulls":true,
+		"rewards":{"astrite":1600},"costs":{},"scalers":[]}]}`
+	code, resp := send(http.MethodPut, "/patches/wuthering-waves/3.0", patch)
+	if code != http.StatusOK || resp.Stats == nil || resp.Stats.Added != 1 || resp.Stats.RewardsDelta["astrite"] != 1600 {
+		t.Fatalf("PUT = %d %+v", code, resp)
+	}
+	generated, err := readGeneratedPatches(resp.OutputPath)
+	if err != nil || len(generated) != 1 || generated[0].ID != "3.0" || generated[0].Sources[0].Rewards.Oroberyl != 1600 {
+		t.Fatalf("generated = %+v, %v", generated, err)
+	}
+	if generated[0].StartTimes["asia"] != "2026-03-01T04:00:00+08:00" {
+		t.Fatalf("start times = %v", generated[0].StartTimes)
+	}
+
+	code, resp = send(http.MethodPut, "/patches/wuthering-waves/3.0", strings.Replace(patch, "1600", "2000", 1))
+	if code != http.StatusOK || resp.Stats.Updated != 1 || resp.Stats.RewardsDelta["astrite"] != 400 {
+		t.Fatalf("second PUT = %d %+v", code, resp)
+	}
+	for _, bad := range []string{
+		strings.Replace(patch, `"always"`, `"weekly"`, 1),
+		strings.Replace(patch, `"durationDays":42`, `"durationDays":0`, 1),
+		`{"id":"3.1","durationDays":42,"sources":[]}`,
+	} {
+		if code, resp := send(http.MethodPut, "/patches/wuthering-waves/3.0", bad); code != http.StatusBadRequest {
+			t.Fatalf("invalid PUT = %d %+v", code, resp)
+		}
+	}
+
+	changeLog, err := os.ReadFile(filepath.Join(dir, defaultChangeLogPath))
+	if err != nil || strings.Count(string(changeLog), `"spreadsheetId":"manual"`) != 2 {
+		t.Fatalf("change log = %s, %v", changeLog, err)
+	}
+
+	if code, resp := send(http.MethodDelete, "/patches/wuthering-waves/3.0", ""); code != http.StatusOK || resp.Stats.Removed != 1 {
+		t.Fatalf("DELETE = %d %+v", code, resp)
+	}
+	if code, _ := send(http.MethodDelete, "/patches/wuthering-waves/3.0", ""); code != http.StatusNotFound {
+		t.Fatalf("second DELETE = %d, want 404", code)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/patches/wuthering-waves/3.0", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated DELETE = %d, want 401", rec.Code)
+	}
+}
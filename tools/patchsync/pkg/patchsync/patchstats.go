@@ -0,0 +1,133 @@
+package patchsync
+
+import (
+	"sort"
+	"time"
+)
+
+// pullTrend compares the pull income of patches that started in the year up to the newest patch with the
+// year before it. Rates are per day of patch duration so patches of different lengths compare fairly.
+type pullTrend struct {
+	From                   string  `json:"from"`
+	To                     string  `json:"to"`
+	Patches                int     `json:"patches"`
+	F2PPullsPerDay         float64 `json:"f2pPullsPerDay"`
+	PaidPullsPerDay        float64 `json:"paidPullsPerDay"`
+	PreviousF2PPullsPerDay float64 `json:"previousF2pPullsPerDay"`
+	ChangePercent          float64 `json:"changePercent"`
+}
+
+// generatedStats is the GENERATED_STATS export. F2P and paid pulls are counted the same way as
+// GENERATED_CUMULATIVE; forecast patches are left out.
+type generatedStats struct {
+	Patches         int        `json:"patches"`
+	MeanF2PPulls    float64    `json:"meanF2pPulls"`
+	MedianF2PPulls  float64    `json:"medianF2pPulls"`
+	MeanPaidPulls   float64    `json:"meanPaidPulls"`
+	MedianPaidPulls float64    `json:"medianPaidPulls"`
+	F2PToPaidRatio  float64    `json:"f2pToPaidRatio"`
+	LastYear        *pullTrend `json:"lastYear,omitempty"`
+}
+
+func meanOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total / float64(len(values))
+}
+
+func medianOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
+
+type patchPulls struct {
+	start    time.Time
+	duration int
+	f2p      float64
+	paid     float64
+}
+
+// pullsPerDay sums the pulls and durations of patches starting in [from, to] and returns the daily rates.
+func pullsPerDay(rows []patchPulls, from, to time.Time) (int, float64, float64) {
+	count, days, f2p, paid := 0, 0, 0.0, 0.0
+	for _, row := range rows {
+		if row.start.Before(from) || row.start.After(to) {
+			continue
+		}
+		count++
+		days += row.duration
+		f2p += row.f2p
+		paid += row.paid
+	}
+	if days == 0 {
+		return count, 0, 0
+	}
+	return count, f2p / float64(days), paid / float64(days)
+}
+
+func buildGeneratedStats(gameID string, patches []Patch) generatedStats {
+	rows := []patchPulls{}
+	f2pValues, paidValues := []float64{}, []float64{}
+	totalF2P, totalPaid := 0.0, 0.0
+	for _, patch := range withoutForecastPatches(patches) {
+		f2p := computePatchTotals(gameID, patch, projectionOptions{}).Pulls
+		paid := computePatchTotals(gameID, patch, projectionOptions{MonthlySub: true, BattlePassTier: 3}).Pulls - f2p
+		f2pValues = append(f2pValues, f2p)
+		paidValues = append(paidValues, paid)
+		totalF2P += f2p
+		totalPaid += paid
+		if start, err := time.Parse(isoDateLayout, patch.StartDate); err == nil && patch.DurationDays > 0 {
+			rows = append(rows, patchPulls{start: start, duration: patch.DurationDays, f2p: f2p, paid: paid})
+		}
+	}
+	stats := generatedStats{
+		Patches:         len(f2pValues),
+		MeanF2PPulls:    roundToTenth(meanOf(f2pValues)),
+		MedianF2PPulls:  roundToTenth(medianOf(f2pValues)),
+		MeanPaidPulls:   roundToTenth(meanOf(paidValues)),
+		MedianPaidPulls: roundToTenth(medianOf(paidValues)),
+	}
+	if totalPaid > 0 {
+		stats.F2PToPaidRatio = roundToHundredth(totalF2P / totalPaid)
+	}
+	if len(rows) == 0 {
+		return stats
+	}
+
+	// The window ends at the newest start date rather than today, so the export only changes with the data.
+	latest := rows[0].start
+	for _, row := range rows[1:] {
+		if row.start.After(latest) {
+			latest = row.start
+		}
+	}
+	from := latest.AddDate(-1, 0, 0).AddDate(0, 0, 1)
+	count, f2pRate, paidRate := pullsPerDay(rows, from, latest)
+	_, previousRate, _ := pullsPerDay(rows, from.AddDate(-1, 0, 0), from.AddDate(0, 0, -1))
+	trend := &pullTrend{
+		From:                   from.Format(isoDateLayout),
+		To:                     latest.Format(isoDateLayout),
+		Patches:                count,
+		F2PPullsPerDay:         roundToHundredth(f2pRate),
+		PaidPullsPerDay:        roundToHundredth(paidRate),
+		PreviousF2PPullsPerDay: roundToHundredth(previousRate),
+	}
+	if previousRate > 0 {
+		trend.ChangePercent = roundToTenth((f2pRate - previousRate) / previousRate * 100)
+	}
+	stats.LastYear = trend
+	return stats
+}
@@ -0,0 +1,45 @@
+package patchsync
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildGeneratedStats(t *testing.T) {
+	patch := func(id, start string, f2p, paid float64) Patch {
+		return Patch{ID: id, Patch: id, StartDate: start, DurationDays: 40, Sources: []Source{
+			source("events", "Events", "always", nil, true, Rewards{Chartered: f2p}),
+			source("monthly", "Monthly", "monthly", nil, true, Rewards{Chartered: paid}),
+		}}
+	}
+	forecast := patch("3.1", "2026-05-01", 500, 0)
+	forecast.Tags = []string{forecastTag}
+	patches := []Patch{
+		patch("1.0", "2024-05-01", 40, 10),
+		patch("2.0", "2025-05-01", 60, 10),
+		patch("2.1", "2025-06-10", 80, 10),
+		patch("3.0", "2026-02-01", 100, 10),
+		forecast,
+	}
+
+	stats := buildGeneratedStats(gameIDWuwa, patches)
+	if stats.Patches != 4 || stats.MeanF2PPulls != 70 || stats.MedianF2PPulls != 70 || stats.MedianPaidPulls != 10 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if stats.F2PToPaidRatio != 7 {
+		t.Fatalf("f2p to paid ratio = %v, want 7", stats.F2PToPaidRatio)
+	}
+	trend := stats.LastYear
+	if trend == nil || trend.From != "2025-02-02" || trend.To != "2026-02-01" || trend.Patches != 3 {
+		t.Fatalf("unexpected trend window %+v", trend)
+	}
+	// 240 pulls over 120 days against 40 pulls over 40 days the year before.
+	if trend.F2PPullsPerDay != 2 || trend.PreviousF2PPullsPerDay != 1 || trend.ChangePercent != 100 {
+		t.Fatalf("unexpected trend rates %+v", trend)
+	}
+
+	content, err := renderGeneratedFile(patches, GeneratedMeta{GameID: gameIDWuwa})
+	if err != nil || !strings.Contains(content, "export const GENERATED_STATS = {") {
+		t.Fatalf("GENERATED_STATS missing from output: %v", err)
+	}
+}
@@ -0,0 +1,3095 @@
+package patchsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultOutputPath    = "src/data/endfield.generated.js"
+	defaultBindAddr      = "127.0.0.1:8787"
+	defaultChangeLogPath = "tools/patchsync/logs/table-changes.jsonl"
+	defaultSyncAllLimit  = 3
+	defaultSyncAllRetry  = 1
+	defaultSyncTimeout   = 15 * time.Minute
+)
+
+var versionSheetPattern = regexp.MustCompile(`^\d+\.\d+$`)
+var versionLikeSheetPattern = regexp.MustCompile(`^\d+\.\d+(?:\*+)?(?:\s*(?:\([^)]+\)|[A-Za-z][A-Za-z0-9 ._-]*))?$`)
+var versionPrefixPattern = regexp.MustCompile(`^\s*[vV]?(\d+)\.(\d+)`)
+var spreadsheetIDFromURLPattern = regexp.MustCompile(`/spreadsheets/d/([a-zA-Z0-9-_]+)`)
+var publishedSpreadsheetIDFromURLPattern = regexp.MustCompile(`/spreadsheets/d/e/([a-zA-Z0-9-_]+)`)
+var patchFieldPattern = regexp.MustCompile(`(?m)(?:\bpatch\s*:|"patch"\s*:)\s*"(\d+\.\d+)"`)
+var generatedPatchesBlockPattern = regexp.MustCompile(`(?s)export const GENERATED_PATCHES\s*=\s*(\[[\s\S]*?\]);`)
+var publishedSheetGIDCache sync.Map
+
+// Published tab lists expire after publishedSheetGIDTTL, so tabs added while serve mode runs show up without
+// a restart. A requested tab missing from the list forces a refresh, at most once per publishedSheetGIDRetry.
+var (
+	publishedSheetGIDTTL   = 10 * time.Minute
+	publishedSheetGIDRetry = 30 * time.Second
+)
+
+type publishedSheetGIDs struct {
+	gids      map[string]string
+	fetchedAt time.Time
+}
+
+func normalizeSheetNameForMatch(raw string) string {
+	return strings.Join(strings.Fields(strings.TrimSpace(raw)), " ")
+}
+
+func isVersionLikeSheetName(raw string) bool {
+	return versionLikeSheetPattern.MatchString(normalizeSheetNameForMatch(raw))
+}
+
+func isPublishedSpreadsheetID(raw string) bool {
+	trimmed := strings.TrimSpace(raw)
+	return strings.HasPrefix(trimmed, "2PACX-")
+}
+
+type Rewards struct {
+	Oroberyl    float64 `json:"oroberyl"`
+	Origeometry float64 `json:"origeometry"`
+	Chartered   float64 `json:"chartered"`
+	Basic       float64 `json:"basic"`
+	Firewalker  float64 `json:"firewalker"`
+	Messenger   float64 `json:"messenger"`
+	Hues        float64 `json:"hues"`
+	Arsenal     float64 `json:"arsenal"`
+}
+
+func normalizeRewardKey(raw string) string {
+	key := strings.ToLower(strings.TrimSpace(raw))
+	replacer := strings.NewReplacer(" ", "", "_", "", "-", "", "'", "", "\"", "")
+	return replacer.Replace(key)
+}
+
+func (r *Rewards) mappedField(key string) *float64 {
+	switch normalizeRewardKey(key) {
+	case "oroberyl", "astrite", "polychrome", "primogem", "stellarjade":
+		return &r.Oroberyl
+	case "origeometry", "lunite", "monochrome", "genesiscrystal", "oneiricshard":
+		return &r.Origeometry
+	case "chartered", "radianttide", "encryptedmastertape", "intertwinedfate", "specialpass":
+		return &r.Chartered
+	case "basic", "lustroustide", "mastertape", "acquaintfate", "railpass":
+		return &r.Basic
+	case "firewalker", "forgingtide":
+		return &r.Firewalker
+	case "messenger":
+		return &r.Messenger
+	case "hues":
+		return &r.Hues
+	case "arsenal", "forgingtoken", "boopon", "starglitter", "tracksofdestiny":
+		return &r.Arsenal
+	}
+	return nil
+}
+
+func (r *Rewards) addMappedValue(key string, value float64) {
+	if field := r.mappedField(key); field != nil {
+		*field += value
+	}
+}
+
+func (r *Rewards) setMappedValue(key string, value float64) bool {
+	field := r.mappedField(key)
+	if field == nil {
+		return false
+	}
+	*field = value
+	return true
+}
+
+func (r *Rewards) UnmarshalJSON(data []byte) error {
+	if strings.TrimSpace(string(data)) == "null" {
+		*r = Rewards{}
+		return nil
+	}
+
+	raw := map[string]float64{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	parsed := Rewards{}
+	for key, value := range raw {
+		parsed.addMappedValue(key, value)
+	}
+	*r = parsed
+	return nil
+}
+
+type Scaler struct {
+	Type      string  `json:"type"`
+	Unit      string  `json:"unit"`
+	EveryDays int     `json:"everyDays"`
+	Rounding  string  `json:"rounding"`
+	Rewards   Rewards `json:"rewards"`
+}
+
+type Source struct {
+	ID           string        `json:"id"`
+	Label        string        `json:"label"`
+	Gate         string        `json:"gate"`
+	OptionKey    *string       `json:"optionKey"`
+	CountInPulls bool          `json:"countInPulls"`
+	NetIncome    bool          `json:"netIncome,omitempty"`
+	Pulls        *float64      `json:"pulls,omitempty"`
+	Rewards      Rewards       `json:"rewards"`
+	Costs        Rewards       `json:"costs"`
+	Scalers      []Scaler      `json:"scalers"`
+	BPCrateModel *BPCrateModel `json:"bpCrateModel,omitempty"`
+	Notes        string        `json:"notes,omitempty"`
+}
+
+type BPCrateModel struct {
+	Type             string  `json:"type"`
+	DaysToLevel60T3  int     `json:"daysToLevel60Tier3"`
+	Tier2XPBonusRate float64 `json:"tier2XpBonus"`
+	Tier3XPBonusRate float64 `json:"tier3XpBonus"`
+}
+
+type Patch struct {
+	ID           string            `json:"id"`
+	Patch        string            `json:"patch"`
+	VersionName  string            `json:"versionName"`
+	StartDate    string            `json:"startDate"`
+	StartTimes   map[string]string `json:"startTimes,omitempty"`
+	DurationDays int               `json:"durationDays"`
+	Tags         []string          `json:"tags,omitempty"`
+	Completeness *float64          `json:"completeness,omitempty"`
+	Notes        string            `json:"notes"`
+	Sources      []Source          `json:"sources"`
+	Shop         []ShopItem        `json:"shop,omitempty"`
+}
+
+type GeneratedMeta struct {
+	SchemaVersion  int                          `json:"schemaVersion"`
+	GameID         string                       `json:"gameId"`
+	SpreadsheetID  string                       `json:"spreadsheetId"`
+	SpreadsheetIDs []string                     `json:"spreadsheetIds,omitempty"`
+	Sheets         []string                     `json:"sheets"`
+	AuxSheets      map[string]string            `json:"auxSheets,omitempty"`
+	CumulativeFrom string                       `json:"cumulativeFrom,omitempty"`
+	WIPMode        string                       `json:"wipMode,omitempty"`
+	Labels         map[string]map[string]string `json:"labels,omitempty"`
+	Currencies     []currencyDef                `json:"currencies,omitempty"`
+	GeneratedAt    string                       `json:"generatedAt"`
+}
+
+type SyncConfig struct {
+	GameID           string
+	SpreadsheetID    string
+	SheetNames       []string
+	ExcludeSheets    []string
+	SheetGIDs        map[string]string
+	OutputPath       string
+	BasePatchesPath  string
+	OverridesDir     string
+	TranslationsDir  string
+	ServerRegions    []ServerRegion
+	PatchStart       string
+	SheetHashPath    string
+	APIDir           string
+	CumulativeFrom   string
+	ForecastPatches  int
+	ForecastWindow   int
+	WIPMode          string
+	CreateBranch     bool
+	BranchPrefix     string
+	RepoRoot         string
+	SkipExisting     bool
+	ForcePatches     []string
+	AllowHistorical  bool
+	LatestPatches    int
+	Backfill         bool
+	DryRun           bool
+	Preview          string
+	ParseStrictness  string
+	DecimalSeparator string
+	BaseOverlap      string
+	ClientTimeout    time.Duration
+	SyncTimeout      time.Duration
+	Clock            Clock
+	SheetSource      string
+	SheetsAPIKey     string
+	LocalSheetsDir   string
+	Fetcher          SheetFetcher
+	GoogleBaseURL    string
+	Outbound         OutboundOptions
+	Notify           NotifyOptions
+	GitLab           GitLabOptions
+	RecordFixtures   string
+	ReplayFixtures   string
+	ContinueOnError  bool
+	SyncAllParallel  int
+	SyncAllRetries   int
+	RequestID        string
+	Profile          bool
+	ChangeLogLimits  ChangeLogRetention
+	HistoryDB        string
+	MaxBodyBytes     int64
+	OutputHistory    OutputHistory
+	OutputEncoding   OutputEncoding
+	ProbeLimits      ProbeOptions
+	BreakerLimit     int
+	breaker          *spreadsheetBreaker
+	logSink          func(string)
+	progressSink     func(syncProgress)
+	DiscoveryTTL     time.Duration
+	DiscoveryCache   string
+	Rediscover       bool
+}
+
+type SyncResult struct {
+	GameID         string
+	Patches        []Patch
+	AllPatches     []Patch
+	SkippedPatches []string
+	FrozenPatches  []patchDiff
+	SheetNames     []string
+	PendingSheets  []string
+	BaseOverlaps   []string
+	OutOfBounds    []string
+	Discrepancies  []pullDiscrepancy
+	OutputPath     string
+	BranchName     string
+	Commit         string
+	MergeRequest   string
+	Logs           []string
+	SheetFailures  []sheetFailure
+	SheetStats     []sheetFetchStat
+	ChangeCount    int
+	Stats          syncStats
+	ChangeLogPath  string
+	GeneratedAt    string
+	Meta           GeneratedMeta
+	Profile        []phaseTiming
+	Progress       []syncProgress
+	Preview        string
+}
+
+// sheetFailure records a sheet that was skipped (or written without its overrides) instead of aborting the sync.
+type sheetFailure struct {
+	Sheet string `json:"sheet"`
+	Stage string `json:"stage"`
+	Code  string `json:"code"`
+	Error string `json:"error"`
+}
+
+// cancelledSyncResult is returned when the sync deadline passes or the caller goes away between sheets. Nothing
+// is written; the result lists what was parsed and what was still pending.
+func cancelledSyncResult(gameID string, logs *syncLog, parsed, pending []string, failures []sheetFailure, cause error) (SyncResult, error) {
+	done, total := len(parsed), len(parsed)+len(failures)+len(pending)
+	appendSyncLog(logs, "sync cancelled after %d of %d sheets (%d pending): %v", done, total, len(pending), cause)
+	err := fmt.Errorf("sync cancelled after %d of %d sheets: %w", done, total, cause)
+	return SyncResult{
+		GameID:        gameID,
+		SheetNames:    parsed,
+		PendingSheets: append([]string(nil), pending...),
+		SheetFailures: failures,
+		Logs:          logs.lines,
+	}, withErrorCode(errCodeSyncCancelled, err)
+}
+
+type sheetRow struct {
+	Name    string
+	Rewards Rewards
+	HasData bool
+}
+
+type syncRequest struct {
+	GameID           string   `json:"gameId"`
+	SpreadsheetID    string   `json:"spreadsheetId"`
+	SheetNames       []string `json:"sheetNames"`
+	CreateBranch     bool     `json:"createBranch"`
+	BranchPrefix     string   `json:"branchPrefix"`
+	Force            []string `json:"force"`
+	Latest           int      `json:"latest"`
+	DryRun           bool     `json:"dryRun"`
+	Preview          string   `json:"preview"`
+	ContinueOnError  bool     `json:"continueOnError"`
+	RefreshDiscovery bool     `json:"refreshDiscovery"`
+}
+
+type syncAllRequest struct {
+	Latest           int  `json:"latest"`
+	DryRun           bool `json:"dryRun"`
+	RefreshDiscovery bool `json:"refreshDiscovery"`
+}
+
+type syncGameResult struct {
+	GameID        string            `json:"gameId"`
+	RequestID     string            `json:"requestId,omitempty"`
+	Sheets        []string          `json:"sheets,omitempty"`
+	Patches       []string          `json:"patches,omitempty"`
+	Skipped       []string          `json:"skipped,omitempty"`
+	Frozen        []patchDiff       `json:"frozen,omitempty"`
+	Failures      []sheetFailure    `json:"failures,omitempty"`
+	Pending       []string          `json:"pending,omitempty"`
+	BaseOverlaps  []string          `json:"baseOverlaps,omitempty"`
+	OutOfBounds   []string          `json:"outOfBounds,omitempty"`
+	Discrepancies []pullDiscrepancy `json:"discrepancies,omitempty"`
+	SheetStats    []sheetFetchStat  `json:"sheetStats,omitempty"`
+	Profile       []phaseTiming     `json:"profile,omitempty"`
+	Progress      []syncProgress    `json:"progress,omitempty"`
+	OutputPath    string            `json:"outputPath,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	Code          string            `json:"code,omitempty"`
+	Logs          []string          `json:"logs,omitempty"`
+	ChangeCount   int               `json:"changeCount,omitempty"`
+	Stats         *syncStats        `json:"stats,omitempty"`
+	ChangeLogPath string            `json:"changeLogPath,omitempty"`
+	GeneratedAt   string            `json:"generatedAt,omitempty"`
+	ErrorClass    string            `json:"errorClass,omitempty"`
+	Retriable     bool              `json:"retriable,omitempty"`
+	Permanent     bool              `json:"permanent,omitempty"`
+	RetryLater    bool              `json:"retryLater,omitempty"`
+	Attempts      int               `json:"attempts,omitempty"`
+}
+
+type syncResponse struct {
+	OK            bool              `json:"ok"`
+	Message       string            `json:"message"`
+	Code          string            `json:"code,omitempty"`
+	RequestID     string            `json:"requestId,omitempty"`
+	GameID        string            `json:"gameId,omitempty"`
+	Sheets        []string          `json:"sheets,omitempty"`
+	Patches       []string          `json:"patches,omitempty"`
+	Skipped       []string          `json:"skipped,omitempty"`
+	Frozen        []patchDiff       `json:"frozen,omitempty"`
+	Failures      []sheetFailure    `json:"failures,omitempty"`
+	Pending       []string          `json:"pending,omitempty"`
+	BaseOverlaps  []string          `json:"baseOverlaps,omitempty"`
+	OutOfBounds   []string          `json:"outOfBounds,omitempty"`
+	Discrepancies []pullDiscrepancy `json:"discrepancies,omitempty"`
+	SheetStats    []sheetFetchStat  `json:"sheetStats,omitempty"`
+	Profile       []phaseTiming     `json:"profile,omitempty"`
+	Progress      []syncProgress    `json:"progress,omitempty"`
+	Preview       string            `json:"preview,omitempty"`
+	OutputPath    string            `json:"outputPath,omitempty"`
+	Branch        string            `json:"branch,omitempty"`
+	Commit        string            `json:"commit,omitempty"`
+	MergeRequest  string            `json:"mergeRequest,omitempty"`
+	Results       []syncGameResult  `json:"results,omitempty"`
+	Summary       *syncAllSummary   `json:"summary,omitempty"`
+	Logs          []string          `json:"logs,omitempty"`
+	ChangeCount   int               `json:"changeCount,omitempty"`
+	Stats         *syncStats        `json:"stats,omitempty"`
+	ChangeLogPath string            `json:"changeLogPath,omitempty"`
+	GeneratedAt   string            `json:"generatedAt,omitempty"`
+	Projection    *projectionResult `json:"projection,omitempty"`
+	Plan          *savingsPlan      `json:"plan,omitempty"`
+	Odds          *oddsResult       `json:"odds,omitempty"`
+	Ledger        *ledgerView       `json:"ledger,omitempty"`
+	Topup         *topupResult      `json:"topup,omitempty"`
+	Checks        []readinessCheck  `json:"checks,omitempty"`
+}
+
+type patchChangeLogEntry struct {
+	Patch          string       `json:"patch"`
+	ChangeType     string       `json:"changeType"`
+	ChangedSources []string     `json:"changedSources,omitempty"`
+	Fields         []fieldDiff  `json:"fields,omitempty"`
+	Sources        []sourceDiff `json:"sources,omitempty"`
+}
+
+type syncChangeLogRecord struct {
+	Timestamp      string                `json:"timestamp"`
+	GameID         string                `json:"gameId"`
+	SpreadsheetID  string                `json:"spreadsheetId"`
+	OutputPath     string                `json:"outputPath"`
+	GeneratedAt    string                `json:"generatedAt"`
+	UpdatedPatches []patchChangeLogEntry `json:"updatedPatches"`
+	Compacted      int                   `json:"compacted,omitempty"`
+}
+
+func zeroRewards() Rewards {
+	return Rewards{}
+}
+
+func (r *Rewards) add(other Rewards) {
+	r.Oroberyl += other.Oroberyl
+	r.Origeometry += other.Origeometry
+	r.Chartered += other.Chartered
+	r.Basic += other.Basic
+	r.Firewalker += other.Firewalker
+	r.Messenger += other.Messenger
+	r.Hues += other.Hues
+	r.Arsenal += other.Arsenal
+}
+
+func (r Rewards) hasAny() bool {
+	return r.Oroberyl != 0 || r.Origeometry != 0 || r.Chartered != 0 ||
+		r.Basic != 0 || r.Firewalker != 0 || r.Messenger != 0 ||
+		r.Hues != 0 || r.Arsenal != 0
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func source(id, label, gate string, optionKey *string, countInPulls bool, rewards Rewards) Source {
+	return Source{
+		ID:           id,
+		Label:        label,
+		Gate:         gate,
+		OptionKey:    optionKey,
+		CountInPulls: countInPulls,
+		NetIncome:    rewards.hasNegative(),
+		Pulls:        nil,
+		Rewards:      rewards,
+		Costs:        zeroRewards(),
+		Scalers:      []Scaler{},
+		BPCrateModel: nil,
+	}
+}
+
+func normalizeName(value string) string {
+	value = strings.TrimSpace(value)
+	value = strings.TrimSuffix(value, ":")
+	value = strings.ReplaceAll(value, "’", "'")
+	value = strings.ReplaceAll(value, "`", "'")
+	value = strings.ToLower(value)
+	value = strings.Join(strings.Fields(value), " ")
+	return value
+}
+
+func parseNumber(raw string) float64 {
+	return parseNumberWith(raw, decimalAuto)
+}
+
+// parseNumberWith parses a numeric cell, guessing the decimal separator per cell when separator is auto.
+func parseNumberWith(raw, separator string) float64 {
+	cleaned := strings.TrimSpace(raw)
+	if cleaned == "" {
+		return 0
+	}
+	cleaned = strings.ReplaceAll(cleaned, "\u00a0", "")
+	cleaned = strings.ReplaceAll(cleaned, " ", "")
+	cleaned = strings.TrimSuffix(cleaned, "%")
+	cleaned, sign := splitNumberSign(cleaned)
+	cleaned, exponent := splitExponent(cleaned)
+	if exponent != "" {
+		separator = mantissaSeparator(cleaned)
+	}
+	if text, ok := explicitDecimalText(cleaned, separator); ok {
+		value, err := strconv.ParseFloat(text+exponent, 64)
+		if err != nil {
+			return 0
+		}
+		return sign * value
+	}
+
+	lastComma := strings.LastIndex(cleaned, ",")
+	lastDot := strings.LastIndex(cleaned, ".")
+	switch {
+	case lastComma >= 0 && lastDot >= 0:
+		if lastComma > lastDot {
+			cleaned = strings.ReplaceAll(cleaned, ".", "")
+			cleaned = strings.ReplaceAll(cleaned, ",", ".")
+		} else {
+			cleaned = strings.ReplaceAll(cleaned, ",", "")
+		}
+	case lastComma >= 0:
+		if strings.Count(cleaned, ",") > 1 {
+			cleaned = strings.ReplaceAll(cleaned, ",", "")
+		} else {
+			parts := strings.Split(cleaned, ",")
+			if len(parts) == 2 && len(parts[1]) == 3 {
+				cleaned = parts[0] + parts[1]
+			} else {
+				cleaned = strings.ReplaceAll(cleaned, ",", ".")
+			}
+		}
+	}
+
+	if strings.Count(cleaned, ".") > 0 {
+		parts := strings.Split(cleaned, ".")
+		isThousands := len(parts) > 1
+		for _, part := range parts {
+			if part == "" {
+				isThousands = false
+				break
+			}
+			for _, r := range part {
+				if r < '0' || r > '9' {
+					isThousands = false
+					break
+				}
+			}
+			if !isThousands {
+				break
+			}
+		}
+		if isThousands {
+			for i := 1; i < len(parts); i++ {
+				if len(parts[i]) != 3 {
+					isThousands = false
+					break
+				}
+			}
+		}
+		if isThousands {
+			cleaned = strings.Join(parts, "")
+		}
+	}
+	value, err := strconv.ParseFloat(cleaned+exponent, 64)
+	if err != nil {
+		return 0
+	}
+	return sign * value
+}
+
+func parseInt(raw string) int {
+	value := parseNumber(raw)
+	return int(value)
+}
+
+func normalizeHeader(raw string) string {
+	return normalizeName(raw)
+}
+
+func findHeaderIndex(headers []string, expected []string, defaultIndex int) int {
+	for idx, header := range headers {
+		h := normalizeHeader(header)
+		for _, candidate := range expected {
+			if h == candidate || strings.Contains(h, candidate) {
+				return idx
+			}
+		}
+	}
+	return defaultIndex
+}
+
+func getCell(record []string, idx int) string {
+	if idx < 0 || idx >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[idx])
+}
+
+func inferDurationDays(headers []string, firstDataRow []string, durationCol int) int {
+	for idx, header := range headers {
+		norm := normalizeHeader(header)
+		if strings.Contains(norm, "version length") || strings.Contains(norm, "version duration") {
+			if idx+1 < len(headers) {
+				if days := parseInt(headers[idx+1]); days > 0 {
+					return days
+				}
+			}
+			if days := parseInt(getCell(firstDataRow, idx)); days > 0 {
+				return days
+			}
+		}
+	}
+	if durationCol >= 0 {
+		if days := parseInt(getCell(firstDataRow, durationCol)); days > 0 {
+			return days
+		}
+	}
+	return 0
+}
+
+func inferDurationDaysFromTitleRow(record []string) int {
+	for _, cell := range record {
+		days := parseInt(cell)
+		if days >= 7 && days <= 120 {
+			return days
+		}
+	}
+	return 0
+}
+
+func inferStartDateFromTitleRow(record []string, order dateOrder) string {
+	for idx, cell := range record {
+		if !strings.Contains(normalizeName(cell), "release date") {
+			continue
+		}
+		for _, candidateIdx := range []int{idx - 1, idx + 1} {
+			date := parseDateToISO(getCell(record, candidateIdx), order)
+			if date != "" {
+				return date
+			}
+		}
+	}
+
+	uniqueDates := make([]string, 0, 1)
+	seen := map[string]struct{}{}
+	for _, cell := range record {
+		date := parseDateToISO(cell, order)
+		if date == "" {
+			continue
+		}
+		if _, ok := seen[date]; ok {
+			continue
+		}
+		seen[date] = struct{}{}
+		uniqueDates = append(uniqueDates, date)
+	}
+	if len(uniqueDates) == 1 {
+		return uniqueDates[0]
+	}
+
+	return ""
+}
+
+func rowFromRecord(record []string, idxName, idxOro, idxOri, idxChartered, idxBasic, idxArsenal int, opts ParseOptions) sheetRow {
+	oroRaw := getCell(record, idxOro)
+	oriRaw := getCell(record, idxOri)
+	charteredRaw := getCell(record, idxChartered)
+	basicRaw := getCell(record, idxBasic)
+	arsenalRaw := getCell(record, idxArsenal)
+
+	hasData := strings.TrimSpace(oroRaw) != "" ||
+		strings.TrimSpace(oriRaw) != "" ||
+		strings.TrimSpace(charteredRaw) != "" ||
+		strings.TrimSpace(basicRaw) != "" ||
+		strings.TrimSpace(arsenalRaw) != ""
+
+	return sheetRow{
+		Name: getCell(record, idxName),
+		Rewards: Rewards{
+			Oroberyl:    opts.number(oroRaw),
+			Origeometry: opts.number(oriRaw),
+			Chartered:   opts.number(charteredRaw),
+			Basic:       opts.number(basicRaw),
+			Arsenal:     opts.number(arsenalRaw),
+		},
+		HasData: hasData,
+	}
+}
+
+func battlePassCoreRewards(input Rewards) Rewards {
+	return Rewards{
+		Origeometry: input.Origeometry,
+		Arsenal:     input.Arsenal,
+	}
+}
+
+func battlePassCrateFallbackRewards(input Rewards) Rewards {
+	return Rewards{
+		Oroberyl:   input.Oroberyl,
+		Chartered:  input.Chartered,
+		Basic:      input.Basic,
+		Firewalker: input.Firewalker,
+		Messenger:  input.Messenger,
+		Hues:       input.Hues,
+	}
+}
+
+
+func fetchText(ctx context.Context, client *http.Client, resourceURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, resourceURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	body := string(bodyBytes)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(body))
+	}
+	return body, nil
+}
+
+func sheetCSVURL(spreadsheetID, sheetName string) string {
+	return fmt.Sprintf(
+		"https://docs.google.com/spreadsheets/d/%s/gviz/tq?tqx=out:csv&sheet=%s",
+		url.PathEscape(strings.TrimSpace(spreadsheetID)),
+		url.QueryEscape(sheetName),
+	)
+}
+
+func publishedSheetCSVURL(spreadsheetID, gid string) string {
+	return fmt.Sprintf(
+		"https://docs.google.com/spreadsheets/d/e/%s/pub?gid=%s&single=true&output=csv",
+		url.PathEscape(strings.TrimSpace(spreadsheetID)),
+		url.QueryEscape(strings.TrimSpace(gid)),
+	)
+}
+
+func discoverPublishedSheetGIDs(ctx context.Context, client *http.Client, spreadsheetID string) (map[string]string, error) {
+	pubHTMLURL := fmt.Sprintf(
+		"https://docs.google.com/spreadsheets/d/e/%s/pubhtml",
+		url.PathEscape(strings.TrimSpace(spreadsheetID)),
+	)
+	body, err := fetchText(ctx, client, pubHTMLURL)
+	if err != nil {
+		return nil, err
+	}
+	gidByName := parsePublishedSheetGIDsFromHTML(body)
+	if len(gidByName) == 0 {
+		return nil, errors.New("no sheet names found in published HTML (no items.push entries or #gid= links)")
+	}
+	return gidByName, nil
+}
+
+func getPublishedSheetGIDs(ctx context.Context, client *http.Client, spreadsheetID string) (map[string]string, error) {
+	return loadPublishedSheetGIDs(ctx, client, spreadsheetID, publishedSheetGIDTTL)
+}
+
+// loadPublishedSheetGIDs returns the cached tabs when they are younger than maxAge and rediscovers them otherwise.
+func loadPublishedSheetGIDs(ctx context.Context, client *http.Client, spreadsheetID string, maxAge time.Duration) (map[string]string, error) {
+	cacheKey := strings.TrimSpace(spreadsheetID)
+	if cached, ok := publishedSheetGIDCache.Load(cacheKey); ok {
+		if typed, okTyped := cached.(publishedSheetGIDs); okTyped && len(typed.gids) > 0 && time.Since(typed.fetchedAt) < maxAge {
+			return typed.gids, nil
+		}
+	}
+	gidByName, err := discoverPublishedSheetGIDs(ctx, client, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	publishedSheetGIDCache.Store(cacheKey, publishedSheetGIDs{gids: gidByName, fetchedAt: time.Now()})
+	return gidByName, nil
+}
+
+func fetchPublishedSheetCSV(ctx context.Context, client *http.Client, spreadsheetID, sheetName string) (string, error) {
+	gidByName, err := getPublishedSheetGIDs(ctx, client, spreadsheetID)
+	if err != nil {
+		return "", err
+	}
+	gid, ok := lookupSheetGID(gidByName, sheetName)
+	if !ok {
+		if gidByName, err = loadPublishedSheetGIDs(ctx, client, spreadsheetID, publishedSheetGIDRetry); err == nil {
+			gid, ok = lookupSheetGID(gidByName, sheetName)
+		}
+	}
+	if !ok {
+		return "", fmt.Errorf("published sheet %q not found", sheetName)
+	}
+	return fetchCSVText(ctx, client, publishedSheetCSVURL(spreadsheetID, gid))
+}
+
+func fetchGvizSheetCSV(ctx context.Context, client *http.Client, spreadsheetID, sheetName string) (string, error) {
+	return fetchCSVText(ctx, client, sheetCSVURL(spreadsheetID, sheetName))
+}
+
+func fetchCSVText(ctx context.Context, client *http.Client, resourceURL string) (string, error) {
+	body, err := fetchText(ctx, client, resourceURL)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(strings.ToLower(body), "<!doctype html") {
+		return "", errors.New("sheet is not accessible as CSV")
+	}
+	return body, nil
+}
+
+type worksheetFeed struct {
+	Feed struct {
+		Entry []struct {
+			Title struct {
+				Text string `json:"$t"`
+			} `json:"title"`
+		} `json:"entry"`
+	} `json:"feed"`
+}
+
+func discoverSheetNamesFromHTML(ctx context.Context, client *http.Client, spreadsheetID string, filter sheetNameFilter, editTabs *editTabCache) ([]string, error) {
+	tabs, err := getEditSheetTabs(ctx, client, editTabs, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	captions := make([]string, 0, len(tabs))
+	for _, tab := range tabs {
+		captions = append(captions, tab.Name)
+	}
+	if len(captions) == 0 {
+		return nil, fmt.Errorf("no sheet tabs found in HTML (no .%s elements)", sheetTabCaptionClass)
+	}
+	names := make([]string, 0, len(captions))
+	for _, caption := range captions {
+		if filter.match(caption) {
+			names = append(names, caption)
+		}
+	}
+	names = uniqueSheetNames(names)
+	sortVersionStrings(names)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no version-like sheet names found in HTML tabs (%s)", describeFoundSheetNames(captions))
+	}
+	return names, nil
+}
+
+func discoverPublishedSheetNames(ctx context.Context, client *http.Client, spreadsheetID string, filter sheetNameFilter) ([]string, error) {
+	gidByName, err := getPublishedSheetGIDs(ctx, client, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(gidByName))
+	for name := range gidByName {
+		if !filter.match(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	names = uniqueSheetNames(names)
+	sortVersionStrings(names)
+	if len(names) == 0 {
+		found := make([]string, 0, len(gidByName))
+		for name := range gidByName {
+			found = append(found, name)
+		}
+		sort.Strings(found)
+		return nil, fmt.Errorf("no version-like sheet names found in published HTML (%s)", describeFoundSheetNames(found))
+	}
+	return names, nil
+}
+
+func discoverGvizSheetNames(ctx context.Context, client *http.Client, spreadsheetID string, parser PatchParser, filter sheetNameFilter, editTabs *editTabCache, probe ProbeOptions) ([]string, error) {
+	collectedNames := make([]string, 0, 32)
+	feedURL := fmt.Sprintf(
+		"https://spreadsheets.google.com/feeds/worksheets/%s/public/basic?alt=json",
+		url.PathEscape(strings.TrimSpace(spreadsheetID)),
+	)
+	body, err := fetchText(ctx, client, feedURL)
+	if err == nil {
+		var payload worksheetFeed
+		if unmarshalErr := json.Unmarshal([]byte(body), &payload); unmarshalErr == nil {
+			names := make([]string, 0, len(payload.Feed.Entry))
+			for _, entry := range payload.Feed.Entry {
+				name := html.UnescapeString(entry.Title.Text)
+				if filter.match(name) {
+					names = append(names, name)
+				}
+			}
+			if len(names) > 0 {
+				collectedNames = append(collectedNames, names...)
+			}
+		}
+	}
+
+	htmlNames, htmlErr := discoverSheetNamesFromHTML(ctx, client, spreadsheetID, filter, editTabs)
+	if htmlErr == nil && len(htmlNames) > 0 {
+		collectedNames = append(collectedNames, htmlNames...)
+	}
+
+	collectedNames = uniqueSheetNames(collectedNames)
+	sortVersionStrings(collectedNames)
+	if len(collectedNames) > 0 {
+		return collectedNames, nil
+	}
+
+	probeNames, probeErr := discoverSheetNamesByProbe(ctx, client, spreadsheetID, parser, probe)
+	probeNames = filter.withoutExcluded(probeNames)
+	if probeErr == nil && len(probeNames) > 0 {
+		return probeNames, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover version sheets automatically: %w", err)
+	}
+	if htmlErr != nil {
+		return nil, fmt.Errorf("failed to discover version sheets automatically: html=%v, probe=%v", htmlErr, probeErr)
+	}
+	return nil, fmt.Errorf("failed to discover version sheets automatically: %w", probeErr)
+}
+func uniqueSheetNames(input []string) []string {
+	seen := map[string]struct{}{}
+	result := make([]string, 0, len(input))
+	for _, item := range input {
+		key := strings.TrimSpace(item)
+		if key == "" {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, item)
+	}
+	return result
+}
+
+func uniqueStrings(input []string) []string {
+	seen := map[string]struct{}{}
+	result := make([]string, 0, len(input))
+	for _, item := range input {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if _, ok := seen[item]; ok {
+			continue
+		}
+		seen[item] = struct{}{}
+		result = append(result, item)
+	}
+	return result
+}
+
+func extractSpreadsheetID(input string) string {
+	value := strings.TrimSpace(input)
+	if value == "" {
+		return ""
+	}
+	if strings.Contains(value, "/spreadsheets/d/e/") {
+		matches := publishedSpreadsheetIDFromURLPattern.FindStringSubmatch(value)
+		if len(matches) >= 2 {
+			return matches[1]
+		}
+	}
+	if strings.Contains(value, "/spreadsheets/d/") {
+		matches := spreadsheetIDFromURLPattern.FindStringSubmatch(value)
+		if len(matches) >= 2 {
+			return matches[1]
+		}
+	}
+	return value
+}
+func resolveFilePath(inputPath string) string {
+	cleanPath := filepath.Clean(strings.TrimSpace(inputPath))
+	if cleanPath == "" {
+		return cleanPath
+	}
+	if filepath.IsAbs(cleanPath) {
+		return cleanPath
+	}
+	candidates := []string{
+		cleanPath,
+		filepath.Join("..", "..", cleanPath),
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return cleanPath
+}
+
+func resolveOutputPath(inputPath string) string {
+	cleanPath := filepath.Clean(strings.TrimSpace(inputPath))
+	if cleanPath == "" {
+		return cleanPath
+	}
+	if filepath.IsAbs(cleanPath) {
+		return cleanPath
+	}
+	candidates := []string{
+		cleanPath,
+		filepath.Join("..", "..", cleanPath),
+	}
+	for _, candidate := range candidates {
+		parent := filepath.Dir(candidate)
+		if parent == "." || parent == "" {
+			return candidate
+		}
+		if stat, err := os.Stat(parent); err == nil && stat.IsDir() {
+			return candidate
+		}
+	}
+	return cleanPath
+}
+
+func parsePatchHeaderMeta(titleCell string, order dateOrder) (string, string) {
+	title := strings.TrimSpace(titleCell)
+	if title == "" {
+		return "", ""
+	}
+
+	startDate := findParenthesizedDate(title, order)
+
+	versionName := ""
+	colonIdx := strings.Index(title, ":")
+	if colonIdx >= 0 {
+		versionName = strings.TrimSpace(title[colonIdx+1:])
+	} else {
+		versionName = title
+	}
+	if open := strings.LastIndex(versionName, "("); open >= 0 {
+		versionName = strings.TrimSpace(versionName[:open])
+	}
+
+	return versionName, startDate
+}
+
+func versionSortKey(value string) (int, int, bool) {
+	match := versionPrefixPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if len(match) < 3 {
+		return 0, 0, false
+	}
+	major, errMajor := strconv.Atoi(match[1])
+	minor, errMinor := strconv.Atoi(match[2])
+	if errMajor != nil || errMinor != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+func sortVersionStrings(values []string) {
+	sort.Slice(values, func(i, j int) bool {
+		majorI, minorI, okI := versionSortKey(values[i])
+		majorJ, minorJ, okJ := versionSortKey(values[j])
+		if okI && okJ {
+			if majorI != majorJ {
+				return majorI < majorJ
+			}
+			return minorI < minorJ
+		}
+		return values[i] < values[j]
+	})
+}
+
+func sortPatches(patches []Patch) {
+	sort.Slice(patches, func(i, j int) bool {
+		majorI, minorI, okI := versionSortKey(patches[i].Patch)
+		majorJ, minorJ, okJ := versionSortKey(patches[j].Patch)
+		if okI && okJ {
+			if majorI != majorJ {
+				return majorI < majorJ
+			}
+			return minorI < minorJ
+		}
+		return patches[i].Patch < patches[j].Patch
+	})
+}
+
+type generatedScaler struct {
+	Type      string             `json:"type"`
+	Unit      string             `json:"unit"`
+	EveryDays int                `json:"everyDays"`
+	Rounding  string             `json:"rounding"`
+	Rewards   map[string]float64 `json:"rewards"`
+}
+
+type generatedSource struct {
+	ID           string             `json:"id"`
+	Label        string             `json:"label"`
+	Gate         string             `json:"gate"`
+	OptionKey    *string            `json:"optionKey"`
+	CountInPulls bool               `json:"countInPulls"`
+	NetIncome    bool               `json:"netIncome,omitempty"`
+	Pulls        *float64           `json:"pulls,omitempty"`
+	Rewards      map[string]float64 `json:"rewards"`
+	Costs        map[string]float64 `json:"costs"`
+	Scalers      []generatedScaler  `json:"scalers"`
+	BPCrateModel *BPCrateModel      `json:"bpCrateModel,omitempty"`
+	Notes        string             `json:"notes,omitempty"`
+}
+
+type generatedPatch struct {
+	ID           string            `json:"id"`
+	Patch        string            `json:"patch"`
+	VersionName  string            `json:"versionName"`
+	StartDate    string            `json:"startDate"`
+	StartTimes   map[string]string `json:"startTimes,omitempty"`
+	DurationDays int               `json:"durationDays"`
+	Tags         []string          `json:"tags,omitempty"`
+	Completeness *float64          `json:"completeness,omitempty"`
+	Notes        string            `json:"notes"`
+	Sources      []generatedSource `json:"sources"`
+	Shop         []ShopItem        `json:"shop,omitempty"`
+	DailyIncome  *dailyIncome      `json:"dailyIncome,omitempty"`
+}
+
+func rewardsForGame(r Rewards, gameID string) map[string]float64 {
+	values := map[string]float64{}
+	for _, currency := range currenciesForGame(gameID) {
+		values[currency.Key] = currency.value(r)
+	}
+	return values
+}
+
+func toGeneratedPatch(patch Patch, gameID string) generatedPatch {
+	sources := make([]generatedSource, 0, len(patch.Sources))
+	for _, src := range patch.Sources {
+		scalers := make([]generatedScaler, 0, len(src.Scalers))
+		for _, scaler := range src.Scalers {
+			scalers = append(scalers, generatedScaler{
+				Type:      scaler.Type,
+				Unit:      scaler.Unit,
+				EveryDays: scaler.EveryDays,
+				Rounding:  scaler.Rounding,
+				Rewards:   rewardsForGame(scaler.Rewards, gameID),
+			})
+		}
+
+		sources = append(sources, generatedSource{
+			ID:           src.ID,
+			Label:        src.Label,
+			Gate:         src.Gate,
+			OptionKey:    src.OptionKey,
+			CountInPulls: src.CountInPulls,
+			NetIncome:    src.NetIncome,
+			Pulls:        src.Pulls,
+			Rewards:      rewardsForGame(src.Rewards, gameID),
+			Costs:        rewardsForGame(src.Costs, gameID),
+			Scalers:      scalers,
+			BPCrateModel: src.BPCrateModel,
+			Notes:        src.Notes,
+		})
+	}
+
+	return generatedPatch{
+		ID:           patch.ID,
+		Patch:        patch.Patch,
+		VersionName:  patch.VersionName,
+		StartDate:    patch.StartDate,
+		StartTimes:   patch.StartTimes,
+		DurationDays: patch.DurationDays,
+		Tags:         patch.Tags,
+		Completeness: patch.Completeness,
+		Notes:        patch.Notes,
+		Sources:      sources,
+		Shop:         patch.Shop,
+		DailyIncome:  computeDailyIncome(patch, gameID),
+	}
+}
+func writeGeneratedFile(path string, patches []Patch, meta GeneratedMeta) error {
+	_, err := writeGeneratedContent(path, patches, meta, OutputEncoding{})
+	return err
+}
+
+// writeGeneratedContent writes the generated file and returns the content it wrote.
+func writeGeneratedContent(path string, patches []Patch, meta GeneratedMeta, encoding OutputEncoding) (string, error) {
+	if path == "" {
+		path = defaultOutputPath
+	}
+	content, err := renderGeneratedFile(patches, meta)
+	if err != nil {
+		return "", err
+	}
+	content = encoding.apply(content)
+	previous, readErr := os.ReadFile(path)
+	if readErr != nil && !errors.Is(readErr, os.ErrNotExist) {
+		return "", fmt.Errorf("read current output: %w", readErr)
+	}
+	if mkErr := os.MkdirAll(filepath.Dir(path), 0o755); mkErr != nil {
+		return "", fmt.Errorf("create output dir: %w", mkErr)
+	}
+	if writeErr := os.WriteFile(path, []byte(content), 0o644); writeErr != nil {
+		return "", fmt.Errorf("write generated file: %w", writeErr)
+	}
+	if verifyErr := verifyGeneratedFile(path, content, meta, encoding); verifyErr != nil {
+		var restoreErr error
+		if readErr == nil {
+			restoreErr = os.WriteFile(path, previous, 0o644)
+		} else {
+			restoreErr = os.Remove(path)
+		}
+		if restoreErr != nil {
+			return "", fmt.Errorf("generated file does not read back (%v), and restoring the previous file failed: %w", verifyErr, restoreErr)
+		}
+		return "", fmt.Errorf("generated file does not read back, previous file restored: %w", verifyErr)
+	}
+	return content, nil
+}
+
+// verifyGeneratedFile reads the file just written back through readGeneratedPatches and renders it again. Any
+// difference means a field did not survive marshaling or block extraction, and the next sync would lose it.
+func verifyGeneratedFile(path, written string, meta GeneratedMeta, encoding OutputEncoding) error {
+	patches, err := readGeneratedPatches(path)
+	if err != nil {
+		return err
+	}
+	rendered, err := renderGeneratedFile(patches, meta)
+	if err != nil {
+		return err
+	}
+	if reread := encoding.apply(rendered); reread != written {
+		writtenLines, rereadLines := strings.Split(written, "\n"), strings.Split(reread, "\n")
+		line := 0
+		for line < len(writtenLines) && line < len(rereadLines) && writtenLines[line] == rereadLines[line] {
+			line++
+		}
+		return fmt.Errorf("%d patches read back, but line %d renders differently", len(patches), line+1)
+	}
+	return nil
+}
+
+func renderGeneratedFile(patches []Patch, meta GeneratedMeta) (string, error) {
+	draftPatches := []Patch{}
+	if meta.WIPMode == wipModeDraft {
+		patches, draftPatches = splitWIPPatches(patches)
+	}
+	outputPatches := make([]generatedPatch, 0, len(patches))
+	for _, patch := range patches {
+		outputPatches = append(outputPatches, toGeneratedPatch(patch, meta.GameID))
+	}
+	patchesJSON, err := json.MarshalIndent(outputPatches, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal patches: %w", err)
+	}
+	outputDrafts := make([]generatedPatch, 0, len(draftPatches))
+	for _, patch := range draftPatches {
+		outputDrafts = append(outputDrafts, toGeneratedPatch(patch, meta.GameID))
+	}
+	draftsJSON, err := json.MarshalIndent(outputDrafts, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal draft patches: %w", err)
+	}
+	meta.SchemaVersion = generatedSchemaVersion
+	meta.Currencies = currencyManifest(meta.GameID)
+	metaJSON, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal meta: %w", err)
+	}
+	cumulativeJSON, err := json.MarshalIndent(buildCumulativePulls(meta.GameID, patches, meta.CumulativeFrom), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal cumulative pulls: %w", err)
+	}
+	optionsJSON, err := json.MarshalIndent(buildGeneratedOptions(meta.GameID, append(append([]Patch{}, patches...), draftPatches...)), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal options: %w", err)
+	}
+	statsJSON, err := json.MarshalIndent(buildGeneratedStats(meta.GameID, patches), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal stats: %w", err)
+	}
+	content := strings.Join([]string{
+		"// Auto-generated by tools/patchsync. Do not edit by hand.",
+		fmt.Sprintf("export const GENERATED_PATCHES = %s;", string(patchesJSON)),
+		fmt.Sprintf("export const GENERATED_DRAFT_PATCHES = %s;", string(draftsJSON)),
+		fmt.Sprintf("export const GENERATED_PATCHES_META = %s;", string(metaJSON)),
+		fmt.Sprintf("export const GENERATED_CUMULATIVE = %s;", string(cumulativeJSON)),
+		fmt.Sprintf("export const GENERATED_OPTIONS = %s;", string(optionsJSON)),
+		fmt.Sprintf("export const GENERATED_STATS = %s;", string(statsJSON)),
+		"",
+	}, "\n")
+	return content, nil
+}
+func readPatchIDsFromContent(content string) []string {
+	matches := patchFieldPattern.FindAllStringSubmatch(content, -1)
+	ids := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if len(match) < 2 {
+			continue
+		}
+		candidate := strings.TrimSpace(match[1])
+		if versionSheetPattern.MatchString(candidate) {
+			ids = append(ids, candidate)
+		}
+	}
+	return uniqueStrings(ids)
+}
+
+func readPatchIDsFromFile(path string) (map[string]struct{}, error) {
+	result := map[string]struct{}{}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return result, nil
+		}
+		return nil, err
+	}
+	for _, patchID := range readPatchIDsFromContent(string(body)) {
+		result[patchID] = struct{}{}
+	}
+	return result, nil
+}
+
+func readGeneratedPatches(path string) ([]Patch, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []Patch{}, nil
+		}
+		return nil, err
+	}
+	match := generatedPatchesBlockPattern.FindSubmatch(body)
+	if len(match) < 2 {
+		return []Patch{}, nil
+	}
+	meta, err := parseGeneratedMeta(body)
+	if err != nil {
+		return nil, err
+	}
+	block, err := migrateGeneratedPatches(match[1], meta.SchemaVersion)
+	if err != nil {
+		return nil, fmt.Errorf("read GENERATED_PATCHES: %w", err)
+	}
+	var patches []Patch
+	if err := json.Unmarshal(block, &patches); err != nil {
+		return nil, fmt.Errorf("parse GENERATED_PATCHES: %w", err)
+	}
+	if draftMatch := generatedDraftPatchesBlockPattern.FindSubmatch(body); len(draftMatch) >= 2 {
+		draftBlock, err := migrateGeneratedPatches(draftMatch[1], meta.SchemaVersion)
+		if err != nil {
+			return nil, fmt.Errorf("read GENERATED_DRAFT_PATCHES: %w", err)
+		}
+		var drafts []Patch
+		if err := json.Unmarshal(draftBlock, &drafts); err != nil {
+			return nil, fmt.Errorf("parse GENERATED_DRAFT_PATCHES: %w", err)
+		}
+		patches = mergePatchesByID(patches, drafts)
+	}
+	return patches, nil
+}
+
+func mergePatchesByID(existing []Patch, additions []Patch) []Patch {
+	byID := make(map[string]Patch, len(existing)+len(additions))
+	order := make([]string, 0, len(existing)+len(additions))
+	for _, patch := range existing {
+		identity := patchIDOrFallback(patch)
+		if identity == "" {
+			continue
+		}
+		if _, ok := byID[identity]; !ok {
+			order = append(order, identity)
+		}
+		byID[identity] = patch
+	}
+	for _, patch := range additions {
+		identity := patchIDOrFallback(patch)
+		if identity == "" {
+			continue
+		}
+		if _, ok := byID[identity]; !ok {
+			order = append(order, identity)
+		}
+		byID[identity] = patch
+	}
+	merged := make([]Patch, 0, len(order))
+	for _, id := range order {
+		if patch, ok := byID[id]; ok {
+			merged = append(merged, patch)
+		}
+	}
+	sortPatches(merged)
+	return merged
+}
+
+type comparablePatch struct {
+	ID           string     `json:"id"`
+	Patch        string     `json:"patch"`
+	VersionName  string     `json:"versionName"`
+	StartDate    string     `json:"startDate"`
+	DurationDays int        `json:"durationDays"`
+	Tags         []string   `json:"tags,omitempty"`
+	Completeness *float64   `json:"completeness,omitempty"`
+	Notes        string     `json:"notes,omitempty"`
+	Sources      []Source   `json:"sources"`
+	Shop         []ShopItem `json:"shop,omitempty"`
+}
+
+func patchComparableValue(patch Patch) comparablePatch {
+	return comparablePatch{
+		ID:           strings.TrimSpace(patch.ID),
+		Patch:        strings.TrimSpace(patch.Patch),
+		VersionName:  strings.TrimSpace(patch.VersionName),
+		StartDate:    strings.TrimSpace(patch.StartDate),
+		DurationDays: patch.DurationDays,
+		Tags:         patch.Tags,
+		Completeness: patch.Completeness,
+		Notes:        strings.TrimSpace(patch.Notes),
+		Sources:      patch.Sources,
+		Shop:         patch.Shop,
+	}
+}
+
+func patchesEquivalent(left, right Patch) bool {
+	leftJSON, leftErr := json.Marshal(patchComparableValue(left))
+	if leftErr != nil {
+		return false
+	}
+	rightJSON, rightErr := json.Marshal(patchComparableValue(right))
+	if rightErr != nil {
+		return false
+	}
+	return string(leftJSON) == string(rightJSON)
+}
+
+// syncLog collects the log lines of one sync; lines are tagged with the request id when the sync came from the API.
+// sink, when set, also receives every line as it is logged.
+type syncLog struct {
+	requestID string
+	clock     Clock
+	lines     []string
+	sink      func(string)
+}
+
+func appendSyncLog(logs *syncLog, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	if logs.requestID != "" {
+		message = fmt.Sprintf("[%s] %s", logs.requestID, message)
+	}
+	timestamped := fmt.Sprintf("[%s] %s", clockOrSystem(logs.clock).Now().Format("15:04:05"), message)
+	logs.lines = append(logs.lines, timestamped)
+	recentSyncLogs.add(timestamped)
+	if logs.sink != nil {
+		logs.sink(timestamped)
+	}
+	fmt.Println(timestamped)
+}
+
+func patchIDOrFallback(patch Patch) string {
+	patchID := strings.TrimSpace(patch.Patch)
+	if patchID == "" {
+		patchID = strings.TrimSpace(patch.ID)
+	}
+	if patchID == "" {
+		return ""
+	}
+	return canonicalPatchID(patchID)
+}
+
+func sourceByID(patch Patch) map[string]Source {
+	result := make(map[string]Source, len(patch.Sources))
+	for _, src := range patch.Sources {
+		sourceID := strings.TrimSpace(src.ID)
+		if sourceID == "" {
+			continue
+		}
+		result[sourceID] = src
+	}
+	return result
+}
+
+func sourcesEquivalent(left, right Source) bool {
+	leftJSON, leftErr := json.Marshal(left)
+	if leftErr != nil {
+		return false
+	}
+	rightJSON, rightErr := json.Marshal(right)
+	if rightErr != nil {
+		return false
+	}
+	return string(leftJSON) == string(rightJSON)
+}
+
+func changedSourceIDs(previous, next Patch) []string {
+	previousSources := sourceByID(previous)
+	nextSources := sourceByID(next)
+	ids := make([]string, 0, len(previousSources)+len(nextSources))
+	seen := map[string]struct{}{}
+	for id := range previousSources {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	for id := range nextSources {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	changed := make([]string, 0, len(ids))
+	for _, id := range ids {
+		prevSource, hasPrev := previousSources[id]
+		nextSource, hasNext := nextSources[id]
+		if !hasPrev || !hasNext || !sourcesEquivalent(prevSource, nextSource) {
+			changed = append(changed, id)
+		}
+	}
+	return changed
+}
+
+func appendChangeLogRecord(path string, record syncChangeLogRecord) error {
+	logPath := resolveOutputPath(path)
+	if strings.TrimSpace(logPath) == "" {
+		return errors.New("change log path is empty")
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return fmt.Errorf("create change log directory: %w", err)
+	}
+	file, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open change log file: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(record); err != nil {
+		return fmt.Errorf("write change log record: %w", err)
+	}
+	return nil
+}
+
+func readChangeLogRecords(path string) ([]syncChangeLogRecord, error) {
+	body, err := os.ReadFile(resolveFilePath(path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []syncChangeLogRecord{}, nil
+		}
+		return nil, err
+	}
+	return parseChangeLogRecords(body, "change log")
+}
+
+// RunSync fetches and parses the configured spreadsheets for one game and writes the generated file unless cfg.DryRun is set.
+// With cfg.GitLab set, a created branch is committed, pushed, and opened as a merge request. The outcome is
+// then reported to the configured notification sinks.
+func RunSync(ctx context.Context, cfg SyncConfig) (SyncResult, error) {
+	result, err := runSyncWithBreaker(ctx, cfg)
+	if err == nil {
+		err = openSyncMergeRequest(ctx, cfg, &result)
+	}
+	notifySyncOutcome(cfg, &result, err)
+	liveSyncProgress.finish(cfg.GameID, err)
+	return result, err
+}
+
+// runSyncWithBreaker syncs one game. When the spreadsheet breaker opened during the run, the error reports that
+// instead of whichever fetch or discovery step happened to fail last.
+func runSyncWithBreaker(ctx context.Context, cfg SyncConfig) (SyncResult, error) {
+	if cfg.breaker == nil {
+		cfg.breaker = newSpreadsheetBreaker(cfg.BreakerLimit)
+	}
+	result, err := runSync(ctx, cfg)
+	if err == nil {
+		return result, nil
+	}
+	var throttled *spreadsheetThrottledError
+	if errors.As(err, &throttled) {
+		return result, withErrorCode(errCodeSpreadsheetThrottled, err)
+	}
+	ids := spreadsheetIDList(cfg.SpreadsheetID)
+	if len(ids) == 0 {
+		if profile, profileErr := ResolveGameProfile(cfg.GameID); profileErr == nil {
+			ids = profile.DefaultSpreadsheetIDs
+		}
+	}
+	if throttledErr := cfg.breaker.throttled(ids); throttledErr != nil {
+		return result, withErrorCode(errCodeSpreadsheetThrottled, fmt.Errorf("%w (last error: %v)", throttledErr, err))
+	}
+	return result, err
+}
+
+func runSync(ctx context.Context, cfg SyncConfig) (SyncResult, error) {
+	if cfg.SyncTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.SyncTimeout)
+		defer cancel()
+	}
+	clock := clockOrSystem(cfg.Clock)
+	logs := syncLog{requestID: cfg.RequestID, clock: clock, lines: make([]string, 0, 64), sink: cfg.logSink}
+	profiler := syncProfiler{enabled: cfg.Profile}
+	profile, profileErr := ResolveGameProfile(cfg.GameID)
+	if profileErr != nil {
+		return SyncResult{}, withErrorCode(errCodeUnknownGame, profileErr)
+	}
+	cfg.GameID = profile.ID
+	appendSyncLog(&logs, "sync start for game=%s", cfg.GameID)
+	progress := newSyncProgressTracker(cfg.GameID, &logs, cfg.progressSink)
+	previewMode, previewErr := parsePreviewMode(cfg.Preview)
+	if previewErr != nil {
+		return SyncResult{}, withErrorCode(errCodeConfig, previewErr)
+	}
+	if previewMode != "" && !cfg.DryRun {
+		return SyncResult{}, withErrorCode(errCodeConfig, errors.New("preview is only available for dry runs"))
+	}
+	if encodingErr := cfg.OutputEncoding.validate(); encodingErr != nil {
+		return SyncResult{}, withErrorCode(errCodeConfig, encodingErr)
+	}
+	overlapPolicy, overlapErr := parseBaseOverlapPolicy(cfg.BaseOverlap)
+	if overlapErr != nil {
+		return SyncResult{}, withErrorCode(errCodeConfig, overlapErr)
+	}
+	if cfg.Backfill {
+		cfg.SkipExisting = false
+		cfg.SheetNames = nil
+		cfg.ForcePatches = nil
+		cfg.LatestPatches = 0
+		appendSyncLog(&logs, "backfill mode: re-parsing every sheet and rewriting output from scratch")
+	}
+	regions := cfg.ServerRegions
+	if len(regions) == 0 {
+		regions = profile.Regions
+	}
+	patchStartRaw := cfg.PatchStart
+	if strings.TrimSpace(patchStartRaw) == "" {
+		patchStartRaw = profile.PatchStart
+	}
+	if strings.TrimSpace(patchStartRaw) == "" {
+		patchStartRaw = defaultPatchStart
+	}
+	patchStart, err := parsePatchStart(patchStartRaw)
+	if err != nil {
+		return SyncResult{}, withErrorCode(errCodeConfig, err)
+	}
+
+	spreadsheetIDs := spreadsheetIDList(cfg.SpreadsheetID)
+	if len(spreadsheetIDs) == 0 {
+		spreadsheetIDs = profile.DefaultSpreadsheetIDs
+	}
+	if len(spreadsheetIDs) == 0 {
+		envKey := spreadsheetEnvKeyForGame(cfg.GameID)
+		if envKey != "" {
+			return SyncResult{}, withErrorCode(errCodeConfig, fmt.Errorf("spreadsheet-id is required (set --spreadsheet-id or %s in .env)", envKey))
+		}
+		return SyncResult{}, withErrorCode(errCodeConfig, errors.New("spreadsheet-id is required"))
+	}
+	cfg.SpreadsheetID = spreadsheetIDs[0]
+	if cfg.ClientTimeout <= 0 {
+		cfg.ClientTimeout = 20 * time.Second
+	}
+	if strings.TrimSpace(cfg.OutputPath) == "" {
+		cfg.OutputPath = profile.DefaultOutputPath
+	}
+	cfg.OutputPath = resolveOutputPath(cfg.OutputPath)
+	if strings.TrimSpace(cfg.BasePatchesPath) == "" {
+		cfg.BasePatchesPath = "src/data/patches.js"
+	}
+	cfg.BasePatchesPath = resolveFilePath(cfg.BasePatchesPath)
+	changeLogPath := resolveOutputPath(defaultChangeLogPath)
+	overridesPath := overridesPathForGame(cfg.OverridesDir, cfg.GameID)
+	gameCfg, err := readGameConfig(overridesPath, cfg.GameID)
+	if err != nil {
+		return SyncResult{}, withErrorCode(errorCode(err), fmt.Errorf("read overrides: %w", err))
+	}
+	patchOverrides, sheetPatterns, mergePolicy, aliases := gameCfg.Patches, gameCfg.Sheets, gameCfg.Merge, gameCfg.Aliases
+	if len(patchOverrides) > 0 {
+		appendSyncLog(&logs, "loaded overrides for %d patches from %s", len(patchOverrides), overridesPath)
+	}
+	sheetFilter, err := newSheetNameFilter(sheetPatterns, cfg.ExcludeSheets)
+	if err != nil {
+		return SyncResult{}, withErrorCode(errCodeConfig, err)
+	}
+	strictness := gameCfg.Strictness
+	if strings.TrimSpace(cfg.ParseStrictness) != "" {
+		if strictness, err = parseStrictnessLevel(cfg.ParseStrictness); err != nil {
+			return SyncResult{}, withErrorCode(errCodeConfig, err)
+		}
+	}
+	if strictness != parseNormal {
+		appendSyncLog(&logs, "parse strictness=%s", strictness)
+	}
+	decimalSeparator := gameCfg.DecimalSeparator
+	if strings.TrimSpace(cfg.DecimalSeparator) != "" {
+		if decimalSeparator, err = parseDecimalSeparator(cfg.DecimalSeparator); err != nil {
+			return SyncResult{}, withErrorCode(errCodeConfig, err)
+		}
+	}
+	if decimalSeparator != decimalAuto {
+		appendSyncLog(&logs, "decimal separator=%s", decimalSeparator)
+	}
+	bounds, pullTolerance, reconcile := gameCfg.Bounds, gameCfg.PullTolerance, gameCfg.Reconcile
+	if reconcile != "" {
+		appendSyncLog(&logs, "reconcile sheet totals into %s", reconcile)
+	}
+	translationsPath := translationsPathForGame(cfg.TranslationsDir, cfg.GameID)
+	translations, err := readSourceTranslations(translationsPath)
+	if err != nil {
+		return SyncResult{}, withErrorCode(errCodeLocalFile, fmt.Errorf("read translations: %w", err))
+	}
+	auxSheetNames := uniqueStrings(append(append([]string{}, sheetPatterns.Aux...), profile.AuxSheetNames...))
+	appendSyncLog(&logs, "spreadsheet=%s", strings.Join(spreadsheetIDs, ","))
+	transport, err := newOutboundTransport(cfg.Outbound)
+	if err == nil {
+		transport, err = newGoogleBaseURLTransport(cfg.GoogleBaseURL, transport)
+	}
+	if err == nil {
+		transport, err = fixtureTransport(cfg, transport)
+	}
+	if err != nil {
+		return SyncResult{}, withErrorCode(errCodeConfig, err)
+	}
+	transport = cfg.breaker.wrap(transport)
+	if strings.TrimSpace(cfg.Outbound.Proxy) != "" {
+		if proxyURL, err := parseProxyURL(cfg.Outbound.Proxy); err == nil {
+			appendSyncLog(&logs, "outbound proxy %s", proxyURL.Redacted())
+		}
+	}
+	if cfg.Outbound.InsecureSkipVerify {
+		appendSyncLog(&logs, "warning: TLS certificate verification is disabled for spreadsheet requests")
+	}
+	client := &http.Client{Timeout: cfg.ClientTimeout, Transport: transport}
+	fetcher := cfg.Fetcher
+	if fetcher == nil {
+		fetcher, err = newSheetFetcher(cfg, client, profile.ParseSheet, sheetFilter)
+		if err != nil {
+			return SyncResult{}, withErrorCode(errCodeConfig, err)
+		}
+	}
+	if cfg.DiscoveryTTL > 0 && strings.TrimSpace(cfg.SheetSource) != sheetSourceLocal {
+		cachePath := cfg.DiscoveryCache
+		if strings.TrimSpace(cachePath) == "" {
+			cachePath = defaultDiscoveryCachePath
+		}
+		fetcher = cachedDiscoveryFetcher{
+			SheetFetcher: fetcher,
+			path:         resolveOutputPath(cachePath),
+			source:       strings.TrimSpace(cfg.SheetSource),
+			variant:      sheetFilter.fingerprint(),
+			ttl:          cfg.DiscoveryTTL,
+			refresh:      cfg.Rediscover,
+			clock:        clock,
+			logs:         &logs,
+		}
+	}
+
+	// With --create-branch the worktree copy from HEAD is the baseline, since that is the file the sync rewrites.
+	branchName := ""
+	outputPath, apiDir := cfg.OutputPath, cfg.APIDir
+	var worktree *syncWorktree
+	apiInWorktree := false
+	if cfg.CreateBranch {
+		opened, branchErr := openSyncWorktree(cfg.RepoRoot, cfg.BranchPrefix, clock.Now())
+		if branchErr != nil {
+			return SyncResult{}, withErrorCode(errCodeGit, branchErr)
+		}
+		defer opened.remove()
+		mapped, inside := opened.path(outputPath)
+		if !inside {
+			return SyncResult{}, withErrorCode(errCodeGit, fmt.Errorf("output %s is outside the repository %s", outputPath, opened.repoRoot))
+		}
+		worktree, branchName, outputPath = opened, opened.branch, mapped
+		if strings.TrimSpace(apiDir) != "" {
+			apiDir, apiInWorktree = worktree.path(apiDir)
+		}
+		appendSyncLog(&logs, "created branch %s in worktree %s", branchName, worktree.dir)
+	}
+
+	if !cfg.DryRun {
+		defer lockOutput(outputPath)()
+	}
+	existingGenerated, err := readGeneratedPatches(outputPath)
+	if err != nil {
+		return SyncResult{}, withErrorCode(errCodeLocalFile, fmt.Errorf("read existing generated patches: %w", err))
+	}
+	appendSyncLog(&logs, "loaded %d existing generated patches", len(existingGenerated))
+	if withoutForecasts := withoutForecastPatches(existingGenerated); len(withoutForecasts) != len(existingGenerated) {
+		appendSyncLog(&logs, "dropped %d forecast patches from previous output", len(existingGenerated)-len(withoutForecasts))
+		existingGenerated = withoutForecasts
+	}
+	existingGenerated = aliases.renameExisting(existingGenerated, &logs)
+	existingGeneratedByID := map[string]Patch{}
+	for _, patch := range existingGenerated {
+		patchID := patchIDOrFallback(patch)
+		if patchID != "" {
+			existingGeneratedByID[patchID] = patch
+		}
+	}
+	baseIDs, err := readBasePatchIDs(cfg.BasePatchesPath, profile.BasePatchesArray)
+	if err != nil {
+		return SyncResult{}, withErrorCode(errCodeLocalFile, fmt.Errorf("read base patches file: %w", err))
+	}
+	basePatchIDs := map[string]struct{}{}
+	if cfg.SkipExisting {
+		readIDs, readErr := readPatchIDsFromFile(cfg.BasePatchesPath)
+		if readErr != nil {
+			return SyncResult{}, withErrorCode(errCodeLocalFile, fmt.Errorf("read base patches file: %w", readErr))
+		}
+		for patchID := range readIDs {
+			basePatchIDs[patchID] = struct{}{}
+		}
+		appendSyncLog(&logs, "loaded %d base patch ids for skip-existing", len(basePatchIDs))
+	}
+
+	forcedPatchIDs := map[string]struct{}{}
+	for _, raw := range cfg.ForcePatches {
+		if patchID := canonicalPatchID(raw); patchID != "" {
+			forcedPatchIDs[patchID] = struct{}{}
+		}
+	}
+	if len(forcedPatchIDs) > 0 {
+		appendSyncLog(&logs, "force resync for %d patches", len(forcedPatchIDs))
+	}
+	frozenIDs := map[string]struct{}{}
+	if !cfg.AllowHistorical {
+		var livePatch string
+		frozenIDs, livePatch = frozenPatchIDs(existingGenerated, clock.Now())
+		if len(frozenIDs) > 0 {
+			appendSyncLog(&logs, "live patch is %s; %d older patches are frozen", livePatch, len(frozenIDs))
+		}
+	}
+	frozenDiffs := []patchDiff{}
+
+	parser := profile.ParseSheet
+
+	requestedSheetNames := uniqueSheetNames(cfg.SheetNames)
+	explicitSheetNames := len(requestedSheetNames) > 0
+	sources := make([]spreadsheetSource, 0, len(spreadsheetIDs))
+	for idx, spreadsheetID := range spreadsheetIDs {
+		discoverNames := requestedSheetNames
+		if len(spreadsheetIDs) > 1 {
+			discoverNames = nil
+			appendSyncLog(&logs, "load spreadsheet %s", spreadsheetID)
+		}
+		progress.phase(progressDiscovery, 0)
+		doneDiscovery := profiler.start("discovery", "")
+		src, loadErr := loadSpreadsheetSource(ctx, fetcher, cfg.GameID, spreadsheetID, idx == 0, discoverNames, auxSheetNames, profile.ShopSheetNames, decimalSeparator, &logs)
+		doneDiscovery()
+		if loadErr != nil {
+			if len(spreadsheetIDs) > 1 {
+				return SyncResult{}, fmt.Errorf("spreadsheet %s: %w", spreadsheetID, loadErr)
+			}
+			return SyncResult{}, loadErr
+		}
+		sources = append(sources, src)
+	}
+
+	sheetNames, sheetSourceIdx := mergeSpreadsheetSheetNames(sources, &logs)
+	if explicitSheetNames && len(sources) > 1 {
+		sheetNames, err = filterRequestedSheetNames(sheetNames, requestedSheetNames)
+		if err != nil {
+			return SyncResult{}, withErrorCode(errCodeNoSheets, err)
+		}
+	}
+	if len(sheetNames) == 0 {
+		return SyncResult{}, withErrorCode(errCodeNoSheets, errors.New("no sheet names to parse"))
+	}
+
+	if strings.TrimSpace(cfg.SheetHashPath) == "" {
+		cfg.SheetHashPath = defaultSheetHashPath
+	}
+	sheetHashPath := resolveOutputPath(cfg.SheetHashPath)
+	structurePath := sheetStructurePath(sheetHashPath)
+	var expectedStructure, nextStructure *sheetStructure
+	if structures, structErr := readSheetStructures(structurePath); structErr != nil {
+		appendSyncLog(&logs, "sheet structure fingerprint unavailable: %v", structErr)
+	} else if recorded, ok := structures[cfg.GameID]; ok {
+		expectedStructure = &recorded
+	}
+	previousHashes, hashErr := readSheetHashes(sheetHashPath, cfg.GameID)
+	if hashErr != nil {
+		appendSyncLog(&logs, "sheet hashes unavailable; parsing all sheets: %v", hashErr)
+		previousHashes = sheetHashRecord{Sheets: map[string]string{}}
+	}
+	auxParts := make([]string, 0, 3*len(sources)+1)
+	for _, src := range sources {
+		auxParts = append(auxParts, src.DataCSV, src.SummaryCSV, src.ShopCSV)
+	}
+	auxParts = append(auxParts, syncSettings{
+		Overrides:        gameCfg,
+		Translations:     translations,
+		Strictness:       strictness,
+		DecimalSeparator: decimalSeparator,
+		WIPMode:          cfg.WIPMode,
+		BaseOverlap:      overlapPolicy,
+		Regions:          regions,
+		PatchStart:       patchStartRaw,
+	}.hash())
+	nextHashes := sheetHashRecord{
+		Aux:    contentHash(auxParts...),
+		Sheets: map[string]string{},
+	}
+	hashSkipEnabled := cfg.SkipExisting && previousHashes.Aux == nextHashes.Aux
+	if hashSkipEnabled {
+		for name, hash := range previousHashes.Sheets {
+			nextHashes.Sheets[name] = hash
+		}
+	}
+
+	syncSheetNames := latestSheetNames(sheetNames, cfg.LatestPatches)
+	if len(syncSheetNames) < len(sheetNames) {
+		appendSyncLog(&logs, "limiting sync to latest %d sheets: %s", len(syncSheetNames), strings.Join(syncSheetNames, ", "))
+	}
+
+	patches := make([]Patch, 0, len(sheetNames))
+	parsedSheetNames := make([]string, 0, len(sheetNames))
+	skippedPatches := make([]string, 0, len(sheetNames))
+	changeEntries := make([]patchChangeLogEntry, 0, len(sheetNames))
+	sheetFailures := []sheetFailure{}
+	sheetStats := []sheetFetchStat{}
+	outOfBounds := []string{}
+	discrepancies := []pullDiscrepancy{}
+	failFast := explicitSheetNames && !cfg.ContinueOnError
+	recordFailure := func(sheetName, stage string, err error) {
+		sheetFailures = append(sheetFailures, sheetFailure{Sheet: sheetName, Stage: stage, Code: errorCode(err), Error: err.Error()})
+	}
+	validPatchRows := 0
+	for sheetIdx, sheetName := range syncSheetNames {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return cancelledSyncResult(cfg.GameID, &logs, parsedSheetNames, syncSheetNames[sheetIdx:], sheetFailures, ctxErr)
+		}
+		progress.sheet(sheetName, sheetIdx+1, len(syncSheetNames))
+		src := sources[sheetSourceIdx[sheetName]]
+		doneFetch := profiler.start("fetch", sheetName)
+		fetchStarted := time.Now()
+		csvText, fetchErr := fetcher.FetchCSV(ctx, src.ID, sheetName)
+		fetchMS := durationMillis(time.Since(fetchStarted))
+		doneFetch()
+		if fetchErr != nil && ctx.Err() != nil {
+			return cancelledSyncResult(cfg.GameID, &logs, parsedSheetNames, syncSheetNames[sheetIdx:], sheetFailures, ctx.Err())
+		}
+		if fetchErr != nil {
+			if failFast {
+				return SyncResult{}, withErrorCode(errCodeSpreadsheetUnreachable, fmt.Errorf("fetch sheet %s: %w", sheetName, fetchErr))
+			}
+			appendSyncLog(&logs, "skip fetch failed sheet %s: %v", sheetName, fetchErr)
+			recordFailure(sheetName, "fetch", withErrorCode(errCodeSpreadsheetUnreachable, fetchErr))
+			continue
+		}
+		sheetStats = append(sheetStats, sheetFetchStat{Sheet: sheetName, Bytes: len(csvText), FetchMS: fetchMS})
+		appendSyncLog(&logs, "fetched sheet %s: %d bytes in %.1f ms", sheetName, len(csvText), fetchMS)
+		sheetHash := contentHash(csvText)
+		if hashSkipEnabled && previousHashes.Sheets[sheetName] == sheetHash {
+			sheetPatchID := canonicalPatchID(sheetName)
+			_, forced := forcedPatchIDs[sheetPatchID]
+			if _, hadPrevious := existingGeneratedByID[sheetPatchID]; hadPrevious && !forced {
+				validPatchRows++
+				skippedPatches = append(skippedPatches, sheetPatchID)
+				appendSyncLog(&logs, "skip unchanged sheet %s (content hash match)", sheetName)
+				continue
+			}
+		}
+		doneParse := profiler.start("parse", sheetName)
+		parseStarted := time.Now()
+		patch, parseErr := parser(sheetName, csvText, ParseOptions{Strictness: strictness, DecimalSeparator: src.DecimalSeparator, Warn: func(message string) {
+			appendSyncLog(&logs, "warning: sheet %s: %s", sheetName, message)
+		}})
+		sheetStats[len(sheetStats)-1].ParseMS = durationMillis(time.Since(parseStarted))
+		doneParse()
+		appendSyncLog(&logs, "parsed sheet %s in %.1f ms", sheetName, sheetStats[len(sheetStats)-1].ParseMS)
+		if parseErr != nil {
+			parseCode := errCodeParse
+			if drift := sheetStructureDrift(expectedStructure, sheetName, csvText, 0, parseErr); len(drift) > 0 {
+				parseCode = errCodeSheetStructure
+				parseErr = fmt.Errorf("sheet structure changed since %s (%s): %w", expectedStructure.Sheet, strings.Join(drift, "; "), parseErr)
+			}
+			if failFast {
+				return SyncResult{}, withErrorCode(parseCode, fmt.Errorf("parse sheet %s: %w", sheetName, parseErr))
+			}
+			appendSyncLog(&logs, "skip parse failed sheet %s: %v", sheetName, parseErr)
+			recordFailure(sheetName, "parse", withErrorCode(parseCode, parseErr))
+			continue
+		}
+		if drift := sheetStructureDrift(expectedStructure, sheetName, csvText, len(patch.Sources), nil); len(drift) > 0 {
+			appendSyncLog(&logs, "sheet structure changed for %s since %s: %s", sheetName, expectedStructure.Sheet, strings.Join(drift, "; "))
+		} else if expectedStructure == nil || len(patch.Sources)*2 >= expectedStructure.Sources {
+			structure := sheetStructureOf(sheetName, csvText)
+			structure.Sources = len(patch.Sources)
+			nextStructure = &structure
+		}
+		applySheetNotes(cfg.GameID, &patch, csvText)
+		applySheetCompleteness(&patch, csvText)
+		doneOverrides := profiler.start("overrides", sheetName)
+		computedPulls := computedSourcePulls(cfg.GameID, patch)
+		if auxSheet, applyErr := applySpreadsheetOverrides(cfg.GameID, &patch, src, reconcile); applyErr != nil {
+			if failFast {
+				return SyncResult{}, withErrorCode(errCodeParse, fmt.Errorf("apply %s overrides for sheet %s: %w", auxSheet, sheetName, applyErr))
+			}
+			appendSyncLog(&logs, "skip %s overrides for %s: %v", auxSheet, sheetName, applyErr)
+			recordFailure(sheetName, auxSheet, withErrorCode(errCodeParse, applyErr))
+		} else {
+			for _, discrepancy := range crossCheckSourcePulls(patch, computedPulls, pullTolerance) {
+				discrepancies = append(discrepancies, discrepancy)
+				appendSyncLog(&logs, "warning: %s sheet disagrees with %s: %s", auxSheet, sheetName, discrepancy)
+			}
+		}
+		validPatchRows++
+		nextHashes.Sheets[sheetName] = sheetHash
+		patchID := patchIDOrFallback(patch)
+		if len(src.DataTags) > 0 {
+			if dataTags, ok := src.DataTags[patchID]; ok {
+				patch.Tags = mergeTagLists(patch.Tags, dataTags)
+			}
+		}
+		appendPatchNotes(&patch, src.DataNotes[patchID])
+		if items, ok := src.Shop[patchID]; ok {
+			patch.Shop = items
+		}
+		if from := aliases.rename(&patch); from != "" {
+			patchID = patchIDOrFallback(patch)
+			appendSyncLog(&logs, "rename patch %s to %s (alias)", from, patchID)
+		}
+		if previous, ok := existingGeneratedByID[patchID]; ok && len(mergePolicy) > 0 {
+			patch = mergeWithExisting(previous, patch, mergePolicy)
+		}
+		if override, ok := patchOverrides[patchID]; ok {
+			if applyErr := applyPatchOverride(&patch, override); applyErr != nil {
+				if failFast {
+					return SyncResult{}, withErrorCode(errCodeParse, fmt.Errorf("apply overrides for sheet %s: %w", sheetName, applyErr))
+				}
+				appendSyncLog(&logs, "skip overrides for %s: %v", sheetName, applyErr)
+				recordFailure(sheetName, "overrides", withErrorCode(errCodeParse, applyErr))
+			} else {
+				appendSyncLog(&logs, "applied overrides for patch %s", patchID)
+			}
+		}
+		applyComputedPulls(cfg.GameID, &patch)
+		doneOverrides()
+		if _, inBase := baseIDs[patchID]; inBase && overlapPolicy == baseOverlapPreferBase {
+			skippedPatches = append(skippedPatches, patchID)
+			appendSyncLog(&logs, "skip patch %s: defined in base patches (--base-overlap %s)", patchID, overlapPolicy)
+			continue
+		}
+		if cfg.WIPMode == wipModeExclude && isWIPPatch(patch) {
+			appendSyncLog(&logs, "skip WIP patch %s (--exclude-wip)", patchID)
+			continue
+		}
+		for _, warning := range bounds.check(cfg.GameID, patch) {
+			outOfBounds = append(outOfBounds, warning)
+			appendSyncLog(&logs, "warning: %s", warning)
+		}
+		previousPatch, hadPrevious := existingGeneratedByID[patchID]
+		_, forced := forcedPatchIDs[patchID]
+		if _, frozen := frozenIDs[patchID]; frozen && hadPrevious && !patchesEquivalent(previousPatch, patch) {
+			diff := diffPatchPair(previousPatch, patch, cfg.GameID)
+			if diff == nil {
+				diff = &patchDiff{Patch: patchID, Status: "changed"}
+			}
+			frozenDiffs = append(frozenDiffs, *diff)
+			delete(nextHashes.Sheets, sheetName)
+			appendSyncLog(&logs, "refuse changes to historical patch %s (use --allow-historical-edits to apply them)", patchID)
+			if cfg.Backfill {
+				patches = append(patches, previousPatch)
+			} else {
+				skippedPatches = append(skippedPatches, patchID)
+			}
+			continue
+		}
+		if cfg.SkipExisting && !forced {
+			if hadPrevious {
+				if patchesEquivalent(previousPatch, patch) {
+					if patchID != "" {
+						skippedPatches = append(skippedPatches, patchID)
+						appendSyncLog(&logs, "skip unchanged patch %s", patchID)
+					}
+					continue
+				}
+			}
+			delete(basePatchIDs, patchID)
+		}
+		if forced && hadPrevious && patchesEquivalent(previousPatch, patch) {
+			appendSyncLog(&logs, "force rewrite of unchanged patch %s", patchID)
+		}
+		if cfg.Backfill && hadPrevious && patchesEquivalent(previousPatch, patch) {
+			appendSyncLog(&logs, "queue unchanged patch %s", patchID)
+		} else {
+			entry := patchChangeLogEntry{
+				Patch:          patchID,
+				ChangeType:     "added",
+				ChangedSources: []string{},
+			}
+			if hadPrevious {
+				entry.ChangeType = "updated"
+				entry.ChangedSources = changedSourceIDs(previousPatch, patch)
+				if diff := diffPatchPair(previousPatch, patch, cfg.GameID); diff != nil {
+					entry.Fields = diff.Fields
+					entry.Sources = diff.Sources
+				}
+			}
+			changeEntries = append(changeEntries, entry)
+			appendSyncLog(&logs, "queue %s patch %s", entry.ChangeType, patchID)
+		}
+		patches = append(patches, patch)
+		parsedSheetNames = append(parsedSheetNames, sheetName)
+		if patchID != "" {
+			existingGeneratedByID[patchID] = patch
+		}
+	}
+	if cfg.Backfill && len(sheetFailures) > 0 {
+		// A backfill rewrites the output from scratch, so a sheet that failed would drop its published patch.
+		appendSyncLog(&logs, "abort backfill: %d sheet(s) failed", len(sheetFailures))
+		return SyncResult{
+			GameID:        cfg.GameID,
+			SheetNames:    parsedSheetNames,
+			SheetFailures: sheetFailures,
+			Logs:          logs.lines,
+		}, withErrorCode(sheetFailures[0].Code, fmt.Errorf("backfill aborted: sheet %s failed: %s", sheetFailures[0].Sheet, sheetFailures[0].Error))
+	}
+	if validPatchRows == 0 && len(patches) == 0 && len(skippedPatches) == 0 {
+		return SyncResult{}, withErrorCode(errCodeNoSheets, errors.New("no valid patch sheets found with N.N names"))
+	}
+	sortPatches(patches)
+	skippedPatches = uniqueStrings(skippedPatches)
+	appendSyncLog(&logs, "parsed=%d changed=%d skipped=%d", validPatchRows, len(patches), len(skippedPatches))
+
+	allPatches := mergePatchesByID(existingGenerated, patches)
+	if cfg.Backfill {
+		allPatches = mergePatchesByID(nil, patches)
+		regenerated := map[string]struct{}{}
+		for _, patch := range allPatches {
+			regenerated[patchIDOrFallback(patch)] = struct{}{}
+		}
+		for _, patch := range existingGenerated {
+			patchID := patchIDOrFallback(patch)
+			if _, ok := regenerated[patchID]; ok || patchID == "" {
+				continue
+			}
+			if _, frozen := frozenIDs[patchID]; frozen {
+				allPatches = mergePatchesByID(allPatches, []Patch{patch})
+				frozenDiffs = append(frozenDiffs, patchDiff{Patch: patchID, Status: "removed"})
+				appendSyncLog(&logs, "keep historical patch %s no longer produced by the spreadsheet", patchID)
+				continue
+			}
+			changeEntries = append(changeEntries, patchChangeLogEntry{
+				Patch:          patchID,
+				ChangeType:     "removed",
+				ChangedSources: []string{},
+			})
+			appendSyncLog(&logs, "drop patch %s no longer produced by the spreadsheet", patchID)
+		}
+	}
+	overlaps := baseOverlaps(baseIDs, allPatches)
+	baseDropped := false
+	if len(overlaps) > 0 {
+		switch overlapPolicy {
+		case baseOverlapError:
+			err := fmt.Errorf("patches %s are defined in both %s and the generated output", strings.Join(overlaps, ", "), cfg.BasePatchesPath)
+			appendSyncLog(&logs, "%v", err)
+			return SyncResult{GameID: cfg.GameID, SheetNames: parsedSheetNames, BaseOverlaps: overlaps, Logs: logs.lines}, withErrorCode(errCodeDuplicatePatch, err)
+		case baseOverlapPreferBase:
+			dropped := map[string]struct{}{}
+			for _, patchID := range overlaps {
+				dropped[patchID] = struct{}{}
+				changeEntries = append(changeEntries, patchChangeLogEntry{
+					Patch:          patchID,
+					ChangeType:     "removed",
+					ChangedSources: []string{},
+				})
+			}
+			kept := make([]Patch, 0, len(allPatches))
+			for _, patch := range allPatches {
+				if _, ok := dropped[patchIDOrFallback(patch)]; !ok {
+					kept = append(kept, patch)
+				}
+			}
+			allPatches, baseDropped = kept, true
+			appendSyncLog(&logs, "drop patches %s from the generated output: defined in base patches", strings.Join(overlaps, ", "))
+		default:
+			appendSyncLog(&logs, "patches %s are also defined in base patches; the generated ones replace them in the app", strings.Join(overlaps, ", "))
+		}
+	}
+	if cfg.WIPMode == wipModeExclude {
+		published, drafts := splitWIPPatches(allPatches)
+		if len(drafts) > 0 {
+			appendSyncLog(&logs, "excluded %d WIP patches from output", len(drafts))
+		}
+		allPatches = published
+	}
+	if cfg.ForecastPatches > 0 {
+		forecasts, forecastErr := forecastPatches(cfg.GameID, allPatches, cfg.ForecastPatches, cfg.ForecastWindow)
+		if forecastErr != nil {
+			appendSyncLog(&logs, "skip forecast: %v", forecastErr)
+		} else {
+			allPatches = append(allPatches, forecasts...)
+			appendSyncLog(&logs, "appended %d forecast patches", len(forecasts))
+		}
+	}
+	applyPatchStartTimes(patches, regions, patchStart)
+	applyPatchStartTimes(allPatches, regions, patchStart)
+	generatedAt := clock.Now().UTC().Format(time.RFC3339)
+	meta := GeneratedMeta{
+		GameID:         cfg.GameID,
+		SpreadsheetID:  cfg.SpreadsheetID,
+		Sheets:         uniqueStrings(append(parsedSheetNames, skippedPatches...)),
+		CumulativeFrom: canonicalPatchID(cfg.CumulativeFrom),
+		WIPMode:        cfg.WIPMode,
+		GeneratedAt:    generatedAt,
+	}
+	if len(spreadsheetIDs) > 1 {
+		meta.SpreadsheetIDs = spreadsheetIDs
+	}
+	labels, unusedLabels := buildSourceLabels(allPatches, translations)
+	meta.Labels = labels
+	if len(labels) > 0 {
+		appendSyncLog(&logs, "source labels for %d locales from %s", len(labels), translationsPath)
+	}
+	if len(unusedLabels) > 0 {
+		appendSyncLog(&logs, "translations without a matching source: %s", strings.Join(unusedLabels, ", "))
+	}
+	for _, src := range sources {
+		if src.AuxSheet != "" {
+			if meta.AuxSheets == nil {
+				meta.AuxSheets = map[string]string{}
+			}
+			meta.AuxSheets[src.ID] = src.AuxSheet
+		}
+	}
+	stats := buildSyncStats(cfg.GameID, changeEntries, existingGenerated, allPatches)
+	progress.phase(progressWrite, 90)
+	preview := ""
+	if previewMode != "" && len(allPatches) > 0 {
+		rendered, renderErr := renderSyncPreview(previewMode, outputPath, allPatches, meta, cfg.OutputEncoding)
+		if renderErr != nil {
+			appendSyncLog(&logs, "dry-run preview failed: %v", renderErr)
+		}
+		preview = rendered
+	}
+	doneWrite := profiler.start("write", "")
+	if !cfg.DryRun && (len(patches) > 0 || baseDropped) {
+		if kept, historyErr := rotateOutputHistory(outputPath, cfg.GameID, cfg.OutputHistory); historyErr != nil {
+			appendSyncLog(&logs, "output history failed: %v", historyErr)
+		} else if kept != "" {
+			appendSyncLog(&logs, "previous output kept as %s", kept)
+		}
+		content, writeErr := writeGeneratedContent(outputPath, allPatches, meta, cfg.OutputEncoding)
+		if writeErr != nil {
+			return SyncResult{}, withErrorCode(errCodeWrite, writeErr)
+		}
+		stats.recordOutput(content)
+		appendSyncLog(&logs, "written generated patches to %s (%d bytes, sha256 %s)", outputPath, stats.BytesWritten, stats.OutputSHA256)
+	}
+
+	if !cfg.DryRun && strings.TrimSpace(apiDir) != "" {
+		apiMeta := meta
+		if len(patches) == 0 {
+			if existingMeta, metaErr := readGeneratedMeta(outputPath); metaErr == nil && existingMeta.GameID != "" {
+				apiMeta = existingMeta
+			}
+		}
+		if apiErr := writeStaticAPI(apiDir, allPatches, apiMeta); apiErr != nil {
+			appendSyncLog(&logs, "static api export failed: %v", apiErr)
+		} else {
+			appendSyncLog(&logs, "static api exported to %s", apiDir)
+		}
+	}
+
+	commit := ""
+	if worktree != nil && !cfg.DryRun && stats.BytesWritten > 0 {
+		paths := []string{outputPath}
+		if apiInWorktree {
+			paths = append(paths, apiDir)
+		}
+		committed, commitErr := worktree.commit(syncCommitTitle(cfg.GameID, patches), paths)
+		if commitErr != nil {
+			return SyncResult{}, withErrorCode(errCodeGit, commitErr)
+		}
+		if commit = committed; commit != "" {
+			appendSyncLog(&logs, "committed %s on branch %s", commit, branchName)
+		}
+	}
+
+	if !cfg.DryRun && len(changeEntries) > 0 {
+		record := syncChangeLogRecord{
+			Timestamp:      clock.Now().UTC().Format(time.RFC3339),
+			GameID:         cfg.GameID,
+			SpreadsheetID:  cfg.SpreadsheetID,
+			OutputPath:     cfg.OutputPath,
+			GeneratedAt:    generatedAt,
+			UpdatedPatches: changeEntries,
+		}
+		segment, logErr := appendChangeLogRecordWithRetention(changeLogPath, cfg.ChangeLogLimits, record, clock.Now())
+		if segment != "" {
+			appendSyncLog(&logs, "rotated change log to %s", segment)
+		}
+		if logErr != nil {
+			appendSyncLog(&logs, "change log write failed: %v", logErr)
+		} else {
+			appendSyncLog(&logs, "change log updated: %s", changeLogPath)
+		}
+		if strings.TrimSpace(cfg.HistoryDB) != "" {
+			if mirrorErr := mirrorChangeLogRecord(cfg.HistoryDB, record); mirrorErr != nil {
+				appendSyncLog(&logs, "history database write failed: %v", mirrorErr)
+			}
+		}
+	}
+
+	if !cfg.DryRun {
+		if hashWriteErr := writeSheetHashes(sheetHashPath, cfg.GameID, nextHashes); hashWriteErr != nil {
+			appendSyncLog(&logs, "sheet hash write failed: %v", hashWriteErr)
+		}
+		if nextStructure != nil {
+			if structWriteErr := writeSheetStructure(structurePath, cfg.GameID, *nextStructure); structWriteErr != nil {
+				appendSyncLog(&logs, "sheet structure write failed: %v", structWriteErr)
+			}
+		}
+	}
+	doneWrite()
+	for _, total := range summarizePhaseTimings(profiler.timings) {
+		appendSyncLog(&logs, "profile: %s took %.1fms", total.Phase, total.DurationMS)
+	}
+
+	progress.phase(progressDone, 100)
+	appendSyncLog(&logs, "sync completed: game=%s changed=%d skipped=%d dryRun=%t", cfg.GameID, len(patches), len(skippedPatches), cfg.DryRun)
+	return SyncResult{
+		GameID:         cfg.GameID,
+		Patches:        patches,
+		AllPatches:     allPatches,
+		SkippedPatches: skippedPatches,
+		FrozenPatches:  frozenDiffs,
+		SheetNames:     parsedSheetNames,
+		BaseOverlaps:   overlaps,
+		OutOfBounds:    outOfBounds,
+		Discrepancies:  discrepancies,
+		OutputPath:     cfg.OutputPath,
+		BranchName:     branchName,
+		Commit:         commit,
+		Logs:           logs.lines,
+		SheetFailures:  sheetFailures,
+		SheetStats:     sheetStats,
+		ChangeCount:    len(changeEntries),
+		Stats:          stats,
+		ChangeLogPath:  changeLogPath,
+		GeneratedAt:    generatedAt,
+		Meta:           meta,
+		Profile:        profiler.timings,
+		Progress:       progress.events,
+		Preview:        preview,
+	}, nil
+}
+
+func latestSheetNames(sortedNames []string, limit int) []string {
+	if limit <= 0 || limit >= len(sortedNames) {
+		return sortedNames
+	}
+	return sortedNames[len(sortedNames)-limit:]
+}
+
+func parseAllowedOrigins(raw string) map[string]struct{} {
+	values := uniqueStrings(strings.Split(raw, ","))
+	allowed := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		allowed[strings.TrimSpace(value)] = struct{}{}
+	}
+	return allowed
+}
+
+func isLoopbackOrigin(origin string) bool {
+	if strings.EqualFold(strings.TrimSpace(origin), "null") {
+		return true
+	}
+	parsed, err := url.Parse(strings.TrimSpace(origin))
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(strings.TrimSpace(parsed.Hostname()))
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+func isOriginAllowed(origin string, allowed map[string]struct{}) bool {
+	if origin == "" {
+		return true
+	}
+	if _, ok := allowed["*"]; ok {
+		return true
+	}
+	if _, ok := allowed[origin]; ok {
+		return true
+	}
+	if isLoopbackOrigin(origin) {
+		return true
+	}
+	return false
+}
+
+func withCORS(w http.ResponseWriter, r *http.Request, allowed map[string]struct{}) bool {
+	origin := strings.TrimSpace(r.Header.Get("Origin"))
+	if origin != "" {
+		if !isOriginAllowed(origin, allowed) {
+			return false
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Vary", "Origin")
+	}
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Patchsync-Token")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	return true
+}
+
+func parseSyncRequestBody(r *http.Request, target any, limit int64) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(body)) > limit {
+		return bodyTooLargeError{limit: limit}
+	}
+	trimmed := strings.TrimSpace(string(body))
+	if trimmed == "" {
+		return nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return err
+	}
+	return nil
+}
+
+func patchNamesFromPatches(patches []Patch) []string {
+	patchNames := make([]string, 0, len(patches))
+	for _, patch := range patches {
+		patchNames = append(patchNames, patch.Patch)
+	}
+	return patchNames
+}
+
+// applySyncRequest applies the fields of a /sync request to the serve defaults.
+func applySyncRequest(cfg SyncConfig, req syncRequest) SyncConfig {
+	if strings.TrimSpace(req.GameID) != "" {
+		cfg.GameID = strings.TrimSpace(req.GameID)
+	}
+	if strings.TrimSpace(req.SpreadsheetID) != "" {
+		cfg.SpreadsheetID = strings.TrimSpace(req.SpreadsheetID)
+	}
+	if strings.TrimSpace(req.BranchPrefix) != "" {
+		cfg.BranchPrefix = strings.TrimSpace(req.BranchPrefix)
+	}
+	cfg.SheetNames = uniqueSheetNames(req.SheetNames)
+	cfg.ForcePatches = uniqueStrings(req.Force)
+	if req.Latest > 0 {
+		cfg.LatestPatches = req.Latest
+	}
+	cfg.CreateBranch = req.CreateBranch
+	cfg.DryRun = req.DryRun
+	cfg.Preview = req.Preview
+	cfg.ContinueOnError = cfg.ContinueOnError || req.ContinueOnError
+	cfg.Rediscover = cfg.Rediscover || req.RefreshDiscovery
+	return cfg
+}
+
+func applySyncAllRequest(cfg SyncConfig, req syncAllRequest) SyncConfig {
+	cfg.SheetNames = nil
+	cfg.CreateBranch = false
+	cfg.BranchPrefix = ""
+	cfg.DryRun = req.DryRun
+	cfg.Rediscover = cfg.Rediscover || req.RefreshDiscovery
+	if req.Latest > 0 {
+		cfg.LatestPatches = req.Latest
+	}
+	return cfg
+}
+
+// syncErrorResponse reports a failed sync, with whatever a cancelled sync got through.
+func syncErrorResponse(result SyncResult, err error) syncResponse {
+	return syncResponse{
+		OK:           false,
+		Message:      err.Error(),
+		Code:         errorCode(err),
+		Sheets:       result.SheetNames,
+		Failures:     result.SheetFailures,
+		Pending:      result.PendingSheets,
+		BaseOverlaps: result.BaseOverlaps,
+		Logs:         result.Logs,
+	}
+}
+
+func buildSyncAllResponse(results []syncGameResult, allOK bool) syncResponse {
+	summary := summarizeSyncAll(results)
+	message := "sync completed for all games"
+	code := ""
+	if !allOK {
+		message = "sync completed with errors: " + summary.describe()
+		code = errCodePartialFailure
+	}
+	return syncResponse{
+		OK:      allOK,
+		Message: message,
+		Code:    code,
+		Results: results,
+		Summary: summary,
+	}
+}
+
+func buildSyncResponseFromResult(result SyncResult) syncResponse {
+	message := "sync completed"
+	if len(result.SheetFailures) > 0 {
+		message = fmt.Sprintf("sync completed with %d sheet failure(s)", len(result.SheetFailures))
+	}
+	if len(result.FrozenPatches) > 0 {
+		message += fmt.Sprintf("; refused changes to %d historical patch(es)", len(result.FrozenPatches))
+	}
+	if len(result.OutOfBounds) > 0 {
+		message += fmt.Sprintf("; %d value(s) outside currency bounds", len(result.OutOfBounds))
+	}
+	if len(result.Discrepancies) > 0 {
+		message += fmt.Sprintf("; %d source(s) disagree with the pull sheet", len(result.Discrepancies))
+	}
+	return syncResponse{
+		OK:            true,
+		Message:       message,
+		GameID:        result.GameID,
+		Sheets:        result.SheetNames,
+		Patches:       patchNamesFromPatches(result.Patches),
+		Skipped:       result.SkippedPatches,
+		Frozen:        result.FrozenPatches,
+		Failures:      result.SheetFailures,
+		BaseOverlaps:  result.BaseOverlaps,
+		OutOfBounds:   result.OutOfBounds,
+		Discrepancies: result.Discrepancies,
+		SheetStats:    result.SheetStats,
+		Profile:       result.Profile,
+		Progress:      result.Progress,
+		Preview:       result.Preview,
+		OutputPath:    result.OutputPath,
+		Branch:        result.BranchName,
+		Commit:        result.Commit,
+		MergeRequest:  result.MergeRequest,
+		Logs:          result.Logs,
+		ChangeCount:   result.ChangeCount,
+		Stats:         &result.Stats,
+		ChangeLogPath: result.ChangeLogPath,
+		GeneratedAt:   result.GeneratedAt,
+	}
+}
+
+// classify records the error class of err; errors without a class are neither retriable nor permanent.
+func (r *syncGameResult) classify(err error) {
+	if class := errorClass(err); class != nil {
+		r.ErrorClass = class.Name
+		r.Retriable = class.Retriable
+		r.Permanent = !class.Retriable
+	}
+}
+
+// runLimited calls fn for every index below count, with at most limit calls running at once (0 means no limit).
+func runLimited(count, limit int, fn func(idx int)) {
+	if limit <= 0 || limit > count {
+		limit = count
+	}
+	slots := make(chan struct{}, max(limit, 1))
+	var wg sync.WaitGroup
+	for idx := range count {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			fn(idx)
+		}()
+	}
+	wg.Wait()
+}
+
+func runSyncAll(ctx context.Context, baseCfg SyncConfig) ([]syncGameResult, bool) {
+	gameIDs := AvailableGameIDs()
+	results := make([]syncGameResult, len(gameIDs))
+	baseCfg.breaker = newSpreadsheetBreaker(baseCfg.BreakerLimit)
+
+	runLimited(len(gameIDs), baseCfg.SyncAllParallel, func(idx int) {
+		results[idx] = syncAllGame(ctx, baseCfg, gameIDs[idx], 1)
+	})
+	retrySyncAllFailures(ctx, baseCfg, results)
+
+	allOK := true
+	for _, r := range results {
+		if r.Error != "" {
+			allOK = false
+			break
+		}
+	}
+	return results, allOK
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, payload syncResponse) {
+	if payload.RequestID == "" {
+		payload.RequestID = w.Header().Get(requestIDHeader)
+	}
+	if !payload.OK && payload.Code == "" {
+		payload.Code = errorCodeForStatus(statusCode)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+// Main runs the patchsync command line: a one-off sync, --serve, or one of the compare, backfill, compact-changes, export-api, golden, history, mock-serve, and service subcommands.
+func Main() {
+	args, envFiles, err := extractEnvFileArgs(os.Args[1:])
+	if err == nil {
+		os.Args = append(os.Args[:1], args...)
+		err = loadDotEnv(envFiles)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "compare":
+			os.Exit(runCompareCommand(os.Args[2:]))
+		case "backfill":
+			os.Exit(runBackfillCommand(os.Args[2:]))
+		case "export-api":
+			os.Exit(runExportAPICommand(os.Args[2:]))
+		case "compact-changes":
+			os.Exit(runCompactChangesCommand(os.Args[2:]))
+		case "history":
+			os.Exit(runHistoryCommand(os.Args[2:]))
+		case "show":
+			os.Exit(runShowCommand(os.Args[2:]))
+		case "golden":
+			os.Exit(runGoldenCommand(os.Args[2:]))
+		case "mock-serve":
+			os.Exit(runMockServeCommand(os.Args[2:]))
+		case "service":
+			if len(os.Args) < 3 || os.Args[2] != "run" {
+				os.Exit(runServiceCommand(os.Args[2:]))
+			}
+			serveArgs, err := serviceRunArgs(os.Args[3:])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			os.Args = append([]string{os.Args[0]}, serveArgs...)
+			if err := loadDotEnv(envFiles); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+	}
+	var (
+		serveMode         bool
+		enableGraphQL     bool
+		gameID            string
+		bindAddr          string
+		allowedOriginsRaw string
+		authToken         string
+		scopedTokensRaw   string
+		tokenFile         string
+		auditLogPath      string
+		accessLog         bool
+		privateReads      bool
+		spreadsheetID     string
+		sheetNamesRaw     string
+		excludeSheetsRaw  string
+		sheetGIDsRaw      string
+		outputPath        string
+		overridesDir      string
+		translationsDir   string
+		serverRegionsRaw  string
+		patchStart        string
+		createBranch      bool
+		branchPrefix      string
+		repoRoot          string
+		pidFile           string
+		skipExisting      bool
+		forceRaw          string
+		allowHistorical   bool
+		latestPatches     int
+		dryRun            bool
+		preview           string
+		apiDir            string
+		cumulativeFrom    string
+		forecastPatches   int
+		forecastWindow    int
+		draftWIP          bool
+		excludeWIP        bool
+		ledgerPath        string
+		clientTimeout     time.Duration
+		syncTimeout       time.Duration
+		drainTimeout      time.Duration
+		maxBodyBytes      int64
+		tlsCert           string
+		tlsKey            string
+		tlsSelfSigned     bool
+		syncRate          float64
+		syncBurst         int
+		syncAllParallel   int
+		syncAllRetries    int
+		continueOnError   bool
+		sheetSource       string
+		sheetsAPIKey      string
+		localSheetsDir    string
+		reproducible      bool
+		profileSync       bool
+		changeLogMaxBytes int64
+		changeLogMaxAge   time.Duration
+		changeLogKeep     int
+		historyDB         string
+		historyDir        string
+		historyKeep       int
+		newline           string
+		parseStrictness   string
+		decimalSeparator  string
+		baseOverlap       string
+		writeBOM          bool
+		discoveryTTL      time.Duration
+		probeMaxMinor     int
+		probeGap          int
+		probeBatch        int
+		breakerThreshold  int
+		refreshDiscovery  bool
+		enablePprof       bool
+		googleBaseURL     string
+		recordFixtures    string
+		replayFixtures    string
+		outbound          OutboundOptions
+		notify            NotifyOptions
+		gitLab            GitLabOptions
+	)
+
+	flag.BoolVar(&serveMode, "serve", false, "Run as local HTTP service for the UI button")
+	flag.BoolVar(&enableGraphQL, "graphql", false, "Expose a read-only /graphql endpoint in serve mode")
+	flag.String("env-file", "", "Extra env file applied over .env and .env.local (repeatable or comma-separated); read before other flags")
+	flag.StringVar(&gameID, "game", defaultGameID, fmt.Sprintf("Game id (%s)", strings.Join(AvailableGameIDs(), ", ")))
+	flag.StringVar(&bindAddr, "addr", defaultBindAddr, "HTTP bind address in serve mode (host:port or unix:///path/to.sock)")
+	flag.StringVar(&allowedOriginsRaw, "allowed-origins", "http://127.0.0.1:5173,http://localhost:5173", "Comma-separated allowed CORS origins in serve mode")
+	flag.StringVar(&authToken, "auth-token", os.Getenv("PATCHSYNC_TOKEN"), "Optional auth token required in X-Patchsync-Token header for /sync")
+	flag.StringVar(&scopedTokensRaw, "tokens", os.Getenv("PATCHSYNC_TOKENS"), "Comma-separated scope:token entries (scopes: read, sync), for example read:abc,sync:def")
+	flag.StringVar(&tokenFile, "token-file", os.Getenv("PATCHSYNC_TOKEN_FILE"), "File with tokens (one per line, optional read:/sync: prefix); reloaded on SIGHUP or when it changes")
+	flag.StringVar(&auditLogPath, "audit-log", defaultAuditLogPath, "Append-only JSONL log of sync and ledger requests (empty disables it)")
+	flag.BoolVar(&accessLog, "access-log", true, "Print one JSON access-log line per HTTP request in serve mode")
+	flag.BoolVar(&privateReads, "private-reads", false, "Require a read or sync token for every endpoint except health checks")
+	flag.StringVar(&spreadsheetID, "spreadsheet-id", "", "Google Spreadsheet ID or full spreadsheet URL (comma-separated list merges spreadsheets in priority order)")
+	flag.StringVar(&sheetSource, "source", sheetSourceGoogle, "Where sheets are read from: google (gviz or published HTML), sheets-api, or local")
+	flag.StringVar(&sheetsAPIKey, "sheets-api-key", os.Getenv("PATCHSYNC_SHEETS_API_KEY"), "Google Sheets API key for --source sheets-api")
+	flag.StringVar(&localSheetsDir, "local-sheets-dir", "", "Directory with <spreadsheet id>/<sheet>.csv files for --source local")
+	flag.StringVar(&googleBaseURL, "google-base-url", "", "Send Google Sheets requests to this base URL instead, for example a patchsync mock-serve instance")
+	flag.StringVar(&recordFixtures, "record-fixtures", "", "Save every HTTP response of the sync (discovery pages, CSVs) to this directory")
+	registerOutboundFlags(flag.CommandLine, &outbound)
+	registerNotifyFlags(flag.CommandLine, &notify)
+	registerGitLabFlags(flag.CommandLine, &gitLab)
+	flag.StringVar(&replayFixtures, "replay-fixtures", "", "Serve every HTTP request of the sync from fixtures saved by --record-fixtures, without network access")
+	flag.StringVar(&sheetNamesRaw, "sheet-names", "", "Comma-separated sheet names (optional, if empty auto-detects N.N sheet names)")
+	flag.StringVar(&excludeSheetsRaw, "exclude-sheets", "", "Comma-separated tab names to leave out of auto-detection (the overrides file can also set sheets.include/exclude patterns)")
+	flag.StringVar(&sheetGIDsRaw, "sheet-gids", "", "Comma-separated name=gid pairs; those tabs are fetched by gid instead of by name")
+	flag.StringVar(&outputPath, "output", "", "Output JS file path (optional; defaults by game)")
+	flag.StringVar(&overridesDir, "overrides-dir", defaultOverridesDir, "Directory with <game>.json manual patch/source overrides")
+	flag.StringVar(&translationsDir, "translations-dir", defaultTranslationsDir, "Directory with <game>.json source label translations per locale")
+	flag.StringVar(&serverRegionsRaw, "server-regions", "", "Server regions with their UTC offsets, e.g. asia=+8,eu=+1,na=-5 (default: per game)")
+	flag.StringVar(&patchStart, "patch-start", "", "Time patches go live: HH:MM in each region's server time, or HH:MM+08:00 for one instant everywhere (default: per game)")
+	flag.BoolVar(&createBranch, "create-branch", false, "Write and commit the generated file on a new git branch, in a temporary worktree")
+	flag.StringVar(&branchPrefix, "branch-prefix", "data/sheets", "Git branch prefix for create-branch")
+	flag.StringVar(&repoRoot, "repo-root", "", "Git repository create-branch works in (default: the one containing the working directory)")
+	flag.BoolVar(&skipExisting, "skip-existing", true, "Skip patches already present in src/data/patches.js and generated output")
+	flag.StringVar(&forceRaw, "force", "", "Comma-separated patch ids to re-parse and overwrite even when unchanged")
+	flag.BoolVar(&allowHistorical, "allow-historical-edits", false, "Apply sheet changes to patches older than the live one instead of only reporting them")
+	flag.IntVar(&latestPatches, "latest", 0, "Sync only the N highest patch versions (0 syncs all)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Parse and validate only, do not write file")
+	flag.StringVar(&preview, "preview", "", "With --dry-run, print the file that would be written (content) or a diff against the current one (diff)")
+	flag.StringVar(&baseOverlap, "base-overlap", baseOverlapPreferGenerated, "Patch ids defined in both src/data/patches.js and the generated output: prefer-generated (report them), prefer-base (leave them out of the output), or error")
+	flag.StringVar(&parseStrictness, "parse-strictness", "", "How sheets with missing aggregate rows are parsed: strict, normal, or lenient (default: the overrides file, else normal)")
+	flag.StringVar(&decimalSeparator, "decimal-separator", "", "Decimal separator of numeric cells: dot, comma, or auto (default: the overrides file, else detected from the Data sheet)")
+	flag.BoolVar(&profileSync, "profile", false, "Report time spent in discovery, each sheet fetch and parse, override application, and file writes")
+	flag.BoolVar(&enablePprof, "pprof", false, "Expose net/http/pprof under /debug/pprof/ in serve mode (requires a sync token when tokens are set)")
+	flag.BoolVar(&reproducible, "reproducible", false, "Pin generatedAt, log, and branch timestamps to SOURCE_DATE_EPOCH (or the HEAD commit time)")
+	flag.BoolVar(&continueOnError, "continue-on-error", false, "With --sheet-names, skip sheets that fail to fetch or parse instead of aborting the sync")
+	flag.StringVar(&apiDir, "api-dir", "", fmt.Sprintf("Also write a static JSON API tree after each sync (for example %s)", defaultAPIDir))
+	flag.StringVar(&cumulativeFrom, "cumulative-from", "", "First patch counted in the GENERATED_CUMULATIVE running totals (defaults to the oldest patch)")
+	flag.IntVar(&forecastPatches, "forecast", 0, "Append N forecast patches averaged from the latest patches (tagged \"forecast\")")
+	flag.IntVar(&forecastWindow, "forecast-window", defaultForecastWindow, "Number of trailing patches averaged by --forecast")
+	flag.BoolVar(&draftWIP, "draft-wip", false, "Write WIP-tagged patches to GENERATED_DRAFT_PATCHES instead of GENERATED_PATCHES")
+	flag.BoolVar(&excludeWIP, "exclude-wip", false, "Leave WIP-tagged patches out of the generated output")
+	flag.StringVar(&ledgerPath, "ledger", defaultLedgerPath, "JSON file storing owned currency balances for the /ledger endpoints")
+	flag.DurationVar(&discoveryTTL, "discovery-ttl", defaultDiscoveryTTL, "Reuse discovered sheet names for this long (0 discovers on every sync)")
+	flag.BoolVar(&refreshDiscovery, "refresh-discovery", false, "Ignore cached sheet names and discover them again")
+	flag.IntVar(&probeMaxMinor, "probe-max-minor", defaultProbeMaxMinor, "Highest minor version probed per major when tabs can only be found by probing")
+	flag.IntVar(&probeGap, "probe-gap", defaultProbeGap, "Consecutive missing minor versions tolerated while probing (for example a skipped 2.3)")
+	flag.IntVar(&probeBatch, "probe-batch", defaultProbeBatch, "Minor versions probed in parallel per batch")
+	flag.IntVar(&breakerThreshold, "breaker-threshold", defaultBreakerThreshold, "Stop requesting a spreadsheet for the rest of a sync or /sync-all run after this many HTTP 429/403 answers in a row (0 disables)")
+	flag.Int64Var(&changeLogMaxBytes, "change-log-max-bytes", defaultChangeLogMaxBytes, "Rotate the change log into a gzipped segment once it reaches this size (0 disables)")
+	flag.DurationVar(&changeLogMaxAge, "change-log-max-age", defaultChangeLogMaxAge, "Rotate the change log once its oldest record is this old (0 disables)")
+	flag.IntVar(&changeLogKeep, "change-log-keep", defaultChangeLogKeep, "Rotated change log segments to keep (0 keeps all)")
+	flag.StringVar(&historyDB, "history-db", "", "SQLite file that mirrors every change log record for `history --db` (empty disables it)")
+	flag.StringVar(&historyDir, "history-dir", defaultOutputHistoryDir, "Directory that keeps replaced generated files as <game>/<generatedAt>.js (empty disables it)")
+	flag.IntVar(&historyKeep, "history-keep", defaultOutputHistoryKeep, "Replaced generated files to keep per game (0 keeps all)")
+	flag.StringVar(&newline, "newline", newlineLF, "Line endings of the generated file: lf or crlf")
+	flag.BoolVar(&writeBOM, "bom", false, "Start the generated file with a UTF-8 byte order mark")
+	flag.DurationVar(&clientTimeout, "timeout", 20*time.Second, "HTTP client timeout")
+	flag.DurationVar(&syncTimeout, "sync-timeout", defaultSyncTimeout, "Overall deadline for one game's sync, checked between sheets (0 disables)")
+	flag.StringVar(&tlsCert, "tls-cert", "", "PEM certificate file; serve mode uses HTTPS when set together with --tls-key")
+	flag.StringVar(&tlsKey, "tls-key", "", "PEM private key file for --tls-cert")
+	flag.BoolVar(&tlsSelfSigned, "tls-self-signed", false, "Serve HTTPS with a freshly generated self-signed certificate (for LAN use)")
+	flag.Float64Var(&syncRate, "sync-rate", defaultSyncRate, "Sync requests per minute allowed per client IP on /sync and /sync-all (0 disables the limit)")
+	flag.IntVar(&syncBurst, "sync-burst", defaultSyncBurst, "Sync requests a client IP may send back to back before --sync-rate applies")
+	flag.IntVar(&syncAllParallel, "sync-all-parallel", defaultSyncAllLimit, "How many games /sync-all syncs at the same time (0 runs all at once)")
+	flag.IntVar(&syncAllRetries, "sync-all-retries", defaultSyncAllRetry, "How many more times /sync-all syncs games that failed with a retriable error (0 disables retries)")
+	flag.Int64Var(&maxBodyBytes, "max-body-bytes", defaultMaxBodyBytes, "Largest accepted JSON request body in serve mode")
+	flag.DurationVar(&drainTimeout, "drain-timeout", defaultDrainTimeout, "How long serve mode waits for in-flight requests on shutdown")
+	flag.StringVar(&pidFile, "pid-file", defaultPIDFile, "PID file written in serve mode (empty disables it)")
+	flag.Parse()
+	if draftWIP && excludeWIP {
+		fmt.Fprintln(os.Stderr, "--draft-wip and --exclude-wip cannot be combined")
+		os.Exit(1)
+	}
+	wipMode := wipModeInline
+	if draftWIP {
+		wipMode = wipModeDraft
+	} else if excludeWIP {
+		wipMode = wipModeExclude
+	}
+	sheetGIDs, err := parseSheetGIDs(sheetGIDsRaw)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	serverRegions, err := parseServerRegions(serverRegionsRaw)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := notify.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := gitLab.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	var clock Clock
+	if reproducible {
+		pinned, err := reproducibleClock()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		clock = pinned
+	}
+
+	defaultCfg := SyncConfig{
+		GameID:           gameID,
+		SpreadsheetID:    spreadsheetID,
+		SheetNames:       uniqueSheetNames(strings.Split(sheetNamesRaw, ",")),
+		ExcludeSheets:    uniqueSheetNames(strings.Split(excludeSheetsRaw, ",")),
+		SheetGIDs:        sheetGIDs,
+		OutputPath:       outputPath,
+		BasePatchesPath:  "src/data/patches.js",
+		OverridesDir:     overridesDir,
+		TranslationsDir:  translationsDir,
+		ServerRegions:    serverRegions,
+		PatchStart:       patchStart,
+		CreateBranch:     createBranch,
+		BranchPrefix:     branchPrefix,
+		RepoRoot:         repoRoot,
+		SkipExisting:     skipExisting,
+		ForcePatches:     uniqueStrings(strings.Split(forceRaw, ",")),
+		AllowHistorical:  allowHistorical,
+		LatestPatches:    latestPatches,
+		APIDir:           apiDir,
+		CumulativeFrom:   cumulativeFrom,
+		ForecastPatches:  forecastPatches,
+		ForecastWindow:   forecastWindow,
+		WIPMode:          wipMode,
+		DryRun:           dryRun,
+		Preview:          preview,
+		ParseStrictness:  parseStrictness,
+		DecimalSeparator: decimalSeparator,
+		BaseOverlap:      baseOverlap,
+		ClientTimeout:    clientTimeout,
+		SyncTimeout:      syncTimeout,
+		Clock:            clock,
+		SheetSource:      sheetSource,
+		SheetsAPIKey:     sheetsAPIKey,
+		LocalSheetsDir:   localSheetsDir,
+		GoogleBaseURL:    googleBaseURL,
+		Outbound:         outbound,
+		Notify:           notify,
+		GitLab:           gitLab,
+		RecordFixtures:   recordFixtures,
+		ReplayFixtures:   replayFixtures,
+		ContinueOnError:  continueOnError,
+		SyncAllParallel:  syncAllParallel,
+		SyncAllRetries:   syncAllRetries,
+		Profile:          profileSync,
+		ChangeLogLimits:  ChangeLogRetention{MaxBytes: changeLogMaxBytes, MaxAge: changeLogMaxAge, Keep: changeLogKeep},
+		HistoryDB:        historyDB,
+		MaxBodyBytes:     maxBodyBytes,
+		OutputHistory:    OutputHistory{Dir: historyDir, Keep: historyKeep},
+		OutputEncoding:   OutputEncoding{Newline: newline, BOM: writeBOM},
+		ProbeLimits:      ProbeOptions{MaxMinor: probeMaxMinor, Gap: probeGap, Batch: probeBatch},
+		BreakerLimit:     breakerThreshold,
+		DiscoveryTTL:     discoveryTTL,
+		Rediscover:       refreshDiscovery,
+	}
+	allowedOrigins := parseAllowedOrigins(allowedOriginsRaw)
+
+	if serveMode {
+		tokens, err := newAuthTokens(authToken, scopedTokensRaw, tokenFile, privateReads)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid tokens: %v\n", err)
+			os.Exit(1)
+		}
+		lock, err := acquireInstanceLock(defaultCfg, pidFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		tokens.watchTokenFile(30 * time.Second)
+		watchConfigReload(envFiles)
+		var audit *auditLogger
+		if strings.TrimSpace(auditLogPath) != "" {
+			audit = &auditLogger{path: resolveOutputPath(auditLogPath)}
+		}
+		mux := http.NewServeMux()
+		syncLimiter := newRateLimiter(syncRate, syncBurst)
+		registerHealthAPI(mux, allowedOrigins, tokens, defaultCfg)
+		mux.HandleFunc("/sync", func(w http.ResponseWriter, r *http.Request) {
+			if !withCORS(w, r, allowedOrigins) {
+				writeJSON(w, http.StatusForbidden, syncResponse{
+					OK:      false,
+					Message: "origin is not allowed",
+				})
+				return
+			}
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if r.Method != http.MethodPost {
+				writeJSON(w, http.StatusMethodNotAllowed, syncResponse{
+					OK:      false,
+					Message: "method not allowed",
+				})
+				return
+			}
+			if !tokens.authorize(r, scopeSync) {
+				writeJSON(w, http.StatusUnauthorized, syncResponse{
+					OK:      false,
+					Message: "unauthorized",
+				})
+				return
+			}
+			if ok, wait := syncLimiter.allow(clientIP(r)); !ok {
+				writeRateLimited(w, wait)
+				return
+			}
+			var req syncRequest
+			if err := parseSyncRequestBody(r, &req, defaultCfg.maxBodyBytes()); err != nil {
+				writeBodyError(w, err)
+				return
+			}
+
+			cfg := applySyncRequest(defaultCfg, req)
+			cfg.RequestID = requestIDFromContext(r.Context())
+
+			result, err := RunSync(r.Context(), cfg)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, syncErrorResponse(result, err))
+				return
+			}
+			writeJSON(w, http.StatusOK, buildSyncResponseFromResult(result))
+		})
+		mux.HandleFunc("/sync-all", func(w http.ResponseWriter, r *http.Request) {
+			if !withCORS(w, r, allowedOrigins) {
+				writeJSON(w, http.StatusForbidden, syncResponse{
+					OK:      false,
+					Message: "origin is not allowed",
+				})
+				return
+			}
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if r.Method != http.MethodPost {
+				writeJSON(w, http.StatusMethodNotAllowed, syncResponse{
+					OK:      false,
+					Message: "method not allowed",
+				})
+				return
+			}
+			if !tokens.authorize(r, scopeSync) {
+				writeJSON(w, http.StatusUnauthorized, syncResponse{
+					OK:      false,
+					Message: "unauthorized",
+				})
+				return
+			}
+			if ok, wait := syncLimiter.allow(clientIP(r)); !ok {
+				writeRateLimited(w, wait)
+				return
+			}
+
+			var req syncAllRequest
+			if err := parseSyncRequestBody(r, &req, defaultCfg.maxBodyBytes()); err != nil {
+				writeBodyError(w, err)
+				return
+			}
+
+			cfg := applySyncAllRequest(defaultCfg, req)
+			cfg.RequestID = requestIDFromContext(r.Context())
+
+			writeJSON(w, http.StatusOK, buildSyncAllResponse(runSyncAll(r.Context(), cfg)))
+		})
+
+		registerReadAPI(mux, allowedOrigins, defaultCfg)
+		registerProjectionAPI(mux, allowedOrigins, defaultCfg)
+		resolvedLedgerPath := resolveOutputPath(ledgerPath)
+		registerPlannerAPI(mux, allowedOrigins, tokens, resolvedLedgerPath, defaultCfg)
+		registerLedgerAPI(mux, allowedOrigins, tokens, resolvedLedgerPath, defaultCfg)
+		registerPatchAPI(mux, allowedOrigins, tokens, defaultCfg)
+		registerOddsAPI(mux, allowedOrigins)
+		registerTopupAPI(mux, allowedOrigins)
+		registerAdminUI(mux, allowedOrigins)
+		socketJobs := newJobGroup()
+		registerSyncSocket(mux, allowedOrigins, tokens, defaultCfg, syncLimiter, audit, socketJobs)
+		if enablePprof {
+			registerPprof(mux, tokens)
+		}
+		if enableGraphQL {
+			registerGraphQL(mux, allowedOrigins, defaultCfg)
+		}
+
+		tlsConfig, err := buildServerTLSConfig(tlsCert, tlsKey, tlsSelfSigned, bindAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tls setup failed: %v\n", err)
+			os.Exit(1)
+		}
+		scheme := "http"
+		if tlsConfig != nil {
+			scheme = "https"
+		}
+		if network, address := listenNetwork(bindAddr); network == "unix" {
+			fmt.Printf("patchsync service listening on %s (%s over unix socket)\n", address, scheme)
+		} else {
+			fmt.Printf("patchsync service listening on %s://%s\n", scheme, bindAddr)
+		}
+		if tokens.empty() {
+			fmt.Println("warning: auth token is empty; set --auth-token or PATCHSYNC_TOKEN for stricter access control")
+		}
+		handler := withAPIVersion(withRequestID(withAuditLog(withReadAuth(mux, tokens, allowedOrigins), audit, defaultCfg.maxBodyBytes()), accessLog))
+		serveErr := runServer(context.Background(), handler, serverOptions{Addr: bindAddr, DrainTimeout: drainTimeout, TLSConfig: tlsConfig, Jobs: socketJobs})
+		lock.release()
+		if serveErr != nil {
+			fmt.Fprintf(os.Stderr, "server failed: %v\n", serveErr)
+			os.Exit(1)
+		}
+		return
+	}
+
+	result, err := RunSync(context.Background(), defaultCfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sync failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Game: %s\n", result.GameID)
+	patchNames := make([]string, 0, len(result.Patches))
+	for _, patch := range result.Patches {
+		patchNames = append(patchNames, patch.Patch)
+	}
+	if len(patchNames) == 0 {
+		fmt.Println("Synced patches: none (all discovered patches are already present)")
+	} else {
+		fmt.Printf("Synced patches: %s\n", strings.Join(patchNames, ", "))
+	}
+	if len(result.SkippedPatches) > 0 {
+		fmt.Printf("Skipped patches: %s\n", strings.Join(result.SkippedPatches, ", "))
+	}
+	if len(result.BaseOverlaps) > 0 {
+		fmt.Printf("Also in base patches: %s (--base-overlap %s)\n", strings.Join(result.BaseOverlaps, ", "), baseOverlap)
+	}
+	for _, failure := range result.SheetFailures {
+		fmt.Printf("Failed sheet %s (%s, %s): %s\n", failure.Sheet, failure.Stage, failure.Code, failure.Error)
+	}
+	for _, frozen := range result.FrozenPatches {
+		fmt.Printf("Refused changes to historical patch %s (%s, %d fields, %d sources); rerun with --allow-historical-edits to apply them\n", frozen.Patch, frozen.Status, len(frozen.Fields), len(frozen.Sources))
+	}
+	fmt.Printf("Output: %s\n", result.OutputPath)
+	if result.Stats.BytesWritten > 0 {
+		fmt.Printf("Written: %d bytes, sha256 %s\n", result.Stats.BytesWritten, result.Stats.OutputSHA256)
+	}
+	fmt.Printf("Changes: %d added, %d updated, %d removed\n", result.Stats.Added, result.Stats.Updated, result.Stats.Removed)
+	if result.BranchName != "" {
+		fmt.Printf("Branch: %s\n", result.BranchName)
+	}
+	if result.Commit != "" {
+		fmt.Printf("Commit: %s\n", result.Commit)
+	}
+	if result.MergeRequest != "" {
+		fmt.Printf("Merge request: %s\n", result.MergeRequest)
+	}
+	if len(result.Profile) > 0 {
+		writeProfileReport(os.Stdout, result.Profile)
+		writeSheetStatsReport(os.Stdout, result.SheetStats)
+	}
+	if result.Preview != "" {
+		fmt.Printf("Preview (%s):\n%s", preview, result.Preview)
+	}
+}
@@ -0,0 +1,72 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunLimitedCapsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	var mu sync.Mutex
+	seen := map[int]bool{}
+	runLimited(6, 2, func(idx int) {
+		current := running.Add(1)
+		for {
+			old := peak.Load()
+			if current <= old || peak.CompareAndSwap(old, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		mu.Lock()
+		seen[idx] = true
+		mu.Unlock()
+	})
+	if len(seen) != 6 {
+		t.Fatalf("ran %d of 6 calls", len(seen))
+	}
+	if peak.Load() != 2 {
+		t.Fatalf("peak concurrency = %d, want 2", peak.Load())
+	}
+}
+
+func TestWriteGeneratedContentRestoresOnReadBackMismatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wuwa.generated.js")
+	meta := GeneratedMeta{GameID: gameIDWuwa, GeneratedAt: "2026-03-08T10:00:00Z", Sheets: []string{}}
+	patches := []Patch{{ID: "3.4", Patch: "3.4", Sources: []Source{{ID: "events", Rewards: Rewards{Oroberyl: 1600}}}}}
+	if _, err := writeGeneratedContent(path, patches, meta, OutputEncoding{}); err != nil {
+		t.Fatalf("writeGeneratedContent() error = %v", err)
+	}
+	previous, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := generatedPatchesBlockPattern
+	t.Cleanup(func() { generatedPatchesBlockPattern = original })
+	// Extraction now picks up the (empty) draft block instead, so the patches are lost on the way back.
+	generatedPatchesBlockPattern = regexp.MustCompile(`export const GENERATED_DRAFT_PATCHES\s*=\s*(\[\]);`)
+	patches = append(patches, Patch{ID: "3.5", Patch: "3.5", Sources: []Source{{ID: "events", Rewards: Rewards{Oroberyl: 800}}}})
+	if _, err := writeGeneratedContent(path, patches, meta, OutputEncoding{}); err == nil || !strings.Contains(err.Error(), "previous file restored") {
+		t.Fatalf("write with a lossy read-back error = %v, want the previous file restored", err)
+	}
+	if body, _ := os.ReadFile(path); string(body) != string(previous) {
+		t.Fatal("generated file was not restored after the failed read-back")
+	}
+
+	fresh := filepath.Join(dir, "new.generated.js")
+	if _, err := writeGeneratedContent(fresh, patches, meta, OutputEncoding{}); err == nil {
+		t.Fatal("expected the read-back check to fail for a new file")
+	}
+	if _, statErr := os.Stat(fresh); !os.IsNotExist(statErr) {
+		t.Fatalf("failed first write left %s behind", fresh)
+	}
+}
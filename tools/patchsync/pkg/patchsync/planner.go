@@ -0,0 +1,212 @@
+package patchsync
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type planRequest struct {
+	GameID      string             `json:"gameId"`
+	StartDate   string             `json:"startDate"`
+	TargetDate  string             `json:"targetDate"`
+	TargetPatch string             `json:"targetPatch"`
+	Owned       map[string]float64 `json:"owned"`
+	Pity        int                `json:"pity"`
+	PityTarget  int                `json:"pityTarget"`
+	UseLedger   bool               `json:"useLedger"`
+	projectionOptions
+}
+
+type savingsPlan struct {
+	GameID         string  `json:"gameId"`
+	StartDate      string  `json:"startDate"`
+	TargetDate     string  `json:"targetDate"`
+	TargetPatch    string  `json:"targetPatch,omitempty"`
+	OwnedPulls     float64 `json:"ownedPulls"`
+	ProjectedPulls float64 `json:"projectedPulls"`
+	AvailablePulls float64 `json:"availablePulls"`
+	Pity           int     `json:"pity"`
+	PityTarget     int     `json:"pityTarget,omitempty"`
+	PullsNeeded    int     `json:"pullsNeeded,omitempty"`
+	Shortfall      float64 `json:"shortfall"`
+	CoveredDays    int     `json:"coveredDays"`
+	Days           int     `json:"days"`
+}
+
+func ownedRewardsFromMap(owned map[string]float64) (Rewards, error) {
+	rewards := Rewards{}
+	keys := make([]string, 0, len(owned))
+	for key := range owned {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		field := rewards.mappedField(key)
+		if field == nil {
+			return Rewards{}, fmt.Errorf("unknown currency %q in owned", key)
+		}
+		*field += owned[key]
+	}
+	return rewards, nil
+}
+
+func (c pullConversion) ownedPulls(r Rewards) float64 {
+	withPremium := r
+	withPremium.Oroberyl += r.Origeometry * c.PremiumToBase
+	return c.pullsFromRewards(withPremium)
+}
+
+func targetDateForPatch(patches []Patch, rawPatchID string) (time.Time, error) {
+	patchID := canonicalPatchID(rawPatchID)
+	for _, patch := range patches {
+		if patchIDOrFallback(patch) != patchID {
+			continue
+		}
+		startDate, err := time.Parse(isoDateLayout, strings.TrimSpace(patch.StartDate))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("patch %s has no start date", patchID)
+		}
+		return startDate, nil
+	}
+	return time.Time{}, fmt.Errorf("patch %s not found", patchID)
+}
+
+func planSavings(gameID string, patches []Patch, req planRequest, start, target time.Time) (savingsPlan, error) {
+	owned, err := ownedRewardsFromMap(req.Owned)
+	if err != nil {
+		return savingsPlan{}, err
+	}
+	if req.Pity < 0 || req.PityTarget < 0 {
+		return savingsPlan{}, errors.New("pity and pityTarget must not be negative")
+	}
+	plan := savingsPlan{
+		GameID:      gameID,
+		StartDate:   start.Format(isoDateLayout),
+		TargetDate:  target.Format(isoDateLayout),
+		TargetPatch: canonicalPatchID(req.TargetPatch),
+		OwnedPulls:  roundToTenth(pullConversionForGame(gameID).ownedPulls(owned)),
+		Pity:        req.Pity,
+		PityTarget:  req.PityTarget,
+	}
+	// Income on the target day itself usually lands after the banner opens, so it is not counted.
+	if target.After(start) {
+		projection, err := projectIncome(gameID, patches, start, target.AddDate(0, 0, -1), req.projectionOptions)
+		if err != nil {
+			return savingsPlan{}, err
+		}
+		plan.ProjectedPulls = projection.Pulls
+		plan.CoveredDays = projection.CoveredDays
+		plan.Days = projection.Days
+	}
+	plan.AvailablePulls = roundToTenth(plan.OwnedPulls + plan.ProjectedPulls)
+	if req.PityTarget > 0 {
+		plan.PullsNeeded = max(0, req.PityTarget-req.Pity)
+		plan.Shortfall = roundToTenth(math.Max(0, float64(plan.PullsNeeded)-math.Floor(plan.AvailablePulls)))
+	}
+	return plan, nil
+}
+
+func runPlan(cfg SyncConfig, req planRequest, now time.Time) (savingsPlan, error) {
+	profile, err := ResolveGameProfile(req.GameID)
+	if err != nil {
+		return savingsPlan{}, err
+	}
+	patches, err := readGeneratedPatches(resolveFilePath(configuredOutputPath(cfg, profile)))
+	if err != nil {
+		return savingsPlan{}, fmt.Errorf("read generated patches: %w", err)
+	}
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if strings.TrimSpace(req.StartDate) != "" {
+		if start, err = time.Parse(isoDateLayout, strings.TrimSpace(req.StartDate)); err != nil {
+			return savingsPlan{}, fmt.Errorf("startDate must be YYYY-MM-DD: %w", err)
+		}
+	}
+	var target time.Time
+	switch {
+	case strings.TrimSpace(req.TargetDate) != "":
+		if target, err = time.Parse(isoDateLayout, strings.TrimSpace(req.TargetDate)); err != nil {
+			return savingsPlan{}, fmt.Errorf("targetDate must be YYYY-MM-DD: %w", err)
+		}
+	case strings.TrimSpace(req.TargetPatch) != "":
+		if target, err = targetDateForPatch(patches, req.TargetPatch); err != nil {
+			return savingsPlan{}, err
+		}
+	default:
+		return savingsPlan{}, errors.New("targetDate or targetPatch is required")
+	}
+	return planSavings(profile.ID, patches, req, start, target)
+}
+
+func registerPlannerAPI(mux *http.ServeMux, allowedOrigins map[string]struct{}, tokens *authTokens, ledgerPath string, cfg SyncConfig) {
+	clock := clockOrSystem(cfg.Clock)
+	mux.HandleFunc("/plan", func(w http.ResponseWriter, r *http.Request) {
+		if !withCORS(w, r, allowedOrigins) {
+			writeJSON(w, http.StatusForbidden, syncResponse{
+				OK:      false,
+				Message: "origin is not allowed",
+			})
+			return
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, syncResponse{
+				OK:      false,
+				Message: "method not allowed",
+			})
+			return
+		}
+		var req planRequest
+		if err := parseSyncRequestBody(r, &req, cfg.maxBodyBytes()); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		if req.UseLedger {
+			if !tokens.authorize(r, scopeRead) {
+				writeJSON(w, http.StatusUnauthorized, syncResponse{
+					OK:      false,
+					Message: "unauthorized",
+				})
+				return
+			}
+			profile, err := ResolveGameProfile(req.GameID)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, syncResponse{
+					OK:      false,
+					Message: err.Error(),
+				})
+				return
+			}
+			entry, err := readLedgerEntry(ledgerPath, profile.ID)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, syncResponse{
+					OK:      false,
+					Message: err.Error(),
+				})
+				return
+			}
+			applyLedgerToPlan(&req, entry)
+		}
+		plan, err := runPlan(cfg, req, clock.Now().UTC())
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, syncResponse{
+				OK:      false,
+				Message: err.Error(),
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, syncResponse{
+			OK:      true,
+			Message: "plan completed",
+			GameID:  plan.GameID,
+			Plan:    &plan,
+		})
+	})
+}
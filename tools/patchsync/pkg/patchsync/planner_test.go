@@ -0,0 +1,41 @@
+package patchsync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlanSavings(t *testing.T) {
+	patches := []Patch{
+		{
+			ID: "2.0", Patch: "2.0", StartDate: "2025-01-02", DurationDays: 40,
+			Sources: []Source{source("events", "Version Events", "always", nil, true, Rewards{Oroberyl: 8000, Chartered: 10})},
+		},
+		{ID: "2.1", Patch: "2.1", StartDate: "2025-02-11", DurationDays: 40, Sources: []Source{}},
+	}
+	target, err := targetDateForPatch(patches, "2.1")
+	if err != nil {
+		t.Fatalf("targetDateForPatch() error = %v", err)
+	}
+	start := time.Date(2025, 1, 22, 0, 0, 0, 0, time.UTC)
+	plan, err := planSavings(gameIDWuwa, patches, planRequest{
+		TargetPatch: "2.1",
+		Owned:       map[string]float64{"astrite": 3200, "lunite": 320, "radiantTide": 4},
+		Pity:        30,
+		PityTarget:  160,
+	}, start, target)
+	if err != nil {
+		t.Fatalf("planSavings() error = %v", err)
+	}
+	// Owned: (3200 + 320) / 160 + 4 = 26; projected: 20 of 40 days of 60 pulls = 30.
+	if plan.OwnedPulls != 26 || plan.ProjectedPulls != 30 || plan.AvailablePulls != 56 {
+		t.Fatalf("owned=%v projected=%v available=%v, want 26/30/56", plan.OwnedPulls, plan.ProjectedPulls, plan.AvailablePulls)
+	}
+	if plan.PullsNeeded != 130 || plan.Shortfall != 74 {
+		t.Fatalf("needed=%d shortfall=%v, want 130/74", plan.PullsNeeded, plan.Shortfall)
+	}
+
+	if _, err := planSavings(gameIDWuwa, patches, planRequest{Owned: map[string]float64{"gold": 1}}, start, target); err == nil {
+		t.Fatalf("expected unknown currency error")
+	}
+}
@@ -0,0 +1,43 @@
+package patchsync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Dry runs can return the generated file they would write (previewContent) or a unified diff of it against the
+// current output (previewDiff).
+const (
+	previewContent = "content"
+	previewDiff    = "diff"
+)
+
+func parsePreviewMode(raw string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(raw)); mode {
+	case "", previewContent, previewDiff:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid preview %q (use %s or %s)", raw, previewContent, previewDiff)
+	}
+}
+
+// renderSyncPreview renders what a dry run would write to outputPath, or its diff against the file there now.
+func renderSyncPreview(mode, outputPath string, patches []Patch, meta GeneratedMeta, encoding OutputEncoding) (string, error) {
+	content, err := renderGeneratedFile(patches, meta)
+	if err != nil {
+		return "", err
+	}
+	content = encoding.apply(content)
+	if mode == previewContent {
+		return content, nil
+	}
+	current, err := os.ReadFile(outputPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("read current output: %w", err)
+	}
+	var diff strings.Builder
+	writeLineDiff(&diff, outputPath, outputPath+" (dry run)", string(current), content)
+	return diff.String(), nil
+}
@@ -0,0 +1,57 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDryRunPreview(t *testing.T) {
+	dir := t.TempDir()
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		DryRun:          true,
+		Preview:         previewContent,
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+	}
+
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	if !strings.Contains(result.Preview, "export const GENERATED_PATCHES") || !strings.Contains(result.Preview, `"3.4"`) {
+		t.Fatalf("content preview does not look like the generated file:\n%s", result.Preview)
+	}
+	if _, statErr := os.Stat(cfg.OutputPath); !os.IsNotExist(statErr) {
+		t.Fatalf("dry run with preview wrote %s", cfg.OutputPath)
+	}
+
+	if err := os.WriteFile(cfg.OutputPath, []byte("export const GENERATED_PATCHES = [];\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Preview = "DIFF"
+	result, err = RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	if !strings.HasPrefix(result.Preview, "--- "+cfg.OutputPath+"\n") || !strings.Contains(result.Preview, "\n-export const GENERATED_PATCHES = [];\n") {
+		t.Fatalf("diff preview = %q", result.Preview)
+	}
+
+	cfg.DryRun = false
+	if _, err := RunSync(t.Context(), cfg); errorCode(err) != errCodeConfig {
+		t.Fatalf("preview without dry run error = %v, want %s", err, errCodeConfig)
+	}
+	cfg.DryRun = true
+	cfg.Preview = "html"
+	if _, err := RunSync(t.Context(), cfg); errorCode(err) != errCodeConfig {
+		t.Fatalf("unknown preview error = %v, want %s", err, errCodeConfig)
+	}
+}
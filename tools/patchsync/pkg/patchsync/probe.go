@@ -0,0 +1,108 @@
+package patchsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProbeMaxMajor = 5
+	defaultProbeMaxMinor = 50
+	defaultProbeGap      = 2
+	defaultProbeBatch    = 8
+	probeRequestTimeout  = 3 * time.Second
+)
+
+// ProbeOptions bounds probe discovery, the last-resort way of finding N.N tabs by requesting them one by one.
+// Zero fields use the defaults.
+type ProbeOptions struct {
+	MaxMajor int // majors 0..MaxMajor are probed through their N.0 tab
+	MaxMinor int // highest minor probed per major
+	Gap      int // consecutive missing minors tolerated before a major is done
+	Batch    int // minors requested at the same time
+}
+
+func (o ProbeOptions) withDefaults() ProbeOptions {
+	if o.MaxMajor <= 0 {
+		o.MaxMajor = defaultProbeMaxMajor
+	}
+	if o.MaxMinor <= 0 {
+		o.MaxMinor = defaultProbeMaxMinor
+	}
+	if o.Gap <= 0 {
+		o.Gap = defaultProbeGap
+	}
+	if o.Batch <= 0 {
+		o.Batch = defaultProbeBatch
+	}
+	return o
+}
+
+// probeSheets requests every candidate at once and reports which ones fetched and parsed, in candidate order.
+func probeSheets(ctx context.Context, client *http.Client, spreadsheetID string, parser PatchParser, candidates []string) []bool {
+	found := make([]bool, len(candidates))
+	var wg sync.WaitGroup
+	for idx, candidate := range candidates {
+		wg.Go(func() {
+			probeCtx, cancel := context.WithTimeout(ctx, probeRequestTimeout)
+			defer cancel()
+			csvText, err := fetchGvizSheetCSV(probeCtx, client, spreadsheetID, candidate)
+			if err == nil {
+				_, err = parser(candidate, csvText, ParseOptions{})
+			}
+			found[idx] = err == nil
+		})
+	}
+	wg.Wait()
+	return found
+}
+
+// discoverSheetNamesByProbe finds majors through their N.0 tab, then probes each major's minors in parallel
+// batches until more than opts.Gap minors in a row are missing or opts.MaxMinor is reached.
+func discoverSheetNamesByProbe(ctx context.Context, client *http.Client, spreadsheetID string, parser PatchParser, opts ProbeOptions) ([]string, error) {
+	opts = opts.withDefaults()
+	majors := make([]string, 0, opts.MaxMajor+1)
+	for major := 0; major <= opts.MaxMajor; major++ {
+		majors = append(majors, fmt.Sprintf("%d.0", major))
+	}
+	majorFound := probeSheets(ctx, client, spreadsheetID, parser, majors)
+
+	names := make([]string, 0, 8)
+	for major, ok := range majorFound {
+		if !ok {
+			continue
+		}
+		names = append(names, majors[major])
+		missStreak := 0
+		for next := 1; next <= opts.MaxMinor && missStreak <= opts.Gap; {
+			last := min(next+opts.Batch-1, opts.MaxMinor)
+			candidates := make([]string, 0, last-next+1)
+			for minor := next; minor <= last; minor++ {
+				candidates = append(candidates, fmt.Sprintf("%d.%d", major, minor))
+			}
+			for idx, found := range probeSheets(ctx, client, spreadsheetID, parser, candidates) {
+				if !found {
+					missStreak++
+					if missStreak > opts.Gap {
+						break
+					}
+					continue
+				}
+				missStreak = 0
+				names = append(names, candidates[idx])
+			}
+			next = last + 1
+		}
+	}
+
+	names = uniqueSheetNames(names)
+	sortVersionStrings(names)
+	if len(names) == 0 {
+		return nil, errors.New("no N.N sheet names found by probe")
+	}
+	return names, nil
+}
@@ -0,0 +1,81 @@
+package patchsync
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func probeTestServer(t *testing.T, sheets []string) (*http.Client, *int) {
+	t.Helper()
+	var mu sync.Mutex
+	inFlight, peak := 0, new(int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		*peak = max(*peak, inFlight)
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		if sheet := r.URL.Query().Get("sheet"); slices.Contains(sheets, sheet) {
+			fmt.Fprint(w, "ok")
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	transport, err := newGoogleBaseURLTransport(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Client{Transport: transport}, peak
+}
+
+func probeTestParser(_ string, csvText string, _ ParseOptions) (Patch, error) {
+	if csvText != "ok" {
+		return Patch{}, errors.New("not a patch sheet")
+	}
+	return Patch{}, nil
+}
+
+func TestDiscoverSheetNamesByProbeToleratesGaps(t *testing.T) {
+	sheets := []string{"1.0", "1.1", "2.0", "2.1", "2.2", "2.5", "2.6"}
+	client, peak := probeTestServer(t, sheets)
+
+	names, err := discoverSheetNamesByProbe(t.Context(), client, "abc", probeTestParser, ProbeOptions{Gap: 2, Batch: 4})
+	if err != nil || !reflect.DeepEqual(names, sheets) {
+		t.Fatalf("probe with gap 2 = %v, %v, want %v", names, err, sheets)
+	}
+	if *peak > 6 {
+		t.Fatalf("peak concurrent probes = %d, want at most the 6 major probes", *peak)
+	}
+
+	names, err = discoverSheetNamesByProbe(t.Context(), client, "abc", probeTestParser, ProbeOptions{Gap: 1, Batch: 4})
+	if want := []string{"1.0", "1.1", "2.0", "2.1", "2.2"}; err != nil || !reflect.DeepEqual(names, want) {
+		t.Fatalf("probe with gap 1 = %v, %v, want %v", names, err, want)
+	}
+
+	names, err = discoverSheetNamesByProbe(t.Context(), client, "abc", probeTestParser, ProbeOptions{MaxMajor: 1, MaxMinor: 0})
+	if want := []string{"1.0", "1.1"}; err != nil || !reflect.DeepEqual(names, want) {
+		t.Fatalf("probe with defaults for zero fields = %v, %v, want %v", names, err, want)
+	}
+}
+
+func TestDiscoverSheetNamesByProbeHonorsMaxMinor(t *testing.T) {
+	client, _ := probeTestServer(t, []string{"3.0", "3.1", "3.2", "3.3"})
+	names, err := discoverSheetNamesByProbe(t.Context(), client, "abc", probeTestParser, ProbeOptions{MaxMinor: 2, Batch: 2})
+	if want := []string{"3.0", "3.1", "3.2"}; err != nil || !reflect.DeepEqual(names, want) {
+		t.Fatalf("probe = %v, %v, want %v", names, err, want)
+	}
+	if _, err := discoverSheetNamesByProbe(t.Context(), client, "missing", probeTestParser, ProbeOptions{MaxMajor: 2}); err == nil {
+		t.Fatalf("expected an error when no major is found")
+	}
+}
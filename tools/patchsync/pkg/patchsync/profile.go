@@ -0,0 +1,88 @@
+package patchsync
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/pprof"
+	"time"
+)
+
+// phaseTiming is one measured step of a sync. Sheet is set for the per-sheet fetch, parse, and overrides phases.
+type phaseTiming struct {
+	Phase      string  `json:"phase"`
+	Sheet      string  `json:"sheet,omitempty"`
+	DurationMS float64 `json:"durationMs"`
+}
+
+// syncProfiler collects phase timings when SyncConfig.Profile is set. It always measures wall time,
+// independent of the sync Clock, so pinned timestamps do not hide slow phases.
+type syncProfiler struct {
+	enabled bool
+	timings []phaseTiming
+}
+
+func (p *syncProfiler) start(phase, sheet string) func() {
+	if !p.enabled {
+		return func() {}
+	}
+	started := time.Now()
+	return func() {
+		p.timings = append(p.timings, phaseTiming{Phase: phase, Sheet: sheet, DurationMS: durationMillis(time.Since(started))})
+	}
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// summarizePhaseTimings totals the timings per phase, in the order phases first ran.
+func summarizePhaseTimings(timings []phaseTiming) []phaseTiming {
+	index := map[string]int{}
+	totals := []phaseTiming{}
+	for _, timing := range timings {
+		idx, ok := index[timing.Phase]
+		if !ok {
+			idx = len(totals)
+			index[timing.Phase] = idx
+			totals = append(totals, phaseTiming{Phase: timing.Phase})
+		}
+		totals[idx].DurationMS += timing.DurationMS
+	}
+	return totals
+}
+
+func writeProfileReport(w io.Writer, timings []phaseTiming) {
+	fmt.Fprintln(w, "Profile:")
+	for _, timing := range timings {
+		label := timing.Phase
+		if timing.Sheet != "" {
+			label += " " + timing.Sheet
+		}
+		fmt.Fprintf(w, "  %-24s %10.1f ms\n", label, timing.DurationMS)
+	}
+	for _, total := range summarizePhaseTimings(timings) {
+		fmt.Fprintf(w, "  %-24s %10.1f ms\n", "total "+total.Phase, total.DurationMS)
+	}
+}
+
+// registerPprof exposes the net/http/pprof handlers under /debug/pprof/ for sync-scoped tokens.
+func registerPprof(mux *http.ServeMux, tokens *authTokens) {
+	guard := func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !tokens.authorize(r, scopeSync) {
+				writeJSON(w, http.StatusUnauthorized, syncResponse{
+					OK:      false,
+					Message: "unauthorized",
+				})
+				return
+			}
+			handler(w, r)
+		}
+	}
+	mux.HandleFunc("/debug/pprof/", guard(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", guard(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", guard(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", guard(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", guard(pprof.Trace))
+}
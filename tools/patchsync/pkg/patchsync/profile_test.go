@@ -0,0 +1,80 @@
+package patchsync
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRunSyncProfileRecordsEveryPhase(t *testing.T) {
+	dir := t.TempDir()
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4", "3.5"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		DryRun:          true,
+		Fetcher: fakeSheetFetcher{sheets: map[string]string{
+			"3.4": wuwaSheetCSV("3.4"),
+			"3.5": wuwaSheetCSV("3.5"),
+		}},
+	}
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	if result.Profile != nil {
+		t.Fatalf("expected no profile without cfg.Profile, got %v", result.Profile)
+	}
+
+	cfg.Profile = true
+	result, err = RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	got := []string{}
+	for _, timing := range result.Profile {
+		got = append(got, timing.Phase+" "+timing.Sheet)
+	}
+	want := []string{"discovery ", "fetch 3.4", "parse 3.4", "overrides 3.4", "fetch 3.5", "parse 3.5", "overrides 3.5", "write "}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("profile phases = %v, want %v", got, want)
+	}
+}
+
+func TestSummarizePhaseTimings(t *testing.T) {
+	totals := summarizePhaseTimings([]phaseTiming{
+		{Phase: "fetch", Sheet: "1.0", DurationMS: 2},
+		{Phase: "parse", Sheet: "1.0", DurationMS: 1},
+		{Phase: "fetch", Sheet: "1.1", DurationMS: 3.5},
+	})
+	want := []phaseTiming{{Phase: "fetch", DurationMS: 5.5}, {Phase: "parse", DurationMS: 1}}
+	if !reflect.DeepEqual(totals, want) {
+		t.Fatalf("summarizePhaseTimings() = %v, want %v", totals, want)
+	}
+}
+
+func TestPprofRequiresSyncToken(t *testing.T) {
+	tokens, err := newAuthTokens("", "read:r,sync:s", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	registerPprof(mux, tokens)
+	for token, want := range map[string]int{"": http.StatusUnauthorized, "r": http.StatusUnauthorized, "s": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/debug/pprof/cmdline", nil)
+		if token != "" {
+			req.Header.Set("X-Patchsync-Token", token)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Fatalf("token %q: status = %d, want %d", token, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package patchsync
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// syncProgress is one progress event of a sync. Index and Total count the version sheets; Percent spans the
+// whole sync: discovery takes the first 5%, the sheets up to 90%, and writing the rest.
+type syncProgress struct {
+	GameID  string `json:"gameId"`
+	Phase   string `json:"phase"`
+	Sheet   string `json:"sheet,omitempty"`
+	Index   int    `json:"index,omitempty"`
+	Total   int    `json:"total,omitempty"`
+	Percent int    `json:"percent"`
+}
+
+const (
+	progressDiscovery = "discovery"
+	progressSheets    = "sheets"
+	progressWrite     = "write"
+	progressDone      = "done"
+	progressFailed    = "failed"
+)
+
+// syncProgressTracker records the progress events of one sync. Each event also replaces the game's entry on
+// liveSyncProgress and goes to sink when set, and a log line is added whenever the phase changes or another
+// 10% is done.
+type syncProgressTracker struct {
+	gameID  string
+	logs    *syncLog
+	events  []syncProgress
+	logged  int
+	current syncProgress
+	sink    func(syncProgress)
+}
+
+func newSyncProgressTracker(gameID string, logs *syncLog, sink func(syncProgress)) *syncProgressTracker {
+	return &syncProgressTracker{gameID: gameID, logs: logs, logged: -1, sink: sink}
+}
+
+func (t *syncProgressTracker) phase(phase string, percent int) {
+	t.report(syncProgress{Phase: phase, Percent: percent})
+}
+
+// sheet reports that sheet index (1-based) of total is being fetched.
+func (t *syncProgressTracker) sheet(name string, index, total int) {
+	percent := 5 + 85*(index-1)/max(total, 1)
+	t.report(syncProgress{Phase: progressSheets, Sheet: name, Index: index, Total: total, Percent: percent})
+}
+
+func (t *syncProgressTracker) report(event syncProgress) {
+	event.GameID = t.gameID
+	changedPhase := event.Phase != t.current.Phase
+	t.current = event
+	t.events = append(t.events, event)
+	liveSyncProgress.set(event)
+	if t.sink != nil {
+		t.sink(event)
+	}
+	if changedPhase || event.Percent/10 > t.logged/10 {
+		t.logged = event.Percent
+		if event.Total > 0 {
+			appendSyncLog(t.logs, "progress: %s %d/%d (%d%%)", event.Phase, event.Index, event.Total, event.Percent)
+		} else {
+			appendSyncLog(t.logs, "progress: %s (%d%%)", event.Phase, event.Percent)
+		}
+	}
+}
+
+// syncProgressBoard holds the latest progress event per game for the admin UI, which polls it via /logs.
+type syncProgressBoard struct {
+	mu     sync.Mutex
+	latest map[string]syncProgress
+}
+
+var liveSyncProgress = &syncProgressBoard{latest: map[string]syncProgress{}}
+
+func (b *syncProgressBoard) set(event syncProgress) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.latest[event.GameID] = event
+}
+
+// finish marks a game's sync as done or failed, keeping the last percent of a failed run.
+func (b *syncProgressBoard) finish(gameID string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	event, ok := b.latest[gameID]
+	if !ok {
+		return
+	}
+	if err != nil {
+		event.Phase = progressFailed
+	} else {
+		event = syncProgress{GameID: gameID, Phase: progressDone, Percent: 100}
+	}
+	b.latest[gameID] = event
+}
+
+// snapshot returns the latest event of every game that has synced, ordered by game ID.
+func (b *syncProgressBoard) snapshot() []syncProgress {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	events := make([]syncProgress, 0, len(b.latest))
+	for _, gameID := range slices.Sorted(maps.Keys(b.latest)) {
+		events = append(events, b.latest[gameID])
+	}
+	return events
+}
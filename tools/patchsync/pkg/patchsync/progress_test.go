@@ -0,0 +1,80 @@
+package patchsync
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunSyncReportsProgress(t *testing.T) {
+	dir := t.TempDir()
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4", "3.5"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		DryRun:          true,
+		Fetcher: fakeSheetFetcher{sheets: map[string]string{
+			"3.4": wuwaSheetCSV("3.4"),
+			"3.5": wuwaSheetCSV("3.5"),
+		}},
+	}
+
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	want := []syncProgress{
+		{GameID: gameIDWuwa, Phase: progressDiscovery, Percent: 0},
+		{GameID: gameIDWuwa, Phase: progressSheets, Sheet: "3.4", Index: 1, Total: 2, Percent: 5},
+		{GameID: gameIDWuwa, Phase: progressSheets, Sheet: "3.5", Index: 2, Total: 2, Percent: 47},
+		{GameID: gameIDWuwa, Phase: progressWrite, Percent: 90},
+		{GameID: gameIDWuwa, Phase: progressDone, Percent: 100},
+	}
+	if !reflect.DeepEqual(result.Progress, want) {
+		t.Fatalf("progress = %+v, want %+v", result.Progress, want)
+	}
+	live := map[string]syncProgress{}
+	for _, event := range liveSyncProgress.snapshot() {
+		live[event.GameID] = event
+	}
+	if got := live[gameIDWuwa]; got.Phase != progressDone || got.Percent != 100 {
+		t.Fatalf("live progress = %+v, want done at 100%%", got)
+	}
+}
+
+func TestSyncProgressLogsEveryTenPercent(t *testing.T) {
+	logs := syncLog{clock: FixedClock{}}
+	tracker := newSyncProgressTracker("test-game", &logs, nil)
+	tracker.phase(progressDiscovery, 0)
+	for index := 1; index <= 20; index++ {
+		tracker.sheet("1."+strings.Repeat("0", index), index, 20)
+	}
+	tracker.phase(progressWrite, 90)
+
+	progressLines := 0
+	for _, line := range logs.lines {
+		if strings.Contains(line, "progress: ") {
+			progressLines++
+		}
+	}
+	if len(tracker.events) != 22 {
+		t.Fatalf("events = %d, want 22", len(tracker.events))
+	}
+	// discovery, the first sheet, one line per further 10% of sheets, and write.
+	if progressLines != 11 {
+		t.Fatalf("logged %d progress lines, want 11:\n%s", progressLines, strings.Join(logs.lines, "\n"))
+	}
+
+	liveSyncProgress.finish("test-game", errors.New("boom"))
+	for _, event := range liveSyncProgress.snapshot() {
+		if event.GameID == "test-game" && (event.Phase != progressFailed || event.Percent != 90) {
+			t.Fatalf("failed progress = %+v, want failed at 90%%", event)
+		}
+	}
+}
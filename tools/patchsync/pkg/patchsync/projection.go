@@ -0,0 +1,266 @@
+package patchsync
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const isoDateLayout = "2006-01-02"
+
+type projectionOptions struct {
+	MonthlySub     bool            `json:"monthlySub"`
+	BattlePassTier int             `json:"battlePassTier"`
+	Options        map[string]bool `json:"options"`
+}
+
+type projectRequest struct {
+	GameID    string `json:"gameId"`
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
+	projectionOptions
+}
+
+type projectionPatch struct {
+	Patch       string             `json:"patch"`
+	StartDate   string             `json:"startDate"`
+	OverlapDays int                `json:"overlapDays"`
+	Fraction    float64            `json:"fraction"`
+	Pulls       float64            `json:"pulls"`
+	Rewards     map[string]float64 `json:"rewards"`
+}
+
+type projectionResult struct {
+	GameID         string             `json:"gameId"`
+	StartDate      string             `json:"startDate"`
+	EndDate        string             `json:"endDate"`
+	Days           int                `json:"days"`
+	CoveredDays    int                `json:"coveredDays"`
+	Rewards        map[string]float64 `json:"rewards"`
+	Costs          map[string]float64 `json:"costs"`
+	Pulls          float64            `json:"pulls"`
+	CurrencyPulls  float64            `json:"currencyPulls"`
+	Patches        []projectionPatch  `json:"patches"`
+	SkippedPatches []string           `json:"skippedPatches,omitempty"`
+}
+
+type patchTotals struct {
+	Rewards    Rewards
+	Costs      Rewards
+	PullSource Rewards
+	Pulls      float64
+}
+
+func normalizeBattlePassTier(tier int) int {
+	if tier == 2 || tier == 3 {
+		return tier
+	}
+	return 1
+}
+
+func sourceEnabled(src Source, opts projectionOptions) bool {
+	tier := normalizeBattlePassTier(opts.BattlePassTier)
+	enabled := false
+	switch src.Gate {
+	case "", "always":
+		enabled = true
+	case "monthly":
+		enabled = opts.MonthlySub
+	case "bp2":
+		enabled = tier >= 2
+	case "bp3":
+		enabled = tier >= 3
+	}
+	if !enabled {
+		return false
+	}
+	if src.OptionKey != nil && *src.OptionKey != "" {
+		return opts.Options[*src.OptionKey]
+	}
+	return true
+}
+
+func bpCrateScale(model BPCrateModel, durationDays, tier int) float64 {
+	daysToLevel60Tier3 := math.Max(0, float64(model.DaysToLevel60T3))
+	tier2Bonus := model.Tier2XPBonusRate
+	tier3Bonus := model.Tier3XPBonusRate
+	currentBonus := 0.0
+	if tier >= 3 {
+		currentBonus = tier3Bonus
+	} else if tier >= 2 {
+		currentBonus = tier2Bonus
+	}
+	duration := math.Max(0, float64(durationDays))
+	referenceRemainingDays := math.Max(0, duration-daysToLevel60Tier3)
+	if referenceRemainingDays <= 0 {
+		return 0
+	}
+	daysToLevel60Current := math.Ceil((daysToLevel60Tier3 * (1 + tier3Bonus)) / (1 + currentBonus))
+	currentRemainingDays := math.Max(0, duration-daysToLevel60Current)
+	timeScale := currentRemainingDays / referenceRemainingDays
+	xpScale := (1 + currentBonus) / (1 + tier3Bonus)
+	return math.Max(0, timeScale*xpScale)
+}
+
+func computePatchTotals(gameID string, patch Patch, opts projectionOptions) patchTotals {
+	conversion := pullConversionForGame(gameID)
+	tier := normalizeBattlePassTier(opts.BattlePassTier)
+	totals := patchTotals{}
+	for _, src := range patch.Sources {
+		if !sourceEnabled(src, opts) {
+			continue
+		}
+		rewards := sourceRewardsForDuration(src, patch.DurationDays)
+		if src.BPCrateModel != nil && src.BPCrateModel.Type == "post_bp60_estimate" {
+			rewards = rewards.scaled(bpCrateScale(*src.BPCrateModel, patch.DurationDays, tier))
+		}
+		totals.Rewards.add(rewards)
+		totals.Costs.add(src.Costs)
+		if !src.CountInPulls {
+			continue
+		}
+		totals.PullSource.add(rewards)
+		if src.Pulls != nil {
+			totals.Pulls += *src.Pulls
+		} else {
+			totals.Pulls += conversion.pullsFromRewards(rewards)
+		}
+	}
+	return totals
+}
+
+func roundRewardMap(values map[string]float64) map[string]float64 {
+	for key, value := range values {
+		values[key] = roundToTenth(value)
+	}
+	return values
+}
+
+func projectIncome(gameID string, patches []Patch, start, end time.Time, opts projectionOptions) (projectionResult, error) {
+	if end.Before(start) {
+		return projectionResult{}, errors.New("endDate must not be before startDate")
+	}
+	// The end date is inclusive, so the window runs until the start of the following day.
+	windowEnd := end.AddDate(0, 0, 1)
+	result := projectionResult{
+		GameID:    gameID,
+		StartDate: start.Format(isoDateLayout),
+		EndDate:   end.Format(isoDateLayout),
+		Days:      int(windowEnd.Sub(start).Hours() / 24),
+		Patches:   []projectionPatch{},
+	}
+	conversion := pullConversionForGame(gameID)
+	total := Rewards{}
+	costs := Rewards{}
+	pullSource := Rewards{}
+	pulls := 0.0
+	for _, patch := range patches {
+		patchID := patchIDOrFallback(patch)
+		patchStart, err := time.Parse(isoDateLayout, strings.TrimSpace(patch.StartDate))
+		if err != nil || patch.DurationDays <= 0 {
+			result.SkippedPatches = append(result.SkippedPatches, patchID)
+			continue
+		}
+		patchEnd := patchStart.AddDate(0, 0, patch.DurationDays)
+		overlapStart := patchStart
+		if start.After(overlapStart) {
+			overlapStart = start
+		}
+		overlapEnd := patchEnd
+		if windowEnd.Before(overlapEnd) {
+			overlapEnd = windowEnd
+		}
+		overlapDays := int(overlapEnd.Sub(overlapStart).Hours() / 24)
+		if overlapDays <= 0 {
+			continue
+		}
+		fraction := float64(overlapDays) / float64(patch.DurationDays)
+		totals := computePatchTotals(gameID, patch, opts)
+		scaledRewards := totals.Rewards.scaled(fraction)
+		total.add(scaledRewards)
+		costs.add(totals.Costs.scaled(fraction))
+		pullSource.add(totals.PullSource.scaled(fraction))
+		pulls += totals.Pulls * fraction
+		result.CoveredDays += overlapDays
+		result.Patches = append(result.Patches, projectionPatch{
+			Patch:       patchID,
+			StartDate:   patch.StartDate,
+			OverlapDays: overlapDays,
+			Fraction:    math.Round(fraction*1000) / 1000,
+			Pulls:       roundToTenth(totals.Pulls * fraction),
+			Rewards:     roundRewardMap(rewardsForGame(scaledRewards, gameID)),
+		})
+	}
+	result.Rewards = roundRewardMap(rewardsForGame(total, gameID))
+	result.Costs = roundRewardMap(rewardsForGame(costs, gameID))
+	result.Pulls = roundToTenth(pulls)
+	if conversion.BasePerPull > 0 {
+		result.CurrencyPulls = roundToTenth(pullSource.Oroberyl / conversion.BasePerPull)
+	}
+	return result, nil
+}
+
+func runProjection(cfg SyncConfig, req projectRequest) (projectionResult, error) {
+	profile, err := ResolveGameProfile(req.GameID)
+	if err != nil {
+		return projectionResult{}, err
+	}
+	start, err := time.Parse(isoDateLayout, strings.TrimSpace(req.StartDate))
+	if err != nil {
+		return projectionResult{}, fmt.Errorf("startDate must be YYYY-MM-DD: %w", err)
+	}
+	end, err := time.Parse(isoDateLayout, strings.TrimSpace(req.EndDate))
+	if err != nil {
+		return projectionResult{}, fmt.Errorf("endDate must be YYYY-MM-DD: %w", err)
+	}
+	patches, err := readGeneratedPatches(resolveFilePath(configuredOutputPath(cfg, profile)))
+	if err != nil {
+		return projectionResult{}, fmt.Errorf("read generated patches: %w", err)
+	}
+	return projectIncome(profile.ID, patches, start, end, req.projectionOptions)
+}
+
+func registerProjectionAPI(mux *http.ServeMux, allowedOrigins map[string]struct{}, cfg SyncConfig) {
+	mux.HandleFunc("/project", func(w http.ResponseWriter, r *http.Request) {
+		if !withCORS(w, r, allowedOrigins) {
+			writeJSON(w, http.StatusForbidden, syncResponse{
+				OK:      false,
+				Message: "origin is not allowed",
+			})
+			return
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, syncResponse{
+				OK:      false,
+				Message: "method not allowed",
+			})
+			return
+		}
+		var req projectRequest
+		if err := parseSyncRequestBody(r, &req, cfg.maxBodyBytes()); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		projection, err := runProjection(cfg, req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, syncResponse{
+				OK:      false,
+				Message: err.Error(),
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, syncResponse{
+			OK:         true,
+			Message:    "projection completed",
+			GameID:     projection.GameID,
+			Projection: &projection,
+		})
+	})
+}
@@ -0,0 +1,64 @@
+package patchsync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProjectIncome(t *testing.T) {
+	monthly := source("monthly", "Lunite Subscription", "monthly", nil, true, Rewards{})
+	monthly.Scalers = []Scaler{{Type: "per_duration", Unit: "day", EveryDays: 1, Rewards: Rewards{Oroberyl: 90}}}
+	bp := source("paidBattlePass", "Paid Battle Pass", "bp2", nil, true, Rewards{Oroberyl: 680, Chartered: 5})
+	bp.Costs = Rewards{Origeometry: 680}
+	patches := []Patch{
+		{
+			ID: "2.0", Patch: "2.0", StartDate: "2025-01-02", DurationDays: 40,
+			Sources: []Source{
+				source("events", "Version Events", "always", nil, true, Rewards{Oroberyl: 8000}),
+				monthly,
+				bp,
+			},
+		},
+		{
+			ID: "2.1", Patch: "2.1", StartDate: "2025-02-11", DurationDays: 40,
+			Sources: []Source{source("events", "Version Events", "always", nil, true, Rewards{Oroberyl: 16000})},
+		},
+		{ID: "2.2", Patch: "2.2", Sources: []Source{}},
+	}
+	start := time.Date(2025, 1, 22, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 2, 20, 0, 0, 0, 0, time.UTC)
+
+	freeOnly, err := projectIncome(gameIDWuwa, patches, start, end, projectionOptions{})
+	if err != nil {
+		t.Fatalf("projectIncome() error = %v", err)
+	}
+	if freeOnly.Days != 30 || freeOnly.CoveredDays != 30 || len(freeOnly.Patches) != 2 {
+		t.Fatalf("days=%d covered=%d patches=%d, want 30/30/2", freeOnly.Days, freeOnly.CoveredDays, len(freeOnly.Patches))
+	}
+	// 20/40 of 8000 plus 10/40 of 16000.
+	if freeOnly.Rewards["astrite"] != 8000 {
+		t.Errorf("astrite = %v, want 8000", freeOnly.Rewards["astrite"])
+	}
+	if freeOnly.Pulls != 50 || freeOnly.CurrencyPulls != 50 {
+		t.Errorf("pulls = %v currencyPulls = %v, want 50/50", freeOnly.Pulls, freeOnly.CurrencyPulls)
+	}
+	if len(freeOnly.SkippedPatches) != 1 || freeOnly.SkippedPatches[0] != "2.2" {
+		t.Errorf("skipped = %v, want [2.2]", freeOnly.SkippedPatches)
+	}
+
+	paid, err := projectIncome(gameIDWuwa, patches, start, end, projectionOptions{MonthlySub: true, BattlePassTier: 2})
+	if err != nil {
+		t.Fatalf("projectIncome() error = %v", err)
+	}
+	// Monthly: half of 40 days * 90; BP: half of 680 astrite and 5 radiant tides.
+	if paid.Rewards["astrite"] != 8000+1800+340 {
+		t.Errorf("astrite = %v, want 10140", paid.Rewards["astrite"])
+	}
+	if paid.Rewards["radiantTide"] != 2.5 || paid.Costs["lunite"] != 340 {
+		t.Errorf("radiantTide = %v lunite cost = %v, want 2.5/340", paid.Rewards["radiantTide"], paid.Costs["lunite"])
+	}
+
+	if _, err := projectIncome(gameIDWuwa, patches, end, start, projectionOptions{}); err == nil {
+		t.Fatalf("expected error when end is before start")
+	}
+}
@@ -0,0 +1,84 @@
+package patchsync
+
+import "strings"
+
+// reconcileProportional spreads the Data or Summary sheet delta over all counted F2P sources by their pulls,
+// instead of adding it to a single source.
+const reconcileProportional = "proportional"
+
+// parseReconcileTarget normalizes the "reconcile" setting of an overrides file: a source ID, proportional, or
+// empty for the game's default source.
+func parseReconcileTarget(raw string) string {
+	target := strings.TrimSpace(raw)
+	if strings.EqualFold(target, reconcileProportional) {
+		return reconcileProportional
+	}
+	return target
+}
+
+// reconcilePullDelta adds delta, the gap between a sheet's F2P total and the sum of the counted sources, to the
+// first of targets the patch can take it on: a source ID it has, or proportional when the counted sources have
+// pulls to weigh by. pulls gives the current pulls of a source. It reports false when no target applied.
+func reconcilePullDelta(patch *Patch, delta float64, counted []int, pulls func(Source) float64, targets ...string) bool {
+	for _, target := range targets {
+		switch target {
+		case "":
+			continue
+		case reconcileProportional:
+			if spreadPullDelta(patch, delta, counted, pulls) {
+				return true
+			}
+		default:
+			for idx := range patch.Sources {
+				if patch.Sources[idx].ID != target {
+					continue
+				}
+				v := roundToTenth(pulls(patch.Sources[idx]) + delta)
+				patch.Sources[idx].Pulls = &v
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// spreadPullDelta splits delta over the counted sources by their pulls. What rounding to a tenth leaves over goes
+// to the largest source, so the sources still add up to the sheet total.
+func spreadPullDelta(patch *Patch, delta float64, counted []int, pulls func(Source) float64) bool {
+	weight, largest := 0.0, -1
+	for _, idx := range counted {
+		w := pulls(patch.Sources[idx])
+		if w <= 0 {
+			continue
+		}
+		weight += w
+		if largest < 0 || w > pulls(patch.Sources[largest]) {
+			largest = idx
+		}
+	}
+	if weight <= 0 {
+		return false
+	}
+	values := map[int]float64{}
+	remainder := delta
+	for _, idx := range counted {
+		base := pulls(patch.Sources[idx])
+		if base <= 0 {
+			continue
+		}
+		values[idx] = roundToTenth(base + delta*base/weight)
+		remainder -= values[idx] - base
+	}
+	values[largest] = roundToTenth(values[largest] + remainder)
+	for idx, v := range values {
+		patch.Sources[idx].Pulls = &v
+	}
+	return true
+}
+
+func sourcePullsOrZero(src Source) float64 {
+	if src.Pulls == nil {
+		return 0
+	}
+	return *src.Pulls
+}
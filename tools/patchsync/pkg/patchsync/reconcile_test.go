@@ -0,0 +1,56 @@
+package patchsync
+
+import "testing"
+
+func reconcileTestPatch() Patch {
+	return Patch{Patch: "3.4", Sources: []Source{
+		{ID: "events", CountInPulls: true},
+		{ID: "permanent", CountInPulls: true},
+		{ID: "endgameModes", CountInPulls: true},
+	}}
+}
+
+func TestApplyWuwaDataPullOverridesReconcileTarget(t *testing.T) {
+	pullsByPatch := map[string]map[string]float64{
+		"3.4": {"events": 30, "permanent": 10, "endgameModes": 20, "__totalF2P": 66},
+	}
+	for _, tc := range []struct {
+		reconcile string
+		want      map[string]float64
+	}{
+		{"", map[string]float64{"events": 30, "permanent": 10, "endgameModes": 26}},
+		{"events", map[string]float64{"events": 36, "permanent": 10, "endgameModes": 20}},
+		{"missing", map[string]float64{"events": 30, "permanent": 10, "endgameModes": 26}},
+		{reconcileProportional, map[string]float64{"events": 33, "permanent": 11, "endgameModes": 22}},
+	} {
+		patch := reconcileTestPatch()
+		if err := applyWuwaDataPullOverrides(&patch, pullsByPatch, tc.reconcile); err != nil {
+			t.Fatalf("applyWuwaDataPullOverrides(%q) error = %v", tc.reconcile, err)
+		}
+		for _, src := range patch.Sources {
+			if src.Pulls == nil || *src.Pulls != tc.want[src.ID] {
+				t.Fatalf("reconcile %q: %s pulls = %v, want %v", tc.reconcile, src.ID, src.Pulls, tc.want[src.ID])
+			}
+		}
+	}
+}
+
+func TestSpreadPullDeltaKeepsTotal(t *testing.T) {
+	patch := reconcileTestPatch()
+	for idx, pulls := range []float64{10, 10, 10} {
+		patch.Sources[idx].Pulls = &pulls
+	}
+	if !spreadPullDelta(&patch, 1, []int{0, 1, 2}, sourcePullsOrZero) {
+		t.Fatal("spreadPullDelta() = false")
+	}
+	sum := 0.0
+	for _, src := range patch.Sources {
+		sum += *src.Pulls
+	}
+	if roundToTenth(sum) != 31 {
+		t.Fatalf("sources add up to %v after spreading 1 pull, want 31", sum)
+	}
+	if parseReconcileTarget(" Proportional ") != reconcileProportional {
+		t.Fatal("parseReconcileTarget() does not normalize proportional")
+	}
+}
@@ -0,0 +1,150 @@
+package patchsync
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerRegion is a game server whose clock runs at a fixed offset from UTC. Offset is in minutes; the
+// servers do not observe daylight saving time.
+type ServerRegion struct {
+	ID     string
+	Offset int
+}
+
+var defaultServerRegions = []ServerRegion{
+	{ID: "asia", Offset: 8 * 60},
+	{ID: "eu", Offset: 1 * 60},
+	{ID: "na", Offset: -5 * 60},
+}
+
+const (
+	// defaultPatchStart is the daily reset, when version content unlocks on games that roll it out per server.
+	defaultPatchStart = "04:00"
+	// hoyoversePatchStart is the end of the version update maintenance, which is the same instant on every server.
+	hoyoversePatchStart = "11:00+08:00"
+)
+
+var (
+	utcOffsetPattern  = regexp.MustCompile(`^(?i:utc|gmt)?\s*([+-])(\d{1,2})(?::?(\d{2}))?$`)
+	patchStartPattern = regexp.MustCompile(`^(\d{1,2}):(\d{2})\s*(.*)$`)
+)
+
+// parseUTCOffset reads offsets such as "+8", "-05:00", "UTC+0530" and returns minutes east of UTC.
+func parseUTCOffset(raw string) (int, error) {
+	trimmed := strings.TrimSpace(raw)
+	if strings.EqualFold(trimmed, "utc") || strings.EqualFold(trimmed, "z") {
+		return 0, nil
+	}
+	match := utcOffsetPattern.FindStringSubmatch(trimmed)
+	if match == nil {
+		return 0, fmt.Errorf("invalid UTC offset %q (use +8, -05:00 or UTC+1)", raw)
+	}
+	hours, _ := strconv.Atoi(match[2])
+	minutes := 0
+	if match[3] != "" {
+		minutes, _ = strconv.Atoi(match[3])
+	}
+	if hours > 14 || minutes > 59 {
+		return 0, fmt.Errorf("invalid UTC offset %q (use +8, -05:00 or UTC+1)", raw)
+	}
+	offset := hours*60 + minutes
+	if match[1] == "-" {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+// parseServerRegions reads --server-regions, a comma-separated list of region=offset pairs.
+func parseServerRegions(raw string) ([]ServerRegion, error) {
+	regions := []ServerRegion{}
+	seen := map[string]struct{}{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, offsetRaw, ok := strings.Cut(entry, "=")
+		id = strings.ToLower(strings.TrimSpace(id))
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid server region %q (use region=offset, e.g. asia=+8)", entry)
+		}
+		if _, dup := seen[id]; dup {
+			return nil, fmt.Errorf("server region %q is listed twice", id)
+		}
+		offset, err := parseUTCOffset(offsetRaw)
+		if err != nil {
+			return nil, fmt.Errorf("server region %s: %w", id, err)
+		}
+		seen[id] = struct{}{}
+		regions = append(regions, ServerRegion{ID: id, Offset: offset})
+	}
+	if len(regions) == 0 {
+		return nil, nil
+	}
+	return regions, nil
+}
+
+// patchStartTime is the time of day a patch goes live. Without an offset it is read in each region's server
+// time, so regions start at different instants; with one, every region starts at the same instant.
+type patchStartTime struct {
+	Hour   int
+	Minute int
+	Offset *int
+}
+
+// parsePatchStart reads "HH:MM" or "HH:MM<offset>", for example "04:00" or "11:00+08:00".
+func parsePatchStart(raw string) (patchStartTime, error) {
+	match := patchStartPattern.FindStringSubmatch(strings.TrimSpace(raw))
+	if match == nil {
+		return patchStartTime{}, fmt.Errorf("invalid patch start %q (use HH:MM or HH:MM+08:00)", raw)
+	}
+	hour, _ := strconv.Atoi(match[1])
+	minute, _ := strconv.Atoi(match[2])
+	if hour > 23 || minute > 59 {
+		return patchStartTime{}, fmt.Errorf("invalid patch start %q (use HH:MM or HH:MM+08:00)", raw)
+	}
+	start := patchStartTime{Hour: hour, Minute: minute}
+	if strings.TrimSpace(match[3]) != "" {
+		offset, err := parseUTCOffset(match[3])
+		if err != nil {
+			return patchStartTime{}, fmt.Errorf("invalid patch start %q: %w", raw, err)
+		}
+		start.Offset = &offset
+	}
+	return start, nil
+}
+
+func fixedZoneForOffset(offset int) *time.Location {
+	return time.FixedZone("", offset*60)
+}
+
+// patchStartTimes returns the RFC 3339 start of startDate per region, written in the region's server time.
+// It returns nil when startDate is not an ISO date.
+func patchStartTimes(startDate string, regions []ServerRegion, start patchStartTime) map[string]string {
+	date, err := time.Parse(isoDateLayout, strings.TrimSpace(startDate))
+	if err != nil || len(regions) == 0 {
+		return nil
+	}
+	times := make(map[string]string, len(regions))
+	for _, region := range regions {
+		zone := fixedZoneForOffset(region.Offset)
+		if start.Offset != nil {
+			zone = fixedZoneForOffset(*start.Offset)
+		}
+		instant := time.Date(date.Year(), date.Month(), date.Day(), start.Hour, start.Minute, 0, 0, zone)
+		times[region.ID] = instant.In(fixedZoneForOffset(region.Offset)).Format(time.RFC3339)
+	}
+	return times
+}
+
+// applyPatchStartTimes recomputes StartTimes for every patch, so a changed region setup also updates patches
+// kept from the previous output.
+func applyPatchStartTimes(patches []Patch, regions []ServerRegion, start patchStartTime) {
+	for idx := range patches {
+		patches[idx].StartTimes = patchStartTimes(patches[idx].StartDate, regions, start)
+	}
+}
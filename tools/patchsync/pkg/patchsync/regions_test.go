@@ -0,0 +1,109 @@
+package patchsync
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseUTCOffset(t *testing.T) {
+	cases := map[string]int{"+8": 480, "UTC+1": 60, "-05:00": -300, "utc+0530": 330, "GMT-3": -180, "Z": 0}
+	for raw, want := range cases {
+		got, err := parseUTCOffset(raw)
+		if err != nil || got != want {
+			t.Errorf("parseUTCOffset(%q) = %d, %v, want %d", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"", "8", "+15", "+08:75", "EST"} {
+		if _, err := parseUTCOffset(raw); err == nil {
+			t.Errorf("parseUTCOffset(%q) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestParseServerRegions(t *testing.T) {
+	regions, err := parseServerRegions(" Asia=+8, na=UTC-5 ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []ServerRegion{{ID: "asia", Offset: 480}, {ID: "na", Offset: -300}}; !reflect.DeepEqual(regions, want) {
+		t.Fatalf("regions = %v, want %v", regions, want)
+	}
+	if regions, err := parseServerRegions(""); err != nil || regions != nil {
+		t.Fatalf("empty regions = %v, %v, want nil", regions, err)
+	}
+	for _, raw := range []string{"asia", "asia=+8,asia=+9", "=+8", "eu=CET"} {
+		if _, err := parseServerRegions(raw); err == nil {
+			t.Errorf("parseServerRegions(%q) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestPatchStartTimes(t *testing.T) {
+	perServer, err := parsePatchStart("04:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := patchStartTimes("2025-04-29", defaultServerRegions, perServer)
+	want := map[string]string{
+		"asia": "2025-04-29T04:00:00+08:00",
+		"eu":   "2025-04-29T04:00:00+01:00",
+		"na":   "2025-04-29T04:00:00-05:00",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("per-server start times = %v, want %v", got, want)
+	}
+
+	shared, err := parsePatchStart("11:00+08:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = patchStartTimes("2025-04-29", defaultServerRegions, shared)
+	want = map[string]string{
+		"asia": "2025-04-29T11:00:00+08:00",
+		"eu":   "2025-04-29T04:00:00+01:00",
+		"na":   "2025-04-28T22:00:00-05:00",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("shared start times = %v, want %v", got, want)
+	}
+
+	if got := patchStartTimes("", defaultServerRegions, shared); got != nil {
+		t.Fatalf("start times without a start date = %v, want nil", got)
+	}
+	for _, raw := range []string{"4", "24:00", "11:00 CET"} {
+		if _, err := parsePatchStart(raw); err == nil {
+			t.Errorf("parsePatchStart(%q) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestRunSyncEmitsStartTimes(t *testing.T) {
+	dir := t.TempDir()
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		ServerRegions:   []ServerRegion{{ID: "sea", Offset: 480}},
+		PatchStart:      "10:30",
+		DryRun:          true,
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+	}
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	patch := result.AllPatches[0]
+	if want := map[string]string{"sea": patch.StartDate + "T10:30:00+08:00"}; !reflect.DeepEqual(patch.StartTimes, want) {
+		t.Fatalf("start times = %v, want %v", patch.StartTimes, want)
+	}
+
+	cfg.PatchStart = "late"
+	if _, err := RunSync(t.Context(), cfg); errorCode(err) != errCodeConfig {
+		t.Fatalf("RunSync() with invalid patch start = %v, want %s", err, errCodeConfig)
+	}
+}
@@ -0,0 +1,118 @@
+package patchsync
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const requestIDHeader = "X-Request-Id"
+
+type requestIDKey struct{}
+
+type accessLogRecord struct {
+	Time       string `json:"time"`
+	RequestID  string `json:"requestId"`
+	RemoteAddr string `json:"remoteAddr"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Status     int    `json:"status"`
+	Bytes      int    `json:"bytes"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// incomingRequestID accepts a caller-provided id only if it is short and printable, so it is safe to echo into logs.
+func incomingRequestID(r *http.Request) string {
+	id := strings.TrimSpace(r.Header.Get(requestIDHeader))
+	if id == "" || len(id) > 64 {
+		return ""
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return ""
+		}
+	}
+	return id
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+type accessLogResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *accessLogResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogResponseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.bytes += n
+	return n, err
+}
+
+// Hijack lets /ws take over the connection through the access log.
+func (w *accessLogResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// withRequestID tags every request with an id (echoed in X-Request-Id and the JSON body) and,
+// when accessLog is set, prints one JSON access-log line per request.
+func withRequestID(next http.Handler, accessLog bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := incomingRequestID(r)
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+		if !accessLog {
+			next.ServeHTTP(w, r)
+			return
+		}
+		started := time.Now()
+		recorder := &accessLogResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		line, _ := json.Marshal(accessLogRecord{
+			Time:       started.UTC().Format(time.RFC3339),
+			RequestID:  id,
+			RemoteAddr: clientIP(r),
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Status:     recorder.status,
+			Bytes:      recorder.bytes,
+			DurationMs: time.Since(started).Milliseconds(),
+		})
+		fmt.Fprintln(os.Stdout, string(line))
+	})
+}
@@ -0,0 +1,45 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithRequestIDTagsResponses(t *testing.T) {
+	var seen string
+	handler := withRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestIDFromContext(r.Context())
+		writeJSON(w, http.StatusOK, syncResponse{OK: true, Message: "ok"})
+	}), false)
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set(requestIDHeader, "client-abc")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var response syncResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if seen != "client-abc" || rec.Header().Get(requestIDHeader) != "client-abc" || response.RequestID != "client-abc" {
+		t.Fatalf("incoming id not propagated: ctx=%q header=%q body=%q", seen, rec.Header().Get(requestIDHeader), response.RequestID)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set(requestIDHeader, "bad id\n")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if seen == "" || strings.ContainsAny(seen, " \n") || rec.Header().Get(requestIDHeader) != seen {
+		t.Fatalf("expected a generated id, got %q", seen)
+	}
+}
+
+func TestAppendSyncLogTagsRequestID(t *testing.T) {
+	logs := syncLog{requestID: "req-1"}
+	appendSyncLog(&logs, "fetch %s", "Data")
+	if len(logs.lines) != 1 || !strings.HasSuffix(logs.lines[0], "] [req-1] fetch Data") {
+		t.Fatalf("unexpected log lines %v", logs.lines)
+	}
+}
@@ -0,0 +1,49 @@
+package patchsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// generatedSchemaVersion is written to GENERATED_PATCHES_META.schemaVersion. Bump it whenever the patch format
+// on disk changes, and add the migration from the previous version to generatedMigrations.
+const generatedSchemaVersion = 1
+
+// generatedMigrations upgrade the raw patch objects of a generated file by one schema version, keyed by the
+// version they upgrade from. Files written before schemaVersion existed are version 0.
+var generatedMigrations = map[int]func(patches []map[string]any) error{
+	// Version 1 only added schemaVersion; the patches themselves did not change.
+	0: func([]map[string]any) error { return nil },
+}
+
+// migrateGeneratedPatches upgrades a GENERATED_PATCHES array written with schema version to the current one, so
+// renamed or restructured fields are carried over instead of being dropped by json.Unmarshal. Files from a newer
+// patchsync are refused for the same reason.
+func migrateGeneratedPatches(block []byte, version int) ([]byte, error) {
+	if version > generatedSchemaVersion {
+		return nil, fmt.Errorf("schema version %d is newer than this patchsync supports (%d)", version, generatedSchemaVersion)
+	}
+	if version < 0 {
+		return nil, fmt.Errorf("invalid schema version %d", version)
+	}
+	if version == generatedSchemaVersion {
+		return block, nil
+	}
+	var patches []map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(block))
+	decoder.UseNumber()
+	if err := decoder.Decode(&patches); err != nil {
+		return nil, err
+	}
+	for from := version; from < generatedSchemaVersion; from++ {
+		migrate, ok := generatedMigrations[from]
+		if !ok {
+			return nil, fmt.Errorf("no migration from schema version %d", from)
+		}
+		if err := migrate(patches); err != nil {
+			return nil, fmt.Errorf("migrate schema version %d to %d: %w", from, from+1, err)
+		}
+	}
+	return json.Marshal(patches)
+}
@@ -0,0 +1,48 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadGeneratedPatchesMigratesOlderSchemas(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wuwa.generated.js")
+	writeGeneratedForTest(t, path, "2026-03-08T10:00:00Z")
+	if meta, err := readGeneratedMeta(path); err != nil || meta.SchemaVersion != generatedSchemaVersion {
+		t.Fatalf("written schemaVersion = %d, %v; want %d", meta.SchemaVersion, err, generatedSchemaVersion)
+	}
+
+	legacy := `export const GENERATED_PATCHES = [{"id": "3.4", "patch": "3.4", "title": "Old name", "sources": []}];
+export const GENERATED_PATCHES_META = {"gameId": "wuthering-waves", "sheets": []};
+`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	original := generatedMigrations[0]
+	t.Cleanup(func() { generatedMigrations[0] = original })
+	generatedMigrations[0] = func(patches []map[string]any) error {
+		for _, patch := range patches {
+			patch["versionName"] = patch["title"]
+			delete(patch, "title")
+		}
+		return nil
+	}
+	patches, err := readGeneratedPatches(path)
+	if err != nil {
+		t.Fatalf("readGeneratedPatches() error = %v", err)
+	}
+	if len(patches) != 1 || patches[0].VersionName != "Old name" {
+		t.Fatalf("migrated patches = %+v, want the renamed field carried over", patches)
+	}
+
+	newer := strings.Replace(legacy, `{"gameId"`, `{"schemaVersion": 99, "gameId"`, 1)
+	if err := os.WriteFile(path, []byte(newer), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readGeneratedPatches(path); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Fatalf("reading a newer schema error = %v, want a refusal", err)
+	}
+}
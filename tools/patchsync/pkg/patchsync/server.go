@@ -0,0 +1,188 @@
+package patchsync
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const defaultDrainTimeout = 30 * time.Second
+
+type serverOptions struct {
+	Addr         string
+	DrainTimeout time.Duration
+	TLSConfig    *tls.Config
+	Jobs         *jobGroup
+}
+
+// jobGroup tracks work that outlives its HTTP request. /ws jobs run on a hijacked connection, which
+// http.Server.Shutdown neither waits for nor cancels, so serveUntilDone drains them through the group. A nil
+// group tracks nothing.
+type jobGroup struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func newJobGroup() *jobGroup {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &jobGroup{ctx: ctx, cancel: cancel}
+}
+
+// track registers a job whose context cancel stops it. It returns false once the group is shutting down;
+// otherwise the caller must call done when the job returns.
+func (g *jobGroup) track(cancel context.CancelFunc) (done func(), ok bool) {
+	if g == nil {
+		return func() {}, true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return nil, false
+	}
+	g.wg.Add(1)
+	stop := context.AfterFunc(g.ctx, cancel)
+	return func() {
+		stop()
+		g.wg.Done()
+	}, true
+}
+
+// shutdown refuses new jobs, cancels the running ones and waits for them until ctx is done.
+func (g *jobGroup) shutdown(ctx context.Context) error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+	g.cancel()
+	finished := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+const unixAddrPrefix = "unix://"
+
+func listenNetwork(addr string) (string, string) {
+	if path, ok := strings.CutPrefix(strings.TrimSpace(addr), unixAddrPrefix); ok {
+		return "unix", path
+	}
+	return "tcp", addr
+}
+
+// listenUnix replaces a socket left behind by a previous run, but refuses to delete any other kind of file.
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o660); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("chmod socket: %w", err)
+	}
+	return listener, nil
+}
+
+func runServer(ctx context.Context, handler http.Handler, opts serverOptions) error {
+	network, address := listenNetwork(opts.Addr)
+	var listener net.Listener
+	var err error
+	if network == "unix" {
+		listener, err = listenUnix(address)
+	} else {
+		listener, err = net.Listen(network, address)
+	}
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", opts.Addr, err)
+	}
+	if opts.TLSConfig != nil {
+		listener = tls.NewListener(listener, opts.TLSConfig)
+	}
+	signalCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return serveUntilDone(signalCtx, handler, listener, opts.DrainTimeout, opts.Jobs)
+}
+
+// serveUntilDone serves until ctx is done, then stops accepting connections and waits up to
+// drainTimeout for in-flight requests. Requests still running after that have their context
+// cancelled, which stops syncs at the next fetch instead of in the middle of writing output.
+// Jobs in the group are cancelled right away and get the same drainTimeout to return.
+func serveUntilDone(ctx context.Context, handler http.Handler, listener net.Listener, drainTimeout time.Duration, jobs *jobGroup) error {
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	server := &http.Server{
+		Handler: handler,
+		BaseContext: func(net.Listener) context.Context {
+			return requestCtx
+		},
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	if drainTimeout <= 0 {
+		drainTimeout = defaultDrainTimeout
+	}
+	fmt.Printf("shutting down; waiting up to %s for in-flight requests\n", drainTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	jobsDrained := make(chan error, 1)
+	go func() {
+		jobsDrained <- jobs.shutdown(shutdownCtx)
+	}()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		cancelRequests()
+		if closeErr := server.Close(); closeErr != nil {
+			return fmt.Errorf("close server: %w", closeErr)
+		}
+		return fmt.Errorf("drain timed out: %w", err)
+	}
+	if err := <-jobsDrained; err != nil {
+		cancelRequests()
+		if closeErr := server.Close(); closeErr != nil {
+			return fmt.Errorf("close server: %w", closeErr)
+		}
+		return fmt.Errorf("drain timed out waiting for socket jobs: %w", err)
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	fmt.Println("patchsync service stopped")
+	return nil
+}
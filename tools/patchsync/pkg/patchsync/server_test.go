@@ -0,0 +1,117 @@
+package patchsync
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServeUntilDoneDrainsInFlightRequests(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serveUntilDone(ctx, handler, listener, time.Second, nil)
+	}()
+
+	response := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			response <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		response <- string(body)
+	}()
+	<-started
+	cancel()
+	if got := <-response; got != "done" {
+		t.Fatalf("in-flight response = %q, want done", got)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("serveUntilDone() error = %v", err)
+	}
+}
+
+func TestServeUntilDoneCancelsAfterDrainTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		close(cancelled)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serveUntilDone(ctx, handler, listener, 50*time.Millisecond, nil)
+	}()
+	go http.Get("http://" + listener.Addr().String())
+	<-started
+	cancel()
+	if err := <-served; err == nil {
+		t.Fatalf("expected drain timeout error")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatalf("request context was not cancelled after the drain timeout")
+	}
+}
+
+func TestServeUntilDoneOnUnixSocket(t *testing.T) {
+	network, path := listenNetwork("unix://" + t.TempDir() + "/patchsync.sock")
+	if network != "unix" {
+		t.Fatalf("network = %s, want unix", network)
+	}
+	listener, err := listenUnix(path)
+	if err != nil {
+		t.Fatalf("listenUnix() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serveUntilDone(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "ok")
+		}), listener, time.Second, nil)
+	}()
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://patchsync/health")
+	if err != nil {
+		t.Fatalf("request over unix socket failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Fatalf("body = %q, want ok", body)
+	}
+	cancel()
+	if err := <-served; err != nil {
+		t.Fatalf("serveUntilDone() error = %v", err)
+	}
+	if _, err := listenUnix(t.TempDir()); err == nil {
+		t.Fatalf("expected an error for a path that is not a socket")
+	}
+}
@@ -0,0 +1,220 @@
+package patchsync
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	defaultServiceName    = "patchsync"
+	defaultSystemdUnitDir = "/etc/systemd/system"
+)
+
+// serviceOptions describe one installed service. Args are the serve flags passed on to "service run".
+type serviceOptions struct {
+	Name    string
+	WorkDir string
+	UnitDir string
+	Exe     string
+	Print   bool
+	Args    []string
+}
+
+// runServiceCommandLine runs a service management command; tests replace it.
+var runServiceCommandLine = func(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s failed: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+func runServiceCommand(args []string) int {
+	if len(args) == 0 || (args[0] != "install" && args[0] != "uninstall") {
+		fmt.Fprintln(os.Stderr, "usage: patchsync service install|uninstall [flags] [-- serve flags] | service run [--workdir dir] [serve flags]")
+		return 2
+	}
+	fs := flag.NewFlagSet("service "+args[0], flag.ContinueOnError)
+	opts := serviceOptions{}
+	fs.StringVar(&opts.Name, "name", defaultServiceName, "Service (systemd unit or Windows task) name")
+	fs.StringVar(&opts.UnitDir, "unit-dir", defaultSystemdUnitDir, "Directory the systemd unit is written to")
+	if args[0] == "install" {
+		fs.StringVar(&opts.WorkDir, "workdir", "", "Working directory of the service, where .env is looked up (default: the current directory)")
+		fs.BoolVar(&opts.Print, "print", false, "Print the unit or task command instead of installing it")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	opts.Args = fs.Args()
+
+	var err error
+	if args[0] == "install" {
+		if err = opts.resolve(); err == nil {
+			err = installService(runtime.GOOS, opts, os.Stdout)
+		}
+	} else {
+		err = uninstallService(runtime.GOOS, opts)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "service %s failed: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// serviceRunArgs handles a leading --workdir of "service run" and returns the command line Main continues
+// with: the serve mode with the remaining flags, run from the service's working directory.
+func serviceRunArgs(args []string) ([]string, error) {
+	workDir := ""
+	if len(args) > 0 && strings.HasPrefix(args[0], "--workdir=") {
+		workDir, args = strings.TrimPrefix(args[0], "--workdir="), args[1:]
+	} else if len(args) > 1 && args[0] == "--workdir" {
+		workDir, args = args[1], args[2:]
+	}
+	if strings.TrimSpace(workDir) != "" {
+		if err := os.Chdir(workDir); err != nil {
+			return nil, fmt.Errorf("service run: %w", err)
+		}
+	}
+	return append([]string{"--serve"}, args...), nil
+}
+
+func (o *serviceOptions) resolve() error {
+	if strings.TrimSpace(o.Name) == "" || strings.ContainsAny(o.Name, `/\ `) {
+		return fmt.Errorf("invalid --name %q", o.Name)
+	}
+	if o.Exe == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		if strings.Contains(exe, "go-build") {
+			return errors.New("the running binary is a temporary go run build; go build patchsync and install from the binary")
+		}
+		o.Exe = exe
+	}
+	if strings.TrimSpace(o.WorkDir) == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		o.WorkDir = cwd
+	}
+	workDir, err := filepath.Abs(o.WorkDir)
+	if err != nil {
+		return err
+	}
+	o.WorkDir = workDir
+	return nil
+}
+
+func installService(goos string, opts serviceOptions, out io.Writer) error {
+	switch goos {
+	case "linux":
+		unit := renderSystemdUnit(opts)
+		if opts.Print {
+			_, err := io.WriteString(out, unit)
+			return err
+		}
+		path := filepath.Join(opts.UnitDir, opts.Name+".service")
+		if err := os.WriteFile(path, []byte(unit), 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Wrote %s\n", path)
+		if err := runServiceCommandLine("systemctl", "daemon-reload"); err != nil {
+			return err
+		}
+		return runServiceCommandLine("systemctl", "enable", "--now", opts.Name+".service")
+	case "windows":
+		create := windowsTaskArgs(opts)
+		if opts.Print {
+			_, err := fmt.Fprintf(out, "schtasks %s\n", strings.Join(create, " "))
+			return err
+		}
+		if err := runServiceCommandLine("schtasks", create...); err != nil {
+			return err
+		}
+		return runServiceCommandLine("schtasks", "/Run", "/TN", opts.Name)
+	}
+	return fmt.Errorf("services are supported on linux (systemd) and windows, not %s", goos)
+}
+
+func uninstallService(goos string, opts serviceOptions) error {
+	switch goos {
+	case "linux":
+		if err := runServiceCommandLine("systemctl", "disable", "--now", opts.Name+".service"); err != nil {
+			return err
+		}
+		if err := os.Remove(filepath.Join(opts.UnitDir, opts.Name+".service")); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return runServiceCommandLine("systemctl", "daemon-reload")
+	case "windows":
+		// Ending a task that is not running fails, which must not keep it installed.
+		_ = runServiceCommandLine("schtasks", "/End", "/TN", opts.Name)
+		return runServiceCommandLine("schtasks", "/Delete", "/F", "/TN", opts.Name)
+	}
+	return fmt.Errorf("services are supported on linux (systemd) and windows, not %s", goos)
+}
+
+func serviceRunCommandLine(opts serviceOptions) []string {
+	return append([]string{opts.Exe, "service", "run", "--workdir", opts.WorkDir}, opts.Args...)
+}
+
+// renderSystemdUnit runs the serve mode until stopped. SIGTERM drains in-flight syncs and SIGHUP re-reads
+// the env files and the token file, so stop and reload map onto them directly.
+func renderSystemdUnit(opts serviceOptions) string {
+	quoted := []string{}
+	for _, arg := range serviceRunCommandLine(opts) {
+		quoted = append(quoted, systemdQuote(arg))
+	}
+	lines := []string{
+		"[Unit]",
+		"Description=patchsync spreadsheet sync service",
+		"Wants=network-online.target",
+		"After=network-online.target",
+		"",
+		"[Service]",
+		"Type=simple",
+		"WorkingDirectory=" + strings.ReplaceAll(opts.WorkDir, "%", "%%"),
+		"ExecStart=" + strings.Join(quoted, " "),
+		"ExecReload=/bin/kill -HUP $MAINPID",
+		"Restart=on-failure",
+		"RestartSec=10",
+		"",
+		"[Install]",
+		"WantedBy=multi-user.target",
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func systemdQuote(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\$;") {
+		return arg
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$")
+	return `"` + replacer.Replace(arg) + `"`
+}
+
+// windowsTaskArgs registers the serve mode as a scheduled task that starts at boot as SYSTEM. The module has
+// no Windows service control bindings, and a task needs none while still surviving logoff and reboots.
+func windowsTaskArgs(opts serviceOptions) []string {
+	quoted := []string{}
+	for _, arg := range serviceRunCommandLine(opts) {
+		if arg == "" || strings.ContainsAny(arg, " \t\"") {
+			arg = `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+		}
+		quoted = append(quoted, arg)
+	}
+	return []string{"/Create", "/F", "/TN", opts.Name, "/SC", "ONSTART", "/RU", "SYSTEM", "/RL", "HIGHEST", "/TR", strings.Join(quoted, " ")}
+}
@@ -0,0 +1,101 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func stubServiceCommands(t *testing.T) *[]string {
+	t.Helper()
+	calls := []string{}
+	previous := runServiceCommandLine
+	runServiceCommandLine = func(name string, args ...string) error {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		return nil
+	}
+	t.Cleanup(func() { runServiceCommandLine = previous })
+	return &calls
+}
+
+func TestInstallSystemdService(t *testing.T) {
+	calls := stubServiceCommands(t)
+	unitDir := t.TempDir()
+	opts := serviceOptions{
+		Name:    "patchsync",
+		WorkDir: "/srv/gacha bookkeeper",
+		UnitDir: unitDir,
+		Exe:     "/usr/local/bin/patchsync",
+		Args:    []string{"--addr", "127.0.0.1:8787", "--allowed-origins", "https://bookkeeper.example.com, https://ops.example.com"},
+	}
+	var out strings.Builder
+	if err := installService("linux", opts, &out); err != nil {
+		t.Fatalf("installService() error = %v", err)
+	}
+	unit, err := os.ReadFile(filepath.Join(unitDir, "patchsync.service"))
+	if err != nil {
+		t.Fatalf("read unit: %v", err)
+	}
+	for _, want := range []string{
+		"WorkingDirectory=/srv/gacha bookkeeper",
+		`ExecStart=/usr/local/bin/patchsync service run --workdir "/srv/gacha bookkeeper" --addr 127.0.0.1:8787 --allowed-origins "https://bookkeeper.example.com, https://ops.example.com"`,
+		"ExecReload=/bin/kill -HUP $MAINPID",
+		"WantedBy=multi-user.target",
+	} {
+		if !strings.Contains(string(unit), want) {
+			t.Fatalf("unit is missing %q:\n%s", want, unit)
+		}
+	}
+	if !slices.Equal(*calls, []string{"systemctl daemon-reload", "systemctl enable --now patchsync.service"}) {
+		t.Fatalf("unexpected commands %v", *calls)
+	}
+
+	*calls = nil
+	if err := uninstallService("linux", opts); err != nil {
+		t.Fatalf("uninstallService() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(unitDir, "patchsync.service")); !os.IsNotExist(err) {
+		t.Fatalf("unit not removed: %v", err)
+	}
+	if !slices.Equal(*calls, []string{"systemctl disable --now patchsync.service", "systemctl daemon-reload"}) {
+		t.Fatalf("unexpected commands %v", *calls)
+	}
+}
+
+func TestInstallWindowsTask(t *testing.T) {
+	calls := stubServiceCommands(t)
+	opts := serviceOptions{
+		Name:    "patchsync",
+		WorkDir: `C:\Users\ops\gacha bookkeeper`,
+		Exe:     `C:\Tools\patchsync.exe`,
+		Args:    []string{"--addr", "127.0.0.1:8787"},
+	}
+	if err := installService("windows", opts, &strings.Builder{}); err != nil {
+		t.Fatalf("installService() error = %v", err)
+	}
+	want := `schtasks /Create /F /TN patchsync /SC ONSTART /RU SYSTEM /RL HIGHEST /TR C:\Tools\patchsync.exe service run --workdir "C:\Users\ops\gacha bookkeeper" --addr 127.0.0.1:8787`
+	if len(*calls) != 2 || (*calls)[0] != want || (*calls)[1] != "schtasks /Run /TN patchsync" {
+		t.Fatalf("unexpected commands %q", *calls)
+	}
+	if err := installService("darwin", opts, &strings.Builder{}); err == nil {
+		t.Fatalf("expected an error for an unsupported OS")
+	}
+}
+
+func TestServiceRunArgs(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(t.TempDir())
+	args, err := serviceRunArgs([]string{"--workdir", dir, "--addr", "127.0.0.1:9000"})
+	if err != nil {
+		t.Fatalf("serviceRunArgs() error = %v", err)
+	}
+	if !slices.Equal(args, []string{"--serve", "--addr", "127.0.0.1:9000"}) {
+		t.Fatalf("unexpected args %v", args)
+	}
+	cwd, _ := os.Getwd()
+	if resolved, _ := filepath.EvalSymlinks(dir); cwd != resolved && cwd != dir {
+		t.Fatalf("working directory = %s, want %s", cwd, dir)
+	}
+}
@@ -0,0 +1,121 @@
+package patchsync
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// sheetPatterns is the "sheets" block of an overrides file: regular expressions matched against tab names,
+// plus Aux names tried before the game's default Data/Summary tab names.
+type sheetPatterns struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+	Aux     []string `json:"aux"`
+}
+
+// sheetNameFilter decides which discovered tabs are patch sheets: version-like names (N.N) and names matching
+// an include pattern, minus names matching an exclude pattern or listed by --exclude-sheets.
+// The zero value accepts exactly the version-like names.
+type sheetNameFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	names   map[string]struct{}
+}
+
+func compileSheetPatterns(kind string, patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sheet %s pattern %q: %w", kind, pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func newSheetNameFilter(patterns sheetPatterns, excludeNames []string) (sheetNameFilter, error) {
+	include, err := compileSheetPatterns("include", patterns.Include)
+	if err != nil {
+		return sheetNameFilter{}, err
+	}
+	exclude, err := compileSheetPatterns("exclude", patterns.Exclude)
+	if err != nil {
+		return sheetNameFilter{}, err
+	}
+	filter := sheetNameFilter{include: include, exclude: exclude}
+	for _, name := range excludeNames {
+		if normalized := strings.ToLower(normalizeSheetNameForMatch(name)); normalized != "" {
+			if filter.names == nil {
+				filter.names = map[string]struct{}{}
+			}
+			filter.names[normalized] = struct{}{}
+		}
+	}
+	return filter, nil
+}
+
+func (f sheetNameFilter) excluded(raw string) bool {
+	normalized := normalizeSheetNameForMatch(raw)
+	if _, ok := f.names[strings.ToLower(normalized)]; ok {
+		return true
+	}
+	for _, re := range f.exclude {
+		if re.MatchString(normalized) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f sheetNameFilter) match(raw string) bool {
+	if f.excluded(raw) {
+		return false
+	}
+	if isVersionLikeSheetName(raw) {
+		return true
+	}
+	normalized := normalizeSheetNameForMatch(raw)
+	for _, re := range f.include {
+		if re.MatchString(normalized) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutExcluded drops excluded names from probe results, which only ever contain N.N names.
+func (f sheetNameFilter) withoutExcluded(names []string) []string {
+	kept := make([]string, 0, len(names))
+	for _, name := range names {
+		if !f.excluded(name) {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// fingerprint identifies a non-default filter, so cached discoveries made with other patterns are not reused.
+func (f sheetNameFilter) fingerprint() string {
+	if len(f.include) == 0 && len(f.exclude) == 0 && len(f.names) == 0 {
+		return ""
+	}
+	parts := []string{}
+	for _, re := range f.include {
+		parts = append(parts, "+"+re.String())
+	}
+	for _, re := range f.exclude {
+		parts = append(parts, "-"+re.String())
+	}
+	names := make([]string, 0, len(f.names))
+	for name := range f.names {
+		names = append(names, "!"+name)
+	}
+	sort.Strings(names)
+	return contentHash(append(parts, names...)...)[:12]
+}
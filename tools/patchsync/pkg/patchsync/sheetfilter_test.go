@@ -0,0 +1,88 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSheetNameFilter(t *testing.T) {
+	filter, err := newSheetNameFilter(sheetPatterns{
+		Include: []string{`^v\d+\.\d+$`},
+		Exclude: []string{`(?i)\bbeta\b`},
+	}, []string{"2.1  JP", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		"2.0":      true,
+		"2.1 JP":   false,
+		"2.1 jp":   false,
+		"v2.2":     true,
+		"2.3 Beta": false,
+		"Data":     false,
+		"v2.x":     false,
+	} {
+		if got := filter.match(name); got != want {
+			t.Errorf("match(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if got := filter.withoutExcluded([]string{"2.0", "2.1 JP", "2.3 beta"}); !reflect.DeepEqual(got, []string{"2.0"}) {
+		t.Fatalf("withoutExcluded() = %v", got)
+	}
+
+	var zero sheetNameFilter
+	if !zero.match("2.1 JP") || zero.match("v2.2") || zero.fingerprint() != "" {
+		t.Fatalf("zero filter should accept exactly the version-like names")
+	}
+	if filter.fingerprint() == "" {
+		t.Fatalf("expected a fingerprint for a configured filter")
+	}
+	if _, err := newSheetNameFilter(sheetPatterns{Include: []string{"("}}, nil); err == nil {
+		t.Fatalf("expected an invalid include pattern to fail")
+	}
+}
+
+func TestVersionPrefixAcceptsLeadingV(t *testing.T) {
+	if got := canonicalPatchID("v2.1"); got != "2.1" {
+		t.Fatalf("canonicalPatchID(v2.1) = %q", got)
+	}
+	names := []string{"v2.10", "2.2", "V2.1"}
+	sortVersionStrings(names)
+	if !reflect.DeepEqual(names, []string{"V2.1", "2.2", "v2.10"}) {
+		t.Fatalf("sortVersionStrings() = %v", names)
+	}
+}
+
+func TestLocalSheetFetcherAppliesSheetPatterns(t *testing.T) {
+	dir := t.TempDir()
+	sheetDir := filepath.Join(dir, "abc")
+	if err := os.MkdirAll(sheetDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"2.0.csv", "v2.1.csv", "2.2 Old.csv", "Data.csv"} {
+		if err := os.WriteFile(filepath.Join(sheetDir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	overridesPath := filepath.Join(dir, "game.json")
+	if err := os.WriteFile(overridesPath, []byte(`{"sheets":{"include":["^v\\d+\\.\\d+$"],"exclude":["Old$"]}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gameCfg, err := readGameConfig(overridesPath, gameIDWuwa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter, err := newSheetNameFilter(gameCfg.Sheets, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := localSheetFetcher{dir: dir, filter: filter}.ListSheets(t.Context(), "abc")
+	if err != nil || !reflect.DeepEqual(names, []string{"2.0", "v2.1"}) {
+		t.Fatalf("ListSheets() = %v, %v", names, err)
+	}
+	if len(gameCfg.Patches) != 0 {
+		t.Fatalf("readGameConfig() patches = %v, want none", gameCfg.Patches)
+	}
+}
@@ -0,0 +1,124 @@
+package patchsync
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// editTabCache remembers the tabs of each spreadsheet's edit page, or the error fetching it, for the
+// lifetime of one fetcher, so discovery, gid lookups, and auxiliary tab lookups share one request.
+// A nil cache stores nothing.
+type editTabCache struct {
+	mu   sync.Mutex
+	byID map[string]editTabsResult
+}
+
+type editTabsResult struct {
+	tabs []sheetTab
+	err  error
+}
+
+func newEditTabCache() *editTabCache {
+	return &editTabCache{byID: map[string]editTabsResult{}}
+}
+
+func (c *editTabCache) load(spreadsheetID string) (editTabsResult, bool) {
+	if c == nil {
+		return editTabsResult{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.byID[strings.TrimSpace(spreadsheetID)]
+	return result, ok
+}
+
+func (c *editTabCache) store(spreadsheetID string, result editTabsResult) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byID[strings.TrimSpace(spreadsheetID)] = result
+}
+
+func sheetExportCSVURL(spreadsheetID, gid string) string {
+	return fmt.Sprintf(
+		"https://docs.google.com/spreadsheets/d/%s/export?format=csv&gid=%s",
+		url.PathEscape(strings.TrimSpace(spreadsheetID)),
+		url.QueryEscape(strings.TrimSpace(gid)),
+	)
+}
+
+// getEditSheetTabs returns the tabs on the edit page of a link-shared spreadsheet, fetching it at most once
+// per cache.
+func getEditSheetTabs(ctx context.Context, client *http.Client, cache *editTabCache, spreadsheetID string) ([]sheetTab, error) {
+	if cached, ok := cache.load(spreadsheetID); ok {
+		return cached.tabs, cached.err
+	}
+	editURL := fmt.Sprintf(
+		"https://docs.google.com/spreadsheets/d/%s/edit",
+		url.PathEscape(strings.TrimSpace(spreadsheetID)),
+	)
+	var result editTabsResult
+	body, err := fetchText(ctx, client, editURL)
+	if err == nil {
+		result.tabs, err = parseSheetTabs(body)
+	}
+	result.err = err
+	cache.store(spreadsheetID, result)
+	return result.tabs, result.err
+}
+
+// sheetTabGIDs maps tab names to gids, skipping tabs whose gid the edit page did not show.
+func sheetTabGIDs(tabs []sheetTab) map[string]string {
+	gidByName := map[string]string{}
+	for _, tab := range tabs {
+		if _, exists := gidByName[tab.Name]; !exists && tab.GID != "" {
+			gidByName[tab.Name] = tab.GID
+		}
+	}
+	return gidByName
+}
+
+// lookupSheetGID finds sheetName in gidByName, falling back to whitespace-insensitive matching.
+func lookupSheetGID(gidByName map[string]string, sheetName string) (string, bool) {
+	if gid, ok := gidByName[sheetName]; ok {
+		return gid, true
+	}
+	normalizedTarget := normalizeSheetNameForMatch(sheetName)
+	for name, gid := range gidByName {
+		if normalizeSheetNameForMatch(name) == normalizedTarget {
+			return gid, true
+		}
+	}
+	return "", false
+}
+
+// parseSheetGIDs reads --sheet-gids entries of the form name=gid, for example "2.1 (JP)=123456,Data=0".
+func parseSheetGIDs(raw string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		idx := strings.LastIndex(entry, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid sheet gid %q (use name=gid)", entry)
+		}
+		name, gid := strings.TrimSpace(entry[:idx]), strings.TrimSpace(entry[idx+1:])
+		if _, err := strconv.ParseInt(gid, 10, 64); err != nil || name == "" {
+			return nil, fmt.Errorf("invalid sheet gid %q (use name=gid)", entry)
+		}
+		result[name] = gid
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
@@ -0,0 +1,125 @@
+package patchsync
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseSheetGIDs(t *testing.T) {
+	got, err := parseSheetGIDs(" 2.1 (JP)=123 , Data=0,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"2.1 (JP)": "123", "Data": "0"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseSheetGIDs() = %v, want %v", got, want)
+	}
+	if got, err := parseSheetGIDs(""); err != nil || got != nil {
+		t.Fatalf("parseSheetGIDs(empty) = %v, %v", got, err)
+	}
+	for _, raw := range []string{"2.1", "=5", "2.1=abc"} {
+		if _, err := parseSheetGIDs(raw); err == nil {
+			t.Errorf("parseSheetGIDs(%q) expected an error", raw)
+		}
+	}
+}
+
+func TestGvizSheetFetcherFetchesByGID(t *testing.T) {
+	var editRequests, gvizRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/spreadsheets/d/abc/edit":
+			editRequests.Add(1)
+			fmt.Fprint(w, `<div class="docs-sheet-tab" id="sheet-button-77"><div class="docs-sheet-tab-caption">2.1 #JP</div></div>`)
+		case "/spreadsheets/d/abc/export":
+			switch r.URL.Query().Get("gid") {
+			case "77":
+				fmt.Fprint(w, "from export")
+			case "5":
+				fmt.Fprint(w, "configured")
+			default:
+				http.NotFound(w, r)
+			}
+		case "/spreadsheets/d/abc/gviz/tq":
+			gvizRequests.Add(1)
+			fmt.Fprint(w, "from gviz "+r.URL.Query().Get("sheet"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	transport, err := newGoogleBaseURLTransport(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := newGoogleSheetFetcher(&http.Client{Transport: transport}, nil)
+	fetcher.gviz.gids = map[string]string{"Data": "5"}
+
+	for sheet, want := range map[string]string{
+		"2.1 #JP": "from export",
+		"Data":    "configured",
+		"2.2":     "from gviz 2.2",
+	} {
+		body, err := fetcher.FetchCSV(t.Context(), "abc", sheet)
+		if err != nil || body != want {
+			t.Fatalf("FetchCSV(%q) = %q, %v, want %q", sheet, body, err, want)
+		}
+	}
+	if editRequests.Load() != 1 || gvizRequests.Load() != 1 {
+		t.Fatalf("edit requests = %d, gviz requests = %d, want 1 and 1", editRequests.Load(), gvizRequests.Load())
+	}
+}
+
+func TestPublishedSheetGIDsRefresh(t *testing.T) {
+	var pubRequests atomic.Int32
+	tabs := `items.push({name: "2.0", gid: "1"});`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/spreadsheets/d/e/2PACX-refresh/pubhtml":
+			pubRequests.Add(1)
+			fmt.Fprint(w, tabs)
+		case "/spreadsheets/d/e/2PACX-refresh/pub":
+			fmt.Fprint(w, "gid "+r.URL.Query().Get("gid"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	transport, err := newGoogleBaseURLTransport(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: transport}
+	t.Cleanup(func() { publishedSheetGIDCache.Delete("2PACX-refresh") })
+	previousRetry := publishedSheetGIDRetry
+	publishedSheetGIDRetry = 0
+	t.Cleanup(func() { publishedSheetGIDRetry = previousRetry })
+
+	if body, err := fetchPublishedSheetCSV(t.Context(), client, "2PACX-refresh", "2.0"); err != nil || body != "gid 1" {
+		t.Fatalf("fetch 2.0 = %q, %v", body, err)
+	}
+	// A tab published after the first discovery is found by refreshing on the miss.
+	tabs += `items.push({name: "2.1", gid: "2"});`
+	if body, err := fetchPublishedSheetCSV(t.Context(), client, "2PACX-refresh", "2.1"); err != nil || body != "gid 2" {
+		t.Fatalf("fetch 2.1 = %q, %v", body, err)
+	}
+	if pubRequests.Load() != 2 {
+		t.Fatalf("pubhtml requests = %d, want 2", pubRequests.Load())
+	}
+
+	publishedSheetGIDRetry = time.Hour
+	if _, err := fetchPublishedSheetCSV(t.Context(), client, "2PACX-refresh", "9.9"); err == nil {
+		t.Fatalf("expected a missing tab error")
+	}
+	if _, err := getPublishedSheetGIDs(t.Context(), client, "2PACX-refresh"); err != nil || pubRequests.Load() != 2 {
+		t.Fatalf("fresh cache refetched: %d requests, %v", pubRequests.Load(), err)
+	}
+	publishedSheetGIDCache.Store("2PACX-refresh", publishedSheetGIDs{gids: map[string]string{"2.0": "1"}, fetchedAt: time.Now().Add(-publishedSheetGIDTTL)})
+	if gids, err := getPublishedSheetGIDs(t.Context(), client, "2PACX-refresh"); err != nil || len(gids) != 2 || pubRequests.Load() != 3 {
+		t.Fatalf("expired cache not refreshed: %v, %d requests, %v", gids, pubRequests.Load(), err)
+	}
+}
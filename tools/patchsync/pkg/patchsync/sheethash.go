@@ -0,0 +1,105 @@
+package patchsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const defaultSheetHashPath = "tools/patchsync/logs/sheet-hashes.json"
+
+// sheetHashMu guards the hash file, which concurrent /sync-all games share and rewrite.
+var sheetHashMu sync.Mutex
+
+type sheetHashRecord struct {
+	Aux    string            `json:"aux"`
+	Sheets map[string]string `json:"sheets"`
+}
+
+func contentHash(parts ...string) string {
+	hasher := sha256.New()
+	for idx, part := range parts {
+		if idx > 0 {
+			hasher.Write([]byte{0})
+		}
+		hasher.Write([]byte(part))
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// syncSettings is everything besides the sheets themselves that shapes the generated patches. Its hash is part of
+// the aux hash, so changing any of it makes the next sync re-parse sheets whose content did not change.
+type syncSettings struct {
+	Overrides        gameConfig
+	Translations     map[string]map[string]string
+	Strictness       string
+	DecimalSeparator string
+	WIPMode          string
+	BaseOverlap      string
+	Regions          []ServerRegion
+	PatchStart       string
+}
+
+func (settings syncSettings) hash() string {
+	body, _ := json.Marshal(settings)
+	return contentHash(string(body))
+}
+
+func readSheetHashFile(path string) (map[string]sheetHashRecord, error) {
+	result := map[string]sheetHashRecord{}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return result, nil
+		}
+		return nil, err
+	}
+	if strings.TrimSpace(string(body)) == "" {
+		return result, nil
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parse sheet hash file: %w", err)
+	}
+	return result, nil
+}
+
+func readSheetHashes(path, gameID string) (sheetHashRecord, error) {
+	sheetHashMu.Lock()
+	defer sheetHashMu.Unlock()
+	all, err := readSheetHashFile(path)
+	if err != nil {
+		return sheetHashRecord{}, err
+	}
+	record := all[gameID]
+	if record.Sheets == nil {
+		record.Sheets = map[string]string{}
+	}
+	return record, nil
+}
+
+func writeSheetHashes(path, gameID string, record sheetHashRecord) error {
+	sheetHashMu.Lock()
+	defer sheetHashMu.Unlock()
+	all, err := readSheetHashFile(path)
+	if err != nil {
+		return err
+	}
+	all[gameID] = record
+	body, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal sheet hashes: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create sheet hash directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(body, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write sheet hashes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package patchsync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSkipExistingReparsesAfterSettingsChange(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	overridesDir := filepath.Join(dir, "overrides")
+	if err := os.MkdirAll(overridesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    overridesDir,
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		SkipExisting:    true,
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+	}
+	sync := func(step string) bool {
+		t.Helper()
+		result, err := RunSync(t.Context(), cfg)
+		if err != nil {
+			t.Fatalf("%s: RunSync() error = %v", step, err)
+		}
+		return strings.Contains(strings.Join(result.Logs, "\n"), "skip unchanged sheet 3.4 (content hash match)")
+	}
+
+	sync("initial")
+	if !sync("unchanged") {
+		t.Fatal("unchanged settings: sheet 3.4 was parsed again")
+	}
+	if err := os.WriteFile(filepath.Join(overridesDir, gameIDWuwa+".json"), []byte(`{"reconcile":"proportional"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if sync("overrides file") {
+		t.Fatal("changed overrides file: sheet 3.4 skipped by hash")
+	}
+	cfg.ParseStrictness = parseLenient
+	if sync("strictness flag") {
+		t.Fatal("changed --parse-strictness: sheet 3.4 skipped by hash")
+	}
+	if !sync("settled") {
+		t.Fatal("settled settings: sheet 3.4 was parsed again")
+	}
+}
+
+func TestWriteSheetHashesConcurrently(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hashes.json")
+	var wg sync.WaitGroup
+	for idx := range 8 {
+		wg.Go(func() {
+			gameID := fmt.Sprintf("game-%d", idx)
+			if err := writeSheetHashes(path, gameID, sheetHashRecord{Aux: gameID}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+	all, err := readSheetHashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 8 {
+		t.Fatalf("hash file has %d games after concurrent writes, want 8", len(all))
+	}
+}
@@ -0,0 +1,32 @@
+package patchsync
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// sheetFetchStat is the download size and timing of one sheet of a sync. Unlike phaseTiming it is recorded on
+// every run, so bloated tabs and slow fetches show up without --profile. ParseMS stays zero for sheets skipped
+// before parsing.
+type sheetFetchStat struct {
+	Sheet   string  `json:"sheet"`
+	Bytes   int     `json:"bytes"`
+	FetchMS float64 `json:"fetchMs"`
+	ParseMS float64 `json:"parseMs,omitempty"`
+}
+
+// writeSheetStatsReport lists the sheets largest first, with the totals last.
+func writeSheetStatsReport(w io.Writer, stats []sheetFetchStat) {
+	sorted := append([]sheetFetchStat{}, stats...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Bytes > sorted[j].Bytes })
+	total := sheetFetchStat{Sheet: "total"}
+	fmt.Fprintln(w, "Sheets:")
+	for _, stat := range sorted {
+		fmt.Fprintf(w, "  %-24s %10d bytes %10.1f ms fetch %10.1f ms parse\n", stat.Sheet, stat.Bytes, stat.FetchMS, stat.ParseMS)
+		total.Bytes += stat.Bytes
+		total.FetchMS += stat.FetchMS
+		total.ParseMS += stat.ParseMS
+	}
+	fmt.Fprintf(w, "  %-24s %10d bytes %10.1f ms fetch %10.1f ms parse\n", total.Sheet, total.Bytes, total.FetchMS, total.ParseMS)
+}
@@ -0,0 +1,57 @@
+package patchsync
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSyncRecordsSheetStats(t *testing.T) {
+	dir := t.TempDir()
+	sheets := map[string]string{"3.4": wuwaSheetCSV("3.4"), "3.5": wuwaSheetCSV("3.5") + "padding\n"}
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4", "3.5", "3.6"},
+		ContinueOnError: true,
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		DryRun:          true,
+		Fetcher:         fakeSheetFetcher{sheets: sheets},
+	}
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	if len(result.SheetStats) != 2 {
+		t.Fatalf("SheetStats = %+v, want the two fetched sheets", result.SheetStats)
+	}
+	logs := strings.Join(result.Logs, "\n")
+	for _, stat := range result.SheetStats {
+		if stat.Bytes != len(sheets[stat.Sheet]) || stat.FetchMS < 0 || stat.ParseMS < 0 {
+			t.Fatalf("stat for %s = %+v, want %d bytes", stat.Sheet, stat, len(sheets[stat.Sheet]))
+		}
+		if !strings.Contains(logs, fmt.Sprintf("fetched sheet %s: %d bytes in ", stat.Sheet, stat.Bytes)) {
+			t.Fatalf("logs do not report the fetch of %s:\n%s", stat.Sheet, logs)
+		}
+	}
+	if response := buildSyncResponseFromResult(result); len(response.SheetStats) != 2 {
+		t.Fatalf("response sheetStats = %+v", response.SheetStats)
+	}
+}
+
+func TestWriteSheetStatsReportListsLargestFirst(t *testing.T) {
+	var out bytes.Buffer
+	writeSheetStatsReport(&out, []sheetFetchStat{
+		{Sheet: "3.4", Bytes: 100, FetchMS: 10, ParseMS: 1},
+		{Sheet: "3.5", Bytes: 900, FetchMS: 20, ParseMS: 2},
+	})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[1], "3.5") || !strings.Contains(lines[3], "1000 bytes") {
+		t.Fatalf("report =\n%s", out.String())
+	}
+}
@@ -0,0 +1,264 @@
+package patchsync
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	sheetTabCaptionClass   = "docs-sheet-tab-caption"
+	sheetTabButtonIDPrefix = "sheet-button-"
+)
+
+type sheetTab struct {
+	Name string
+	GID  string
+}
+
+// describeFoundSheetNames lists what discovery did find, so a markup change is easy to tell apart from a renamed tab.
+func describeFoundSheetNames(names []string) string {
+	if len(names) == 0 {
+		return "found no tabs"
+	}
+	const shown = 10
+	if len(names) > shown {
+		return fmt.Sprintf("found %d tabs: %s, ...", len(names), strings.Join(names[:shown], ", "))
+	}
+	return fmt.Sprintf("found %d tabs: %s", len(names), strings.Join(names, ", "))
+}
+
+func hasClass(node *html.Node, class string) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == "class" {
+			for _, field := range strings.Fields(attr.Val) {
+				if field == class {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func attrValue(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func nodeText(node *html.Node) string {
+	var sb strings.Builder
+	for child := range node.Descendants() {
+		if child.Type == html.TextNode {
+			sb.WriteString(child.Data)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// parseSheetTabs returns every tab on a spreadsheet edit page, in page order. GID comes from the enclosing
+// sheet-button-<gid> element and is empty when the page has none.
+func parseSheetTabs(body string) ([]sheetTab, error) {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("parse edit page HTML: %w", err)
+	}
+	tabs := []sheetTab{}
+	for node := range doc.Descendants() {
+		if node.Type != html.ElementNode || !hasClass(node, sheetTabCaptionClass) {
+			continue
+		}
+		caption := nodeText(node)
+		if caption == "" {
+			continue
+		}
+		tab := sheetTab{Name: caption}
+		for parent := node.Parent; parent != nil; parent = parent.Parent {
+			if gid, ok := strings.CutPrefix(attrValue(parent, "id"), sheetTabButtonIDPrefix); ok {
+				if _, err := strconv.ParseInt(gid, 10, 64); err == nil {
+					tab.GID = gid
+				}
+				break
+			}
+		}
+		tabs = append(tabs, tab)
+	}
+	return tabs, nil
+}
+
+// parsePublishedSheetGIDsFromHTML maps tab names to gids on a pubhtml page. It reads the
+// items.push({...}) script literals first and falls back to the #gid= links of the sheet menu.
+func parsePublishedSheetGIDsFromHTML(body string) map[string]string {
+	result := map[string]string{}
+	for _, item := range scanPublishedSheetItems(body) {
+		name := strings.TrimSpace(html.UnescapeString(item["name"]))
+		gid := strings.TrimSpace(item["gid"])
+		if name == "" || gid == "" {
+			continue
+		}
+		if _, exists := result[name]; !exists {
+			result[name] = gid
+		}
+	}
+	if len(result) > 0 {
+		return result
+	}
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return result
+	}
+	for node := range doc.Descendants() {
+		if node.Type != html.ElementNode || node.Data != "a" {
+			continue
+		}
+		_, gid, ok := strings.Cut(attrValue(node, "href"), "#gid=")
+		name := nodeText(node)
+		if !ok || name == "" {
+			continue
+		}
+		if _, err := strconv.ParseInt(gid, 10, 64); err != nil {
+			continue
+		}
+		if _, exists := result[name]; !exists {
+			result[name] = gid
+		}
+	}
+	return result
+}
+
+// scanPublishedSheetItems reads the flat object literals passed to items.push(...). Keys may be
+// bare or quoted and values may be quoted strings, numbers, or other tokens, which are kept as text.
+func scanPublishedSheetItems(body string) []map[string]string {
+	const marker = "items.push("
+	items := []map[string]string{}
+	for rest := body; ; {
+		idx := strings.Index(rest, marker)
+		if idx < 0 {
+			return items
+		}
+		rest = strings.TrimLeft(rest[idx+len(marker):], " \t\r\n")
+		if !strings.HasPrefix(rest, "{") {
+			continue
+		}
+		item, consumed := scanObjectLiteral(rest[1:])
+		rest = rest[1+consumed:]
+		if len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+}
+
+func scanObjectLiteral(src string) (map[string]string, int) {
+	item := map[string]string{}
+	pos := 0
+	skipSpace := func() {
+		for pos < len(src) && strings.IndexByte(" \t\r\n,", src[pos]) >= 0 {
+			pos++
+		}
+	}
+	for {
+		skipSpace()
+		if pos >= len(src) || src[pos] == '}' {
+			return item, min(pos+1, len(src))
+		}
+		var key string
+		if src[pos] == '"' || src[pos] == '\'' {
+			value, n, ok := scanJSString(src[pos:])
+			if !ok {
+				return item, len(src)
+			}
+			key = value
+			pos += n
+		} else {
+			start := pos
+			for pos < len(src) && src[pos] != ':' && src[pos] != '}' && src[pos] != ',' {
+				pos++
+			}
+			key = strings.TrimSpace(src[start:pos])
+		}
+		skipSpace()
+		if pos >= len(src) || src[pos] != ':' {
+			continue
+		}
+		pos++
+		for pos < len(src) && strings.IndexByte(" \t\r\n", src[pos]) >= 0 {
+			pos++
+		}
+		if pos < len(src) && (src[pos] == '"' || src[pos] == '\'') {
+			value, n, ok := scanJSString(src[pos:])
+			if !ok {
+				return item, len(src)
+			}
+			item[key] = value
+			pos += n
+			continue
+		}
+		start := pos
+		depth := 0
+		for pos < len(src) {
+			c := src[pos]
+			if depth == 0 && (c == ',' || c == '}') {
+				break
+			}
+			switch c {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				depth--
+			}
+			pos++
+		}
+		item[key] = strings.TrimSpace(src[start:pos])
+	}
+}
+
+// scanJSString decodes the quoted JS string at the start of src and returns its length in bytes.
+func scanJSString(src string) (string, int, bool) {
+	quote := src[0]
+	var sb strings.Builder
+	for pos := 1; pos < len(src); {
+		c := src[pos]
+		switch {
+		case c == quote:
+			return sb.String(), pos + 1, true
+		case c == '\\' && pos+1 < len(src):
+			pos++
+			switch esc := src[pos]; esc {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'x', 'u':
+				width := 2
+				if esc == 'u' {
+					width = 4
+				}
+				if pos+width < len(src) {
+					if code, err := strconv.ParseUint(src[pos+1:pos+1+width], 16, 32); err == nil {
+						sb.WriteRune(rune(code))
+						pos += width
+						break
+					}
+				}
+				sb.WriteByte(esc)
+			default:
+				sb.WriteByte(esc)
+			}
+			pos++
+		default:
+			r, size := utf8.DecodeRuneInString(src[pos:])
+			sb.WriteRune(r)
+			pos += size
+		}
+	}
+	return "", len(src), false
+}
@@ -0,0 +1,54 @@
+package patchsync
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSheetTabs(t *testing.T) {
+	body := `<html><body><div id="sheet-tabs">
+<div class="docs-sheet-tab" id="sheet-button-0"><div class="goog-inline-block docs-sheet-tab-caption">1.0</div></div>
+<div class="docs-sheet-tab" id="sheet-button-1843"><div class="docs-sheet-tab-caption"><span>2.1 (STC)</span></div></div>
+<div class="docs-sheet-tab" id="sheet-button-x"><div class="docs-sheet-tab-caption">Data &amp; Notes</div></div>
+</div></body></html>`
+	tabs, err := parseSheetTabs(body)
+	if err != nil {
+		t.Fatalf("parseSheetTabs() error = %v", err)
+	}
+	want := []sheetTab{{Name: "1.0", GID: "0"}, {Name: "2.1 (STC)", GID: "1843"}, {Name: "Data & Notes"}}
+	if !reflect.DeepEqual(tabs, want) {
+		t.Fatalf("tabs = %v, want %v", tabs, want)
+	}
+}
+
+func TestParsePublishedSheetGIDsFromHTML(t *testing.T) {
+	script := `<script>var items = [];
+items.push({name: "2.0", pageUrl: "https:\/\/docs.google.com\/x?gid=12", gid: "12",initialSheet: (false)});
+items.push({ "name":'Data & Pulls', gid: 345 });
+items.push({name: "2.0", gid: "999"});
+</script>`
+	got := parsePublishedSheetGIDsFromHTML(script)
+	want := map[string]string{"2.0": "12", "Data & Pulls": "345"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("gids = %v, want %v", got, want)
+	}
+
+	menu := `<ul id="sheet-menu"><li><a href="#gid=0">1.0</a></li><li><a href="#gid=77">Summary</a></li><li><a href="#top">Top</a></li></ul>`
+	got = parsePublishedSheetGIDsFromHTML(menu)
+	want = map[string]string{"1.0": "0", "Summary": "77"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("menu fallback gids = %v, want %v", got, want)
+	}
+
+	if got := parsePublishedSheetGIDsFromHTML(`items.push({name: "unterminated`); len(got) != 0 {
+		t.Fatalf("expected no gids from truncated script, got %v", got)
+	}
+}
+
+func TestDescribeFoundSheetNames(t *testing.T) {
+	names := strings.Split("a,b,c,d,e,f,g,h,i,j,k,l", ",")
+	if got := describeFoundSheetNames(names); got != "found 12 tabs: a, b, c, d, e, f, g, h, i, j, ..." {
+		t.Fatalf("describeFoundSheetNames() = %q", got)
+	}
+}
@@ -0,0 +1,120 @@
+package patchsync
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// shopSheetCandidates are tried for games that keep an exchange shop tab. The tab has a header row starting
+// with "Item", optional "Cost" and "Currency" columns, and one column per patch holding the stock buyable
+// during that patch.
+var shopSheetCandidates = []string{"Exchange Shop", "Shop", "Shop Stock"}
+
+// ShopItem is one exchange shop entry of a patch. Reward is the currency key when the item is a currency
+// of the game, and Pulls the stock converted with the currency manifest. Shop items are inventory only:
+// the version sheets already count what the shop yields in their own sources.
+type ShopItem struct {
+	Item     string  `json:"item"`
+	Reward   string  `json:"reward,omitempty"`
+	Cost     float64 `json:"cost,omitempty"`
+	Currency string  `json:"currency,omitempty"`
+	Stock    float64 `json:"stock"`
+	Pulls    float64 `json:"pulls,omitempty"`
+}
+
+// currencyKeyForName matches a sheet label such as "Intertwined Fate" or "Masterless Starglitter" to a
+// currency of the game by key, display name, or reward field.
+func currencyKeyForName(gameID, name string) string {
+	want := normalizeRewardKey(name)
+	if want == "" {
+		return ""
+	}
+	for _, currency := range currenciesForGame(gameID) {
+		if normalizeRewardKey(currency.Key) == want || normalizeRewardKey(currency.Name) == want {
+			return currency.Key
+		}
+	}
+	unit := Rewards{}
+	if !unit.setMappedValue(want, 1) {
+		return ""
+	}
+	for _, currency := range currenciesForGame(gameID) {
+		if currency.value(unit) > 0 {
+			return currency.Key
+		}
+	}
+	return ""
+}
+
+func parseShopSheet(gameID, csvText string) (map[string][]ShopItem, error) {
+	reader := csv.NewReader(strings.NewReader(csvText))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("csv parse error: %w", err)
+	}
+
+	headerIdx := -1
+	for idx, record := range records {
+		if name := normalizeName(getCell(record, 0)); name == "item" || name == "items" {
+			headerIdx = idx
+			break
+		}
+	}
+	if headerIdx < 0 {
+		return nil, errors.New(`shop sheet has no "Item" header row`)
+	}
+	header := records[headerIdx]
+	patchCols := explicitDataSheetPatchColumns(header)
+	if len(patchCols) == 0 {
+		return nil, errors.New("shop sheet has no patch columns")
+	}
+	costCol, currencyCol := -1, -1
+	for idx, cell := range header {
+		switch normalizeName(cell) {
+		case "cost", "price":
+			costCol = idx
+		case "currency", "paid with":
+			currencyCol = idx
+		}
+	}
+	rates := map[string]float64{}
+	for _, currency := range currencyManifest(gameID) {
+		rates[currency.Key] = currency.PullsPerUnit
+	}
+
+	result := map[string][]ShopItem{}
+	for _, record := range records[headerIdx+1:] {
+		item := getCell(record, 0)
+		if item == "" || strings.HasPrefix(item, "*") {
+			continue
+		}
+		entry := ShopItem{Item: item, Reward: currencyKeyForName(gameID, item)}
+		if cost, ok := parseDataPullValue(getCell(record, costCol)); ok {
+			entry.Cost = cost
+		}
+		if currency := getCell(record, currencyCol); currency != "" {
+			entry.Currency = cmp.Or(currencyKeyForName(gameID, currency), currency)
+		}
+		for _, colIdx := range slices.Sorted(maps.Keys(patchCols)) {
+			stock, ok := parseDataPullValue(getCell(record, colIdx))
+			if !ok || stock <= 0 {
+				continue
+			}
+			patchItem := entry
+			patchItem.Stock = stock
+			patchItem.Pulls = roundToTenth(stock * rates[entry.Reward])
+			result[patchCols[colIdx]] = append(result[patchCols[colIdx]], patchItem)
+		}
+	}
+	if len(result) == 0 {
+		return nil, errors.New("shop sheet has no stocked items")
+	}
+	return result, nil
+}
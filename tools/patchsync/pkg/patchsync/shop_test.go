@@ -0,0 +1,59 @@
+package patchsync
+
+import (
+	"strings"
+	"testing"
+)
+
+const genshinShopCSV = `Paimon's Bargains,,,,
+Item,Cost,Currency,5.3,5.4
+Intertwined Fate,5,Masterless Starglitter,10,10
+Acquaint Fate,5,Starglitter,10,
+Hero's Wit,100,Stardust,"1,5",5
+*Stock resets monthly,,,,
+`
+
+func TestParseShopSheet(t *testing.T) {
+	shop, err := parseShopSheet(gameIDGenshin, genshinShopCSV)
+	if err != nil {
+		t.Fatalf("parseShopSheet() error = %v", err)
+	}
+	if len(shop["5.3"]) != 3 || len(shop["5.4"]) != 2 {
+		t.Fatalf("unexpected inventory %+v", shop)
+	}
+	fate := shop["5.4"][0]
+	if fate.Item != "Intertwined Fate" || fate.Reward != "intertwinedFate" || fate.Currency != "starglitter" || fate.Cost != 5 || fate.Stock != 10 || fate.Pulls != 10 {
+		t.Fatalf("unexpected fate entry %+v", fate)
+	}
+	wit := shop["5.3"][2]
+	if wit.Reward != "" || wit.Currency != "Stardust" || wit.Stock != 1.5 || wit.Pulls != 0 {
+		t.Fatalf("unexpected non-currency entry %+v", wit)
+	}
+
+	if _, err := parseShopSheet(gameIDGenshin, "Version,5.3\nEvents,10\n"); err == nil || !strings.Contains(err.Error(), "Item") {
+		t.Fatalf("expected a missing header error, got %v", err)
+	}
+}
+
+func TestLoadSpreadsheetSourceReadsShop(t *testing.T) {
+	fetcher := fakeSheetFetcher{sheets: map[string]string{
+		"Embers Store": "Item,Cost,Currency,3.4\nStar Rail Special Pass,150,Undying Embers,5\n",
+	}}
+	logs := syncLog{}
+	profile, err := ResolveGameProfile(gameIDHsr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := loadSpreadsheetSource(t.Context(), fetcher, gameIDHsr, "fake", true, []string{"3.4"}, profile.AuxSheetNames, profile.ShopSheetNames, decimalAuto, &logs)
+	if err != nil {
+		t.Fatalf("loadSpreadsheetSource() error = %v", err)
+	}
+	items := src.Shop["3.4"]
+	if src.ShopSheet != "Embers Store" || len(items) != 1 || items[0].Reward != "specialPass" || items[0].Pulls != 5 {
+		t.Fatalf("unexpected shop %q %+v", src.ShopSheet, items)
+	}
+	generated := toGeneratedPatch(Patch{ID: "3.4", Shop: items}, gameIDHsr)
+	if len(generated.Shop) != 1 {
+		t.Fatalf("shop missing from the generated patch")
+	}
+}
@@ -0,0 +1,139 @@
+package patchsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var slackEventKinds = []string{notifyChanges, notifyNoop, notifyFailure}
+
+// SlackOptions configure the Slack sink. Events lists the event kinds to post; the webhook URL is a secret,
+// so it is usually set through PATCHSYNC_SLACK_WEBHOOK.
+type SlackOptions struct {
+	WebhookURL string
+	Events     []string
+	Filter     NotifyFilter
+}
+
+func registerSlackFlags(fs *flag.FlagSet, opts *SlackOptions) {
+	opts.Events = []string{notifyChanges, notifyFailure}
+	fs.StringVar(&opts.WebhookURL, "slack-webhook", os.Getenv("PATCHSYNC_SLACK_WEBHOOK"), "Slack incoming webhook URL for sync notifications (empty disables Slack)")
+	fs.Var(commaListValue{values: &opts.Events}, "slack-events", "Comma-separated events posted to Slack: changes, noop, failure")
+	registerNotifyFilterFlags(fs, "slack", &opts.Filter)
+}
+
+func (o SlackOptions) enabled() bool {
+	return strings.TrimSpace(o.WebhookURL) != ""
+}
+
+func (o SlackOptions) validate() error {
+	if !o.enabled() {
+		return nil
+	}
+	parsed, err := url.Parse(o.WebhookURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return errors.New("invalid --slack-webhook (use the https URL of an incoming webhook)")
+	}
+	for _, kind := range o.Events {
+		if !slices.Contains(slackEventKinds, kind) {
+			return fmt.Errorf("unknown --slack-events entry %q (use %s)", kind, strings.Join(slackEventKinds, ", "))
+		}
+	}
+	return nil
+}
+
+type slackNotifier struct {
+	opts   SlackOptions
+	client *http.Client
+}
+
+func newSlackNotifier(opts SlackOptions) *slackNotifier {
+	return &slackNotifier{opts: opts, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *slackNotifier) notify(event notifyEvent) error {
+	if !slices.Contains(n.opts.Events, event.Kind) {
+		return nil
+	}
+	body, err := json.Marshal(buildSlackMessage(event))
+	if err != nil {
+		return fmt.Errorf("marshal slack message: %w", err)
+	}
+	resp, err := n.client.Post(n.opts.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("post slack message: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("post slack message: %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// buildSlackMessage renders an event as Block Kit. Text is the plain fallback shown in notifications.
+func buildSlackMessage(event notifyEvent) slackMessage {
+	title := fmt.Sprintf("%s: no changes", event.GameID)
+	switch event.Kind {
+	case notifyChanges:
+		title = fmt.Sprintf("%s: %d added, %d updated, %d removed", event.GameID, event.Stats.Added, event.Stats.Updated, event.Stats.Removed)
+	case notifyFailure:
+		title = fmt.Sprintf("%s: sync failed %d times in a row", event.GameID, event.Failures)
+	}
+	message := slackMessage{
+		Text:   "patchsync " + title,
+		Blocks: []slackBlock{{Type: "header", Text: &slackText{Type: "plain_text", Text: title}}},
+	}
+
+	fields := []slackText{}
+	if event.Kind == notifyFailure {
+		fields = append(fields, slackText{Type: "mrkdwn", Text: "*Error*\n" + event.Error})
+		if event.Code != "" {
+			fields = append(fields, slackText{Type: "mrkdwn", Text: "*Code*\n`" + event.Code + "`"})
+		}
+	} else {
+		if len(event.Patches) > 0 {
+			fields = append(fields, slackText{Type: "mrkdwn", Text: "*Patches*\n" + strings.Join(event.Patches, ", ")})
+		}
+		if deltas := formatRewardsDelta(event.Stats.RewardsDelta); len(deltas) > 0 {
+			fields = append(fields, slackText{Type: "mrkdwn", Text: "*Rewards*\n" + strings.Join(deltas, "\n")})
+		}
+	}
+	if len(fields) > 0 {
+		message.Blocks = append(message.Blocks, slackBlock{Type: "section", Fields: fields})
+	}
+
+	footer := "patchsync · " + event.Time.UTC().Format(time.RFC3339)
+	if event.OutputPath != "" {
+		footer += " · `" + event.OutputPath + "`"
+	}
+	message.Blocks = append(message.Blocks, slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: footer}}})
+	return message
+}
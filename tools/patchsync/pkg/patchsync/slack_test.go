@@ -0,0 +1,67 @@
+package patchsync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlackNotifierPostsSelectedEvents(t *testing.T) {
+	posted := []slackMessage{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message slackMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("decode slack message: %v", err)
+		}
+		posted = append(posted, message)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	notifier := newSlackNotifier(SlackOptions{WebhookURL: server.URL, Events: []string{notifyChanges}})
+	now := time.Date(2025, 6, 2, 5, 0, 0, 0, time.UTC)
+	changes := notifyEvent{
+		Kind:       notifyChanges,
+		GameID:     gameIDGenshin,
+		Time:       now,
+		Patches:    []string{"5.7", "5.8"},
+		Stats:      syncStats{Added: 1, Updated: 1, RewardsDelta: map[string]float64{"primogem": 9000}},
+		OutputPath: "src/data/genshin.generated.js",
+	}
+	for _, event := range []notifyEvent{changes, {Kind: notifyNoop, GameID: gameIDGenshin, Time: now}} {
+		if err := notifier.notify(event); err != nil {
+			t.Fatalf("notify() error = %v", err)
+		}
+	}
+	if len(posted) != 1 {
+		t.Fatalf("expected only the changes event to be posted, got %d", len(posted))
+	}
+	message := posted[0]
+	if message.Blocks[0].Type != "header" || message.Blocks[0].Text.Text != "genshin-impact: 1 added, 1 updated, 0 removed" {
+		t.Fatalf("unexpected header %+v", message.Blocks[0])
+	}
+	if len(message.Blocks) != 3 || !strings.Contains(message.Blocks[1].Fields[1].Text, "primogem +9000") {
+		t.Fatalf("unexpected blocks %+v", message.Blocks)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer failing.Close()
+	notifier = newSlackNotifier(SlackOptions{WebhookURL: failing.URL, Events: slackEventKinds})
+	if err := notifier.notify(changes); err == nil || !strings.Contains(err.Error(), "invalid_token") {
+		t.Fatalf("expected webhook error, got %v", err)
+	}
+}
+
+func TestSlackOptionsValidate(t *testing.T) {
+	if err := (SlackOptions{WebhookURL: "http://hooks.slack.com/services/x", Events: []string{notifyChanges}}).validate(); err == nil {
+		t.Fatalf("expected plain http webhook to be rejected")
+	}
+	if err := (SlackOptions{WebhookURL: "https://hooks.slack.com/services/x", Events: []string{"success"}}).validate(); err == nil {
+		t.Fatalf("expected unknown event error")
+	}
+}
@@ -0,0 +1,121 @@
+package patchsync
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// SMTPOptions configure the email sink. Host is host:port; the password is only read from
+// PATCHSYNC_SMTP_PASSWORD so it never shows up in process listings.
+type SMTPOptions struct {
+	Host     string
+	Username string
+	Password string
+	From     string
+	To       []string
+	Filter   NotifyFilter
+}
+
+func registerSMTPFlags(fs *flag.FlagSet, opts *SMTPOptions) {
+	opts.Password = os.Getenv("PATCHSYNC_SMTP_PASSWORD")
+	opts.To = uniqueStrings(strings.Split(os.Getenv("PATCHSYNC_SMTP_TO"), ","))
+	fs.StringVar(&opts.Host, "smtp-host", os.Getenv("PATCHSYNC_SMTP_HOST"), "SMTP server as host:port for email notifications (empty disables email)")
+	fs.StringVar(&opts.Username, "smtp-user", os.Getenv("PATCHSYNC_SMTP_USER"), "SMTP user name; the password is read from PATCHSYNC_SMTP_PASSWORD")
+	fs.StringVar(&opts.From, "smtp-from", os.Getenv("PATCHSYNC_SMTP_FROM"), "Sender address of notification emails")
+	fs.Var(commaListValue{values: &opts.To}, "smtp-to", "Comma-separated recipients of notification emails")
+	registerNotifyFilterFlags(fs, "smtp", &opts.Filter)
+}
+
+func (o SMTPOptions) enabled() bool {
+	return strings.TrimSpace(o.Host) != ""
+}
+
+func (o SMTPOptions) validate() error {
+	if !o.enabled() {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(o.Host); err != nil {
+		return fmt.Errorf("invalid --smtp-host %q (use host:port)", o.Host)
+	}
+	if strings.TrimSpace(o.From) == "" {
+		return errors.New("--smtp-from is required with --smtp-host")
+	}
+	if len(o.To) == 0 {
+		return errors.New("--smtp-to is required with --smtp-host")
+	}
+	if o.Username != "" && o.Password == "" {
+		return errors.New("PATCHSYNC_SMTP_PASSWORD is required with --smtp-user")
+	}
+	return nil
+}
+
+type smtpNotifier struct {
+	opts     SMTPOptions
+	sendMail func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func newSMTPNotifier(opts SMTPOptions) *smtpNotifier {
+	return &smtpNotifier{opts: opts, sendMail: smtp.SendMail}
+}
+
+// notify mails syncs that changed patches and reported failures; syncs without changes are not worth an email.
+func (n *smtpNotifier) notify(event notifyEvent) error {
+	if event.Kind == notifyNoop {
+		return nil
+	}
+	var auth smtp.Auth
+	if n.opts.Username != "" {
+		host, _, _ := net.SplitHostPort(n.opts.Host)
+		auth = smtp.PlainAuth("", n.opts.Username, n.opts.Password, host)
+	}
+	if err := n.sendMail(n.opts.Host, auth, n.opts.From, n.opts.To, buildNotifyEmail(n.opts, event)); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	return nil
+}
+
+func notifySubject(event notifyEvent) string {
+	if event.Kind == notifyFailure {
+		return fmt.Sprintf("[patchsync] %s: sync failed %d times in a row", event.GameID, event.Failures)
+	}
+	return fmt.Sprintf("[patchsync] %s: %d added, %d updated, %d removed", event.GameID, event.Stats.Added, event.Stats.Updated, event.Stats.Removed)
+}
+
+func buildNotifyEmail(opts SMTPOptions, event notifyEvent) []byte {
+	var body strings.Builder
+	if event.Kind == notifyFailure {
+		fmt.Fprintf(&body, "The last %d syncs of %s failed.\r\n\r\n", event.Failures, event.GameID)
+		fmt.Fprintf(&body, "Error: %s\r\n", event.Error)
+		if event.Code != "" {
+			fmt.Fprintf(&body, "Code: %s\r\n", event.Code)
+		}
+	} else {
+		fmt.Fprintf(&body, "Sync of %s changed the generated patches.\r\n\r\n", event.GameID)
+		if len(event.Patches) > 0 {
+			fmt.Fprintf(&body, "Patches: %s\r\n", strings.Join(event.Patches, ", "))
+		}
+		fmt.Fprintf(&body, "Added: %d, updated: %d, removed: %d\r\n", event.Stats.Added, event.Stats.Updated, event.Stats.Removed)
+		if deltas := formatRewardsDelta(event.Stats.RewardsDelta); len(deltas) > 0 {
+			fmt.Fprintf(&body, "Rewards: %s\r\n", strings.Join(deltas, ", "))
+		}
+		if event.OutputPath != "" {
+			fmt.Fprintf(&body, "Output: %s\r\n", event.OutputPath)
+		}
+	}
+
+	headers := []string{
+		"From: " + opts.From,
+		"To: " + strings.Join(opts.To, ", "),
+		"Subject: " + notifySubject(event),
+		"Date: " + event.Time.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+	}
+	return []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + body.String())
+}
@@ -0,0 +1,51 @@
+package patchsync
+
+import (
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSMTPNotifierSendsChangesAndFailures(t *testing.T) {
+	opts := SMTPOptions{Host: "smtp.example.com:587", Username: "bot", Password: "secret", From: "patchsync@example.com", To: []string{"a@example.com", "b@example.com"}}
+	sent := []string{}
+	notifier := newSMTPNotifier(opts)
+	notifier.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		if addr != opts.Host || auth == nil || from != opts.From || len(to) != 2 {
+			t.Fatalf("unexpected envelope addr=%s from=%s to=%v", addr, from, to)
+		}
+		sent = append(sent, string(msg))
+		return nil
+	}
+	now := time.Date(2025, 6, 2, 5, 0, 0, 0, time.UTC)
+
+	if err := notifier.notify(notifyEvent{Kind: notifyNoop, GameID: gameIDWuwa, Time: now}); err != nil || len(sent) != 0 {
+		t.Fatalf("no-op syncs should not send mail: sent=%d err=%v", len(sent), err)
+	}
+	changes := notifyEvent{
+		Kind:    notifyChanges,
+		GameID:  gameIDWuwa,
+		Time:    now,
+		Patches: []string{"2.4"},
+		Stats:   syncStats{Added: 1, RewardsDelta: map[string]float64{"astrite": 12000, "radiantTide": -2}},
+	}
+	if err := notifier.notify(changes); err != nil {
+		t.Fatalf("notify() error = %v", err)
+	}
+	failure := notifyEvent{Kind: notifyFailure, GameID: gameIDWuwa, Time: now, Failures: 2, Error: "fetch failed", Code: errCodeSpreadsheetUnreachable}
+	if err := notifier.notify(failure); err != nil {
+		t.Fatalf("notify() error = %v", err)
+	}
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 emails, got %d", len(sent))
+	}
+	for _, want := range []string{"Subject: [patchsync] wuthering-waves: 1 added, 0 updated, 0 removed", "Patches: 2.4", "Rewards: astrite +12000, radiantTide -2", "To: a@example.com, b@example.com"} {
+		if !strings.Contains(sent[0], want) {
+			t.Fatalf("changes email missing %q:\n%s", want, sent[0])
+		}
+	}
+	if !strings.Contains(sent[1], "sync failed 2 times in a row") || !strings.Contains(sent[1], "Code: ERR_SPREADSHEET_UNREACHABLE") {
+		t.Fatalf("unexpected failure email:\n%s", sent[1])
+	}
+}
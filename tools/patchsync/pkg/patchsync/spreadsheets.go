@@ -0,0 +1,223 @@
+package patchsync
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type spreadsheetSource struct {
+	ID               string
+	SheetNames       []string
+	AuxSheet         string
+	DataCSV          string
+	SummaryCSV       string
+	DataPulls        map[string]map[string]float64
+	SummaryPulls     map[string]float64
+	DataTags         map[string][]string
+	DataNotes        map[string][]string
+	DecimalSeparator string
+	ShopSheet        string
+	ShopCSV          string
+	Shop             map[string][]ShopItem
+}
+
+func spreadsheetIDList(raw string) []string {
+	ids := make([]string, 0, 2)
+	for _, part := range strings.Split(raw, ",") {
+		if id := extractSpreadsheetID(part); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return uniqueStrings(ids)
+}
+
+func gameUsesDataSheet(gameID string) bool {
+	return gameID == gameIDEndfield || gameID == gameIDWuwa || gameID == gameIDZzz || gameID == gameIDHsr
+}
+
+func parseGameDataSheet(gameID, csvText string, sheetNames []string, separator string) (map[string]map[string]float64, error) {
+	switch gameID {
+	case gameIDEndfield:
+		return parseEndfieldDataSheet(csvText, sheetNames, separator)
+	case gameIDWuwa:
+		return parseWuwaDataSheet(csvText, sheetNames, separator)
+	case gameIDZzz:
+		return parseZzzDataSheet(csvText, sheetNames, separator)
+	case gameIDHsr:
+		return parseHsrDataSheet(csvText, sheetNames, separator)
+	}
+	return nil, fmt.Errorf("game %s has no Data sheet parser", gameID)
+}
+
+func applyGameDataPullOverrides(gameID string, patch *Patch, pullsByPatch map[string]map[string]float64, reconcile string) error {
+	switch gameID {
+	case gameIDEndfield:
+		return applyEndfieldDataPullOverrides(patch, pullsByPatch)
+	case gameIDWuwa:
+		return applyWuwaDataPullOverrides(patch, pullsByPatch, reconcile)
+	case gameIDZzz:
+		return applyZzzDataPullOverrides(patch, pullsByPatch, reconcile)
+	case gameIDHsr:
+		return applyHsrDataPullOverrides(patch, pullsByPatch, reconcile)
+	}
+	return nil
+}
+
+// loadSpreadsheetSource reads the aux sheets of one spreadsheet. An auto decimal separator is replaced by the one
+// the Data sheet uses, when it tells. Genshin needs the Summary sheet of the primary spreadsheet only; the others
+// go without Summary overrides when theirs is missing.
+func loadSpreadsheetSource(ctx context.Context, fetcher SheetFetcher, gameID, spreadsheetID string, primary bool, explicitSheetNames, auxSheetNames, shopSheetNames []string, decimalSeparator string, logs *syncLog) (spreadsheetSource, error) {
+	src := spreadsheetSource{ID: spreadsheetID, DecimalSeparator: cmp.Or(decimalSeparator, decimalAuto)}
+	if gameUsesDataSheet(gameID) {
+		appendSyncLog(logs, "fetch Data sheet")
+		dataSheet, dataCSV, dataErr := resolveAuxSheet(ctx, fetcher, spreadsheetID, auxSheetNames)
+		if dataErr != nil {
+			appendSyncLog(logs, "Data sheet unavailable for %s; continuing without pull overrides: %v", gameID, dataErr)
+		} else {
+			if dataSheet != "Data" {
+				appendSyncLog(logs, "using tab %q as the Data sheet", dataSheet)
+			}
+			src.AuxSheet = dataSheet
+			src.DataCSV = dataCSV
+			parsedTags, tagsErr := parseDataSheetPatchTags(dataCSV)
+			if tagsErr == nil {
+				src.DataTags = parsedTags
+			} else {
+				appendSyncLog(logs, "Data sheet tags unavailable for %s: %v", gameID, tagsErr)
+			}
+			src.DataNotes = parseDataSheetFootnotes(dataCSV)
+			if formulaErrors := dataSheetFormulaErrors(dataCSV); len(formulaErrors) > 0 {
+				appendSyncLog(logs, "warning: Data sheet formula errors, cells skipped: %s", strings.Join(formulaErrors, "; "))
+			}
+			if src.DecimalSeparator == decimalAuto {
+				if detected := detectDecimalSeparator(dataCSV); detected != decimalAuto {
+					src.DecimalSeparator = detected
+					appendSyncLog(logs, "Data sheet uses decimal %s", detected)
+				}
+			}
+		}
+	}
+
+	sheetNames := uniqueSheetNames(explicitSheetNames)
+	if len(sheetNames) == 0 {
+		discovered, err := fetcher.ListSheets(ctx, spreadsheetID)
+		if err != nil {
+			return spreadsheetSource{}, withErrorCode(errCodeSpreadsheetUnreachable, err)
+		}
+		sheetNames = discovered
+	}
+	if len(sheetNames) == 0 {
+		return spreadsheetSource{}, withErrorCode(errCodeNoSheets, errors.New("no sheet names to parse"))
+	}
+	sortVersionStrings(sheetNames)
+	src.SheetNames = sheetNames
+	appendSyncLog(logs, "sheet names discovered: %d", len(sheetNames))
+
+	if src.DataCSV != "" {
+		parsedPulls, parseDataErr := parseGameDataSheet(gameID, src.DataCSV, sheetNames, src.DecimalSeparator)
+		if parseDataErr != nil {
+			appendSyncLog(logs, "Data sheet pull overrides unavailable for %s; continuing without overrides: %v", gameID, parseDataErr)
+		} else {
+			src.DataPulls = parsedPulls
+		}
+	}
+
+	if gameID == gameIDGenshin {
+		summarySheet, summaryCSV, summaryErr := resolveAuxSheet(ctx, fetcher, spreadsheetID, auxSheetNames)
+		if summaryErr != nil && !primary {
+			appendSyncLog(logs, "warning: Summary sheet unavailable in %s; its patches keep the sheet pulls: %v", spreadsheetID, summaryErr)
+			return loadShopSheet(ctx, fetcher, gameID, src, shopSheetNames, logs), nil
+		}
+		if summaryErr != nil {
+			return spreadsheetSource{}, withErrorCode(errCodeSpreadsheetUnreachable, fmt.Errorf("fetch Summary sheet for %s: %w", gameID, summaryErr))
+		}
+		parsedSummaryPulls, parseSummaryErr := parseGenshinSummaryPullTotals(summaryCSV, sheetNames)
+		if parseSummaryErr != nil {
+			return spreadsheetSource{}, withErrorCode(errCodeParse, fmt.Errorf("parse Summary sheet for %s: %w", gameID, parseSummaryErr))
+		}
+		if summarySheet != "Summary" {
+			appendSyncLog(logs, "using tab %q as the Summary sheet", summarySheet)
+		}
+		src.AuxSheet = summarySheet
+		src.SummaryCSV = summaryCSV
+		src.SummaryPulls = parsedSummaryPulls
+	}
+	return loadShopSheet(ctx, fetcher, gameID, src, shopSheetNames, logs), nil
+}
+
+func loadShopSheet(ctx context.Context, fetcher SheetFetcher, gameID string, src spreadsheetSource, shopSheetNames []string, logs *syncLog) spreadsheetSource {
+	if len(shopSheetNames) == 0 {
+		return src
+	}
+	shopSheet, shopCSV, shopErr := resolveAuxSheet(ctx, fetcher, src.ID, shopSheetNames)
+	if shopErr != nil {
+		appendSyncLog(logs, "shop sheet unavailable for %s: %v", gameID, shopErr)
+		return src
+	}
+	shop, parseShopErr := parseShopSheet(gameID, shopCSV)
+	if parseShopErr != nil {
+		appendSyncLog(logs, "skip shop sheet %q: %v", shopSheet, parseShopErr)
+		return src
+	}
+	appendSyncLog(logs, "shop inventory for %d patches from tab %q", len(shop), shopSheet)
+	src.ShopSheet = shopSheet
+	src.ShopCSV = shopCSV
+	src.Shop = shop
+	return src
+}
+
+// applySpreadsheetOverrides applies the Data or Summary sheet pulls of src to patch. reconcile is where the gap to
+// the sheet's F2P total goes, see reconcilePullDelta; empty keeps the game's default source.
+func applySpreadsheetOverrides(gameID string, patch *Patch, src spreadsheetSource, reconcile string) (string, error) {
+	if gameID == gameIDGenshin {
+		if src.SummaryPulls == nil {
+			return "Summary", nil
+		}
+		return cmp.Or(src.AuxSheet, "Summary"), applyGenshinSummaryPullOverrides(patch, src.SummaryPulls, reconcile)
+	}
+	if src.DataPulls == nil {
+		return "Data", nil
+	}
+	return cmp.Or(src.AuxSheet, "Data"), applyGameDataPullOverrides(gameID, patch, src.DataPulls, reconcile)
+}
+
+func mergeSpreadsheetSheetNames(sources []spreadsheetSource, logs *syncLog) ([]string, map[string]int) {
+	names := make([]string, 0, 32)
+	sourceIdx := map[string]int{}
+	claimedBy := map[string]string{}
+	for idx, src := range sources {
+		for _, sheetName := range src.SheetNames {
+			patchID := canonicalPatchID(sheetName)
+			if owner, claimed := claimedBy[patchID]; claimed {
+				appendSyncLog(logs, "ignore sheet %s from %s: patch %s already provided by %s", sheetName, src.ID, patchID, owner)
+				continue
+			}
+			claimedBy[patchID] = src.ID
+			sourceIdx[sheetName] = idx
+			names = append(names, sheetName)
+		}
+	}
+	sortVersionStrings(names)
+	return names, sourceIdx
+}
+
+func filterRequestedSheetNames(available []string, requested []string) ([]string, error) {
+	byPatchID := map[string]string{}
+	for _, name := range available {
+		byPatchID[canonicalPatchID(name)] = name
+	}
+	filtered := make([]string, 0, len(requested))
+	for _, name := range requested {
+		match, ok := byPatchID[canonicalPatchID(name)]
+		if !ok {
+			return nil, fmt.Errorf("sheet %s not found in any configured spreadsheet", name)
+		}
+		filtered = append(filtered, match)
+	}
+	filtered = uniqueSheetNames(filtered)
+	sortVersionStrings(filtered)
+	return filtered, nil
+}
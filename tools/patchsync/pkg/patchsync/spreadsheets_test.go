@@ -0,0 +1,62 @@
+package patchsync
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeSpreadsheetSheetNamesPrefersEarlierSpreadsheet(t *testing.T) {
+	sources := []spreadsheetSource{
+		{ID: "current", SheetNames: []string{"2.0", "2.1", "2.2 (STC)"}},
+		{ID: "archive", SheetNames: []string{"1.0", "1.1", "2.0", "2.2"}},
+	}
+	logs := syncLog{}
+	names, sourceIdx := mergeSpreadsheetSheetNames(sources, &logs)
+
+	wantNames := []string{"1.0", "1.1", "2.0", "2.1", "2.2 (STC)"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("names = %v, want %v", names, wantNames)
+	}
+	wantIdx := map[string]int{"1.0": 1, "1.1": 1, "2.0": 0, "2.1": 0, "2.2 (STC)": 0}
+	if !reflect.DeepEqual(sourceIdx, wantIdx) {
+		t.Fatalf("sourceIdx = %v, want %v", sourceIdx, wantIdx)
+	}
+	if len(logs.lines) != 2 {
+		t.Fatalf("expected 2 shadowed-sheet log lines, got %d: %v", len(logs.lines), logs.lines)
+	}
+}
+
+func TestSpreadsheetIDList(t *testing.T) {
+	raw := "https://docs.google.com/spreadsheets/d/abc123/edit, def456 ,abc123,"
+	got := spreadsheetIDList(raw)
+	want := []string{"abc123", "def456"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("spreadsheetIDList() = %v, want %v", got, want)
+	}
+}
+
+func TestGenshinSummaryRequiredOnlyForPrimarySpreadsheet(t *testing.T) {
+	fetcher := fakeSheetFetcher{sheets: map[string]string{"5.0": "Version 5.0"}}
+	profile, err := ResolveGameProfile(gameIDGenshin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := syncLog{}
+	if _, err := loadSpreadsheetSource(t.Context(), fetcher, gameIDGenshin, "current", true, nil, profile.AuxSheetNames, nil, decimalAuto, &logs); errorCode(err) != errCodeSpreadsheetUnreachable {
+		t.Fatalf("primary spreadsheet without Summary: error = %v, want %s", err, errCodeSpreadsheetUnreachable)
+	}
+
+	logs = syncLog{}
+	src, err := loadSpreadsheetSource(t.Context(), fetcher, gameIDGenshin, "archive", false, nil, profile.AuxSheetNames, nil, decimalAuto, &logs)
+	if err != nil {
+		t.Fatalf("secondary spreadsheet without Summary: error = %v", err)
+	}
+	if src.SummaryPulls != nil || !reflect.DeepEqual(src.SheetNames, []string{"5.0"}) {
+		t.Fatalf("secondary source = %+v, want sheet 5.0 without Summary pulls", src)
+	}
+	patch := Patch{Sources: []Source{source("events", "Events", "always", nil, true, Rewards{Oroberyl: 1600})}}
+	if _, err := applySpreadsheetOverrides(gameIDGenshin, &patch, src, ""); err != nil {
+		t.Fatalf("applySpreadsheetOverrides() without Summary pulls error = %v", err)
+	}
+}
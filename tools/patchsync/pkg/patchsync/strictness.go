@@ -0,0 +1,81 @@
+package patchsync
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Parse strictness levels decide what a version sheet parser does when aggregate rows are missing. Required rows
+// are the ones a patch is useless without; expected rows are the other sources the game's sheets normally have.
+//
+//   - strict fails the sheet on any missing required or expected row
+//   - normal fails on missing required rows and uses zeros, with a warning, for expected ones
+//   - lenient uses zeros, with a warning, for every missing row
+const (
+	parseStrict  = "strict"
+	parseNormal  = "normal"
+	parseLenient = "lenient"
+)
+
+// ParseOptions configure one PatchParser call. Warn receives warnings about the sheet; when nil they are printed
+// to stderr. DecimalSeparator is one of the decimal* separators, auto when empty.
+type ParseOptions struct {
+	Strictness       string
+	DecimalSeparator string
+	Warn             func(string)
+}
+
+func parseStrictnessLevel(raw string) (string, error) {
+	switch level := strings.ToLower(strings.TrimSpace(raw)); level {
+	case "":
+		return parseNormal, nil
+	case parseStrict, parseNormal, parseLenient:
+		return level, nil
+	default:
+		return "", fmt.Errorf("invalid parse strictness %q (use %s, %s, or %s)", raw, parseStrict, parseNormal, parseLenient)
+	}
+}
+
+func (opts ParseOptions) warnf(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	if opts.Warn == nil {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", message)
+		return
+	}
+	opts.Warn(message)
+}
+
+// checkAggregateRows applies opts.Strictness to the aggregate rows a parser of sheetKind sheets did not find.
+// A nil error means the parser goes on with zeros for the missing rows.
+func (opts ParseOptions) checkAggregateRows(sheetKind string, missingRequired, missingExpected []string) error {
+	switch opts.Strictness {
+	case parseStrict:
+		if missing := append(append([]string{}, missingRequired...), missingExpected...); len(missing) > 0 {
+			return fmt.Errorf("missing aggregate rows in %s sheet: %s", sheetKind, strings.Join(missing, ", "))
+		}
+	case parseLenient:
+		if missing := append(append([]string{}, missingRequired...), missingExpected...); len(missing) > 0 {
+			opts.warnf("missing aggregate rows in %s sheet, using zeros: %s", sheetKind, strings.Join(missing, ", "))
+		}
+	default:
+		if len(missingRequired) > 0 {
+			return fmt.Errorf("missing required aggregate rows in %s sheet: %s", sheetKind, strings.Join(missingRequired, ", "))
+		}
+		if len(missingExpected) > 0 {
+			opts.warnf("missing aggregate rows in %s sheet, using zeros: %s", sheetKind, strings.Join(missingExpected, ", "))
+		}
+	}
+	return nil
+}
+
+// missingRows returns the names in want that found does not have, in order.
+func missingRows(found func(name string) bool, want ...string) []string {
+	missing := []string{}
+	for _, name := range want {
+		if !found(name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
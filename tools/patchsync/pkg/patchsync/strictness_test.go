@@ -0,0 +1,99 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// wuwaSheetWithout drops the named aggregate rows from wuwaSheetCSV, along with the totals that would no longer
+// add up.
+func wuwaSheetWithout(version string, rows ...string) string {
+	kept := []string{}
+	for _, line := range strings.Split(strings.TrimSuffix(wuwaSheetCSV(version), "\n"), "\n") {
+		label, _, _ := strings.Cut(line, ",")
+		drop := strings.HasPrefix(label, "Total ")
+		for _, row := range rows {
+			drop = drop || label == row
+		}
+		if !drop {
+			kept = append(kept, line)
+		}
+	}
+	return csvLines(kept...)
+}
+
+func TestParseStrictnessLevels(t *testing.T) {
+	noPaidRows := wuwaSheetWithout("3.4", "Paid Pioneer Podcast", "Lunite Subscription")
+	var warnings []string
+	warn := func(message string) { warnings = append(warnings, message) }
+
+	if _, err := parseSheetToPatchWuwa("3.4", noPaidRows, ParseOptions{Warn: warn}); err != nil {
+		t.Fatalf("normal parse without expected rows error = %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "paid pioneer podcast, lunite subscription") {
+		t.Fatalf("normal parse warnings = %v, want the missing expected rows", warnings)
+	}
+	if _, err := parseSheetToPatchWuwa("3.4", noPaidRows, ParseOptions{Strictness: parseStrict, Warn: warn}); err == nil || !strings.Contains(err.Error(), "lunite subscription") {
+		t.Fatalf("strict parse error = %v, want the missing rows", err)
+	}
+
+	noRecurring := wuwaSheetWithout("3.4", "Recurring Sources")
+	if _, err := parseSheetToPatchWuwa("3.4", noRecurring, ParseOptions{Warn: warn}); err == nil || !strings.Contains(err.Error(), "missing required aggregate rows") {
+		t.Fatalf("normal parse without a required row error = %v", err)
+	}
+	warnings = nil
+	patch, err := parseSheetToPatchWuwa("3.4", noRecurring, ParseOptions{Strictness: parseLenient, Warn: warn})
+	if err != nil {
+		t.Fatalf("lenient parse error = %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "recurring sources") {
+		t.Fatalf("lenient parse warnings = %v", warnings)
+	}
+	for _, src := range patch.Sources {
+		if src.ID == "endgameModes" && src.Rewards.hasAny() {
+			t.Fatalf("missing row parsed as %+v, want zeros", src.Rewards)
+		}
+	}
+}
+
+func TestRunSyncUsesGameParseStrictness(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	overridesDir := filepath.Join(dir, "overrides")
+	if err := os.MkdirAll(overridesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(overridesDir, gameIDWuwa+".json"), []byte(`{"strictness":"strict"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    overridesDir,
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		DryRun:          true,
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetWithout("3.4", "Lunite Subscription")}},
+	}
+	if _, err := RunSync(t.Context(), cfg); errorCode(err) != errCodeParse {
+		t.Fatalf("strict game sync error = %v, want %s", err, errCodeParse)
+	}
+
+	cfg.ParseStrictness = "normal"
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() with --parse-strictness normal error = %v", err)
+	}
+	if logs := strings.Join(result.Logs, "\n"); !strings.Contains(logs, "warning: sheet 3.4: missing aggregate rows") {
+		t.Fatalf("sync logs do not carry the parse warning:\n%s", logs)
+	}
+
+	cfg.ParseStrictness = "loose"
+	if _, err := RunSync(t.Context(), cfg); errorCode(err) != errCodeConfig {
+		t.Fatalf("unknown strictness error = %v, want %s", err, errCodeConfig)
+	}
+}
@@ -0,0 +1,179 @@
+package patchsync
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const sheetStructureFileName = "sheet-structure.json"
+
+// sheetStructure fingerprints the layout of a game's version sheets: the row labels in order, the widest row,
+// and how many sources the parser found. It is recorded from the newest sheet of every healthy sync.
+type sheetStructure struct {
+	Sheet   string   `json:"sheet"`
+	Labels  []string `json:"labels"`
+	Columns int      `json:"columns"`
+	Sources int      `json:"sources"`
+}
+
+// sheetStructurePath keeps the fingerprints next to the sheet hashes, so tests and golden runs that move
+// one move both.
+func sheetStructurePath(sheetHashPath string) string {
+	return filepath.Join(filepath.Dir(sheetHashPath), sheetStructureFileName)
+}
+
+// sheetStructureOf reads the first text cell of every row as its label. Cells with digits are skipped, since
+// version headers and dates change from sheet to sheet.
+func sheetStructureOf(sheetName, csvText string) sheetStructure {
+	reader := csv.NewReader(strings.NewReader(csvText))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, _ := reader.ReadAll()
+	structure := sheetStructure{Sheet: sheetName, Labels: []string{}}
+	for _, record := range records {
+		width := len(record)
+		for width > 0 && strings.TrimSpace(record[width-1]) == "" {
+			width--
+		}
+		structure.Columns = max(structure.Columns, width)
+		for _, cell := range record[:width] {
+			label := strings.ToLower(normalizeSheetNameForMatch(cell))
+			if label == "" {
+				continue
+			}
+			if !strings.ContainsFunc(label, unicode.IsDigit) {
+				structure.Labels = append(structure.Labels, label)
+			}
+			break
+		}
+	}
+	return structure
+}
+
+// diffSheetStructure describes how got differs from expected, or returns nil when the rows and width match.
+func diffSheetStructure(expected, got sheetStructure) []string {
+	const shown = 5
+	list := func(items []string) string {
+		if len(items) > shown {
+			return strings.Join(items[:shown], ", ") + fmt.Sprintf(" and %d more", len(items)-shown)
+		}
+		return strings.Join(items, ", ")
+	}
+	gotIndex := map[string]int{}
+	for idx, label := range got.Labels {
+		if _, exists := gotIndex[label]; !exists {
+			gotIndex[label] = idx
+		}
+	}
+	expectedIndex := map[string]int{}
+	missing, moved := []string{}, []string{}
+	for idx, label := range expected.Labels {
+		if _, exists := expectedIndex[label]; exists {
+			continue
+		}
+		expectedIndex[label] = idx
+		gotIdx, ok := gotIndex[label]
+		switch {
+		case !ok:
+			missing = append(missing, fmt.Sprintf("%q", label))
+		case gotIdx != idx:
+			moved = append(moved, fmt.Sprintf("%q row %d -> %d", label, idx+1, gotIdx+1))
+		}
+	}
+	added := []string{}
+	for idx, label := range got.Labels {
+		if _, ok := expectedIndex[label]; !ok && gotIndex[label] == idx {
+			added = append(added, fmt.Sprintf("%q", label))
+		}
+	}
+
+	changes := []string{}
+	if len(missing) > 0 {
+		changes = append(changes, "missing rows "+list(missing))
+	}
+	if len(added) > 0 {
+		changes = append(changes, "new rows "+list(added))
+	}
+	// Inserted or removed rows shift everything below them, so moves are only worth reporting on their own.
+	if len(moved) > 0 && len(missing) == 0 && len(added) == 0 {
+		changes = append(changes, "moved "+list(moved))
+	}
+	if expected.Columns != got.Columns {
+		changes = append(changes, fmt.Sprintf("columns %d -> %d", expected.Columns, got.Columns))
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// sheetStructureDrift compares a sheet against the recorded fingerprint. It reports drift when the sheet
+// failed to parse or yielded fewer than half of the expected sources, and the layout differs.
+func sheetStructureDrift(expected *sheetStructure, sheetName, csvText string, parsedSources int, parseErr error) []string {
+	if expected == nil {
+		return nil
+	}
+	if parseErr == nil && parsedSources*2 >= expected.Sources {
+		return nil
+	}
+	changes := diffSheetStructure(*expected, sheetStructureOf(sheetName, csvText))
+	if len(changes) > 0 && parseErr == nil {
+		changes = append(changes, fmt.Sprintf("sources %d -> %d", expected.Sources, parsedSources))
+	}
+	return changes
+}
+
+// sheetStructureMu guards the fingerprint file, which concurrent /sync-all games share and rewrite.
+var sheetStructureMu sync.Mutex
+
+func readSheetStructures(path string) (map[string]sheetStructure, error) {
+	sheetStructureMu.Lock()
+	defer sheetStructureMu.Unlock()
+	return readSheetStructureFile(path)
+}
+
+func readSheetStructureFile(path string) (map[string]sheetStructure, error) {
+	result := map[string]sheetStructure{}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return result, nil
+		}
+		return nil, err
+	}
+	if strings.TrimSpace(string(body)) == "" {
+		return result, nil
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parse sheet structure file: %w", err)
+	}
+	return result, nil
+}
+
+func writeSheetStructure(path, gameID string, structure sheetStructure) error {
+	sheetStructureMu.Lock()
+	defer sheetStructureMu.Unlock()
+	all, err := readSheetStructureFile(path)
+	if err != nil {
+		return err
+	}
+	all[gameID] = structure
+	body, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal sheet structure: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create sheet structure directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(body, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write sheet structure: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package patchsync
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSheetStructureOf(t *testing.T) {
+	// "Total F2P" carries a digit and is skipped like the version header.
+	got := sheetStructureOf("3.4", wuwaSheetCSV("3.4"))
+	want := []string{
+		"version length", "version events", "permanent content", "mailbox/miscellaneous", "recurring sources",
+		"paid pioneer podcast", "lunite subscription", "total paid",
+	}
+	if !reflect.DeepEqual(got.Labels, want) || got.Columns != 5 || got.Sheet != "3.4" {
+		t.Fatalf("sheetStructureOf() = %+v", got)
+	}
+}
+
+func TestDiffSheetStructure(t *testing.T) {
+	expected := sheetStructure{Labels: []string{"a", "b", "c"}, Columns: 5}
+	if changes := diffSheetStructure(expected, expected); changes != nil {
+		t.Fatalf("identical structures differ: %v", changes)
+	}
+	changes := diffSheetStructure(expected, sheetStructure{Labels: []string{"a", "x", "c"}, Columns: 4})
+	if want := []string{`missing rows "b"`, `new rows "x"`, "columns 5 -> 4"}; !reflect.DeepEqual(changes, want) {
+		t.Fatalf("diff = %v, want %v", changes, want)
+	}
+	changes = diffSheetStructure(expected, sheetStructure{Labels: []string{"a", "c", "b"}, Columns: 5})
+	if want := []string{`moved "b" row 2 -> 3, "c" row 3 -> 2`}; !reflect.DeepEqual(changes, want) {
+		t.Fatalf("reorder diff = %v, want %v", changes, want)
+	}
+
+	recorded := sheetStructure{Labels: []string{"a", "b"}, Columns: 2, Sources: 6}
+	if drift := sheetStructureDrift(&recorded, "3.5", "a,1\nb,2\n", 6, nil); drift != nil {
+		t.Fatalf("healthy sheet reported drift: %v", drift)
+	}
+	drift := sheetStructureDrift(&recorded, "3.5", "a,1\n", 2, nil)
+	if want := []string{`missing rows "b"`, "sources 6 -> 2"}; !reflect.DeepEqual(drift, want) {
+		t.Fatalf("drift = %v, want %v", drift, want)
+	}
+}
+
+func TestRunSyncReportsSheetStructureChanges(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		SheetNames:      []string{"3.4"},
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+	}
+	if _, err := RunSync(t.Context(), cfg); err != nil {
+		t.Fatalf("first RunSync() error = %v", err)
+	}
+	structures, err := readSheetStructures(filepath.Join(dir, sheetStructureFileName))
+	if err != nil || structures[gameIDWuwa].Sheet != "3.4" || structures[gameIDWuwa].Sources == 0 {
+		t.Fatalf("recorded structures = %+v, %v", structures, err)
+	}
+
+	cfg.SheetNames = []string{"3.5"}
+	cfg.Fetcher = fakeSheetFetcher{sheets: map[string]string{
+		"3.5": strings.Replace(wuwaSheetCSV("3.5"), "Version Events,", "Event Rewards,", 1),
+	}}
+	_, err = RunSync(t.Context(), cfg)
+	if err == nil || errorCode(err) != errCodeSheetStructure {
+		t.Fatalf("expected %s, got %v", errCodeSheetStructure, err)
+	}
+	for _, want := range []string{"sheet structure changed since 3.4", `missing rows "version events"`, `new rows "event rewards"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestWriteSheetStructureConcurrently(t *testing.T) {
+	path := filepath.Join(t.TempDir(), sheetStructureFileName)
+	var wg sync.WaitGroup
+	for idx := range 8 {
+		wg.Go(func() {
+			if err := writeSheetStructure(path, fmt.Sprintf("game-%d", idx), sheetStructure{Sheet: "3.4"}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+	all, err := readSheetStructures(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 8 {
+		t.Fatalf("structure file has %d games after concurrent writes, want 8", len(all))
+	}
+}
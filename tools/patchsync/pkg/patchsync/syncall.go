@@ -0,0 +1,146 @@
+package patchsync
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// syncAllRetryDelay is the pause before /sync-all retries its failed games.
+var syncAllRetryDelay = 2 * time.Second
+
+// syncAllSummary sums up the games of a /sync-all run that failed, grouped by cause: "rate_limited" for
+// throttled spreadsheets, otherwise the error class ("parse", "spreadsheet_unreachable", ...) or "other".
+type syncAllSummary struct {
+	Failed    int                 `json:"failed"`
+	ByCause   map[string][]string `json:"byCause,omitempty"`
+	Retried   []string            `json:"retried,omitempty"`
+	Recovered []string            `json:"recovered,omitempty"`
+}
+
+func syncAllGame(ctx context.Context, baseCfg SyncConfig, id string, attempt int) syncGameResult {
+	if err := ctx.Err(); err != nil {
+		return syncGameResult{GameID: id, Error: err.Error(), Code: errCodeSyncCancelled, Attempts: attempt}
+	}
+
+	cfg := baseCfg
+	cfg.GameID = id
+	cfg.SpreadsheetID = ""
+	cfg.SheetNames = nil
+	cfg.OutputPath = ""
+	cfg.CreateBranch = false
+	cfg.BranchPrefix = ""
+
+	if baseCfg.RequestID != "" {
+		cfg.RequestID = baseCfg.RequestID + "-" + id
+	}
+
+	result, err := RunSync(ctx, cfg)
+	if err != nil {
+		failed := syncGameResult{
+			GameID:       id,
+			RequestID:    cfg.RequestID,
+			Sheets:       result.SheetNames,
+			Failures:     result.SheetFailures,
+			Pending:      result.PendingSheets,
+			BaseOverlaps: result.BaseOverlaps,
+			Error:        err.Error(),
+			Code:         errorCode(err),
+			RetryLater:   errorCode(err) == errCodeSpreadsheetThrottled,
+			Attempts:     attempt,
+		}
+		failed.classify(err)
+		return failed
+	}
+	return syncGameResult{
+		GameID:        result.GameID,
+		RequestID:     cfg.RequestID,
+		Sheets:        result.SheetNames,
+		Patches:       patchNamesFromPatches(result.Patches),
+		Skipped:       result.SkippedPatches,
+		Frozen:        result.FrozenPatches,
+		Failures:      result.SheetFailures,
+		BaseOverlaps:  result.BaseOverlaps,
+		OutOfBounds:   result.OutOfBounds,
+		Discrepancies: result.Discrepancies,
+		SheetStats:    result.SheetStats,
+		Profile:       result.Profile,
+		Progress:      result.Progress,
+		OutputPath:    result.OutputPath,
+		Logs:          result.Logs,
+		ChangeCount:   result.ChangeCount,
+		Stats:         &result.Stats,
+		ChangeLogPath: result.ChangeLogPath,
+		GeneratedAt:   result.GeneratedAt,
+		Attempts:      attempt,
+	}
+}
+
+// retrySyncAllFailures syncs the games that failed with a retriable error again, up to SyncAllRetries more
+// times. Throttled games are not retried: the breaker keeps their spreadsheet closed for the whole run.
+func retrySyncAllFailures(ctx context.Context, baseCfg SyncConfig, results []syncGameResult) {
+	for attempt := 2; attempt <= baseCfg.SyncAllRetries+1; attempt++ {
+		retry := []int{}
+		for idx, result := range results {
+			if result.Error != "" && result.Retriable && !result.RetryLater {
+				retry = append(retry, idx)
+			}
+		}
+		if len(retry) == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(syncAllRetryDelay):
+		}
+		runLimited(len(retry), baseCfg.SyncAllParallel, func(n int) {
+			idx := retry[n]
+			results[idx] = syncAllGame(ctx, baseCfg, results[idx].GameID, attempt)
+		})
+	}
+}
+
+func summarizeSyncAll(results []syncGameResult) *syncAllSummary {
+	summary := &syncAllSummary{}
+	for _, result := range results {
+		if result.Attempts > 1 {
+			summary.Retried = append(summary.Retried, result.GameID)
+			if result.Error == "" {
+				summary.Recovered = append(summary.Recovered, result.GameID)
+			}
+		}
+		if result.Error == "" {
+			continue
+		}
+		summary.Failed++
+		cause := cmp.Or(result.ErrorClass, "other")
+		if result.RetryLater {
+			cause = "rate_limited"
+		}
+		if summary.ByCause == nil {
+			summary.ByCause = map[string][]string{}
+		}
+		summary.ByCause[cause] = append(summary.ByCause[cause], result.GameID)
+	}
+	if summary.Failed == 0 && len(summary.Retried) == 0 {
+		return nil
+	}
+	return summary
+}
+
+// describe renders the failures as "rate_limited: zenless-zone-zero; parse: honkai-star-rail".
+func (s *syncAllSummary) describe() string {
+	if s == nil || s.Failed == 0 {
+		return "no failures"
+	}
+	parts := []string{}
+	for _, cause := range slices.Sorted(maps.Keys(s.ByCause)) {
+		parts = append(parts, fmt.Sprintf("%s: %s", cause, strings.Join(s.ByCause[cause], ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
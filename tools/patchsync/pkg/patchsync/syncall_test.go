@@ -0,0 +1,92 @@
+package patchsync
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// flakyListFetcher fails the first failures sheet listings, as an unreachable spreadsheet would.
+type flakyListFetcher struct {
+	fakeSheetFetcher
+	failures int32
+	calls    *atomic.Int32
+}
+
+func (f flakyListFetcher) ListSheets(ctx context.Context, spreadsheetID string) ([]string, error) {
+	if f.calls.Add(1) <= f.failures {
+		return nil, errors.New("connection reset")
+	}
+	return f.fakeSheetFetcher.ListSheets(ctx, spreadsheetID)
+}
+
+func TestRunSyncAllRetriesRetriableFailures(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for _, gameID := range AvailableGameIDs() {
+		t.Setenv(spreadsheetEnvKeyForGame(gameID), "")
+		t.Setenv(legacySpreadsheetEnvKey(gameID), "")
+	}
+	t.Setenv(spreadsheetEnvKeyForGame(gameIDWuwa), "flaky")
+	previousDelay := syncAllRetryDelay
+	syncAllRetryDelay = 0
+	t.Cleanup(func() { syncAllRetryDelay = previousDelay })
+
+	calls := &atomic.Int32{}
+	cfg := SyncConfig{
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		DryRun:          true,
+		SyncAllRetries:  2,
+		Fetcher: flakyListFetcher{
+			fakeSheetFetcher: fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+			failures:         1,
+			calls:            calls,
+		},
+	}
+	results, allOK := runSyncAll(t.Context(), cfg)
+	if allOK {
+		t.Fatalf("games without a spreadsheet should fail")
+	}
+	for _, result := range results {
+		switch {
+		case result.GameID == gameIDWuwa:
+			if result.Error != "" || result.Attempts != 2 {
+				t.Fatalf("wuwa should recover on the retry: %+v", result)
+			}
+		case result.ErrorClass != "validation" || !result.Permanent || result.Attempts != 1:
+			t.Fatalf("permanent failure of %s retried or misclassified: %+v", result.GameID, result)
+		}
+	}
+
+	summary := summarizeSyncAll(results)
+	if summary.Failed != 4 || len(summary.ByCause["validation"]) != 4 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	if !slices.Equal(summary.Retried, []string{gameIDWuwa}) || !slices.Equal(summary.Recovered, []string{gameIDWuwa}) {
+		t.Fatalf("retried %v, recovered %v", summary.Retried, summary.Recovered)
+	}
+}
+
+func TestSummarizeSyncAllSeparatesRateLimits(t *testing.T) {
+	summary := summarizeSyncAll([]syncGameResult{
+		{GameID: gameIDZzz, Error: "throttled", ErrorClass: "spreadsheet_unreachable", Retriable: true, RetryLater: true, Attempts: 1},
+		{GameID: gameIDHsr, Error: "bad cell", ErrorClass: "parse", Permanent: true, Attempts: 1},
+		{GameID: gameIDGenshin, Error: "boom", Attempts: 1},
+		{GameID: gameIDWuwa, Attempts: 1},
+	})
+	if got := summary.describe(); got != "other: genshin-impact; parse: honkai-star-rail; rate_limited: zenless-zone-zero" {
+		t.Fatalf("describe() = %q", got)
+	}
+	if summarizeSyncAll([]syncGameResult{{GameID: gameIDWuwa, Attempts: 1}}) != nil {
+		t.Fatalf("a clean run should have no summary")
+	}
+	if !strings.Contains((*syncAllSummary)(nil).describe(), "no failures") {
+		t.Fatalf("nil summary should describe no failures")
+	}
+}
@@ -0,0 +1,286 @@
+package patchsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// socketMessage is one JSON frame on /ws. Clients send "auth", "sync", "sync-all", and "cancel"; the server sends
+// "ready", "started", "log", "progress", "result", and "error". Request holds the /sync or /sync-all body.
+type socketMessage struct {
+	Type     string          `json:"type"`
+	Job      string          `json:"job,omitempty"`
+	Token    string          `json:"token,omitempty"`
+	Request  json.RawMessage `json:"request,omitempty"`
+	Line     string          `json:"line,omitempty"`
+	Progress *syncProgress   `json:"progress,omitempty"`
+	Result   *syncResponse   `json:"result,omitempty"`
+	Message  string          `json:"message,omitempty"`
+	Code     string          `json:"code,omitempty"`
+}
+
+const (
+	socketSync    = "sync"
+	socketSyncAll = "sync-all"
+)
+
+// syncSocket is one /ws connection. Jobs run concurrently and are cancelled when the connection closes.
+type syncSocket struct {
+	conn    *websocket.Conn
+	request *http.Request
+	cfg     SyncConfig
+	tokens  *authTokens
+	limiter *rateLimiter
+	audit   *auditLogger
+	group   *jobGroup
+
+	sendMu sync.Mutex
+
+	mu         sync.Mutex
+	authorized bool
+	token      string
+	jobs       map[string]context.CancelFunc
+	nextJob    int
+	running    sync.WaitGroup
+}
+
+func registerSyncSocket(mux *http.ServeMux, allowedOrigins map[string]struct{}, tokens *authTokens, cfg SyncConfig, limiter *rateLimiter, audit *auditLogger, group *jobGroup) {
+	server := websocket.Server{
+		Handshake: func(_ *websocket.Config, r *http.Request) error {
+			if origin := strings.TrimSpace(r.Header.Get("Origin")); origin != "" && !isOriginAllowed(origin, allowedOrigins) {
+				return fmt.Errorf("origin %s is not allowed", origin)
+			}
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			conn.MaxPayloadBytes = int(cfg.maxBodyBytes())
+			socket := &syncSocket{
+				conn:    conn,
+				request: conn.Request(),
+				cfg:     cfg,
+				tokens:  tokens,
+				limiter: limiter,
+				audit:   audit,
+				group:   group,
+				jobs:    map[string]context.CancelFunc{},
+			}
+			socket.serve()
+		},
+	}
+	mux.Handle("GET /ws", server)
+}
+
+func (s *syncSocket) send(msg socketMessage) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	_ = websocket.JSON.Send(s.conn, msg)
+}
+
+func (s *syncSocket) sendError(job, code, message string) {
+	s.send(socketMessage{Type: "error", Job: job, Code: code, Message: message})
+}
+
+func (s *syncSocket) serve() {
+	ctx, cancel := context.WithCancel(s.request.Context())
+	defer func() {
+		cancel()
+		s.running.Wait()
+	}()
+	if s.tokens.authorizeToken("", scopeSync) {
+		s.authorized = true
+		s.send(socketMessage{Type: "ready"})
+	}
+	for {
+		var msg socketMessage
+		if err := websocket.JSON.Receive(s.conn, &msg); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				s.sendError("", errCodeBadRequest, "invalid message: "+err.Error())
+				continue
+			}
+			if errors.Is(err, websocket.ErrFrameTooLarge) {
+				s.sendError("", errCodeBodyTooLarge, fmt.Sprintf("message exceeds %d bytes", s.cfg.maxBodyBytes()))
+				continue
+			}
+			return
+		}
+		s.handle(ctx, msg)
+	}
+}
+
+func (s *syncSocket) handle(ctx context.Context, msg socketMessage) {
+	switch msg.Type {
+	case "auth":
+		if !s.tokens.authorizeToken(msg.Token, scopeSync) {
+			s.sendError("", errCodeUnauthorized, "unauthorized")
+			return
+		}
+		s.mu.Lock()
+		s.authorized, s.token = true, msg.Token
+		s.mu.Unlock()
+		s.send(socketMessage{Type: "ready"})
+	case socketSync, socketSyncAll:
+		s.start(ctx, msg)
+	case "cancel":
+		s.mu.Lock()
+		cancel, ok := s.jobs[msg.Job]
+		s.mu.Unlock()
+		if !ok {
+			s.sendError(msg.Job, errCodeNotFound, "no running job "+msg.Job)
+			return
+		}
+		cancel()
+	default:
+		s.sendError(msg.Job, errCodeBadRequest, fmt.Sprintf("unknown message type %q", msg.Type))
+	}
+}
+
+func decodeSocketRequest(raw json.RawMessage, target any) error {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(target)
+}
+
+func (s *syncSocket) start(ctx context.Context, msg socketMessage) {
+	s.mu.Lock()
+	authorized := s.authorized
+	s.mu.Unlock()
+	if !authorized {
+		s.sendError(msg.Job, errCodeUnauthorized, "unauthorized")
+		return
+	}
+	if ok, wait := s.limiter.allow(clientIP(s.request)); !ok {
+		seconds := max(1, int(math.Ceil(wait.Seconds())))
+		s.sendError(msg.Job, errCodeRateLimited, fmt.Sprintf("rate limit exceeded; retry in %ds", seconds))
+		return
+	}
+
+	cfg := s.cfg
+	if msg.Type == socketSync {
+		var req syncRequest
+		if err := decodeSocketRequest(msg.Request, &req); err != nil {
+			s.sendError(msg.Job, errCodeBadRequest, "invalid request: "+err.Error())
+			return
+		}
+		cfg = applySyncRequest(cfg, req)
+	} else {
+		var req syncAllRequest
+		if err := decodeSocketRequest(msg.Request, &req); err != nil {
+			s.sendError(msg.Job, errCodeBadRequest, "invalid request: "+err.Error())
+			return
+		}
+		cfg = applySyncAllRequest(cfg, req)
+	}
+
+	s.mu.Lock()
+	job := strings.TrimSpace(msg.Job)
+	if job == "" {
+		s.nextJob++
+		job = "job-" + strconv.Itoa(s.nextJob)
+	}
+	if _, running := s.jobs[job]; running {
+		s.mu.Unlock()
+		s.sendError(job, errCodeBadRequest, "job "+job+" is already running")
+		return
+	}
+	jobCtx, cancel := context.WithCancel(ctx)
+	tracked, ok := s.group.track(cancel)
+	if !ok {
+		s.mu.Unlock()
+		cancel()
+		s.sendError(job, errCodeNotReady, "server is shutting down")
+		return
+	}
+	s.jobs[job] = cancel
+	s.running.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.running.Done()
+		defer tracked()
+		defer func() {
+			s.mu.Lock()
+			delete(s.jobs, job)
+			s.mu.Unlock()
+			cancel()
+		}()
+		s.runJob(jobCtx, job, msg.Type, cfg)
+	}()
+}
+
+// runJob streams the job's log lines and progress events, then sends the same response /sync or /sync-all would
+// return, minus the logs that were already streamed.
+func (s *syncSocket) runJob(ctx context.Context, job, kind string, cfg SyncConfig) {
+	cfg.RequestID = strings.TrimPrefix(requestIDFromContext(s.request.Context())+"-"+job, "-")
+	cfg.logSink = func(line string) {
+		s.send(socketMessage{Type: "log", Job: job, Line: line})
+	}
+	cfg.progressSink = func(event syncProgress) {
+		s.send(socketMessage{Type: "progress", Job: job, Progress: &event})
+	}
+	target := cfg.GameID
+	if kind == socketSyncAll {
+		target = "all games"
+	}
+	s.send(socketMessage{Type: "started", Job: job, Message: target})
+
+	started := time.Now()
+	var response syncResponse
+	status := http.StatusOK
+	if kind == socketSync {
+		result, err := RunSync(ctx, cfg)
+		if err != nil {
+			response = syncErrorResponse(result, err)
+			status = http.StatusBadRequest
+		} else {
+			response = buildSyncResponseFromResult(result)
+		}
+	} else {
+		response = buildSyncAllResponse(runSyncAll(ctx, cfg))
+	}
+	response.RequestID = cfg.RequestID
+	response.Logs = nil
+	s.send(socketMessage{Type: "result", Job: job, Result: &response})
+	s.record(cfg, kind, status, response.Message, started)
+}
+
+func (s *syncSocket) record(cfg SyncConfig, kind string, status int, outcome string, started time.Time) {
+	if s.audit == nil {
+		return
+	}
+	s.mu.Lock()
+	token := s.token
+	s.mu.Unlock()
+	gameID := cfg.GameID
+	if kind == socketSyncAll {
+		gameID = ""
+	}
+	_ = s.audit.append(auditRecord{
+		Timestamp:        started.UTC().Format(time.RFC3339),
+		RequestID:        cfg.RequestID,
+		RemoteAddr:       clientIP(s.request),
+		Method:           "WS",
+		Path:             "/ws/" + kind,
+		GameID:           gameID,
+		DryRun:           cfg.DryRun,
+		TokenFingerprint: tokenFingerprint(token),
+		Status:           status,
+		Outcome:          outcome,
+		DurationMs:       time.Since(started).Milliseconds(),
+	})
+}
@@ -0,0 +1,201 @@
+package patchsync
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// blockingSheetFetcher serves sheets from memory but waits for cancellation before returning the named sheet.
+type blockingSheetFetcher struct {
+	fakeSheetFetcher
+	block string
+}
+
+func (f blockingSheetFetcher) FetchCSV(ctx context.Context, id string, sheetName string) (string, error) {
+	if sheetName == f.block {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	return f.fakeSheetFetcher.FetchCSV(ctx, id, sheetName)
+}
+
+func dialSyncSocket(t *testing.T, cfg SyncConfig, tokens *authTokens) *websocket.Conn {
+	t.Helper()
+	mux := http.NewServeMux()
+	registerSyncSocket(mux, map[string]struct{}{}, tokens, cfg, nil, nil, nil)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", "", server.URL)
+	if err != nil {
+		t.Fatalf("dial /ws: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	return conn
+}
+
+// receiveUntil reads frames until one of type want arrives and returns everything read.
+func receiveUntil(t *testing.T, conn *websocket.Conn, want string) []socketMessage {
+	t.Helper()
+	messages := []socketMessage{}
+	for {
+		var msg socketMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			t.Fatalf("waiting for %q: %v (got %+v)", want, err, messages)
+		}
+		messages = append(messages, msg)
+		if msg.Type == want {
+			return messages
+		}
+	}
+}
+
+func socketSyncConfig(t *testing.T, fetcher SheetFetcher) SyncConfig {
+	dir := t.TempDir()
+	return SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		Fetcher:         fetcher,
+	}
+}
+
+func TestSyncSocketRunsSyncAndStreams(t *testing.T) {
+	tokens, err := newAuthTokens("", "sync:syncer", "", false)
+	if err != nil {
+		t.Fatalf("newAuthTokens() error = %v", err)
+	}
+	cfg := socketSyncConfig(t, fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}})
+	conn := dialSyncSocket(t, cfg, tokens)
+
+	request, _ := json.Marshal(syncRequest{SheetNames: []string{"3.4"}, DryRun: true})
+	_ = websocket.JSON.Send(conn, socketMessage{Type: socketSync, Job: "first", Request: request})
+	if got := receiveUntil(t, conn, "error"); got[len(got)-1].Code != errCodeUnauthorized {
+		t.Fatalf("sync before auth = %+v, want %s", got, errCodeUnauthorized)
+	}
+
+	_ = websocket.JSON.Send(conn, socketMessage{Type: "auth", Token: "syncer"})
+	receiveUntil(t, conn, "ready")
+	_ = websocket.JSON.Send(conn, socketMessage{Type: socketSync, Job: "first", Request: request})
+	messages := receiveUntil(t, conn, "result")
+
+	counts := map[string]int{}
+	for _, msg := range messages {
+		if msg.Job != "first" {
+			t.Fatalf("message for job %q, want first: %+v", msg.Job, msg)
+		}
+		counts[msg.Type]++
+	}
+	if counts["started"] != 1 || counts["log"] == 0 || counts["progress"] == 0 {
+		t.Fatalf("streamed %v, want started, logs, and progress", counts)
+	}
+	result := messages[len(messages)-1].Result
+	if result == nil || !result.OK || len(result.Patches) != 1 || result.Logs != nil {
+		t.Fatalf("result = %+v, want one patch and no repeated logs", result)
+	}
+}
+
+func TestSyncSocketCancelsJob(t *testing.T) {
+	fetcher := blockingSheetFetcher{
+		fakeSheetFetcher: fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4"), "3.5": wuwaSheetCSV("3.5")}},
+		block:            "3.5",
+	}
+	conn := dialSyncSocket(t, socketSyncConfig(t, fetcher), nil)
+	receiveUntil(t, conn, "ready")
+
+	request, _ := json.Marshal(syncRequest{SheetNames: []string{"3.4", "3.5"}, DryRun: true})
+	_ = websocket.JSON.Send(conn, socketMessage{Type: socketSync, Request: request})
+	for {
+		msgs := receiveUntil(t, conn, "progress")
+		if progress := msgs[len(msgs)-1].Progress; progress.Sheet == "3.5" {
+			break
+		}
+	}
+	_ = websocket.JSON.Send(conn, socketMessage{Type: "cancel", Job: "job-1"})
+	messages := receiveUntil(t, conn, "result")
+	result := messages[len(messages)-1].Result
+	if result.OK || result.Code != errCodeSyncCancelled || strings.Join(result.Pending, ",") != "3.5" {
+		t.Fatalf("cancelled result = %+v, want %s with 3.5 pending", result, errCodeSyncCancelled)
+	}
+
+	_ = websocket.JSON.Send(conn, socketMessage{Type: "cancel", Job: "job-1"})
+	if got := receiveUntil(t, conn, "error"); got[len(got)-1].Code != errCodeNotFound {
+		t.Fatalf("cancel of finished job = %+v, want %s", got, errCodeNotFound)
+	}
+}
+
+// slowCancelFetcher blocks on the named sheet until its context is cancelled, then takes a while to return.
+type slowCancelFetcher struct {
+	fakeSheetFetcher
+	block    string
+	started  chan struct{}
+	finished *atomic.Bool
+}
+
+func (f slowCancelFetcher) FetchCSV(ctx context.Context, id string, sheetName string) (string, error) {
+	if sheetName != f.block {
+		return f.fakeSheetFetcher.FetchCSV(ctx, id, sheetName)
+	}
+	close(f.started)
+	<-ctx.Done()
+	time.Sleep(100 * time.Millisecond)
+	f.finished.Store(true)
+	return "", ctx.Err()
+}
+
+func TestServeUntilDoneDrainsSocketJobs(t *testing.T) {
+	fetcher := slowCancelFetcher{
+		fakeSheetFetcher: fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+		block:            "3.4",
+		started:          make(chan struct{}),
+		finished:         &atomic.Bool{},
+	}
+	jobs := newJobGroup()
+	mux := http.NewServeMux()
+	registerSyncSocket(mux, map[string]struct{}{}, nil, socketSyncConfig(t, fetcher), nil, nil, jobs)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serveUntilDone(ctx, mux, listener, time.Second, jobs)
+	}()
+
+	url := "ws://" + listener.Addr().String() + "/ws"
+	conn, err := websocket.Dial(url, "", "http://"+listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial /ws: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	receiveUntil(t, conn, "ready")
+	request, _ := json.Marshal(syncRequest{SheetNames: []string{"3.4"}, DryRun: true})
+	_ = websocket.JSON.Send(conn, socketMessage{Type: socketSync, Request: request})
+	<-fetcher.started
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Fatalf("serveUntilDone() error = %v", err)
+	}
+	if !fetcher.finished.Load() {
+		t.Fatal("serveUntilDone() returned while a socket job was still running")
+	}
+	if _, ok := jobs.track(func() {}); ok {
+		t.Fatal("job group accepted a job after shutdown")
+	}
+}
@@ -0,0 +1,103 @@
+package patchsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// syncStats summarizes what a sync wrote, so automation can decide on a rebuild without reading the output.
+// OutputSHA256 and BytesWritten stay empty when nothing was written (dry runs, no changes).
+type syncStats struct {
+	OutputSHA256 string             `json:"outputSha256,omitempty"`
+	BytesWritten int                `json:"bytesWritten,omitempty"`
+	Added        int                `json:"added"`
+	Updated      int                `json:"updated"`
+	Removed      int                `json:"removed"`
+	RewardsDelta map[string]float64 `json:"rewardsDelta,omitempty"`
+	Patches      []patchPullsDelta  `json:"patches,omitempty"`
+}
+
+// patchPullsDelta is how much one changed patch moved the total pulls (every gate on, option toggles off).
+// Tag- or note-only edits have a zero delta.
+type patchPullsDelta struct {
+	Patch      string  `json:"patch"`
+	ChangeType string  `json:"changeType"`
+	WIP        bool    `json:"wip,omitempty"`
+	PullsDelta float64 `json:"pullsDelta"`
+}
+
+func totalPatchPulls(gameID string, patch Patch) float64 {
+	return computePatchTotals(gameID, patch, projectionOptions{MonthlySub: true, BattlePassTier: 3}).Pulls
+}
+
+func (stats *syncStats) recordOutput(content string) {
+	sum := sha256.Sum256([]byte(content))
+	stats.OutputSHA256 = hex.EncodeToString(sum[:])
+	stats.BytesWritten = len(content)
+}
+
+// totalRewards adds up the rewards of every source of patches, keyed by the game's currency names.
+func totalRewards(gameID string, patches []Patch) map[string]float64 {
+	totals := map[string]float64{}
+	for _, patch := range patches {
+		for _, src := range patch.Sources {
+			for currency, amount := range rewardsForGame(src.Rewards, gameID) {
+				totals[currency] += amount
+			}
+		}
+	}
+	return totals
+}
+
+// buildSyncStats counts the change log entries by type and compares reward totals before and after the sync.
+// Forecast patches are left out of the totals, since every sync regenerates them.
+func buildSyncStats(gameID string, entries []patchChangeLogEntry, before, after []Patch) syncStats {
+	stats := syncStats{}
+	beforeByID, afterByID := map[string]Patch{}, map[string]Patch{}
+	for _, patch := range before {
+		beforeByID[patchIDOrFallback(patch)] = patch
+	}
+	for _, patch := range after {
+		afterByID[patchIDOrFallback(patch)] = patch
+	}
+	for _, entry := range entries {
+		previous, hadPrevious := beforeByID[entry.Patch]
+		next, hasNext := afterByID[entry.Patch]
+		delta := patchPullsDelta{Patch: entry.Patch, ChangeType: entry.ChangeType}
+		if hasNext {
+			delta.WIP = isWIPPatch(next)
+			delta.PullsDelta = totalPatchPulls(gameID, next)
+		} else if hadPrevious {
+			delta.WIP = isWIPPatch(previous)
+		}
+		if hadPrevious {
+			delta.PullsDelta -= totalPatchPulls(gameID, previous)
+		}
+		delta.PullsDelta = roundToTenth(delta.PullsDelta)
+		stats.Patches = append(stats.Patches, delta)
+		switch entry.ChangeType {
+		case "added":
+			stats.Added++
+		case "updated":
+			stats.Updated++
+		case "removed":
+			stats.Removed++
+		}
+	}
+	previous := totalRewards(gameID, withoutForecastPatches(before))
+	next := totalRewards(gameID, withoutForecastPatches(after))
+	for currency := range next {
+		if _, ok := previous[currency]; !ok {
+			previous[currency] = 0
+		}
+	}
+	for currency, amount := range previous {
+		if delta := roundToTenth(next[currency] - amount); delta != 0 {
+			if stats.RewardsDelta == nil {
+				stats.RewardsDelta = map[string]float64{}
+			}
+			stats.RewardsDelta[currency] = delta
+		}
+	}
+	return stats
+}
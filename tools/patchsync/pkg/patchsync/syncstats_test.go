@@ -0,0 +1,67 @@
+package patchsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuildSyncStats(t *testing.T) {
+	before := []Patch{
+		{Patch: "3.4", Sources: []Source{{ID: "events", Rewards: Rewards{Oroberyl: 5000, Chartered: 30}}}},
+	}
+	after := []Patch{
+		{Patch: "3.4", Sources: []Source{{ID: "events", Rewards: Rewards{Oroberyl: 5200, Chartered: 30}}}},
+		{Patch: "3.5", Sources: []Source{{ID: "events", Rewards: Rewards{Oroberyl: 4000, Basic: 5}}}},
+		{Patch: "3.6", Tags: []string{forecastTag}, Sources: []Source{{ID: "events", Rewards: Rewards{Oroberyl: 9999}}}},
+	}
+	entries := []patchChangeLogEntry{{Patch: "3.4", ChangeType: "updated"}, {Patch: "3.5", ChangeType: "added"}}
+	stats := buildSyncStats(gameIDWuwa, entries, before, after)
+	if stats.Added != 1 || stats.Updated != 1 || stats.Removed != 0 {
+		t.Fatalf("counts = %+v", stats)
+	}
+	if want := map[string]float64{"astrite": 4200, "lustrousTide": 5}; !reflect.DeepEqual(stats.RewardsDelta, want) {
+		t.Fatalf("rewards delta = %v, want %v", stats.RewardsDelta, want)
+	}
+	if len(stats.Patches) != 2 || stats.Patches[1].Patch != "3.5" || stats.Patches[1].ChangeType != "added" {
+		t.Fatalf("per-patch deltas = %+v", stats.Patches)
+	}
+	if stats.OutputSHA256 != "" || stats.BytesWritten != 0 {
+		t.Fatalf("output recorded without a write: %+v", stats)
+	}
+}
+
+func TestRunSyncReportsOutputHash(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		OutputPath:      filepath.Join(dir, "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "hashes.json"),
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+	}
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	body, err := os.ReadFile(cfg.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(body)
+	if result.Stats.OutputSHA256 != hex.EncodeToString(sum[:]) || result.Stats.BytesWritten != len(body) {
+		t.Fatalf("stats = %+v, want the hash and size of the written file (%d bytes)", result.Stats, len(body))
+	}
+	if result.Stats.Added != 1 || result.Stats.RewardsDelta["astrite"] == 0 {
+		t.Fatalf("stats = %+v, want one added patch with an astrite delta", result.Stats)
+	}
+	if response := buildSyncResponseFromResult(result); response.Stats == nil || response.Stats.OutputSHA256 != result.Stats.OutputSHA256 {
+		t.Fatalf("response stats = %+v", response.Stats)
+	}
+}
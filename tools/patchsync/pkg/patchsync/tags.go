@@ -0,0 +1,89 @@
+package patchsync
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Patch tags recognized in tab names, header cells, and Data sheet columns. docs/PATCH_WORKFLOW.md lists
+// the markers behind each one.
+const (
+	tagWIP         = "WIP"
+	tagBeta        = "beta"
+	tagSpeculative = "speculative"
+	tagConfirmed   = "confirmed"
+	tagRerun       = "rerun"
+)
+
+func tagWordPattern(words string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:` + words + `)(?:[^a-z0-9]|$)`)
+}
+
+var wipTagPattern = tagWordPattern(`wip|stc`)
+
+// patchTagMarkers is checked in order, which is also the order tags are emitted in.
+var patchTagMarkers = []struct {
+	tag     string
+	pattern *regexp.Regexp
+}{
+	{tagWIP, wipTagPattern},
+	{tagBeta, tagWordPattern(`beta|cbt`)},
+	// A version followed by asterisks ("2.1*") marks numbers the sheet author has not verified yet.
+	{tagSpeculative, regexp.MustCompile(`(?i)\d\*+|(?:^|[^a-z0-9])(?:speculative|spec|leaks?|estimated?|tbc|unconfirmed)(?:[^a-z0-9]|$)`)},
+	{tagConfirmed, tagWordPattern(`confirmed|official|final`)},
+	{tagRerun, tagWordPattern(`re-?runs?`)},
+}
+
+func patchTagsFromSheetName(values ...string) []string {
+	tags := []string{}
+	for _, marker := range patchTagMarkers {
+		for _, raw := range values {
+			normalized := normalizeSheetNameForMatch(raw)
+			if normalized != "" && marker.pattern.MatchString(normalized) {
+				tags = append(tags, marker.tag)
+				break
+			}
+		}
+	}
+	return mergeTagLists(nil, tags)
+}
+
+// canonicalTag spells known tags the documented way, so "wip" and "Beta" merge with "WIP" and "beta".
+func canonicalTag(tag string) string {
+	tag = strings.TrimSpace(tag)
+	for _, marker := range patchTagMarkers {
+		if strings.EqualFold(tag, marker.tag) {
+			return marker.tag
+		}
+	}
+	return tag
+}
+
+// mergeTagLists appends extra to base without duplicates. "confirmed" wins over "speculative", so a Data sheet
+// that confirms a patch clears the asterisk of its tab.
+func mergeTagLists(base []string, extra []string) []string {
+	if len(base) == 0 && len(extra) == 0 {
+		return nil
+	}
+	seen := map[string]struct{}{}
+	merged := make([]string, 0, len(base)+len(extra))
+	for _, tag := range append(append([]string{}, base...), extra...) {
+		normalized := canonicalTag(tag)
+		if normalized == "" {
+			continue
+		}
+		if _, ok := seen[normalized]; ok {
+			continue
+		}
+		seen[normalized] = struct{}{}
+		merged = append(merged, normalized)
+	}
+	if slices.Contains(merged, tagConfirmed) {
+		merged = slices.DeleteFunc(merged, func(tag string) bool { return tag == tagSpeculative })
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
@@ -0,0 +1,56 @@
+package patchsync
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPatchTagsFromSheetName(t *testing.T) {
+	cases := []struct {
+		values []string
+		want   []string
+	}{
+		{[]string{"2.1"}, nil},
+		{[]string{"2.1 WIP"}, []string{tagWIP}},
+		{[]string{"2.1 (STC)"}, []string{tagWIP}},
+		{[]string{"2.1*"}, []string{tagSpeculative}},
+		{[]string{"2.1", "Version 2.1* (Beta)"}, []string{tagBeta, tagSpeculative}},
+		{[]string{"2.1 leaks", "Version 2.1 - confirmed"}, []string{tagConfirmed}},
+		{[]string{"2.1 Rerun"}, []string{tagRerun}},
+		{[]string{"2.1 unconfirmed"}, []string{tagSpeculative}},
+		{[]string{"Alphabet"}, nil},
+	}
+	for _, tc := range cases {
+		if got := patchTagsFromSheetName(tc.values...); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("patchTagsFromSheetName(%q) = %v, want %v", tc.values, got, tc.want)
+		}
+	}
+}
+
+func TestMergeTagLists(t *testing.T) {
+	got := mergeTagLists([]string{"wip", " Beta ", "custom"}, []string{"WIP", "beta", "custom", ""})
+	if want := []string{tagWIP, tagBeta, "custom"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("merged tags = %v, want %v", got, want)
+	}
+	got = mergeTagLists([]string{tagSpeculative}, []string{"Confirmed"})
+	if want := []string{tagConfirmed}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("confirmed merge = %v, want %v", got, want)
+	}
+	if got := mergeTagLists(nil, []string{" "}); got != nil {
+		t.Fatalf("blank tags = %v, want nil", got)
+	}
+}
+
+func TestParseDataSheetPatchTagsTaxonomy(t *testing.T) {
+	tags, err := parseDataSheetPatchTags(csvLines(
+		"Version,2.0,2.1*,2.2 (beta),2.3 rerun",
+		"Events,100,200,300,400",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"2.1": {tagSpeculative}, "2.2": {tagBeta}, "2.3": {tagRerun}}
+	if !reflect.DeepEqual(tags, want) {
+		t.Fatalf("data sheet tags = %v, want %v", tags, want)
+	}
+}
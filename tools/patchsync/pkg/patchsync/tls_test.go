@@ -0,0 +1,50 @@
+package patchsync
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSelfSignedCertificateServesHTTPS(t *testing.T) {
+	hosts := selfSignedHosts("192.168.1.20:8787")
+	if len(hosts) != 4 || hosts[3] != "192.168.1.20" {
+		t.Fatalf("hosts = %v", hosts)
+	}
+	cert, err := generateSelfSignedCertificate(hosts, time.Now())
+	if err != nil {
+		t.Fatalf("generateSelfSignedCertificate() error = %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	server.StartTLS()
+	defer server.Close()
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("HTTPS request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	if _, err := buildServerTLSConfig("cert.pem", "", false, ""); err == nil {
+		t.Fatalf("expected error when only --tls-cert is set")
+	}
+	if config, err := buildServerTLSConfig("", "", false, ""); err != nil || config != nil {
+		t.Fatalf("expected plain HTTP without TLS flags, got %v %v", config, err)
+	}
+}
@@ -0,0 +1,284 @@
+package patchsync
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FirstBuyDouble replaces Bonus with Base on the first purchase of a tier.
+type topupPackage struct {
+	ID             string  `json:"id"`
+	Price          float64 `json:"price"`
+	Base           int     `json:"base"`
+	Bonus          int     `json:"bonus"`
+	FirstBuyDouble bool    `json:"firstBuyDouble"`
+}
+
+type topupPurchase struct {
+	ID       string  `json:"id"`
+	FirstBuy bool    `json:"firstBuy"`
+	Count    int     `json:"count"`
+	Price    float64 `json:"price"`
+	Currency int     `json:"currency"`
+}
+
+type topupValue struct {
+	ID                     string  `json:"id"`
+	Price                  float64 `json:"price"`
+	PullsPerDollar         float64 `json:"pullsPerDollar"`
+	FirstBuyPullsPerDollar float64 `json:"firstBuyPullsPerDollar,omitempty"`
+}
+
+type topupResult struct {
+	GameID         string          `json:"gameId"`
+	Currency       string          `json:"currency"`
+	Shortfall      float64         `json:"shortfall"`
+	CurrencyNeeded int             `json:"currencyNeeded"`
+	FirstBuy       bool            `json:"firstBuy"`
+	Purchases      []topupPurchase `json:"purchases"`
+	TotalPrice     float64         `json:"totalPrice"`
+	TotalCurrency  int             `json:"totalCurrency"`
+	TotalPulls     float64         `json:"totalPulls"`
+	Ranking        []topupValue    `json:"ranking"`
+}
+
+// Standard USD price tiers of the premium-currency shop. Endfield tiers are estimates until the global price list is final.
+var topupCatalogsByGame = map[string][]topupPackage{
+	gameIDEndfield: {
+		{ID: "tier1", Price: 0.99, Base: 3, Bonus: 0, FirstBuyDouble: true},
+		{ID: "tier2", Price: 4.99, Base: 15, Bonus: 1, FirstBuyDouble: true},
+		{ID: "tier3", Price: 14.99, Base: 45, Bonus: 5, FirstBuyDouble: true},
+		{ID: "tier4", Price: 29.99, Base: 90, Bonus: 12, FirstBuyDouble: true},
+		{ID: "tier5", Price: 49.99, Base: 150, Bonus: 25, FirstBuyDouble: true},
+		{ID: "tier6", Price: 99.99, Base: 300, Bonus: 60, FirstBuyDouble: true},
+	},
+	gameIDWuwa:    standardTopupCatalog(),
+	gameIDZzz:     standardTopupCatalog(),
+	gameIDGenshin: standardTopupCatalog(),
+	gameIDHsr:     standardTopupCatalog(),
+}
+
+func standardTopupCatalog() []topupPackage {
+	return []topupPackage{
+		{ID: "tier1", Price: 0.99, Base: 60, Bonus: 0, FirstBuyDouble: true},
+		{ID: "tier2", Price: 4.99, Base: 300, Bonus: 30, FirstBuyDouble: true},
+		{ID: "tier3", Price: 14.99, Base: 980, Bonus: 110, FirstBuyDouble: true},
+		{ID: "tier4", Price: 29.99, Base: 1980, Bonus: 260, FirstBuyDouble: true},
+		{ID: "tier5", Price: 49.99, Base: 3280, Bonus: 600, FirstBuyDouble: true},
+		{ID: "tier6", Price: 99.99, Base: 6480, Bonus: 1600, FirstBuyDouble: true},
+	}
+}
+
+func premiumCurrencyKey(gameID string) string {
+	for key, value := range rewardsForGame(Rewards{Origeometry: 1}, gameID) {
+		if value == 1 {
+			return key
+		}
+	}
+	return "origeometry"
+}
+
+func priceCents(price float64) int {
+	return int(math.Round(price * 100))
+}
+
+func premiumPerPull(c pullConversion) float64 {
+	if c.PremiumToBase <= 0 {
+		return 0
+	}
+	return c.BasePerPull / c.PremiumToBase
+}
+
+type topupOption struct {
+	pkg      topupPackage
+	firstBuy bool
+	amount   int
+	cents    int
+}
+
+// cheapestTopup finds the cheapest multiset of purchases worth at least need units.
+// First-buy doubles can be used once per tier; regular purchases can repeat.
+func cheapestTopup(catalog []topupPackage, need int, firstBuy bool) ([]int, []topupOption) {
+	options := []topupOption{}
+	for _, pkg := range catalog {
+		if firstBuy && pkg.FirstBuyDouble {
+			options = append(options, topupOption{pkg: pkg, firstBuy: true, amount: pkg.Base * 2, cents: priceCents(pkg.Price)})
+		}
+		options = append(options, topupOption{pkg: pkg, amount: pkg.Base + pkg.Bonus, cents: priceCents(pkg.Price)})
+	}
+	type state struct {
+		cents  int
+		counts []int
+	}
+	best := make([]state, need+1)
+	for idx := 1; idx <= need; idx++ {
+		best[idx].cents = -1
+	}
+	best[0].counts = make([]int, len(options))
+	relax := func(from, optionIdx int) {
+		if best[from].cents < 0 {
+			return
+		}
+		option := options[optionIdx]
+		to := min(need, from+option.amount)
+		cents := best[from].cents + option.cents
+		if best[to].cents >= 0 && best[to].cents <= cents {
+			return
+		}
+		counts := append([]int(nil), best[from].counts...)
+		counts[optionIdx]++
+		best[to] = state{cents: cents, counts: counts}
+	}
+	for optionIdx, option := range options {
+		if option.amount <= 0 {
+			continue
+		}
+		if option.firstBuy {
+			for from := need - 1; from >= 0; from-- {
+				relax(from, optionIdx)
+			}
+			continue
+		}
+		for from := 0; from < need; from++ {
+			relax(from, optionIdx)
+		}
+	}
+	return best[need].counts, options
+}
+
+func rankTopupPackages(catalog []topupPackage, perPull float64) []topupValue {
+	ranking := make([]topupValue, 0, len(catalog))
+	for _, pkg := range catalog {
+		if pkg.Price <= 0 || perPull <= 0 {
+			continue
+		}
+		value := topupValue{
+			ID:             pkg.ID,
+			Price:          pkg.Price,
+			PullsPerDollar: math.Round(float64(pkg.Base+pkg.Bonus)/perPull/pkg.Price*100) / 100,
+		}
+		if pkg.FirstBuyDouble {
+			value.FirstBuyPullsPerDollar = math.Round(float64(pkg.Base*2)/perPull/pkg.Price*100) / 100
+		}
+		ranking = append(ranking, value)
+	}
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return ranking[i].PullsPerDollar > ranking[j].PullsPerDollar
+	})
+	return ranking
+}
+
+func planTopup(gameID string, shortfall float64, firstBuy bool) (topupResult, error) {
+	profile, err := ResolveGameProfile(gameID)
+	if err != nil {
+		return topupResult{}, err
+	}
+	catalog, ok := topupCatalogsByGame[profile.ID]
+	if !ok {
+		return topupResult{}, fmt.Errorf("no top-up catalog for game %s", profile.ID)
+	}
+	if shortfall < 0 {
+		return topupResult{}, errors.New("shortfall must not be negative")
+	}
+	perPull := premiumPerPull(pullConversionForGame(profile.ID))
+	if perPull <= 0 {
+		return topupResult{}, fmt.Errorf("game %s has no premium currency conversion", profile.ID)
+	}
+	result := topupResult{
+		GameID:         profile.ID,
+		Currency:       premiumCurrencyKey(profile.ID),
+		Shortfall:      shortfall,
+		CurrencyNeeded: int(math.Ceil(shortfall*perPull - 1e-9)),
+		FirstBuy:       firstBuy,
+		Purchases:      []topupPurchase{},
+		Ranking:        rankTopupPackages(catalog, perPull),
+	}
+	if result.CurrencyNeeded <= 0 {
+		return result, nil
+	}
+	counts, options := cheapestTopup(catalog, result.CurrencyNeeded, firstBuy)
+	cents := 0
+	for idx, count := range counts {
+		if count == 0 {
+			continue
+		}
+		option := options[idx]
+		result.Purchases = append(result.Purchases, topupPurchase{
+			ID:       option.pkg.ID,
+			FirstBuy: option.firstBuy,
+			Count:    count,
+			Price:    option.pkg.Price,
+			Currency: option.amount * count,
+		})
+		cents += option.cents * count
+		result.TotalCurrency += option.amount * count
+	}
+	result.TotalPrice = float64(cents) / 100
+	result.TotalPulls = roundToTenth(float64(result.TotalCurrency) / perPull)
+	return result, nil
+}
+
+func parseTopupQuery(r *http.Request) (string, float64, bool, error) {
+	query := r.URL.Query()
+	shortfall, err := strconv.ParseFloat(strings.TrimSpace(query.Get("shortfall")), 64)
+	if err != nil {
+		return "", 0, false, errors.New("shortfall must be a number")
+	}
+	firstBuy := true
+	if raw := strings.TrimSpace(query.Get("firstBuy")); raw != "" {
+		if firstBuy, err = strconv.ParseBool(raw); err != nil {
+			return "", 0, false, errors.New("firstBuy must be true or false")
+		}
+	}
+	return query.Get("game"), shortfall, firstBuy, nil
+}
+
+func registerTopupAPI(mux *http.ServeMux, allowedOrigins map[string]struct{}) {
+	mux.HandleFunc("/topup", func(w http.ResponseWriter, r *http.Request) {
+		if !withCORS(w, r, allowedOrigins) {
+			writeJSON(w, http.StatusForbidden, syncResponse{
+				OK:      false,
+				Message: "origin is not allowed",
+			})
+			return
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, syncResponse{
+				OK:      false,
+				Message: "method not allowed",
+			})
+			return
+		}
+		gameID, shortfall, firstBuy, err := parseTopupQuery(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, syncResponse{
+				OK:      false,
+				Message: err.Error(),
+			})
+			return
+		}
+		topup, err := planTopup(gameID, shortfall, firstBuy)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, syncResponse{
+				OK:      false,
+				Message: err.Error(),
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, syncResponse{
+			OK:      true,
+			Message: "top-up plan computed",
+			GameID:  topup.GameID,
+			Topup:   &topup,
+		})
+	})
+}
@@ -0,0 +1,37 @@
+package patchsync
+
+import "testing"
+
+func TestPlanTopup(t *testing.T) {
+	// 10 pulls in Genshin need 1600 genesis crystals; the first-buy 980 tier alone gives 1960.
+	result, err := planTopup(gameIDGenshin, 10, true)
+	if err != nil {
+		t.Fatalf("planTopup() error = %v", err)
+	}
+	if result.CurrencyNeeded != 1600 || result.Currency != "genesisCrystal" {
+		t.Fatalf("needed=%d currency=%s, want 1600 genesisCrystal", result.CurrencyNeeded, result.Currency)
+	}
+	if result.TotalPrice != 14.99 || len(result.Purchases) != 1 || !result.Purchases[0].FirstBuy || result.Purchases[0].ID != "tier3" {
+		t.Fatalf("unexpected first-buy plan %+v", result)
+	}
+
+	result, err = planTopup(gameIDGenshin, 10, false)
+	if err != nil {
+		t.Fatalf("planTopup() error = %v", err)
+	}
+	// 1090 + 330 + 3 x 60 = 1600 for 14.99 + 4.99 + 2.97.
+	if result.TotalPrice != 22.95 || result.TotalCurrency != 1600 || result.TotalPulls != 10 {
+		t.Fatalf("price=%v currency=%d pulls=%v, want 22.95/1600/10", result.TotalPrice, result.TotalCurrency, result.TotalPulls)
+	}
+	if result.Ranking[0].ID != "tier6" {
+		t.Fatalf("best value tier = %s, want tier6", result.Ranking[0].ID)
+	}
+
+	if _, err := planTopup(gameIDGenshin, -1, true); err == nil {
+		t.Fatalf("expected negative shortfall error")
+	}
+	empty, err := planTopup(gameIDEndfield, 0, true)
+	if err != nil || len(empty.Purchases) != 0 || empty.TotalPrice != 0 {
+		t.Fatalf("zero shortfall should need no purchases, got %+v err=%v", empty, err)
+	}
+}
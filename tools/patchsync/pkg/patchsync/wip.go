@@ -0,0 +1,36 @@
+package patchsync
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	wipModeInline  = ""
+	wipModeDraft   = "draft"
+	wipModeExclude = "exclude"
+)
+
+var generatedDraftPatchesBlockPattern = regexp.MustCompile(`(?s)export const GENERATED_DRAFT_PATCHES\s*=\s*(\[[\s\S]*?\]);`)
+
+func isWIPPatch(patch Patch) bool {
+	for _, tag := range patch.Tags {
+		if strings.EqualFold(strings.TrimSpace(tag), "WIP") {
+			return true
+		}
+	}
+	return false
+}
+
+func splitWIPPatches(patches []Patch) ([]Patch, []Patch) {
+	published := make([]Patch, 0, len(patches))
+	drafts := []Patch{}
+	for _, patch := range patches {
+		if isWIPPatch(patch) {
+			drafts = append(drafts, patch)
+			continue
+		}
+		published = append(published, patch)
+	}
+	return published, drafts
+}
@@ -0,0 +1,46 @@
+package patchsync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteGeneratedFileDraftWIP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuwa.generated.js")
+	meta := GeneratedMeta{GameID: gameIDWuwa, SpreadsheetID: "sheet", WIPMode: wipModeDraft}
+	patches := []Patch{
+		{ID: "2.0", Patch: "2.0", Sources: []Source{}},
+		{ID: "2.1", Patch: "2.1", Tags: []string{"WIP"}, Sources: []Source{}},
+	}
+	if err := writeGeneratedFile(path, patches, meta); err != nil {
+		t.Fatalf("writeGeneratedFile() error = %v", err)
+	}
+	payload, err := readGeneratedPayload(path)
+	if err != nil {
+		t.Fatalf("readGeneratedPayload() error = %v", err)
+	}
+	if len(payload.Patches) != 1 {
+		t.Fatalf("expected only the published patch in GENERATED_PATCHES, got %d", len(payload.Patches))
+	}
+	body, _ := os.ReadFile(path)
+	draftMatch := generatedDraftPatchesBlockPattern.FindStringSubmatch(string(body))
+	if len(draftMatch) < 2 || !strings.Contains(draftMatch[1], `"2.1"`) {
+		t.Fatalf("expected 2.1 in GENERATED_DRAFT_PATCHES, got %q", draftMatch)
+	}
+	// Drafts still count as existing generated patches for the next sync.
+	all, err := readGeneratedPatches(path)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("readGeneratedPatches() = %d patches, err %v; want 2", len(all), err)
+	}
+
+	meta.WIPMode = wipModeInline
+	if err := writeGeneratedFile(path, patches, meta); err != nil {
+		t.Fatalf("writeGeneratedFile() error = %v", err)
+	}
+	body, _ = os.ReadFile(path)
+	if !strings.Contains(string(body), "export const GENERATED_DRAFT_PATCHES = [];") {
+		t.Fatalf("expected an empty draft block in inline mode")
+	}
+}
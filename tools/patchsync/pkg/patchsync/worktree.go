@@ -0,0 +1,111 @@
+package patchsync
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w (%s)", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// resolveRepoRoot returns the top level of the repository containing dir, or the working directory when dir
+// is empty.
+func resolveRepoRoot(dir string) (string, error) {
+	if strings.TrimSpace(dir) == "" {
+		dir = "."
+	}
+	return runGit(dir, "rev-parse", "--show-toplevel")
+}
+
+// syncWorktree is a temporary checkout of a new sync branch. Syncs with --create-branch write and commit
+// there, so the operator's checkout keeps its branch, index, and uncommitted work.
+type syncWorktree struct {
+	repoRoot  string
+	dir       string
+	branch    string
+	committed bool
+}
+
+func openSyncWorktree(repoRoot, prefix string, now time.Time) (*syncWorktree, error) {
+	root, err := resolveRepoRoot(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		prefix = "data/sheets"
+	}
+	branch := fmt.Sprintf("%s-%s", prefix, now.Format("20060102-150405"))
+	dir, err := os.MkdirTemp("", "patchsync-worktree-")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := runGit(root, "worktree", "add", "-b", branch, dir, "HEAD"); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	return &syncWorktree{repoRoot: root, dir: dir, branch: branch}, nil
+}
+
+// path maps a path inside the repository to the same file in the worktree. Paths outside the repository
+// are returned unchanged with ok=false.
+func (w *syncWorktree) path(target string) (string, bool) {
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return target, false
+	}
+	// The parent may not exist yet (a fresh --api-dir); git reports the root with symlinks resolved.
+	if parent, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		abs = filepath.Join(parent, filepath.Base(abs))
+	}
+	rel, err := filepath.Rel(w.repoRoot, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return target, false
+	}
+	return filepath.Join(w.dir, rel), true
+}
+
+// commit commits paths on the worktree branch and returns the new commit, or "" when nothing changed.
+func (w *syncWorktree) commit(message string, paths []string) (string, error) {
+	if _, err := runGit(w.dir, append([]string{"add", "--"}, paths...)...); err != nil {
+		return "", err
+	}
+	if status, err := runGit(w.dir, "status", "--porcelain", "--untracked-files=no"); err != nil || status == "" {
+		return "", err
+	}
+	if _, err := runGit(w.dir, "commit", "-q", "-m", message); err != nil {
+		return "", err
+	}
+	w.committed = true
+	return runGit(w.dir, "rev-parse", "HEAD")
+}
+
+// remove deletes the worktree. A branch with a sync commit stays in the repository; one without (a failed
+// sync or one that changed nothing) is deleted too. A worktree that cannot be removed is only pruned by the
+// next "git worktree prune", so this is best effort.
+func (w *syncWorktree) remove() {
+	_, _ = runGit(w.repoRoot, "worktree", "remove", "--force", w.dir)
+	_ = os.RemoveAll(w.dir)
+	if !w.committed {
+		_, _ = runGit(w.repoRoot, "branch", "-D", w.branch)
+	}
+}
+
+func syncCommitTitle(gameID string, patches []Patch) string {
+	title := fmt.Sprintf("Sync %s patches", gameID)
+	if names := patchNamesFromPatches(patches); len(names) > 0 {
+		title += " " + strings.Join(names, ", ")
+	}
+	return title
+}
@@ -0,0 +1,182 @@
+package patchsync
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v (%s)", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// initSyncRepo creates a repository on main with one commit and makes it the working directory.
+func initSyncRepo(t *testing.T) string {
+	t.Helper()
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "patchsync")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "patchsync@example.com")
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+	gitOutput(t, dir, "init", "-q", "-b", "main")
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("operator work\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitOutput(t, dir, "add", "notes.txt")
+	gitOutput(t, dir, "commit", "-q", "-m", "initial")
+	return dir
+}
+
+func TestRunSyncCreatesBranchInWorktree(t *testing.T) {
+	dir := initSyncRepo(t)
+	// Uncommitted work of the operator, one change staged and one not.
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("operator work, staged\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitOutput(t, dir, "add", "notes.txt")
+	if err := os.WriteFile(filepath.Join(dir, "draft.txt"), []byte("untracked\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		OutputPath:      filepath.Join(dir, "src", "data", "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "logs", "hashes.json"),
+		APIDir:          filepath.Join(dir, "public", "api"),
+		CreateBranch:    true,
+		Clock:           FixedClock(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+	}
+	result, err := RunSync(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RunSync() error = %v", err)
+	}
+	if result.BranchName != "data/sheets-20250102-030405" || result.Commit == "" {
+		t.Fatalf("unexpected branch %q / commit %q", result.BranchName, result.Commit)
+	}
+
+	if head := gitOutput(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); head != "main" {
+		t.Fatalf("operator checkout moved to %q", head)
+	}
+	if status := gitOutput(t, dir, "status", "--porcelain", "--", "notes.txt", "draft.txt"); status != "M  notes.txt\n?? draft.txt" {
+		t.Fatalf("operator work changed:\n%s", status)
+	}
+	if _, err := os.Stat(cfg.OutputPath); !os.IsNotExist(err) {
+		t.Fatalf("generated file should only be written on the branch, stat err = %v", err)
+	}
+	if worktrees := gitOutput(t, dir, "worktree", "list"); strings.Count(worktrees, "\n") != 0 {
+		t.Fatalf("temporary worktree left behind:\n%s", worktrees)
+	}
+
+	files := gitOutput(t, dir, "show", "--name-only", "--format=%s", result.Commit)
+	for _, want := range []string{"Sync wuthering-waves patches 3.4", "src/data/wuwa.generated.js", "public/api/wuthering-waves/index.json"} {
+		if !strings.Contains(files, want) {
+			t.Fatalf("commit is missing %q:\n%s", want, files)
+		}
+	}
+	if strings.Contains(files, "notes.txt") {
+		t.Fatalf("operator work ended up in the sync commit:\n%s", files)
+	}
+}
+
+func TestRunSyncRejectsOutputOutsideRepository(t *testing.T) {
+	dir := initSyncRepo(t)
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		OutputPath:      filepath.Join(t.TempDir(), "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "logs", "hashes.json"),
+		CreateBranch:    true,
+		Fetcher:         fakeSheetFetcher{sheets: map[string]string{"3.4": wuwaSheetCSV("3.4")}},
+	}
+	_, err := RunSync(t.Context(), cfg)
+	if err == nil || errorCode(err) != errCodeGit || !strings.Contains(err.Error(), "outside the repository") {
+		t.Fatalf("expected ERR_GIT for an output outside the repository, got %v", err)
+	}
+	if worktrees := gitOutput(t, dir, "worktree", "list"); strings.Count(worktrees, "\n") != 0 {
+		t.Fatalf("temporary worktree left behind:\n%s", worktrees)
+	}
+}
+
+func TestRunSyncBranchStartsFromCommittedOutput(t *testing.T) {
+	dir := initSyncRepo(t)
+	cfg := SyncConfig{
+		GameID:          gameIDWuwa,
+		SpreadsheetID:   "fake",
+		OutputPath:      filepath.Join(dir, "src", "data", "wuwa.generated.js"),
+		BasePatchesPath: filepath.Join(dir, "patches.js"),
+		OverridesDir:    filepath.Join(dir, "overrides"),
+		SheetHashPath:   filepath.Join(dir, "logs", "hashes.json"),
+		Clock:           FixedClock(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)),
+	}
+	sync := func(cfg SyncConfig, sheet string) (SyncResult, error) {
+		cfg.Fetcher = fakeSheetFetcher{sheets: map[string]string{sheet: wuwaSheetCSV(sheet)}}
+		return RunSync(t.Context(), cfg)
+	}
+	patchIDs := func(path string) string {
+		patches, err := readGeneratedPatches(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, patch := range patches {
+			ids = append(ids, patchIDOrFallback(patch))
+		}
+		return strings.Join(ids, ",")
+	}
+
+	if _, err := sync(cfg, "3.3"); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	gitOutput(t, dir, "add", "src")
+	gitOutput(t, dir, "commit", "-q", "-m", "sync 3.3")
+	// An uncommitted local sync must not leak into the branch.
+	if _, err := sync(cfg, "3.5"); err != nil {
+		t.Fatalf("local sync: %v", err)
+	}
+
+	branchCfg := cfg
+	branchCfg.CreateBranch = true
+	result, err := sync(branchCfg, "3.4")
+	if err != nil || result.Commit == "" {
+		t.Fatalf("branch sync commit %q: %v", result.Commit, err)
+	}
+	committed := filepath.Join(t.TempDir(), "wuwa.generated.js")
+	if err := os.WriteFile(committed, []byte(gitOutput(t, dir, "show", result.BranchName+":src/data/wuwa.generated.js")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := patchIDs(committed); got != "3.3,3.4" {
+		t.Fatalf("branch patches = %s, want 3.3,3.4", got)
+	}
+	if got := patchIDs(cfg.OutputPath); got != "3.3,3.5" {
+		t.Fatalf("operator patches = %s, want 3.3,3.5", got)
+	}
+
+	// A branch sync that fails leaves no empty branch behind.
+	branchCfg.Clock = FixedClock(time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC))
+	branchCfg.Fetcher = fakeSheetFetcher{sheets: map[string]string{}}
+	if _, err := RunSync(t.Context(), branchCfg); err == nil {
+		t.Fatalf("expected the sync without sheets to fail")
+	}
+	if branches := gitOutput(t, dir, "branch", "--list", "data/sheets-*"); strings.Count(branches, "data/sheets-") != 1 {
+		t.Fatalf("unexpected sync branches:\n%s", branches)
+	}
+}